@@ -0,0 +1,25 @@
+package main
+
+// Informações gerais da documentação da v2. Os docs de cada versão são gerados separadamente:
+//
+//	swag init -g cmd/server/main.go -o docs/v1 --instanceName v1 --tags '!products-v2'
+//	swag init -d cmd/server,internal,pkg -g docs_v2.go -o docs/v2 --instanceName v2 --tags '!products'
+
+//@title Go Expert API Example
+//@version 2.0
+//@description This is a sample server for a Go Expert API Example. Version 2 returns products with a structured price and rating.
+//@termsOfService http://swagger.io/terms/
+
+//@contact.name API Support
+//@contact.url http://www.swagger.io/support
+//@contact.email
+
+//@license.name Apache 2.0
+//@license.url http://www.apache.org/licenses/LICENSE-2.0.html
+
+//@host localhost:8000
+//@BasePath /v2
+
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name Authorization
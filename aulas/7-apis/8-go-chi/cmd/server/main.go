@@ -3,22 +3,39 @@ package main
 import (
 	// "github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/configs"
 
+	"context"
+	"errors"
 	"fmt"
+	"log"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/configs"
-	_ "github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/docs"
+	v1 "github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/docs/v1"
+	v2 "github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/docs/v2"
 	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/entity"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/auth/oidc"
 	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/database"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/featureflag"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/grpcserver"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/jobs"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/webserver"
 	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/webserver/handlers"
-	"github.com/go-chi/chi"
-	"github.com/go-chi/chi/middleware"
-	"github.com/go-chi/jwtauth"
-	httpSwagger "github.com/swaggo/http-swagger"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/webserver/openapi"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/webserver/sse"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
+// shutdownTimeout é quanto as requisições e RPCs em andamento têm para terminar depois do SIGINT/SIGTERM.
+// Streams (SSE e gRPC) não terminam sozinhos e são encerrados quando o prazo acaba
+const shutdownTimeout = 15 * time.Second
+
 //@title Go Expert API Example
 //@version 1.0
 //@description This is a sample server for a Go Expert API Example.
@@ -32,61 +49,187 @@ import (
 //@license.url http://www.apache.org/licenses/LICENSE-2.0.html
 
 //@host localhost:8000
-//@BasePath /
+//@BasePath /v1
 
 // @securityDefinitions.apikey ApiKeyAuth
 // @in header
 // @name Authorization
 func main() {
-	configs := configs.Conf{
-		DBDriver:      configs.GetDBDriver(),
-		DBHost:        configs.GetDBHost(),
-		DBPort:        configs.GetDBPort(),
-		DBUser:        configs.GetDBUser(),
-		DBPassword:    configs.GetDBPassword(),
-		DBName:        configs.GetDBName(),
-		WebServerPort: configs.GetWebServerPort(),
-		JWTSecret:     configs.GetJWTSecret(),
-		JWTExpiresIn:  configs.GetJWTExpiresIn(),
-		TokenAuth:     configs.GetTokenAuth(),
+	// Padrões, .env, variáveis de ambiente e flags, nessa ordem de prioridade. Ex.: go run . --web-server-port=9000
+	configs, err := configs.Load(configs.LoadOptions{File: configs.DefaultFile, Args: os.Args[1:]})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	if configs.PrintConfig {
+		if err := configs.Print(os.Stdout); err != nil {
+			panic(err)
+		}
+		return
+	}
+
+	v1DeprecatedAt, v1Sunset, err := configs.APIV1Deprecation()
+	if err != nil {
+		panic(err)
+	}
+
+	jobOptions, err := jobOptions(configs)
+	if err != nil {
+		panic(err)
 	}
 
-	db, err := gorm.Open(sqlite.Open("test.db"), &gorm.Config{})
+	corsOptions, err := corsOptions(configs)
 	if err != nil {
 		panic(err)
 	}
-	db.AutoMigrate(&entity.Product{}, &entity.User{})
 
+	securityOptions, err := securityOptions(configs)
+	if err != nil {
+		panic(err)
+	}
+
+	flagRefreshInterval, err := configs.FeatureFlagRefreshInterval()
+	if err != nil {
+		panic(err)
+	}
+
+	// SIGINT/SIGTERM cancelam ctx: os servidores param de aceitar conexões e os jobs interrompidos voltam para a fila
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// O spec gerado pelo swag é a fonte da verdade para validar as requisições de cada versão
+	v1Validator, err := openapi.NewValidator(v1.SwaggerInfov1.ReadDoc(), openapi.Options{})
+	if err != nil {
+		panic(err)
+	}
+	v2Validator, err := openapi.NewValidator(v2.SwaggerInfov2.ReadDoc(), openapi.Options{})
+	if err != nil {
+		panic(err)
+	}
+
+	// A discovery acontece na subida: um issuer configurado e fora do ar impede o servidor de subir
+	var oidcClient *oidc.Client
+	if configs.OIDCEnabled() {
+		oidcClient, err = oidc.Discover(ctx, oidcConfig(configs))
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	db, err := gorm.Open(sqlite.Open(configs.DBName), &gorm.Config{})
+	if err != nil {
+		panic(err)
+	}
+	db.AutoMigrate(&entity.Product{}, &entity.User{}, &entity.PriceChange{}, &entity.Cart{}, &entity.CartItem{}, &entity.Coupon{}, &entity.PromotionRule{}, &entity.Review{}, &entity.AuditEntry{}, &entity.Job{}, &entity.Wishlist{}, &entity.WishlistItem{}, &entity.Notification{}, &entity.FeatureFlag{})
+
+	// Os jobs ficam no mesmo banco da API e rodam em goroutines deste mesmo processo
+	jobDB := database.NewJob(db)
 	productDB := database.NewProduct(db)
-	userDB := database.NewUser(db)
-	productHandler := handlers.NewProductHandler(productDB)
-	userHandler := handlers.NewUserHandler(userDB, configs.TokenAuth, configs.JWTExpiresIn)
-
-	router := chi.NewRouter()
-	router.Use(middleware.Logger)
-	router.Use(middleware.Recoverer)
-
-	router.Route("/products", func(router chi.Router) {
-		// Middleware para verificar o token JWT em todas as rotas deste grupo
-		router.Use(jwtauth.Verifier(configs.TokenAuth))
-
-		// Middleware que exige autenticação para todas as rotas dentro deste grupo
-		router.Use(jwtauth.Authenticator)
-
-		router.Post("/", productHandler.CreateProduct)
-		router.Get("/", productHandler.GetProduct)
-		router.Get("/", productHandler.GetProducts)
-		router.Get("/{id}", productHandler.GetProduct)
-		router.Put("/{id}", productHandler.UpdateProduct)
-		router.Delete("/{id}", productHandler.DeleteProduct)
+	runner := jobs.NewRunner(jobDB, jobOptions)
+	if err := jobs.RegisterDefaults(runner, productDB); err != nil {
+		panic(err)
+	}
+	var background sync.WaitGroup
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		runner.Run(ctx)
+	}()
+
+	priceHistoryDB := database.NewPriceHistory(db)
+	transaction := database.NewTransaction(db)
+	productBroker := sse.NewBroker(sse.DefaultReplaySize)
+
+	// O gRPC usa os mesmos repositórios e o mesmo broker: mudanças feitas por ele aparecem no stream SSE
+	grpcServer := grpcserver.NewServer(grpcserver.Dependencies{
+		ProductDB:      productDB,
+		PriceHistoryDB: priceHistoryDB,
+		Transaction:    transaction,
+		KeyRing:        configs.KeyRing,
+		Events:         productBroker,
+	})
+	listener, err := net.Listen("tcp", ":"+configs.GRPCServerPort)
+	if err != nil {
+		panic(err)
+	}
+	go func() {
+		if err := grpcServer.Serve(listener); err != nil {
+			log.Printf("grpc server: %v", err)
+			stop()
+		}
+	}()
+
+	// Cada instância guarda as flags em memória e relê o banco periodicamente para ver as mudanças das outras
+	flagDB := database.NewFeatureFlag(db)
+	features := featureflag.NewStore(flagDB, flagRefreshInterval)
+	go features.Run(ctx)
+
+	router := webserver.NewRouter(webserver.Dependencies{
+		ProductDB:      productDB,
+		PriceHistoryDB: priceHistoryDB,
+		UserDB:         database.NewUser(db),
+		CartDB:         database.NewCart(db),
+		CouponDB:       database.NewCoupon(db),
+		PromotionDB:    database.NewPromotionRule(db),
+		ReviewDB:       database.NewReview(db),
+		AuditDB:        database.NewAudit(db),
+		JobDB:          jobDB,
+		WishlistDB:     database.NewWishlist(db),
+		NotificationDB: database.NewNotification(db),
+		Transaction:    transaction,
+		KeyRing:        configs.KeyRing,
+		JWTExpiresIn:   configs.JWTExpiresIn,
+		ProductBroker:  productBroker,
+		AdminEmails:    configs.AdminEmailList(),
+		Jobs:           runner,
+		Deprecations: map[string]handlers.DeprecationPolicy{
+			webserver.APIV1: {DeprecatedAt: v1DeprecatedAt, Sunset: v1Sunset, Successor: "/v2"},
+		},
+		Validators: map[string]*openapi.Validator{
+			webserver.APIV1: v1Validator,
+			webserver.APIV2: v2Validator,
+		},
+		CORS:         corsOptions,
+		Security:     securityOptions,
+		MaxBodyBytes: maxBodyBytes(configs),
+		OIDC:         oidcClient,
+
+		FeatureFlagDB: flagDB,
+		Features:      features,
 	})
 
-	router.Post("/users", userHandler.CreateUser)
-	router.Post("/users/generate-token", userHandler.GetJWT)
+	server := &http.Server{Addr: ":" + configs.WebServerPort, Handler: router}
+	go func() {
+		if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("http server: %v", err)
+			stop()
+		}
+	}()
 
-	router.Get("/docs/*", httpSwagger.Handler(httpSwagger.URL("http://localhost:8000/docs/doc.json")))
+	<-ctx.Done()
+	log.Println("shutting down")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		server.Close()
+	}
+
+	grpcStopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(grpcStopped)
+	}()
+	select {
+	case <-grpcStopped:
+	case <-shutdownCtx.Done():
+		grpcServer.Stop()
+	}
 
-	http.ListenAndServe(":8000", router)
+	// Os workers só terminam depois de gravar o resultado (ou devolver à fila) os jobs em andamento
+	background.Wait()
 }
 
 // Middleware to log incoming requests
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/configs"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/auth/oidc"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/jobs"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/webserver/handlers"
+)
+
+// As funções abaixo traduzem a configuração para as opções de cada pacote, assim o configs
+// não depende da camada web nem dos jobs. Os valores já foram validados pelo configs.Load
+
+// jobOptions parte de jobs.DefaultOptions e troca só o que foi configurado em JOB_*
+func jobOptions(conf *configs.Conf) (jobs.Options, error) {
+	options := jobs.DefaultOptions()
+	if conf.JobWorkers > 0 {
+		options.Workers = conf.JobWorkers
+	}
+	if conf.JobMaxAttempts > 0 {
+		options.MaxAttempts = conf.JobMaxAttempts
+	}
+
+	interval, err := conf.JobPollIntervalDuration()
+	if err != nil {
+		return options, err
+	}
+	if interval > 0 {
+		options.PollInterval = interval
+	}
+
+	return options, nil
+}
+
+func corsOptions(conf *configs.Conf) (handlers.CORSOptions, error) {
+	maxAge, err := conf.CORSMaxAgeDuration()
+	if err != nil {
+		return handlers.CORSOptions{}, err
+	}
+
+	return handlers.CORSOptions{
+		AllowedOrigins:   conf.CORSAllowedOriginList(),
+		AllowedHeaders:   conf.CORSAllowedHeaderList(),
+		AllowCredentials: conf.CORSAllowCredentials,
+		MaxAge:           maxAge,
+	}, nil
+}
+
+func securityOptions(conf *configs.Conf) (handlers.SecurityOptions, error) {
+	maxAge, err := conf.HSTSMaxAgeDuration()
+	if err != nil {
+		return handlers.SecurityOptions{}, err
+	}
+
+	return handlers.SecurityOptions{
+		HSTSMaxAge:            maxAge,
+		HSTSIncludeSubdomains: conf.HSTSIncludeSubdomains,
+		ContentSecurityPolicy: conf.ContentSecurityPolicy,
+	}, nil
+}
+
+// maxBodyBytes devolve o limite do corpo das requisições, 0 quando o limite está desligado
+func maxBodyBytes(conf *configs.Conf) int64 {
+	switch {
+	case conf.MaxRequestBodyBytes == 0:
+		return handlers.DefaultMaxBodyBytes
+	case conf.MaxRequestBodyBytes < 0:
+		return 0
+	default:
+		return conf.MaxRequestBodyBytes
+	}
+}
+
+func oidcConfig(conf *configs.Conf) oidc.Config {
+	return oidc.Config{
+		Issuer:       conf.OIDCIssuer,
+		ClientID:     conf.OIDCClientID,
+		ClientSecret: conf.OIDCClientSecret,
+		RedirectURL:  conf.OIDCRedirectURL,
+		Scopes:       conf.OIDCScopeList(),
+	}
+}
@@ -1,85 +1,155 @@
 package configs
 
 import (
+	"strings"
+	"time"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/auth"
 	"github.com/go-chi/jwtauth"
-	"github.com/spf13/viper"
 )
 
+// Conf é montada por Load. Campos com secret:"true" nunca aparecem em Print
 type Conf struct {
 	DBDriver      string `mapstructure:"DB_DRIVER"`
 	DBHost        string `mapstructure:"DB_HOST"`
 	DBPort        string `mapstructure:"DB_PORT"`
 	DBUser        string `mapstructure:"DB_USER"`
-	DBPassword    string `mapstructure:"DB_PASSWORD"`
+	DBPassword    string `mapstructure:"DB_PASSWORD" secret:"true"`
 	DBName        string `mapstructure:"DB_NAME"`
 	WebServerPort string `mapstructure:"WEB_SERVER_PORT"`
-	JWTSecret     string `mapstructure:"JWT_SECRET"`
+	JWTSecret     string `mapstructure:"JWT_SECRET" secret:"true"`
 	JWTExpiresIn  int    `mapstructure:"JWT_EXPIRES_IN"`
-	TokenAuth     *jwtauth.JWTAuth
+	// HS256 (padrão, usa JWT_SECRET), RS256 ou ES256 (usam chaves PEM)
+	JWTAlgorithm            string `mapstructure:"JWT_ALGORITHM"`
+	JWTSigningKeyFile       string `mapstructure:"JWT_SIGNING_KEY_FILE"`
+	JWTVerificationKeyFiles string `mapstructure:"JWT_VERIFICATION_KEY_FILES"`
+	// Emails separados por vírgula que recebem a role admin ao gerar o token
+	AdminEmails string `mapstructure:"ADMIN_EMAILS"`
+	// Datas RFC 3339 da depreciação e da desativação da v1. Vazio mantém a v1 ativa
+	APIV1DeprecatedAt string `mapstructure:"API_V1_DEPRECATED_AT"`
+	APIV1Sunset       string `mapstructure:"API_V1_SUNSET"`
+	TokenAuth         *jwtauth.JWTAuth
+	KeyRing           *auth.KeyRing
+	// PrintConfig vem da flag --print-config: o servidor mostra a configuração efetiva e termina
+	PrintConfig bool `mapstructure:"-"`
+
+	// Jobs em segundo plano. Valores vazios usam os padrões do runner; o intervalo é uma duração Go (ex.: 500ms)
+	JobWorkers      int    `mapstructure:"JOB_WORKERS"`
+	JobPollInterval string `mapstructure:"JOB_POLL_INTERVAL"`
+	JobMaxAttempts  int    `mapstructure:"JOB_MAX_ATTEMPTS"`
+
+	// Origens separadas por vírgula liberadas no CORS (ex.: https://loja.com.br,https://*.loja.com.br). Vazio desliga o CORS
+	CORSAllowedOrigins   string `mapstructure:"CORS_ALLOWED_ORIGINS"`
+	CORSAllowedHeaders   string `mapstructure:"CORS_ALLOWED_HEADERS"`
+	CORSAllowCredentials bool   `mapstructure:"CORS_ALLOW_CREDENTIALS"`
+	CORSMaxAge           string `mapstructure:"CORS_MAX_AGE"`
+	// Durações Go (ex.: 8760h). HSTS vazio não envia o Strict-Transport-Security
+	HSTSMaxAge            string `mapstructure:"HSTS_MAX_AGE"`
+	HSTSIncludeSubdomains bool   `mapstructure:"HSTS_INCLUDE_SUBDOMAINS"`
+	ContentSecurityPolicy string `mapstructure:"CONTENT_SECURITY_POLICY"`
+	// Tamanho máximo do corpo em bytes. Zero usa o padrão do servidor e negativo desliga o limite
+	MaxRequestBodyBytes int64 `mapstructure:"MAX_REQUEST_BODY_BYTES"`
+
+	// Login pelo SSO (OpenID Connect). OIDC_ISSUER vazio desliga o login; OIDC_SCOPES é separado por vírgula
+	OIDCIssuer       string `mapstructure:"OIDC_ISSUER"`
+	OIDCClientID     string `mapstructure:"OIDC_CLIENT_ID"`
+	OIDCClientSecret string `mapstructure:"OIDC_CLIENT_SECRET" secret:"true"`
+	OIDCRedirectURL  string `mapstructure:"OIDC_REDIRECT_URL"`
+	OIDCScopes       string `mapstructure:"OIDC_SCOPES"`
+
+	// Porta do servidor gRPC de produtos, que roda no mesmo processo da API REST
+	GRPCServerPort string `mapstructure:"GRPC_SERVER_PORT"`
+
+	// Duração Go entre as releituras das feature flags. Vazio usa featureflag.DefaultRefreshInterval
+	FeatureFlagRefresh string `mapstructure:"FEATURE_FLAG_REFRESH_INTERVAL"`
+}
+
+func newKeyRing(config *Conf) (*auth.KeyRing, error) {
+	if config.JWTAlgorithm == "" || config.JWTAlgorithm == "HS256" {
+		return auth.NewHMACKeyRing(config.JWTSecret)
+	}
+
+	// Lista separada por vírgula com as chaves públicas ainda aceitas durante a rotação
+	return auth.LoadKeyRing(config.JWTAlgorithm, config.JWTSigningKeyFile, splitList(config.JWTVerificationKeyFiles))
 }
 
-var config *Conf
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
 
-func init() {
-	viper.SetConfigName("app_confi")
-	viper.SetConfigType("env")
-	viper.AddConfigPath(".")
-	viper.SetConfigFile(".env")
+	return items
+}
 
-	// AutomaticEnv carrega variáveis de ambiente em detrimento de variáveis de configuração(.env)
-	viper.AutomaticEnv()
+// AdminEmailList devolve ADMIN_EMAILS já separado por vírgula
+func (c *Conf) AdminEmailList() []string {
+	return splitList(c.AdminEmails)
+}
 
-	err := viper.ReadInConfig()
-	if err != nil {
-		panic(err)
+// APIV1Deprecation converte as datas de API_V1_DEPRECATED_AT e API_V1_SUNSET. Datas ausentes ficam zeradas
+func (c *Conf) APIV1Deprecation() (deprecatedAt, sunset time.Time, err error) {
+	if c.APIV1DeprecatedAt != "" {
+		if deprecatedAt, err = time.Parse(time.RFC3339, c.APIV1DeprecatedAt); err != nil {
+			return deprecatedAt, sunset, err
+		}
 	}
 
-	// O Unmarshal vai mapear as variáveis do arquivo de configuração para a struct conf
-	// O Unmarshal no Go serve para decodificar dados de um formato (como JSON ou YAML) para uma estrutura Go
-	err = viper.Unmarshal(&config)
-	if err != nil {
-		panic(err)
+	if c.APIV1Sunset != "" {
+		if sunset, err = time.Parse(time.RFC3339, c.APIV1Sunset); err != nil {
+			return deprecatedAt, sunset, err
+		}
 	}
 
-	config.TokenAuth = jwtauth.New("HS256", []byte(config.JWTSecret), nil)
+	return deprecatedAt, sunset, nil
 }
 
-func GetDBDriver() string {
-	return config.DBDriver
+// JobPollIntervalDuration converte JOB_POLL_INTERVAL. Vazio devolve zero, que o servidor troca pelo padrão do runner
+func (c *Conf) JobPollIntervalDuration() (time.Duration, error) {
+	return parseDuration(c.JobPollInterval)
 }
 
-func GetDBHost() string {
-	return config.DBHost
+// CORSAllowedOriginList devolve CORS_ALLOWED_ORIGINS já separado por vírgula
+func (c *Conf) CORSAllowedOriginList() []string {
+	return splitList(c.CORSAllowedOrigins)
 }
 
-func GetDBPort() string {
-	return config.DBPort
+// CORSAllowedHeaderList devolve CORS_ALLOWED_HEADERS já separado por vírgula. Vazio usa os padrões do middleware
+func (c *Conf) CORSAllowedHeaderList() []string {
+	return splitList(c.CORSAllowedHeaders)
 }
 
-func GetDBUser() string {
-	return config.DBUser
+// CORSMaxAgeDuration converte CORS_MAX_AGE. Vazio devolve zero, que não envia o Access-Control-Max-Age
+func (c *Conf) CORSMaxAgeDuration() (time.Duration, error) {
+	return parseDuration(c.CORSMaxAge)
 }
 
-func GetDBPassword() string {
-	return config.DBPassword
+// HSTSMaxAgeDuration converte HSTS_MAX_AGE. Vazio devolve zero, que não envia o Strict-Transport-Security
+func (c *Conf) HSTSMaxAgeDuration() (time.Duration, error) {
+	return parseDuration(c.HSTSMaxAge)
 }
 
-func GetDBName() string {
-	return config.DBName
+// OIDCScopeList devolve OIDC_SCOPES já separado por vírgula
+func (c *Conf) OIDCScopeList() []string {
+	return splitList(c.OIDCScopes)
 }
 
-func GetWebServerPort() string {
-	return config.WebServerPort
+func (c *Conf) OIDCEnabled() bool {
+	return c.OIDCIssuer != ""
 }
 
-func GetJWTSecret() string {
-	return config.JWTSecret
+// FeatureFlagRefreshInterval converte FEATURE_FLAG_REFRESH_INTERVAL. Vazio devolve zero, que o store troca pelo padrão
+func (c *Conf) FeatureFlagRefreshInterval() (time.Duration, error) {
+	return parseDuration(c.FeatureFlagRefresh)
 }
 
-func GetJWTExpiresIn() int {
-	return config.JWTExpiresIn
-}
+// parseDuration aceita durações Go (ex.: 500ms, 8760h), com vazio valendo zero
+func parseDuration(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
 
-func GetTokenAuth() *jwtauth.JWTAuth {
-	return config.TokenAuth
+	return time.ParseDuration(value)
 }
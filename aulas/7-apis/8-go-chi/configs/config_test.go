@@ -0,0 +1,129 @@
+package configs
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const secret = "0123456789abcdef0123456789abcdef"
+
+func writeFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoadUsesDefaults(t *testing.T) {
+	conf, err := Load(LoadOptions{Environ: []string{"JWT_SECRET=" + secret}})
+	require.NoError(t, err)
+
+	assert.Equal(t, "sqlite", conf.DBDriver)
+	assert.Equal(t, "8000", conf.WebServerPort)
+	assert.Equal(t, 300, conf.JWTExpiresIn)
+	assert.Equal(t, "HS256", conf.JWTAlgorithm)
+	assert.NotNil(t, conf.KeyRing)
+	assert.NotNil(t, conf.TokenAuth)
+	assert.False(t, conf.PrintConfig)
+}
+
+func TestLoadLayers(t *testing.T) {
+	file := writeFile(t, "app.env", "JWT_SECRET="+secret+"\nWEB_SERVER_PORT=8080\nJWT_EXPIRES_IN=60\nJOB_WORKERS=2\nCORS_ALLOW_CREDENTIALS=true\n")
+
+	// Ambiente sobrescreve o arquivo e as flags sobrescrevem o ambiente
+	conf, err := Load(LoadOptions{
+		File:    file,
+		Environ: []string{"WEB_SERVER_PORT=8081", "JWT_EXPIRES_IN=120", "PATH=/bin"},
+		Args:    []string{"--web-server-port=8082"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "8082", conf.WebServerPort)
+	assert.Equal(t, 120, conf.JWTExpiresIn)
+	assert.Equal(t, 2, conf.JobWorkers)
+	assert.True(t, conf.CORSAllowCredentials)
+}
+
+func TestLoadReadsYAMLAndJSON(t *testing.T) {
+	for name, content := range map[string]string{
+		"config.yaml": "JWT_SECRET: " + secret + "\nJOB_POLL_INTERVAL: 2s\nMAX_REQUEST_BODY_BYTES: 2048\n",
+		"config.json": `{"JWT_SECRET": "` + secret + `", "JOB_POLL_INTERVAL": "2s", "MAX_REQUEST_BODY_BYTES": 2048}`,
+	} {
+		conf, err := Load(LoadOptions{Args: []string{"--config", writeFile(t, name, content)}, Environ: []string{}})
+		require.NoError(t, err, name)
+
+		interval, err := conf.JobPollIntervalDuration()
+		require.NoError(t, err, name)
+		assert.Equal(t, 2*time.Second, interval, name)
+		assert.Equal(t, int64(2048), conf.MaxRequestBodyBytes, name)
+	}
+}
+
+func TestLoadFileIsOptionalUnlessExplicit(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), ".env")
+
+	_, err := Load(LoadOptions{File: missing, Environ: []string{"JWT_SECRET=" + secret}})
+	assert.NoError(t, err)
+
+	_, err = Load(LoadOptions{Args: []string{"--config", missing}, Environ: []string{"JWT_SECRET=" + secret}})
+	assert.True(t, errors.Is(err, ErrInvalidConfig))
+}
+
+func TestLoadListsEveryProblem(t *testing.T) {
+	_, err := Load(LoadOptions{Environ: []string{
+		"DB_DRIVER=oracle",
+		"WEB_SERVER_PORT=70000",
+		"JWT_SECRET=short",
+		"JWT_EXPIRES_IN=0",
+		"JOB_POLL_INTERVAL=soon",
+		"API_V1_SUNSET=tomorrow",
+		"FEATURE_FLAG_REFRESH_INTERVAL=often",
+	}})
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrInvalidConfig))
+
+	for _, key := range []string{"DB_DRIVER", "WEB_SERVER_PORT", "JWT_SECRET", "JWT_EXPIRES_IN", "JOB_POLL_INTERVAL", "API_V1_SUNSET", "FEATURE_FLAG_REFRESH_INTERVAL"} {
+		assert.Contains(t, err.Error(), key)
+	}
+
+	_, err = Load(LoadOptions{Environ: []string{"JWT_SECRET=" + secret, "GRPC_SERVER_PORT=8000"}})
+	assert.Contains(t, err.Error(), "GRPC_SERVER_PORT")
+
+	_, err = Load(LoadOptions{Environ: []string{"JWT_ALGORITHM=RS256"}})
+	assert.Contains(t, err.Error(), "JWT_SIGNING_KEY_FILE")
+
+	_, err = Load(LoadOptions{Environ: []string{"JWT_SECRET=" + secret, "OIDC_ISSUER=accounts.example.com"}})
+	for _, key := range []string{"OIDC_ISSUER", "OIDC_CLIENT_ID", "OIDC_REDIRECT_URL"} {
+		assert.Contains(t, err.Error(), key)
+	}
+
+	_, err = Load(LoadOptions{Environ: []string{"JWT_SECRET=" + secret, "CORS_ALLOWED_ORIGINS=https://loja.com.br,*", "CORS_ALLOW_CREDENTIALS=true"}})
+	assert.Contains(t, err.Error(), "CORS_ALLOW_CREDENTIALS")
+
+	_, err = Load(LoadOptions{Args: []string{"--unknown"}, Environ: []string{}})
+	assert.True(t, errors.Is(err, ErrInvalidConfig))
+}
+
+func TestPrintRedactsSecrets(t *testing.T) {
+	conf, err := Load(LoadOptions{
+		Args:    []string{"--print-config", "--db-password=root"},
+		Environ: []string{"JWT_SECRET=" + secret},
+	})
+	require.NoError(t, err)
+	assert.True(t, conf.PrintConfig)
+
+	var output bytes.Buffer
+	require.NoError(t, conf.Print(&output))
+
+	assert.Contains(t, output.String(), "JWT_SECRET=[REDACTED]\n")
+	assert.Contains(t, output.String(), "DB_PASSWORD=[REDACTED]\n")
+	assert.Contains(t, output.String(), "WEB_SERVER_PORT=8000\n")
+	assert.NotContains(t, output.String(), secret)
+	assert.NotContains(t, output.String(), "root")
+}
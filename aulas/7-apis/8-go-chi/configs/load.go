@@ -0,0 +1,300 @@
+package configs
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	// DefaultFile é lido quando existe no diretório atual; com --config o arquivo passa a ser obrigatório
+	DefaultFile = ".env"
+
+	// MinJWTSecretLength é o tamanho da chave do HS256 (256 bits)
+	MinJWTSecretLength = 32
+
+	redacted = "[REDACTED]"
+)
+
+var (
+	ErrInvalidConfig = errors.New("invalid configuration")
+
+	// O servidor só abre bancos sqlite (DB_NAME é o arquivo)
+	drivers    = []string{"sqlite"}
+	algorithms = []string{"HS256", "RS256", "ES256"}
+
+	defaults = map[string]interface{}{
+		"DB_DRIVER":        "sqlite",
+		"DB_NAME":          "test.db",
+		"WEB_SERVER_PORT":  "8000",
+		"GRPC_SERVER_PORT": "50051",
+		"JWT_EXPIRES_IN":   300,
+		"JWT_ALGORITHM":    "HS256",
+	}
+)
+
+// LoadOptions diz de onde vem a configuração. Cada camada sobrescreve a anterior:
+// valores padrão, arquivo (.env, YAML ou JSON), variáveis de ambiente e flags
+type LoadOptions struct {
+	// File vazio não lê arquivo nenhum. Um arquivo que não existe só é erro quando veio de --config
+	File string
+	// Args são as flags da linha de comando, normalmente os.Args[1:]. Cada chave vira uma flag (DB_DRIVER -> --db-driver)
+	Args []string
+	// Environ no formato CHAVE=valor. Nil usa os.Environ(), assim os testes não dependem do ambiente
+	Environ []string
+}
+
+// Load monta e valida a configuração. Em caso de erro todos os problemas encontrados são devolvidos juntos
+func Load(options LoadOptions) (*Conf, error) {
+	keys := configKeys()
+	values := map[string]interface{}{}
+	for key, value := range defaults {
+		values[key] = value
+	}
+
+	flags, file, printConfig, err := parseFlags(keys, options.Args)
+	if err != nil {
+		return nil, err
+	}
+
+	required := file != ""
+	if !required {
+		file = options.File
+	}
+	if file != "" {
+		if err := readFile(file, required, keys, values); err != nil {
+			return nil, err
+		}
+	}
+
+	environ := options.Environ
+	if environ == nil {
+		environ = os.Environ()
+	}
+	for _, variable := range environ {
+		key, value, _ := strings.Cut(variable, "=")
+		if _, ok := keys[key]; ok {
+			values[key] = value
+		}
+	}
+
+	for key, value := range flags {
+		values[key] = value
+	}
+
+	// O Unmarshal do viper converte os valores (sempre texto no .env e no ambiente) para os tipos da struct
+	v := viper.New()
+	for key, value := range values {
+		v.Set(key, value)
+	}
+
+	var conf Conf
+	if err := v.Unmarshal(&conf); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+	conf.PrintConfig = printConfig
+
+	if err := conf.Validate(); err != nil {
+		return nil, err
+	}
+
+	conf.KeyRing, err = newKeyRing(&conf)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+	conf.TokenAuth = conf.KeyRing.TokenAuth()
+
+	return &conf, nil
+}
+
+// Validate confere todos os campos e junta os problemas em um único erro, um por linha
+func (c *Conf) Validate() error {
+	var problems []error
+	problem := func(key, format string, args ...interface{}) {
+		problems = append(problems, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+	}
+
+	if !contains(drivers, c.DBDriver) {
+		problem("DB_DRIVER", "must be one of %s, got %q", strings.Join(drivers, ", "), c.DBDriver)
+	}
+	if c.DBName == "" {
+		problem("DB_NAME", "is required")
+	}
+
+	if port, err := strconv.Atoi(c.WebServerPort); err != nil || port < 1 || port > 65535 {
+		problem("WEB_SERVER_PORT", "must be a port between 1 and 65535, got %q", c.WebServerPort)
+	}
+	if port, err := strconv.Atoi(c.GRPCServerPort); err != nil || port < 1 || port > 65535 {
+		problem("GRPC_SERVER_PORT", "must be a port between 1 and 65535, got %q", c.GRPCServerPort)
+	} else if c.GRPCServerPort == c.WebServerPort {
+		problem("GRPC_SERVER_PORT", "must be different from WEB_SERVER_PORT")
+	}
+
+	switch {
+	case !contains(algorithms, c.JWTAlgorithm):
+		problem("JWT_ALGORITHM", "must be one of %s, got %q", strings.Join(algorithms, ", "), c.JWTAlgorithm)
+	case c.JWTAlgorithm == "HS256" && len(c.JWTSecret) < MinJWTSecretLength:
+		problem("JWT_SECRET", "must have at least %d characters", MinJWTSecretLength)
+	case c.JWTAlgorithm != "HS256" && c.JWTSigningKeyFile == "":
+		problem("JWT_SIGNING_KEY_FILE", "is required for %s", c.JWTAlgorithm)
+	}
+	if c.JWTExpiresIn <= 0 {
+		problem("JWT_EXPIRES_IN", "must be greater than zero")
+	}
+
+	if _, _, err := c.APIV1Deprecation(); err != nil {
+		problem("API_V1_DEPRECATED_AT/API_V1_SUNSET", "must be RFC 3339 dates: %v", err)
+	}
+	if c.JobWorkers < 0 {
+		problem("JOB_WORKERS", "must not be negative")
+	}
+	if c.JobMaxAttempts < 0 {
+		problem("JOB_MAX_ATTEMPTS", "must not be negative")
+	}
+	if _, err := c.JobPollIntervalDuration(); err != nil {
+		problem("JOB_POLL_INTERVAL", "must be a duration: %v", err)
+	}
+	if _, err := c.CORSMaxAgeDuration(); err != nil {
+		problem("CORS_MAX_AGE", "must be a duration: %v", err)
+	}
+	if c.CORSAllowCredentials && contains(c.CORSAllowedOriginList(), "*") {
+		problem("CORS_ALLOW_CREDENTIALS", "cannot be used with \"*\" in CORS_ALLOWED_ORIGINS")
+	}
+	if _, err := c.HSTSMaxAgeDuration(); err != nil {
+		problem("HSTS_MAX_AGE", "must be a duration: %v", err)
+	}
+	if interval, err := c.FeatureFlagRefreshInterval(); err != nil {
+		problem("FEATURE_FLAG_REFRESH_INTERVAL", "must be a duration: %v", err)
+	} else if interval < 0 {
+		problem("FEATURE_FLAG_REFRESH_INTERVAL", "must not be negative")
+	}
+
+	if c.OIDCEnabled() {
+		if issuer, err := url.Parse(c.OIDCIssuer); err != nil || issuer.Host == "" {
+			problem("OIDC_ISSUER", "must be an absolute url, got %q", c.OIDCIssuer)
+		}
+		if c.OIDCClientID == "" {
+			problem("OIDC_CLIENT_ID", "is required when OIDC_ISSUER is set")
+		}
+		if redirect, err := url.Parse(c.OIDCRedirectURL); err != nil || redirect.Host == "" {
+			problem("OIDC_REDIRECT_URL", "must be an absolute url when OIDC_ISSUER is set, got %q", c.OIDCRedirectURL)
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("%w:\n%w", ErrInvalidConfig, errors.Join(problems...))
+}
+
+// Print escreve a configuração efetiva no formato do .env, ordenada por chave e sem os segredos
+func (c *Conf) Print(writer io.Writer) error {
+	value := reflect.ValueOf(c).Elem()
+	var lines []string
+	for key, field := range configKeys() {
+		output := fmt.Sprint(value.FieldByIndex(field.Index).Interface())
+		if field.Tag.Get("secret") == "true" && output != "" {
+			output = redacted
+		}
+		lines = append(lines, key+"="+output)
+	}
+	sort.Strings(lines)
+
+	_, err := fmt.Fprintln(writer, strings.Join(lines, "\n"))
+	return err
+}
+
+// configKeys lista os campos de Conf que vêm da configuração, indexados pela chave da tag mapstructure
+func configKeys() map[string]reflect.StructField {
+	keys := map[string]reflect.StructField{}
+	confType := reflect.TypeOf(Conf{})
+	for i := 0; i < confType.NumField(); i++ {
+		field := confType.Field(i)
+		if key := field.Tag.Get("mapstructure"); key != "" && key != "-" {
+			keys[key] = field
+		}
+	}
+
+	return keys
+}
+
+func parseFlags(keys map[string]reflect.StructField, args []string) (map[string]string, string, bool, error) {
+	set := flag.NewFlagSet("server", flag.ContinueOnError)
+	set.SetOutput(io.Discard)
+
+	file := set.String("config", "", "configuration file (.env, .yaml or .json)")
+	printConfig := set.Bool("print-config", false, "print the effective configuration and exit")
+
+	names := map[string]string{}
+	for key := range keys {
+		name := strings.ReplaceAll(strings.ToLower(key), "_", "-")
+		names[name] = key
+		set.String(name, "", key)
+	}
+
+	if err := set.Parse(args); err != nil {
+		return nil, "", false, fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+
+	// Só as flags informadas entram, senão o valor vazio sobrescreveria o arquivo e o ambiente
+	values := map[string]string{}
+	set.Visit(func(f *flag.Flag) {
+		if key, ok := names[f.Name]; ok {
+			values[key] = f.Value.String()
+		}
+	})
+
+	return values, *file, *printConfig, nil
+}
+
+func readFile(path string, required bool, keys map[string]reflect.StructField, values map[string]interface{}) error {
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) && !required {
+		return nil
+	}
+
+	v := viper.New()
+	v.SetConfigFile(path)
+	switch extension := strings.ToLower(filepath.Ext(path)); extension {
+	case ".yaml", ".yml":
+		v.SetConfigType("yaml")
+	case ".json":
+		v.SetConfigType("json")
+	case ".env", "":
+		v.SetConfigType("env")
+	default:
+		return fmt.Errorf("%w: unsupported config file %q", ErrInvalidConfig, extension)
+	}
+
+	if err := v.ReadInConfig(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+
+	for key := range keys {
+		if v.IsSet(key) {
+			values[key] = v.Get(key)
+		}
+	}
+
+	return nil
+}
+
+func contains(items []string, value string) bool {
+	for _, item := range items {
+		if item == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -1,21 +1,186 @@
 package dto
 
+import "time"
+
 type CreateProductInput struct {
-	Name  string `json:"name"`
-	Price int    `json:"price"`
+	Name     string `json:"name" xml:"name" binding:"required" minLength:"1"`
+	Price    int    `json:"price" xml:"price" binding:"required" minimum:"1"`
+	Category string `json:"category" xml:"category"`
 }
 
 type CreateUserInput struct {
-	Name     string `json:"name"`
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Name     string `json:"name" xml:"name" binding:"required" minLength:"1"`
+	Email    string `json:"email" xml:"email" binding:"required" minLength:"3"`
+	Password string `json:"password" xml:"password" binding:"required" minLength:"6"`
 }
 
 type GetJWTInput struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email    string `json:"email" xml:"email" binding:"required"`
+	Password string `json:"password" xml:"password" binding:"required"`
 }
 
 type GetJWTOutput struct {
 	AccessToken string `json:"access_token"`
 }
+
+// TOTPChallengeOutput é a resposta do login por senha de quem tem o segundo fator ativo
+type TOTPChallengeOutput struct {
+	ChallengeToken string `json:"challenge_token"`
+	ExpiresIn      int    `json:"expires_in"`
+}
+
+// TOTPLoginInput aceita em Code tanto o código do app autenticador quanto um código de recuperação
+type TOTPLoginInput struct {
+	ChallengeToken string `json:"challenge_token" xml:"challenge_token" binding:"required"`
+	Code           string `json:"code" xml:"code" binding:"required"`
+}
+
+type TOTPCodeInput struct {
+	Code string `json:"code" xml:"code" binding:"required"`
+}
+
+// TOTPEnrollmentOutput só é devolvido no enroll: os códigos de recuperação não podem ser consultados depois
+type TOTPEnrollmentOutput struct {
+	Secret          string   `json:"secret"`
+	ProvisioningURI string   `json:"provisioning_uri"`
+	RecoveryCodes   []string `json:"recovery_codes"`
+}
+
+type AddCartItemInput struct {
+	ProductID string `json:"product_id" xml:"product_id" binding:"required" format:"uuid"`
+	Quantity  int    `json:"quantity" xml:"quantity" binding:"required" minimum:"1"`
+}
+
+type UpdateCartItemInput struct {
+	Quantity int `json:"quantity" xml:"quantity" binding:"required" minimum:"1"`
+}
+
+type CartItemOutput struct {
+	ProductID string `json:"product_id"`
+	Name      string `json:"name"`
+	Quantity  int    `json:"quantity"`
+	UnitPrice int    `json:"unit_price"`
+	LineTotal int    `json:"line_total"`
+}
+
+type CartPriceChangeOutput struct {
+	ProductID string `json:"product_id"`
+	OldPrice  int    `json:"old_price"`
+	NewPrice  int    `json:"new_price"`
+}
+
+// CartOutput traz os totais calculados no servidor e o que mudou desde a última validação do carrinho
+type CartOutput struct {
+	ID              string                  `json:"id"`
+	Items           []CartItemOutput        `json:"items"`
+	PriceChanges    []CartPriceChangeOutput `json:"price_changes"`
+	RemovedProducts []string                `json:"removed_products"`
+	Total           int                     `json:"total"`
+}
+
+type WishlistInput struct {
+	Name string `json:"name" xml:"name" binding:"required" minLength:"1"`
+}
+
+type AddWishlistItemInput struct {
+	ProductID string `json:"product_id" xml:"product_id" binding:"required" format:"uuid"`
+}
+
+// Percentage omitido vale 100: sem ele a flag é booleana, ligada para todos quando enabled
+type CreateFeatureFlagInput struct {
+	Key         string   `json:"key" xml:"key" binding:"required" minLength:"1" maxLength:"64" pattern:"^[a-z0-9][a-z0-9._-]*$"`
+	Description string   `json:"description" xml:"description"`
+	Enabled     bool     `json:"enabled" xml:"enabled"`
+	Percentage  *int     `json:"percentage,omitempty" xml:"percentage,omitempty" minimum:"0" maximum:"100"`
+	UserIDs     []string `json:"user_ids,omitempty" xml:"user_ids,omitempty"`
+	Roles       []string `json:"roles,omitempty" xml:"roles,omitempty"`
+}
+
+// UpdateFeatureFlagInput substitui a flag inteira, com a mesma regra do percentage da criação
+type UpdateFeatureFlagInput struct {
+	Description string   `json:"description" xml:"description"`
+	Enabled     bool     `json:"enabled" xml:"enabled"`
+	Percentage  *int     `json:"percentage,omitempty" xml:"percentage,omitempty" minimum:"0" maximum:"100"`
+	UserIDs     []string `json:"user_ids,omitempty" xml:"user_ids,omitempty"`
+	Roles       []string `json:"roles,omitempty" xml:"roles,omitempty"`
+}
+
+type CreateCouponInput struct {
+	Code         string    `json:"code" xml:"code" binding:"required" minLength:"1"`
+	DiscountType string    `json:"discount_type" xml:"discount_type" binding:"required" enums:"percentage,fixed"`
+	Value        int       `json:"value" xml:"value" binding:"required" minimum:"1"`
+	StartsAt     time.Time `json:"starts_at" xml:"starts_at" binding:"required" format:"date-time"`
+	EndsAt       time.Time `json:"ends_at" xml:"ends_at" binding:"required" format:"date-time"`
+	MaxUses      int       `json:"max_uses" xml:"max_uses" minimum:"0"`
+	MinCartValue int       `json:"min_cart_value" xml:"min_cart_value" minimum:"0"`
+}
+
+// CreatePromotionRuleInput deve informar product_id ou category
+type CreatePromotionRuleInput struct {
+	Name         string    `json:"name" xml:"name" binding:"required" minLength:"1"`
+	ProductID    string    `json:"product_id" xml:"product_id"`
+	Category     string    `json:"category" xml:"category"`
+	DiscountType string    `json:"discount_type" xml:"discount_type" binding:"required" enums:"percentage,fixed"`
+	Value        int       `json:"value" xml:"value" binding:"required" minimum:"1"`
+	StartsAt     time.Time `json:"starts_at" xml:"starts_at" binding:"required" format:"date-time"`
+	EndsAt       time.Time `json:"ends_at" xml:"ends_at" binding:"required" format:"date-time"`
+}
+
+type EvaluateItemInput struct {
+	ProductID string `json:"product_id" xml:"product_id" binding:"required"`
+	Quantity  int    `json:"quantity" xml:"quantity" binding:"required" minimum:"1"`
+}
+
+type EvaluatePromotionsInput struct {
+	Items      []EvaluateItemInput `json:"items" xml:"items>item" binding:"required" minItems:"1"`
+	CouponCode string              `json:"coupon_code" xml:"coupon_code"`
+}
+
+type ReviewInput struct {
+	Rating  int    `json:"rating" xml:"rating" binding:"required" minimum:"1" maximum:"5"`
+	Comment string `json:"comment" xml:"comment"`
+}
+
+// DefaultCurrency é a única moeda aceita; a v2 já expõe a moeda para permitir outras no futuro
+const DefaultCurrency = "BRL"
+
+// Money é o formato de preço da v2. Amount é o mesmo valor inteiro do price da v1
+type Money struct {
+	Amount   int    `json:"amount" xml:"amount" binding:"required" minimum:"1"`
+	Currency string `json:"currency" xml:"currency" example:"BRL"`
+}
+
+type CreateProductV2Input struct {
+	Name     string `json:"name" xml:"name" binding:"required" minLength:"1"`
+	Price    Money  `json:"price" xml:"price" binding:"required"`
+	Category string `json:"category" xml:"category"`
+}
+
+type RatingOutput struct {
+	Average float64 `json:"average"`
+	Count   int     `json:"count"`
+}
+
+type ProductV2Output struct {
+	ID        string       `json:"id"`
+	Name      string       `json:"name"`
+	Price     Money        `json:"price"`
+	Category  string       `json:"category"`
+	Rating    RatingOutput `json:"rating"`
+	CreatedAt time.Time    `json:"created_at"`
+	UpdatedAt time.Time    `json:"updated_at"`
+}
+
+// ProductListV2Output envelopa a listagem para que a paginação possa crescer sem mudar o formato
+type ProductListV2Output struct {
+	Data  []ProductV2Output `json:"data"`
+	Page  int               `json:"page"`
+	Limit int               `json:"limit"`
+}
+
+// JobAcceptedOutput é a resposta 202 das operações longas. O andamento é consultado em StatusURL
+type JobAcceptedOutput struct {
+	ID        string `json:"id"`
+	Status    string `json:"status"`
+	StatusURL string `json:"status_url"`
+}
@@ -0,0 +1,136 @@
+package entity
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/pkg/entity"
+)
+
+const (
+	AuditCreate = "create"
+	AuditUpdate = "update"
+	AuditDelete = "delete"
+
+	// AnonymousActor é usado nas rotas públicas, onde não há JWT (ex.: cadastro de usuário)
+	AnonymousActor = "anonymous"
+)
+
+var (
+	ErrInvalidAuditAction = errors.New("invalid audit action")
+)
+
+// AuditChange guarda o valor antigo e o novo de um campo. Em criações Before é nulo e em remoções After é nulo
+type AuditChange struct {
+	Before interface{} `json:"before" extensions:"x-nullable"`
+	After  interface{} `json:"after" extensions:"x-nullable"`
+}
+
+// AuditChanges é gravado como JSON em uma única coluna
+type AuditChanges map[string]AuditChange
+
+func (c AuditChanges) Value() (driver.Value, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(data), nil
+}
+
+func (c *AuditChanges) Scan(value interface{}) error {
+	switch data := value.(type) {
+	case nil:
+		*c = nil
+		return nil
+	case string:
+		return json.Unmarshal([]byte(data), c)
+	case []byte:
+		return json.Unmarshal(data, c)
+	default:
+		return fmt.Errorf("unsupported audit changes type %T", value)
+	}
+}
+
+type AuditEntry struct {
+	ID         entity.ID    `json:"id"`
+	Actor      string       `json:"actor" gorm:"index"`
+	Action     string       `json:"action"`
+	EntityType string       `json:"entity" gorm:"index"`
+	EntityID   string       `json:"entity_id" gorm:"index"`
+	Changes    AuditChanges `json:"changes" gorm:"type:text"`
+	CreatedAt  time.Time    `json:"created_at" gorm:"index"`
+}
+
+// NewAuditEntry calcula a diferença entre before e after usando a serialização JSON das entidades,
+// assim campos com json:"-" (ex.: a senha do usuário) nunca vão para o log
+func NewAuditEntry(actor, action, entityType, entityID string, before, after interface{}) (*AuditEntry, error) {
+	if action != AuditCreate && action != AuditUpdate && action != AuditDelete {
+		return nil, ErrInvalidAuditAction
+	}
+
+	if actor == "" {
+		actor = AnonymousActor
+	}
+
+	changes, err := diff(before, after)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuditEntry{
+		ID:         entity.NewID(),
+		Actor:      actor,
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Changes:    changes,
+		CreatedAt:  time.Now(),
+	}, nil
+}
+
+func diff(before, after interface{}) (AuditChanges, error) {
+	beforeFields, err := toFields(before)
+	if err != nil {
+		return nil, err
+	}
+
+	afterFields, err := toFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := AuditChanges{}
+	for field, value := range beforeFields {
+		if newValue, ok := afterFields[field]; !ok || !reflect.DeepEqual(value, newValue) {
+			changes[field] = AuditChange{Before: value, After: afterFields[field]}
+		}
+	}
+
+	for field, value := range afterFields {
+		if _, ok := beforeFields[field]; !ok {
+			changes[field] = AuditChange{After: value}
+		}
+	}
+
+	return changes, nil
+}
+
+func toFields(value interface{}) (map[string]interface{}, error) {
+	fields := map[string]interface{}{}
+	if value == nil || (reflect.ValueOf(value).Kind() == reflect.Ptr && reflect.ValueOf(value).IsNil()) {
+		return fields, nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(data, &fields)
+	return fields, err
+}
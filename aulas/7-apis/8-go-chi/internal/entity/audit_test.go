@@ -0,0 +1,56 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewAuditEntryCreate(t *testing.T) {
+	user, _ := NewUser("John", "john@email.com", "123456")
+
+	entry, err := NewAuditEntry("", AuditCreate, "user", user.ID.String(), nil, user)
+	assert.Nil(t, err)
+	assert.Equal(t, AnonymousActor, entry.Actor)
+	assert.Equal(t, AuditChange{After: "John"}, entry.Changes["name"])
+
+	// Campos fora do JSON não entram no log
+	_, ok := entry.Changes["password"]
+	assert.False(t, ok)
+	_, ok = entry.Changes["Password"]
+	assert.False(t, ok)
+}
+
+func TestNewAuditEntryUpdateOnlyKeepsChangedFields(t *testing.T) {
+	product, _ := NewProduct("Product 1", 10)
+	updated := *product
+	updated.Price = 20
+
+	entry, err := NewAuditEntry("user-id", AuditUpdate, "product", product.ID.String(), product, &updated)
+	assert.Nil(t, err)
+	assert.Equal(t, "user-id", entry.Actor)
+	assert.Len(t, entry.Changes, 1)
+	assert.Equal(t, AuditChange{Before: 10.0, After: 20.0}, entry.Changes["price"])
+}
+
+func TestNewAuditEntryDelete(t *testing.T) {
+	product, _ := NewProduct("Product 1", 10)
+
+	entry, err := NewAuditEntry("user-id", AuditDelete, "product", product.ID.String(), product, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, AuditChange{Before: "Product 1"}, entry.Changes["name"])
+
+	_, err = NewAuditEntry("user-id", "patch", "product", product.ID.String(), product, nil)
+	assert.Equal(t, ErrInvalidAuditAction, err)
+}
+
+func TestAuditChangesValueAndScan(t *testing.T) {
+	changes := AuditChanges{"price": {Before: 10.0, After: 20.0}}
+
+	value, err := changes.Value()
+	assert.Nil(t, err)
+
+	var scanned AuditChanges
+	assert.Nil(t, scanned.Scan(value))
+	assert.Equal(t, changes, scanned)
+}
@@ -0,0 +1,211 @@
+package entity
+
+import (
+	"errors"
+	"time"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/pkg/entity"
+)
+
+var (
+	ErrUserIsRequired   = errors.New("user is required")
+	ErrInvalidQuantity  = errors.New("invalid quantity")
+	ErrCartItemNotFound = errors.New("cart item not found")
+	ErrCartConflict     = errors.New("cart was changed by another request")
+)
+
+type Cart struct {
+	ID        entity.ID  `json:"id"`
+	UserID    string     `json:"user_id" gorm:"uniqueIndex"`
+	Items     []CartItem `json:"items" gorm:"foreignKey:CartID;constraint:OnDelete:CASCADE"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	// Version é conferida e incrementada a cada Save, para que duas requisições não gravem por cima uma da outra
+	Version int `json:"-" gorm:"not null;default:0"`
+}
+
+// CartItem guarda o preço unitário do momento em que foi adicionado (ou revalidado pela última vez).
+// Assim conseguimos avisar o comprador quando o preço do produto mudar
+type CartItem struct {
+	ID        entity.ID `json:"id"`
+	CartID    entity.ID `json:"-" gorm:"index"`
+	ProductID entity.ID `json:"product_id" gorm:"index"`
+	Quantity  int       `json:"quantity"`
+	UnitPrice int       `json:"unit_price"`
+	// PreviousPrice e Removed são as mudanças do catálogo aplicadas por ApplyProduct e RemoveProduct que o
+	// comprador ainda não viu. O próximo Revalidate as devolve e limpa
+	PreviousPrice int  `json:"-"`
+	Removed       bool `json:"-"`
+}
+
+// CartPriceChange descreve um item cujo preço mudou desde a última vez que o carrinho foi validado
+type CartPriceChange struct {
+	ProductID entity.ID `json:"product_id"`
+	OldPrice  int       `json:"old_price"`
+	NewPrice  int       `json:"new_price"`
+}
+
+func NewCart(userID string) (*Cart, error) {
+	if userID == "" {
+		return nil, ErrUserIsRequired
+	}
+
+	return &Cart{
+		ID:        entity.NewID(),
+		UserID:    userID,
+		Items:     []CartItem{},
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}, nil
+}
+
+// AddItem soma a quantidade caso o produto já esteja no carrinho
+func (c *Cart) AddItem(product *Product, quantity int) error {
+	if quantity <= 0 {
+		return ErrInvalidQuantity
+	}
+
+	for i := range c.Items {
+		if c.Items[i].ProductID == product.ID {
+			c.Items[i].Quantity += quantity
+			c.Items[i].UnitPrice = product.Price
+			c.UpdatedAt = time.Now()
+			return nil
+		}
+	}
+
+	c.Items = append(c.Items, CartItem{
+		ID:        entity.NewID(),
+		CartID:    c.ID,
+		ProductID: product.ID,
+		Quantity:  quantity,
+		UnitPrice: product.Price,
+	})
+	c.UpdatedAt = time.Now()
+
+	return nil
+}
+
+func (c *Cart) UpdateQuantity(productID entity.ID, quantity int) error {
+	if quantity <= 0 {
+		return ErrInvalidQuantity
+	}
+
+	for i := range c.Items {
+		if c.Items[i].ProductID == productID {
+			c.Items[i].Quantity = quantity
+			c.UpdatedAt = time.Now()
+			return nil
+		}
+	}
+
+	return ErrCartItemNotFound
+}
+
+func (c *Cart) RemoveItem(productID entity.ID) error {
+	for i := range c.Items {
+		if c.Items[i].ProductID == productID {
+			c.Items = append(c.Items[:i], c.Items[i+1:]...)
+			c.UpdatedAt = time.Now()
+			return nil
+		}
+	}
+
+	return ErrCartItemNotFound
+}
+
+// ApplyProduct aplica o preço novo do produto no carrinho, guardando o preço anterior para o próximo Revalidate
+// avisar o comprador. Devolve se o carrinho mudou
+func (c *Cart) ApplyProduct(product *Product) bool {
+	changed := false
+	for i := range c.Items {
+		item := &c.Items[i]
+		if item.ProductID != product.ID || item.Removed || item.UnitPrice == product.Price {
+			continue
+		}
+
+		if item.PreviousPrice == 0 {
+			item.PreviousPrice = item.UnitPrice
+		}
+		item.UnitPrice = product.Price
+		changed = true
+	}
+
+	if changed {
+		c.UpdatedAt = time.Now()
+	}
+	return changed
+}
+
+// RemoveProduct marca o item do produto excluído. Ele sai do carrinho no próximo Revalidate, que avisa o comprador
+func (c *Cart) RemoveProduct(productID entity.ID) bool {
+	changed := false
+	for i := range c.Items {
+		if c.Items[i].ProductID == productID && !c.Items[i].Removed {
+			c.Items[i].Removed = true
+			changed = true
+		}
+	}
+
+	if changed {
+		c.UpdatedAt = time.Now()
+	}
+	return changed
+}
+
+// Revalidate confere cada item com o catálogo: produtos removidos saem do carrinho e
+// itens com preço diferente recebem o preço atual. As mudanças já aplicadas por ApplyProduct e RemoveProduct
+// também são devolvidas. find deve devolver (nil, nil) quando o produto não existe
+func (c *Cart) Revalidate(find func(productID string) (*Product, error)) ([]CartPriceChange, []entity.ID, error) {
+	var changes []CartPriceChange
+	var removed []entity.ID
+
+	items := make([]CartItem, 0, len(c.Items))
+	for _, item := range c.Items {
+		if item.Removed {
+			removed = append(removed, item.ProductID)
+			continue
+		}
+
+		product, err := find(item.ProductID.String())
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if product == nil {
+			removed = append(removed, item.ProductID)
+			continue
+		}
+
+		// PreviousPrice é o último preço que o comprador viu
+		oldPrice := item.UnitPrice
+		if item.PreviousPrice != 0 {
+			oldPrice = item.PreviousPrice
+		}
+		if product.Price != oldPrice {
+			changes = append(changes, CartPriceChange{ProductID: item.ProductID, OldPrice: oldPrice, NewPrice: product.Price})
+		}
+
+		item.PreviousPrice = 0
+		item.UnitPrice = product.Price
+		items = append(items, item)
+	}
+
+	// Um preço que voltou ao que o comprador viu não gera aviso nem precisa ser gravado: a próxima leitura
+	// chega ao mesmo resultado
+	c.Items = items
+	if len(changes) > 0 || len(removed) > 0 {
+		c.UpdatedAt = time.Now()
+	}
+
+	return changes, removed, nil
+}
+
+func (c *Cart) Total() int {
+	total := 0
+	for _, item := range c.Items {
+		total += item.Quantity * item.UnitPrice
+	}
+
+	return total
+}
@@ -0,0 +1,113 @@
+package entity
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/pkg/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewCart(t *testing.T) {
+	cart, err := NewCart("user-id")
+
+	assert.Nil(t, err)
+	assert.NotEmpty(t, cart.ID)
+	assert.Equal(t, "user-id", cart.UserID)
+	assert.Empty(t, cart.Items)
+
+	cart, err = NewCart("")
+	assert.Nil(t, cart)
+	assert.Equal(t, ErrUserIsRequired, err)
+}
+
+func TestCartItems(t *testing.T) {
+	cart, _ := NewCart("user-id")
+	product, _ := NewProduct("Product 1", 10)
+	product2, _ := NewProduct("Product 2", 25)
+
+	assert.Nil(t, cart.AddItem(product, 2))
+	assert.Nil(t, cart.AddItem(product, 1))
+	assert.Nil(t, cart.AddItem(product2, 1))
+	assert.Len(t, cart.Items, 2)
+	assert.Equal(t, 3, cart.Items[0].Quantity)
+	assert.Equal(t, 55, cart.Total())
+
+	assert.Equal(t, ErrInvalidQuantity, cart.AddItem(product, 0))
+
+	assert.Nil(t, cart.UpdateQuantity(product2.ID, 4))
+	assert.Equal(t, 130, cart.Total())
+	assert.Equal(t, ErrInvalidQuantity, cart.UpdateQuantity(product2.ID, -1))
+	assert.Equal(t, ErrCartItemNotFound, cart.UpdateQuantity(entity.NewID(), 1))
+
+	assert.Nil(t, cart.RemoveItem(product.ID))
+	assert.Len(t, cart.Items, 1)
+	assert.Equal(t, ErrCartItemNotFound, cart.RemoveItem(product.ID))
+}
+
+func TestCartRevalidate(t *testing.T) {
+	cart, _ := NewCart("user-id")
+	product, _ := NewProduct("Product 1", 10)
+	product2, _ := NewProduct("Product 2", 25)
+	cart.AddItem(product, 2)
+	cart.AddItem(product2, 1)
+
+	catalog := map[string]*Product{product.ID.String(): {ID: product.ID, Name: product.Name, Price: 15}}
+	changes, removed, err := cart.Revalidate(func(productID string) (*Product, error) {
+		return catalog[productID], nil
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []CartPriceChange{{ProductID: product.ID, OldPrice: 10, NewPrice: 15}}, changes)
+	assert.Equal(t, []entity.ID{product2.ID}, removed)
+	assert.Len(t, cart.Items, 1)
+	assert.Equal(t, 30, cart.Total())
+
+	_, _, err = cart.Revalidate(func(productID string) (*Product, error) {
+		return nil, errors.New("database unavailable")
+	})
+	assert.NotNil(t, err)
+	assert.Len(t, cart.Items, 1)
+}
+
+func TestCartCatalogChanges(t *testing.T) {
+	cart, _ := NewCart("user-id")
+	product, _ := NewProduct("Product 1", 10)
+	product2, _ := NewProduct("Product 2", 25)
+	cart.AddItem(product, 2)
+	cart.AddItem(product2, 1)
+
+	// As mudanças do catálogo entram no carrinho na hora, mas o aviso fica para a próxima leitura
+	product.Price = 12
+	assert.True(t, cart.ApplyProduct(product))
+	product.Price = 15
+	assert.True(t, cart.ApplyProduct(product))
+	assert.False(t, cart.ApplyProduct(product))
+	assert.True(t, cart.RemoveProduct(product2.ID))
+	assert.False(t, cart.RemoveProduct(product2.ID))
+
+	catalog := map[string]*Product{product.ID.String(): product}
+	find := func(productID string) (*Product, error) {
+		return catalog[productID], nil
+	}
+
+	changes, removed, err := cart.Revalidate(find)
+	assert.Nil(t, err)
+	assert.Equal(t, []CartPriceChange{{ProductID: product.ID, OldPrice: 10, NewPrice: 15}}, changes)
+	assert.Equal(t, []entity.ID{product2.ID}, removed)
+	assert.Equal(t, 30, cart.Total())
+
+	// Depois de avisado, nada se repete
+	changes, removed, _ = cart.Revalidate(find)
+	assert.Empty(t, changes)
+	assert.Empty(t, removed)
+
+	// Preço que volta ao que o comprador viu não gera aviso
+	product.Price = 20
+	cart.ApplyProduct(product)
+	product.Price = 15
+	cart.ApplyProduct(product)
+	changes, _, _ = cart.Revalidate(find)
+	assert.Empty(t, changes)
+	assert.Equal(t, 30, cart.Total())
+}
@@ -0,0 +1,132 @@
+package entity
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"regexp"
+	"time"
+)
+
+var (
+	ErrInvalidFlagKey    = errors.New("flag key must have only lowercase letters, digits, dots, dashes and underscores")
+	ErrInvalidPercentage = errors.New("percentage must be between 0 and 100")
+)
+
+var flagKeyPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{0,63}$`)
+
+// FeatureFlag liga um comportamento aos poucos, sem novo deploy. Com Enabled falso ninguém recebe a flag;
+// com ela ligada, recebem os usuários e roles das listas e Percentage por cento dos demais usuários.
+// Uma flag booleana é só Enabled com Percentage 100
+type FeatureFlag struct {
+	Key         string     `json:"key" gorm:"primaryKey"`
+	Description string     `json:"description"`
+	Enabled     bool       `json:"enabled"`
+	Percentage  int        `json:"percentage"`
+	UserIDs     StringList `json:"user_ids" gorm:"type:text"`
+	Roles       StringList `json:"roles" gorm:"type:text"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// StringList é gravada como JSON em uma coluna, como os códigos de recuperação do usuário
+type StringList []string
+
+func (l StringList) Value() (driver.Value, error) {
+	data, err := json.Marshal(l)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(data), nil
+}
+
+func (l *StringList) Scan(value interface{}) error {
+	switch data := value.(type) {
+	case nil:
+		*l = nil
+		return nil
+	case string:
+		return json.Unmarshal([]byte(data), l)
+	case []byte:
+		return json.Unmarshal(data, l)
+	default:
+		return fmt.Errorf("unsupported string list type %T", value)
+	}
+}
+
+func NewFeatureFlag(key, description string, enabled bool, percentage int, userIDs, roles []string) (*FeatureFlag, error) {
+	flag := &FeatureFlag{
+		Key:         key,
+		Description: description,
+		Enabled:     enabled,
+		Percentage:  percentage,
+		UserIDs:     userIDs,
+		Roles:       roles,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+
+	if err := flag.Validate(); err != nil {
+		return nil, err
+	}
+
+	return flag, nil
+}
+
+func (f *FeatureFlag) Validate() error {
+	if !flagKeyPattern.MatchString(f.Key) {
+		return ErrInvalidFlagKey
+	}
+
+	if f.Percentage < 0 || f.Percentage > 100 {
+		return ErrInvalidPercentage
+	}
+
+	return nil
+}
+
+// EnabledFor decide se o usuário recebe a flag. userID e role vêm do JWT; usuários anônimos só recebem
+// flags com 100%, já que sem id não há como manter a mesma resposta entre as requisições
+func (f *FeatureFlag) EnabledFor(userID, role string) bool {
+	if !f.Enabled {
+		return false
+	}
+
+	if userID != "" && contains(f.UserIDs, userID) {
+		return true
+	}
+	if role != "" && contains(f.Roles, role) {
+		return true
+	}
+
+	if f.Percentage >= 100 {
+		return true
+	}
+	if f.Percentage <= 0 || userID == "" {
+		return false
+	}
+
+	return f.bucket(userID) < f.Percentage
+}
+
+// bucket distribui os usuários de 0 a 99. A chave entra no hash para que cada flag sorteie um grupo diferente,
+// e o mesmo usuário continua no grupo quando o percentual aumenta
+func (f *FeatureFlag) bucket(userID string) int {
+	hash := fnv.New32a()
+	hash.Write([]byte(f.Key + ":" + userID))
+
+	return int(hash.Sum32() % 100)
+}
+
+func contains(values []string, value string) bool {
+	for _, item := range values {
+		if item == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,82 @@
+package entity
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewFeatureFlag(t *testing.T) {
+	flag, err := NewFeatureFlag("new-pricing", "Nova regra de preço", true, 10, nil, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "new-pricing", flag.Key)
+
+	_, err = NewFeatureFlag("New Pricing", "", true, 10, nil, nil)
+	assert.Equal(t, ErrInvalidFlagKey, err)
+
+	_, err = NewFeatureFlag("", "", true, 10, nil, nil)
+	assert.Equal(t, ErrInvalidFlagKey, err)
+
+	_, err = NewFeatureFlag("new-pricing", "", true, 101, nil, nil)
+	assert.Equal(t, ErrInvalidPercentage, err)
+}
+
+func TestFeatureFlagTargeting(t *testing.T) {
+	flag, _ := NewFeatureFlag("new-dto", "", true, 0, []string{"user-1"}, []string{"admin"})
+
+	assert.True(t, flag.EnabledFor("user-1", "user"))
+	assert.True(t, flag.EnabledFor("user-2", "admin"))
+	assert.False(t, flag.EnabledFor("user-2", "user"))
+	assert.False(t, flag.EnabledFor("", ""))
+
+	// Desligada, a flag não vale nem para os alvos
+	flag.Enabled = false
+	assert.False(t, flag.EnabledFor("user-1", "admin"))
+
+	flag.Enabled = true
+	flag.Percentage = 100
+	assert.True(t, flag.EnabledFor("user-2", "user"))
+	assert.True(t, flag.EnabledFor("", ""))
+}
+
+func TestFeatureFlagPercentage(t *testing.T) {
+	flag, _ := NewFeatureFlag("new-pricing", "", true, 30, nil, nil)
+
+	enabled := map[string]bool{}
+	for i := 0; i < 1000; i++ {
+		userID := fmt.Sprintf("user-%d", i)
+		enabled[userID] = flag.EnabledFor(userID, "user")
+	}
+
+	count := 0
+	for _, value := range enabled {
+		if value {
+			count++
+		}
+	}
+	assert.InDelta(t, 300, count, 60)
+
+	// A resposta é estável, e quem já recebeu continua recebendo quando o percentual aumenta
+	flag.Percentage = 60
+	for userID, value := range enabled {
+		if value {
+			assert.True(t, flag.EnabledFor(userID, "user"))
+		}
+	}
+
+	assert.False(t, flag.EnabledFor("", ""))
+}
+
+func TestStringListValue(t *testing.T) {
+	value, err := StringList{"a", "b"}.Value()
+	assert.Nil(t, err)
+	assert.Equal(t, `["a","b"]`, value)
+
+	var list StringList
+	assert.Nil(t, list.Scan(`["a","b"]`))
+	assert.Equal(t, StringList{"a", "b"}, list)
+	assert.Nil(t, list.Scan(nil))
+	assert.Nil(t, list)
+	assert.Error(t, list.Scan(42))
+}
@@ -0,0 +1,145 @@
+package entity
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/pkg/entity"
+)
+
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+
+	DefaultJobMaxAttempts = 5
+)
+
+var (
+	ErrJobTypeIsRequired   = errors.New("job type is required")
+	ErrInvalidMaxAttempts  = errors.New("max attempts must be at least 1")
+	ErrJobAlreadyEnqueued  = errors.New("job already enqueued")
+	ErrJobNotFinished      = errors.New("job has not finished yet")
+	ErrJobFailed           = errors.New("job failed")
+	ErrJobAttemptsExceeded = errors.New("job exceeded its attempts")
+	// ErrJobLeaseLost aparece quando o prazo do worker venceu e outro worker já pegou o job
+	ErrJobLeaseLost = errors.New("job lease lost")
+)
+
+// Job é uma unidade de trabalho executada fora da requisição HTTP. Payload e Result são texto livre
+// (normalmente JSON ou CSV) e só o tipo sabe interpretá-los
+type Job struct {
+	ID          entity.ID `json:"id"`
+	Type        string    `json:"type" gorm:"index"`
+	Payload     string    `json:"-" gorm:"type:text"`
+	Status      string    `json:"status" gorm:"index"`
+	Attempts    int       `json:"attempts"`
+	MaxAttempts int       `json:"max_attempts"`
+	// RunAt é quando o job pode rodar: agora, uma data agendada ou o próximo retry
+	RunAt     time.Time `json:"run_at" gorm:"index"`
+	LastError string    `json:"last_error,omitempty"`
+	Result    string    `json:"-" gorm:"type:text"`
+	// ResultType é o Content-Type do Result (ex.: text/csv)
+	ResultType string `json:"-"`
+	// Owner é o usuário que pediu o job; só ele (ou um admin) consulta o status
+	Owner string `json:"-" gorm:"index"`
+	// UniqueKey evita que o mesmo job seja enfileirado duas vezes (ex.: o mesmo disparo do cron em duas instâncias)
+	UniqueKey *string `json:"-" gorm:"uniqueIndex"`
+	// LockedUntil é o prazo do worker que pegou o job. Se ele morrer, o job volta a ser elegível depois disso
+	LockedUntil time.Time  `json:"-"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+func NewJob(jobType string, payload interface{}, runAt time.Time, maxAttempts int) (*Job, error) {
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if runAt.IsZero() {
+		runAt = now
+	}
+
+	job := &Job{
+		ID:          entity.NewID(),
+		Type:        jobType,
+		Payload:     string(encoded),
+		Status:      JobQueued,
+		MaxAttempts: maxAttempts,
+		RunAt:       runAt,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	err = job.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+func (j *Job) Validate() error {
+	if j.Type == "" {
+		return ErrJobTypeIsRequired
+	}
+
+	if j.MaxAttempts < 1 {
+		return ErrInvalidMaxAttempts
+	}
+
+	return nil
+}
+
+// Decode lê o payload no formato que foi enfileirado
+func (j *Job) Decode(payload interface{}) error {
+	return json.Unmarshal([]byte(j.Payload), payload)
+}
+
+func (j *Job) Finished() bool {
+	return j.Status == JobSucceeded || j.Status == JobFailed
+}
+
+func (j *Job) Succeed(result, resultType string, now time.Time) {
+	j.Status = JobSucceeded
+	j.Result = result
+	j.ResultType = resultType
+	j.LastError = ""
+	j.finish(now)
+}
+
+// Fail agenda uma nova tentativa em retryAt ou, se as tentativas acabaram, marca o job como falho
+func (j *Job) Fail(err error, retryAt, now time.Time) {
+	j.LastError = err.Error()
+	j.LockedUntil = time.Time{}
+	j.UpdatedAt = now
+
+	if j.Attempts >= j.MaxAttempts {
+		j.Status = JobFailed
+		j.finish(now)
+		return
+	}
+
+	j.Status = JobQueued
+	j.RunAt = retryAt
+}
+
+// Release devolve à fila um job interrompido pelo worker (ex.: shutdown), sem contar a tentativa nem
+// aplicar backoff: o job não falhou, só não terminou
+func (j *Job) Release(now time.Time) {
+	j.Status = JobQueued
+	j.Attempts--
+	j.LockedUntil = time.Time{}
+	j.UpdatedAt = now
+}
+
+func (j *Job) finish(now time.Time) {
+	j.LockedUntil = time.Time{}
+	j.FinishedAt = &now
+	j.UpdatedAt = now
+}
@@ -0,0 +1,74 @@
+package entity
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewJob(t *testing.T) {
+	job, err := NewJob("products.export", map[string]string{"format": "csv"}, time.Time{}, 3)
+	assert.Nil(t, err)
+	assert.Equal(t, JobQueued, job.Status)
+	assert.Equal(t, `{"format":"csv"}`, job.Payload)
+	assert.False(t, job.RunAt.IsZero())
+
+	var payload map[string]string
+	assert.Nil(t, job.Decode(&payload))
+	assert.Equal(t, "csv", payload["format"])
+
+	_, err = NewJob("", nil, time.Time{}, 3)
+	assert.Equal(t, ErrJobTypeIsRequired, err)
+
+	_, err = NewJob("products.export", nil, time.Time{}, 0)
+	assert.Equal(t, ErrInvalidMaxAttempts, err)
+}
+
+func TestJobRetriesUntilAttemptsRunOut(t *testing.T) {
+	job, _ := NewJob("products.export", nil, time.Time{}, 2)
+	now := time.Now()
+	retryAt := now.Add(time.Minute)
+
+	job.Attempts = 1
+	job.Fail(errors.New("timeout"), retryAt, now)
+	assert.Equal(t, JobQueued, job.Status)
+	assert.Equal(t, retryAt, job.RunAt)
+	assert.Equal(t, "timeout", job.LastError)
+	assert.False(t, job.Finished())
+
+	job.Attempts = 2
+	job.Fail(errors.New("timeout"), retryAt, now)
+	assert.Equal(t, JobFailed, job.Status)
+	assert.True(t, job.Finished())
+	assert.Equal(t, now, *job.FinishedAt)
+}
+
+func TestJobSucceed(t *testing.T) {
+	job, _ := NewJob("products.export", nil, time.Time{}, 2)
+	job.Attempts = 1
+	job.Fail(errors.New("timeout"), time.Now(), time.Now())
+
+	job.Succeed("id,name", "text/csv", time.Now())
+	assert.Equal(t, JobSucceeded, job.Status)
+	assert.Equal(t, "id,name", job.Result)
+	assert.Equal(t, "text/csv", job.ResultType)
+	assert.Empty(t, job.LastError)
+	assert.NotNil(t, job.FinishedAt)
+}
+
+func TestJobRelease(t *testing.T) {
+	job, _ := NewJob("products.export", nil, time.Time{}, 2)
+	runAt := job.RunAt
+	job.Status = JobRunning
+	job.Attempts = 1
+	job.LockedUntil = time.Now().Add(time.Minute)
+
+	job.Release(time.Now())
+	assert.Equal(t, JobQueued, job.Status)
+	assert.Equal(t, 0, job.Attempts)
+	assert.Equal(t, runAt, job.RunAt)
+	assert.True(t, job.LockedUntil.IsZero())
+	assert.Empty(t, job.LastError)
+}
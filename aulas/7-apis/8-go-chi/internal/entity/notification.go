@@ -0,0 +1,48 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/pkg/entity"
+)
+
+// Tipos de notificação gerados pelas mudanças do catálogo nos produtos das listas de desejos
+const (
+	NotificationPriceDrop      = "wishlist_price_drop"
+	NotificationProductRemoved = "wishlist_product_removed"
+)
+
+// Notification é um aviso para o dono da lista. No produto removido NewPrice fica zerado
+type Notification struct {
+	ID          entity.ID `json:"id"`
+	UserID      string    `json:"user_id" gorm:"index"`
+	Type        string    `json:"type"`
+	WishlistID  entity.ID `json:"wishlist_id"`
+	ProductID   entity.ID `json:"product_id"`
+	ProductName string    `json:"product_name"`
+	OldPrice    int       `json:"old_price"`
+	NewPrice    int       `json:"new_price"`
+	CreatedAt   time.Time `json:"created_at" gorm:"index"`
+}
+
+func NewPriceDropNotification(wishlist *Wishlist, item WishlistItem, oldPrice int) *Notification {
+	return newNotification(NotificationPriceDrop, wishlist, item, oldPrice, item.Price)
+}
+
+func NewProductRemovedNotification(wishlist *Wishlist, item WishlistItem) *Notification {
+	return newNotification(NotificationProductRemoved, wishlist, item, item.Price, 0)
+}
+
+func newNotification(notificationType string, wishlist *Wishlist, item WishlistItem, oldPrice, newPrice int) *Notification {
+	return &Notification{
+		ID:          entity.NewID(),
+		UserID:      wishlist.UserID,
+		Type:        notificationType,
+		WishlistID:  wishlist.ID,
+		ProductID:   item.ProductID,
+		ProductName: item.ProductName,
+		OldPrice:    oldPrice,
+		NewPrice:    newPrice,
+		CreatedAt:   time.Now(),
+	}
+}
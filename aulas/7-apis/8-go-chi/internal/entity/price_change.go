@@ -0,0 +1,111 @@
+package entity
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/pkg/entity"
+)
+
+var ErrInvalidPeriod = errors.New("invalid period")
+
+// PriceChange registra cada alteração de preço de um produto, com quem alterou e quando
+type PriceChange struct {
+	ID        entity.ID `json:"id"`
+	ProductID entity.ID `json:"product_id" gorm:"index"`
+	OldPrice  int       `json:"old_price"`
+	NewPrice  int       `json:"new_price"`
+	ChangedBy string    `json:"changed_by"`
+	ChangedAt time.Time `json:"changed_at" gorm:"index"`
+}
+
+func NewPriceChange(productID entity.ID, oldPrice, newPrice int, changedBy string) *PriceChange {
+	return &PriceChange{
+		ID:        entity.NewID(),
+		ProductID: productID,
+		OldPrice:  oldPrice,
+		NewPrice:  newPrice,
+		ChangedBy: changedBy,
+		ChangedAt: time.Now(),
+	}
+}
+
+// PriceSummary é o resumo dos preços praticados em um período.
+// Average é ponderada pelo tempo em que cada preço ficou vigente
+type PriceSummary struct {
+	From    time.Time `json:"from"`
+	To      time.Time `json:"to"`
+	Min     int       `json:"min"`
+	Max     int       `json:"max"`
+	Average float64   `json:"average"`
+	Changes int       `json:"changes"`
+}
+
+// SummarizePrices calcula min, max e média do preço vigente entre from e to.
+// history deve conter todas as alterações do produto (não apenas as do período),
+// pois o preço no início do período depende da última alteração anterior a ele
+func SummarizePrices(currentPrice int, history []*PriceChange, from, to time.Time) (*PriceSummary, error) {
+	if to.Before(from) {
+		return nil, ErrInvalidPeriod
+	}
+
+	changes := make([]*PriceChange, len(history))
+	copy(changes, history)
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].ChangedAt.Before(changes[j].ChangedAt)
+	})
+
+	price := priceAt(currentPrice, changes, from)
+	summary := &PriceSummary{From: from, To: to, Min: price, Max: price}
+
+	var weighted float64
+	cursor := from
+	for _, change := range changes {
+		if !change.ChangedAt.After(from) {
+			continue
+		}
+		if change.ChangedAt.After(to) {
+			break
+		}
+
+		weighted += float64(price) * change.ChangedAt.Sub(cursor).Seconds()
+		cursor = change.ChangedAt
+		price = change.NewPrice
+		summary.Changes++
+
+		if price < summary.Min {
+			summary.Min = price
+		}
+		if price > summary.Max {
+			summary.Max = price
+		}
+	}
+	weighted += float64(price) * to.Sub(cursor).Seconds()
+
+	total := to.Sub(from).Seconds()
+	if total == 0 {
+		summary.Average = float64(price)
+		return summary, nil
+	}
+	summary.Average = weighted / total
+
+	return summary, nil
+}
+
+// priceAt devolve o preço vigente no instante informado. changes precisa estar ordenado por ChangedAt
+func priceAt(currentPrice int, changes []*PriceChange, instant time.Time) int {
+	price := currentPrice
+	if len(changes) > 0 {
+		price = changes[0].OldPrice
+	}
+
+	for _, change := range changes {
+		if change.ChangedAt.After(instant) {
+			break
+		}
+		price = change.NewPrice
+	}
+
+	return price
+}
@@ -0,0 +1,64 @@
+package entity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/pkg/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewPriceChange(t *testing.T) {
+	productID := entity.NewID()
+	change := NewPriceChange(productID, 10, 20, "user-id")
+
+	assert.NotEmpty(t, change.ID)
+	assert.Equal(t, productID, change.ProductID)
+	assert.Equal(t, 10, change.OldPrice)
+	assert.Equal(t, 20, change.NewPrice)
+	assert.Equal(t, "user-id", change.ChangedBy)
+	assert.NotEmpty(t, change.ChangedAt)
+}
+
+func TestSummarizePrices(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	history := []*PriceChange{
+		{OldPrice: 100, NewPrice: 200, ChangedAt: start.Add(10 * time.Hour)},
+		{OldPrice: 200, NewPrice: 50, ChangedAt: start.Add(20 * time.Hour)},
+	}
+
+	// 0h-10h: 100, 10h-20h: 200, 20h-40h: 50
+	summary, err := SummarizePrices(50, history, start, start.Add(40*time.Hour))
+	assert.Nil(t, err)
+	assert.Equal(t, 50, summary.Min)
+	assert.Equal(t, 200, summary.Max)
+	assert.Equal(t, 2, summary.Changes)
+	assert.InDelta(t, (100*10+200*10+50*20)/40.0, summary.Average, 0.0001)
+
+	// Período que começa depois da primeira alteração usa o preço vigente naquele instante
+	summary, err = SummarizePrices(50, history, start.Add(15*time.Hour), start.Add(25*time.Hour))
+	assert.Nil(t, err)
+	assert.Equal(t, 50, summary.Min)
+	assert.Equal(t, 200, summary.Max)
+	assert.Equal(t, 1, summary.Changes)
+	assert.InDelta(t, 125.0, summary.Average, 0.0001)
+}
+
+func TestSummarizePricesWithoutHistory(t *testing.T) {
+	now := time.Now()
+
+	summary, err := SummarizePrices(30, nil, now.Add(-time.Hour), now)
+	assert.Nil(t, err)
+	assert.Equal(t, 30, summary.Min)
+	assert.Equal(t, 30, summary.Max)
+	assert.Equal(t, 30.0, summary.Average)
+	assert.Equal(t, 0, summary.Changes)
+}
+
+func TestSummarizePricesWithInvalidPeriod(t *testing.T) {
+	now := time.Now()
+
+	summary, err := SummarizePrices(30, nil, now, now.Add(-time.Hour))
+	assert.Nil(t, summary)
+	assert.Equal(t, ErrInvalidPeriod, err)
+}
@@ -16,11 +16,16 @@ var (
 )
 
 type Product struct {
-	ID        entity.ID `json:"id"`
-	Name      string    `json:"name"`
-	Price     int       `json:"price"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID       entity.ID `json:"id"`
+	Name     string    `json:"name"`
+	Price    int       `json:"price"`
+	Category string    `json:"category,omitempty" gorm:"index"`
+	// Agregado das avaliações, mantido de forma incremental pelo repositório de reviews
+	RatingAverage float64   `json:"rating_average"`
+	RatingCount   int       `json:"rating_count"`
+	RatingSum     int       `json:"-"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
 }
 
 func (p *Product) Validate() error {
@@ -68,3 +73,17 @@ func NewProduct(name string, price int) (*Product, error) {
 
 	return product, nil
 }
+
+// ApplyRating ajusta o agregado das avaliações. Criar uma review soma (1, nota), apagar soma (-1, -nota)
+// e editar soma (0, nota nova - nota antiga)
+func (p *Product) ApplyRating(countDelta, sumDelta int) {
+	p.RatingCount += countDelta
+	p.RatingSum += sumDelta
+
+	if p.RatingCount <= 0 {
+		p.RatingCount, p.RatingSum, p.RatingAverage = 0, 0, 0
+		return
+	}
+
+	p.RatingAverage = float64(p.RatingSum) / float64(p.RatingCount)
+}
@@ -0,0 +1,322 @@
+package entity
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/pkg/entity"
+)
+
+const (
+	DiscountPercentage = "percentage"
+	DiscountFixed      = "fixed"
+)
+
+var (
+	ErrCouponCodeIsRequired     = errors.New("coupon code is required")
+	ErrInvalidDiscountType      = errors.New("invalid discount type")
+	ErrInvalidDiscountValue     = errors.New("invalid discount value")
+	ErrInvalidValidity          = errors.New("invalid validity window")
+	ErrInvalidUsageLimit        = errors.New("invalid usage limit")
+	ErrInvalidMinCartValue      = errors.New("invalid minimum cart value")
+	ErrPromotionScopeIsRequired = errors.New("promotion must target a product or a category")
+	ErrCouponNotActive          = errors.New("coupon is not active")
+	ErrCouponUsageLimitReached  = errors.New("coupon usage limit reached")
+	ErrCouponMinCartValue       = errors.New("cart value is below the coupon minimum")
+	ErrItemsAreRequired         = errors.New("items are required")
+)
+
+// Discount é compartilhado por cupons e regras de promoção.
+// Percentage usa Value de 1 a 100; Fixed usa Value na mesma unidade de Product.Price
+type Discount struct {
+	DiscountType string `json:"discount_type"`
+	Value        int    `json:"value"`
+}
+
+func (d Discount) Validate() error {
+	switch d.DiscountType {
+	case DiscountPercentage:
+		if d.Value <= 0 || d.Value > 100 {
+			return ErrInvalidDiscountValue
+		}
+	case DiscountFixed:
+		if d.Value <= 0 {
+			return ErrInvalidDiscountValue
+		}
+	default:
+		return ErrInvalidDiscountType
+	}
+
+	return nil
+}
+
+// Amount devolve quanto deve ser descontado de amount, nunca mais do que o próprio amount
+func (d Discount) Amount(amount int) int {
+	discount := d.Value
+	if d.DiscountType == DiscountPercentage {
+		discount = amount * d.Value / 100
+	}
+
+	if discount > amount {
+		return amount
+	}
+
+	return discount
+}
+
+// Validity é a janela em que cupons e promoções valem. Datas zeradas deixam a janela aberta naquela ponta
+type Validity struct {
+	StartsAt time.Time `json:"starts_at"`
+	EndsAt   time.Time `json:"ends_at"`
+}
+
+func (v Validity) Validate() error {
+	if !v.StartsAt.IsZero() && !v.EndsAt.IsZero() && !v.EndsAt.After(v.StartsAt) {
+		return ErrInvalidValidity
+	}
+
+	return nil
+}
+
+func (v Validity) Contains(now time.Time) bool {
+	if !v.StartsAt.IsZero() && now.Before(v.StartsAt) {
+		return false
+	}
+
+	if !v.EndsAt.IsZero() && !now.Before(v.EndsAt) {
+		return false
+	}
+
+	return true
+}
+
+// Coupon é aplicado sobre o subtotal do pedido, depois das promoções automáticas.
+// MaxUses e MinCartValue iguais a zero significam sem limite
+type Coupon struct {
+	ID           entity.ID `json:"id"`
+	Code         string    `json:"code" gorm:"uniqueIndex"`
+	Discount     `gorm:"embedded"`
+	Validity     `gorm:"embedded"`
+	MaxUses      int       `json:"max_uses"`
+	Uses         int       `json:"uses"`
+	MinCartValue int       `json:"min_cart_value"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// NormalizeCouponCode deixa a busca por código indiferente a maiúsculas e espaços
+func NormalizeCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func NewCoupon(code string, discount Discount, validity Validity, maxUses, minCartValue int) (*Coupon, error) {
+	coupon := &Coupon{
+		ID:           entity.NewID(),
+		Code:         NormalizeCouponCode(code),
+		Discount:     discount,
+		Validity:     validity,
+		MaxUses:      maxUses,
+		MinCartValue: minCartValue,
+		CreatedAt:    time.Now(),
+	}
+
+	err := coupon.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return coupon, nil
+}
+
+func (c *Coupon) Validate() error {
+	if c.Code == "" {
+		return ErrCouponCodeIsRequired
+	}
+
+	if err := c.Discount.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.Validity.Validate(); err != nil {
+		return err
+	}
+
+	if c.MaxUses < 0 {
+		return ErrInvalidUsageLimit
+	}
+
+	if c.MinCartValue < 0 {
+		return ErrInvalidMinCartValue
+	}
+
+	return nil
+}
+
+// Check verifica se o cupom pode ser usado em um pedido com o subtotal informado
+func (c *Coupon) Check(subtotal int, now time.Time) error {
+	if !c.Validity.Contains(now) {
+		return ErrCouponNotActive
+	}
+
+	if c.MaxUses > 0 && c.Uses >= c.MaxUses {
+		return ErrCouponUsageLimitReached
+	}
+
+	if subtotal < c.MinCartValue {
+		return ErrCouponMinCartValue
+	}
+
+	return nil
+}
+
+// PromotionRule é aplicada automaticamente aos itens do produto ou da categoria alvo.
+// Descontos fixos valem por unidade
+type PromotionRule struct {
+	ID        entity.ID `json:"id"`
+	Name      string    `json:"name"`
+	ProductID string    `json:"product_id,omitempty" gorm:"index"`
+	Category  string    `json:"category,omitempty" gorm:"index"`
+	Discount  `gorm:"embedded"`
+	Validity  `gorm:"embedded"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func NewPromotionRule(name, productID, category string, discount Discount, validity Validity) (*PromotionRule, error) {
+	rule := &PromotionRule{
+		ID:        entity.NewID(),
+		Name:      name,
+		ProductID: productID,
+		Category:  category,
+		Discount:  discount,
+		Validity:  validity,
+		CreatedAt: time.Now(),
+	}
+
+	err := rule.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+func (r *PromotionRule) Validate() error {
+	if r.Name == "" {
+		return ErrNameIsRequired
+	}
+
+	if r.ProductID == "" && r.Category == "" {
+		return ErrPromotionScopeIsRequired
+	}
+
+	if r.ProductID != "" {
+		if _, err := entity.ParseID(r.ProductID); err != nil {
+			return ErrInvalidID
+		}
+	}
+
+	if err := r.Discount.Validate(); err != nil {
+		return err
+	}
+
+	return r.Validity.Validate()
+}
+
+// AppliesTo diz se a regra vale para o produto no instante informado
+func (r *PromotionRule) AppliesTo(product *Product, now time.Time) bool {
+	if !r.Validity.Contains(now) {
+		return false
+	}
+
+	if r.ProductID != "" && r.ProductID != product.ID.String() {
+		return false
+	}
+
+	if r.Category != "" && r.Category != product.Category {
+		return false
+	}
+
+	return true
+}
+
+type PricedItem struct {
+	Product  *Product
+	Quantity int
+}
+
+type EvaluatedItem struct {
+	ProductID   string `json:"product_id"`
+	Name        string `json:"name"`
+	Quantity    int    `json:"quantity"`
+	UnitPrice   int    `json:"unit_price"`
+	Subtotal    int    `json:"subtotal"`
+	Discount    int    `json:"discount"`
+	Total       int    `json:"total"`
+	PromotionID string `json:"promotion_id,omitempty"`
+	Promotion   string `json:"promotion,omitempty"`
+}
+
+// Evaluation é o detalhamento de um pedido com promoções e cupom aplicados
+type Evaluation struct {
+	Items             []EvaluatedItem `json:"items"`
+	Subtotal          int             `json:"subtotal"`
+	PromotionDiscount int             `json:"promotion_discount"`
+	CouponCode        string          `json:"coupon_code,omitempty"`
+	CouponDiscount    int             `json:"coupon_discount"`
+	Total             int             `json:"total"`
+}
+
+// Evaluate aplica em cada item a promoção de maior desconto (elas não acumulam) e, por fim,
+// o cupom sobre o valor já com promoções. coupon pode ser nil
+func Evaluate(items []PricedItem, rules []*PromotionRule, coupon *Coupon, now time.Time) (*Evaluation, error) {
+	if len(items) == 0 {
+		return nil, ErrItemsAreRequired
+	}
+
+	evaluation := &Evaluation{Items: make([]EvaluatedItem, 0, len(items))}
+	for _, item := range items {
+		if item.Quantity <= 0 {
+			return nil, ErrInvalidQuantity
+		}
+
+		evaluated := EvaluatedItem{
+			ProductID: item.Product.ID.String(),
+			Name:      item.Product.Name,
+			Quantity:  item.Quantity,
+			UnitPrice: item.Product.Price,
+			Subtotal:  item.Product.Price * item.Quantity,
+		}
+
+		for _, rule := range rules {
+			if !rule.AppliesTo(item.Product, now) {
+				continue
+			}
+
+			discount := rule.Amount(item.Product.Price) * item.Quantity
+			if discount > evaluated.Discount {
+				evaluated.Discount = discount
+				evaluated.PromotionID = rule.ID.String()
+				evaluated.Promotion = rule.Name
+			}
+		}
+
+		evaluated.Total = evaluated.Subtotal - evaluated.Discount
+		evaluation.Items = append(evaluation.Items, evaluated)
+		evaluation.Subtotal += evaluated.Subtotal
+		evaluation.PromotionDiscount += evaluated.Discount
+	}
+
+	evaluation.Total = evaluation.Subtotal - evaluation.PromotionDiscount
+
+	if coupon != nil {
+		if err := coupon.Check(evaluation.Total, now); err != nil {
+			return nil, err
+		}
+
+		evaluation.CouponCode = coupon.Code
+		evaluation.CouponDiscount = coupon.Amount(evaluation.Total)
+		evaluation.Total -= evaluation.CouponDiscount
+	}
+
+	return evaluation, nil
+}
@@ -0,0 +1,105 @@
+package entity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiscountAmount(t *testing.T) {
+	assert.Equal(t, 25, Discount{DiscountType: DiscountPercentage, Value: 25}.Amount(100))
+	assert.Equal(t, 30, Discount{DiscountType: DiscountFixed, Value: 30}.Amount(100))
+	// O desconto nunca passa do valor
+	assert.Equal(t, 100, Discount{DiscountType: DiscountFixed, Value: 150}.Amount(100))
+
+	assert.Equal(t, ErrInvalidDiscountValue, Discount{DiscountType: DiscountPercentage, Value: 101}.Validate())
+	assert.Equal(t, ErrInvalidDiscountValue, Discount{DiscountType: DiscountFixed, Value: 0}.Validate())
+	assert.Equal(t, ErrInvalidDiscountType, Discount{DiscountType: "bogus", Value: 10}.Validate())
+}
+
+func TestNewCoupon(t *testing.T) {
+	coupon, err := NewCoupon(" promo10 ", Discount{DiscountType: DiscountPercentage, Value: 10}, Validity{}, 5, 1000)
+	assert.Nil(t, err)
+	assert.Equal(t, "PROMO10", coupon.Code)
+	assert.Equal(t, 5, coupon.MaxUses)
+
+	_, err = NewCoupon("", Discount{DiscountType: DiscountFixed, Value: 10}, Validity{}, 0, 0)
+	assert.Equal(t, ErrCouponCodeIsRequired, err)
+
+	now := time.Now()
+	_, err = NewCoupon("X", Discount{DiscountType: DiscountFixed, Value: 10}, Validity{StartsAt: now, EndsAt: now.Add(-time.Hour)}, 0, 0)
+	assert.Equal(t, ErrInvalidValidity, err)
+
+	_, err = NewCoupon("X", Discount{DiscountType: DiscountFixed, Value: 10}, Validity{}, -1, 0)
+	assert.Equal(t, ErrInvalidUsageLimit, err)
+}
+
+func TestCouponCheck(t *testing.T) {
+	now := time.Now()
+	coupon, _ := NewCoupon("X", Discount{DiscountType: DiscountFixed, Value: 10}, Validity{StartsAt: now.Add(-time.Hour), EndsAt: now.Add(time.Hour)}, 1, 100)
+
+	assert.Nil(t, coupon.Check(100, now))
+	assert.Equal(t, ErrCouponMinCartValue, coupon.Check(99, now))
+	assert.Equal(t, ErrCouponNotActive, coupon.Check(100, now.Add(-2*time.Hour)))
+	assert.Equal(t, ErrCouponNotActive, coupon.Check(100, now.Add(time.Hour)))
+
+	coupon.Uses = 1
+	assert.Equal(t, ErrCouponUsageLimitReached, coupon.Check(100, now))
+}
+
+func TestNewPromotionRule(t *testing.T) {
+	product, _ := NewProduct("Product 1", 100)
+
+	rule, err := NewPromotionRule("Promo", product.ID.String(), "", Discount{DiscountType: DiscountFixed, Value: 10}, Validity{})
+	assert.Nil(t, err)
+	assert.True(t, rule.AppliesTo(product, time.Now()))
+
+	_, err = NewPromotionRule("Promo", "", "", Discount{DiscountType: DiscountFixed, Value: 10}, Validity{})
+	assert.Equal(t, ErrPromotionScopeIsRequired, err)
+
+	_, err = NewPromotionRule("Promo", "not-an-id", "", Discount{DiscountType: DiscountFixed, Value: 10}, Validity{})
+	assert.Equal(t, ErrInvalidID, err)
+
+	_, err = NewPromotionRule("", "", "books", Discount{DiscountType: DiscountFixed, Value: 10}, Validity{})
+	assert.Equal(t, ErrNameIsRequired, err)
+}
+
+func TestEvaluate(t *testing.T) {
+	now := time.Now()
+	book, _ := NewProduct("Book", 100)
+	book.Category = "books"
+	pen, _ := NewProduct("Pen", 20)
+
+	categoryRule, _ := NewPromotionRule("Books 10%", "", "books", Discount{DiscountType: DiscountPercentage, Value: 10}, Validity{})
+	productRule, _ := NewPromotionRule("Book -15", book.ID.String(), "", Discount{DiscountType: DiscountFixed, Value: 15}, Validity{})
+	expiredRule, _ := NewPromotionRule("Pen -50%", pen.ID.String(), "", Discount{DiscountType: DiscountPercentage, Value: 50}, Validity{EndsAt: now.Add(-time.Hour)})
+	coupon, _ := NewCoupon("OFF10", Discount{DiscountType: DiscountFixed, Value: 10}, Validity{}, 0, 200)
+
+	items := []PricedItem{{Product: book, Quantity: 2}, {Product: pen, Quantity: 3}}
+	evaluation, err := Evaluate(items, []*PromotionRule{categoryRule, productRule, expiredRule}, coupon, now)
+	assert.Nil(t, err)
+
+	// Vale a promoção de maior desconto para cada item
+	assert.Equal(t, 30, evaluation.Items[0].Discount)
+	assert.Equal(t, "Book -15", evaluation.Items[0].Promotion)
+	assert.Equal(t, 170, evaluation.Items[0].Total)
+	assert.Equal(t, 0, evaluation.Items[1].Discount)
+	assert.Empty(t, evaluation.Items[1].PromotionID)
+
+	assert.Equal(t, 260, evaluation.Subtotal)
+	assert.Equal(t, 30, evaluation.PromotionDiscount)
+	assert.Equal(t, "OFF10", evaluation.CouponCode)
+	assert.Equal(t, 10, evaluation.CouponDiscount)
+	assert.Equal(t, 220, evaluation.Total)
+
+	// O mínimo do cupom é comparado com o valor já com as promoções
+	_, err = Evaluate([]PricedItem{{Product: book, Quantity: 2}}, []*PromotionRule{productRule}, coupon, now)
+	assert.Equal(t, ErrCouponMinCartValue, err)
+
+	_, err = Evaluate(nil, nil, nil, now)
+	assert.Equal(t, ErrItemsAreRequired, err)
+
+	_, err = Evaluate([]PricedItem{{Product: pen, Quantity: 0}}, nil, nil, now)
+	assert.Equal(t, ErrInvalidQuantity, err)
+}
@@ -0,0 +1,77 @@
+package entity
+
+import (
+	"errors"
+	"time"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/pkg/entity"
+)
+
+const (
+	MinRating = 1
+	MaxRating = 5
+)
+
+var (
+	ErrInvalidRating = errors.New("rating must be between 1 and 5")
+)
+
+// Review tem índice único em (product_id, user_id): cada usuário avalia um produto uma única vez
+type Review struct {
+	ID        entity.ID `json:"id"`
+	ProductID string    `json:"product_id" gorm:"uniqueIndex:idx_review_product_user"`
+	UserID    string    `json:"user_id" gorm:"uniqueIndex:idx_review_product_user"`
+	Rating    int       `json:"rating"`
+	Comment   string    `json:"comment"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func NewReview(productID, userID string, rating int, comment string) (*Review, error) {
+	review := &Review{
+		ID:        entity.NewID(),
+		ProductID: productID,
+		UserID:    userID,
+		Rating:    rating,
+		Comment:   comment,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	err := review.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return review, nil
+}
+
+func (r *Review) Validate() error {
+	if r.ProductID == "" {
+		return ErrIDIsRequired
+	}
+
+	if r.UserID == "" {
+		return ErrUserIsRequired
+	}
+
+	if r.Rating < MinRating || r.Rating > MaxRating {
+		return ErrInvalidRating
+	}
+
+	return nil
+}
+
+// Edit altera nota e comentário. O ajuste do agregado do produto fica com o repositório,
+// que compara com a nota gravada
+func (r *Review) Edit(rating int, comment string) error {
+	if rating < MinRating || rating > MaxRating {
+		return ErrInvalidRating
+	}
+
+	r.Rating = rating
+	r.Comment = comment
+	r.UpdatedAt = time.Now()
+
+	return nil
+}
@@ -0,0 +1,53 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewReview(t *testing.T) {
+	review, err := NewReview("product-id", "user-id", 4, "Good")
+	assert.Nil(t, err)
+	assert.NotEmpty(t, review.ID)
+	assert.Equal(t, 4, review.Rating)
+
+	_, err = NewReview("product-id", "user-id", 0, "")
+	assert.Equal(t, ErrInvalidRating, err)
+
+	_, err = NewReview("product-id", "user-id", 6, "")
+	assert.Equal(t, ErrInvalidRating, err)
+
+	_, err = NewReview("product-id", "", 3, "")
+	assert.Equal(t, ErrUserIsRequired, err)
+}
+
+func TestReviewEdit(t *testing.T) {
+	review, _ := NewReview("product-id", "user-id", 4, "Good")
+
+	err := review.Edit(2, "Broke after a week")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, review.Rating)
+	assert.Equal(t, "Broke after a week", review.Comment)
+
+	err = review.Edit(9, "")
+	assert.Equal(t, ErrInvalidRating, err)
+	assert.Equal(t, 2, review.Rating)
+}
+
+func TestProductApplyRating(t *testing.T) {
+	product, _ := NewProduct("Product 1", 10)
+
+	product.ApplyRating(1, 5)
+	product.ApplyRating(1, 2)
+	assert.Equal(t, 2, product.RatingCount)
+	assert.Equal(t, 3.5, product.RatingAverage)
+
+	product.ApplyRating(0, 2)
+	assert.Equal(t, 4.5, product.RatingAverage)
+
+	product.ApplyRating(-1, -4)
+	product.ApplyRating(-1, -5)
+	assert.Equal(t, 0, product.RatingCount)
+	assert.Equal(t, 0.0, product.RatingAverage)
+}
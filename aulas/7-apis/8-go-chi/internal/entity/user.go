@@ -1,16 +1,83 @@
 package entity
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql/driver"
+	"encoding/base32"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
 	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/pkg/entity"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/pkg/totp"
 	"golang.org/x/crypto/bcrypt"
 )
 
+const (
+	RecoveryCodeCount = 10
+	// Depois de MaxTOTPFailures códigos errados seguidos o segundo fator fica bloqueado por TOTPLockDuration
+	MaxTOTPFailures  = 5
+	TOTPLockDuration = 15 * time.Minute
+)
+
+var (
+	ErrTOTPAlreadyEnabled = errors.New("two-factor authentication already enabled")
+	ErrTOTPNotEnrolled    = errors.New("two-factor authentication not enrolled")
+	ErrInvalidTOTPCode    = errors.New("invalid two-factor code")
+	ErrTOTPLocked         = errors.New("too many invalid two-factor codes")
+	ErrOIDCAlreadyLinked  = errors.New("user already linked to another sso account")
+)
+
 // Usando o - para omitir o campo da serialização JSON
 type User struct {
-	ID       entity.ID `json:"id"`
-	Name     string    `json:"name"`
-	Email    string    `json:"email"`
-	Password string    `json:"-"`
+	ID          entity.ID `json:"id"`
+	Name        string    `json:"name"`
+	Email       string    `json:"email"`
+	Password    string    `json:"-"`
+	TOTPEnabled bool      `json:"totp_enabled"`
+	// O segredo fica gravado desde o enroll, mas só passa a ser exigido depois da confirmação
+	TOTPSecret string `json:"-"`
+	// Último passo de tempo aceito, impedindo que o mesmo código seja usado duas vezes
+	TOTPLastStep    int64         `json:"-"`
+	TOTPFailures    int           `json:"-"`
+	TOTPLockedUntil time.Time     `json:"-"`
+	RecoveryCodes   RecoveryCodes `json:"-" gorm:"type:text"`
+
+	// Conta do SSO vinculada (issuer + sub do ID token). Usuários criados pelo SSO não têm senha
+	OIDCIssuer  string `json:"-" gorm:"column:oidc_issuer"`
+	OIDCSubject string `json:"-" gorm:"column:oidc_subject;index"`
+}
+
+// RecoveryCodes guarda apenas o SHA-256 dos códigos de recuperação, gravados como JSON em uma coluna.
+// Os códigos são aleatórios e longos, então não precisam de um hash lento como o bcrypt
+type RecoveryCodes []string
+
+func (c RecoveryCodes) Value() (driver.Value, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(data), nil
+}
+
+func (c *RecoveryCodes) Scan(value interface{}) error {
+	switch data := value.(type) {
+	case nil:
+		*c = nil
+		return nil
+	case string:
+		return json.Unmarshal([]byte(data), c)
+	case []byte:
+		return json.Unmarshal(data, c)
+	default:
+		return fmt.Errorf("unsupported recovery codes type %T", value)
+	}
 }
 
 /*
@@ -31,7 +98,143 @@ func NewUser(name, email, password string) (*User, error) {
 	}, err
 }
 
+// NewOIDCUser cria o usuário no primeiro login pelo SSO. Sem senha, o login por email e senha sempre falha
+func NewOIDCUser(name, email, issuer, subject string) *User {
+	return &User{
+		ID:          entity.NewID(),
+		Name:        name,
+		Email:       email,
+		OIDCIssuer:  issuer,
+		OIDCSubject: subject,
+	}
+}
+
+// LinkOIDC vincula um usuário existente à conta do SSO. Um usuário só pode ter uma conta vinculada
+func (u *User) LinkOIDC(issuer, subject string) error {
+	if u.OIDCSubject != "" && (u.OIDCIssuer != issuer || u.OIDCSubject != subject) {
+		return ErrOIDCAlreadyLinked
+	}
+
+	u.OIDCIssuer = issuer
+	u.OIDCSubject = subject
+	return nil
+}
+
 func (u *User) IsPasswordValid(password string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password))
 	return err == nil
 }
+
+// EnrollTOTP gera um novo segredo e os códigos de recuperação. Os códigos em texto puro só existem no retorno,
+// então precisam ser mostrados ao usuário nesse momento. Refazer o enroll antes de confirmar troca o segredo
+func (u *User) EnrollTOTP() (secret string, recoveryCodes []string, err error) {
+	if u.TOTPEnabled {
+		return "", nil, ErrTOTPAlreadyEnabled
+	}
+
+	secret, err = totp.GenerateSecret()
+	if err != nil {
+		return "", nil, err
+	}
+
+	hashes := make(RecoveryCodes, 0, RecoveryCodeCount)
+	for i := 0; i < RecoveryCodeCount; i++ {
+		code, err := newRecoveryCode()
+		if err != nil {
+			return "", nil, err
+		}
+
+		recoveryCodes = append(recoveryCodes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+
+	u.TOTPSecret = secret
+	u.RecoveryCodes = hashes
+	u.TOTPLastStep = 0
+	u.TOTPFailures = 0
+	u.TOTPLockedUntil = time.Time{}
+
+	return secret, recoveryCodes, nil
+}
+
+// ConfirmTOTP ativa o segundo fator depois que o usuário prova que o app autenticador gera códigos válidos
+func (u *User) ConfirmTOTP(code string, now time.Time) error {
+	if u.TOTPEnabled {
+		return ErrTOTPAlreadyEnabled
+	}
+
+	if u.TOTPSecret == "" {
+		return ErrTOTPNotEnrolled
+	}
+
+	step, ok := totp.Validate(u.TOTPSecret, code, now)
+	if !ok {
+		return ErrInvalidTOTPCode
+	}
+
+	u.TOTPEnabled = true
+	u.TOTPLastStep = step
+	return nil
+}
+
+// VerifySecondFactor aceita um código do app autenticador ou um código de recuperação, que é consumido.
+// Erros contam para o bloqueio, então o usuário sempre precisa ser salvo depois da chamada
+func (u *User) VerifySecondFactor(code string, now time.Time) error {
+	if !u.TOTPEnabled {
+		return ErrTOTPNotEnrolled
+	}
+
+	if now.Before(u.TOTPLockedUntil) {
+		return ErrTOTPLocked
+	}
+
+	if step, ok := totp.Validate(u.TOTPSecret, code, now); ok && step > u.TOTPLastStep {
+		u.TOTPLastStep = step
+		u.TOTPFailures = 0
+		return nil
+	}
+
+	if u.useRecoveryCode(code) {
+		u.TOTPFailures = 0
+		return nil
+	}
+
+	u.TOTPFailures++
+	if u.TOTPFailures >= MaxTOTPFailures {
+		u.TOTPFailures = 0
+		u.TOTPLockedUntil = now.Add(TOTPLockDuration)
+	}
+
+	return ErrInvalidTOTPCode
+}
+
+func (u *User) useRecoveryCode(code string) bool {
+	hash := hashRecoveryCode(code)
+
+	for i, stored := range u.RecoveryCodes {
+		if subtle.ConstantTimeCompare([]byte(stored), []byte(hash)) == 1 {
+			// Cria um novo slice para não alterar cópias que compartilham o array original
+			u.RecoveryCodes = append(u.RecoveryCodes[:i:i], u.RecoveryCodes[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+// Códigos no formato xxxxx-xxxxx, com 50 bits aleatórios
+func newRecoveryCode() (string, error) {
+	random := make([]byte, 10)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+
+	code := strings.ToLower(base32.StdEncoding.EncodeToString(random))[:10]
+	return code[:5] + "-" + code[5:], nil
+}
+
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
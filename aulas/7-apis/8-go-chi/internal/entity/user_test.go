@@ -1,8 +1,11 @@
 package entity
 
 import (
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/pkg/totp"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -25,3 +28,80 @@ func TestIsPasswordValid(t *testing.T) {
 	assert.True(t, user.IsPasswordValid("123456"))
 	assert.False(t, user.IsPasswordValid("senhaerrada"))
 }
+
+func TestOIDCUser(t *testing.T) {
+	user := NewOIDCUser("Fulano de Tal", "joao@detal.com.br", "https://sso.detal.com.br", "123")
+
+	assert.NotEmpty(t, user.ID)
+	assert.Empty(t, user.Password)
+	assert.False(t, user.IsPasswordValid(""))
+
+	assert.Nil(t, user.LinkOIDC("https://sso.detal.com.br", "123"))
+	assert.Equal(t, ErrOIDCAlreadyLinked, user.LinkOIDC("https://sso.detal.com.br", "456"))
+	assert.Equal(t, ErrOIDCAlreadyLinked, user.LinkOIDC("https://outro.sso.com", "123"))
+
+	existing, _ := NewUser("Fulano de Tal", "joao@detal.com.br", "123456")
+	assert.Nil(t, existing.LinkOIDC("https://sso.detal.com.br", "123"))
+	assert.Equal(t, "123", existing.OIDCSubject)
+	assert.True(t, existing.IsPasswordValid("123456"))
+}
+
+func TestEnrollAndConfirmTOTP(t *testing.T) {
+	user, _ := NewUser("Fulano de Tal", "joao@detal.com.br", "123456")
+	now := time.Now()
+
+	assert.Equal(t, ErrTOTPNotEnrolled, user.ConfirmTOTP("123456", now))
+
+	secret, recoveryCodes, err := user.EnrollTOTP()
+	assert.Nil(t, err)
+	assert.NotEmpty(t, secret)
+	assert.Len(t, recoveryCodes, RecoveryCodeCount)
+	assert.Len(t, user.RecoveryCodes, RecoveryCodeCount)
+	assert.NotContains(t, user.RecoveryCodes, recoveryCodes[0])
+	assert.False(t, user.TOTPEnabled)
+
+	assert.Equal(t, ErrInvalidTOTPCode, user.ConfirmTOTP("000000", now.Add(time.Hour)))
+
+	code, _ := totp.Code(secret, totp.Step(now))
+	assert.Nil(t, user.ConfirmTOTP(code, now))
+	assert.True(t, user.TOTPEnabled)
+
+	_, _, err = user.EnrollTOTP()
+	assert.Equal(t, ErrTOTPAlreadyEnabled, err)
+}
+
+func TestVerifySecondFactor(t *testing.T) {
+	user, _ := NewUser("Fulano de Tal", "joao@detal.com.br", "123456")
+	now := time.Now()
+	secret, recoveryCodes, _ := user.EnrollTOTP()
+
+	code, _ := totp.Code(secret, totp.Step(now))
+	assert.Equal(t, ErrTOTPNotEnrolled, user.VerifySecondFactor(code, now))
+	user.ConfirmTOTP(code, now)
+
+	// O código usado na confirmação não pode ser reutilizado
+	assert.Equal(t, ErrInvalidTOTPCode, user.VerifySecondFactor(code, now))
+
+	next, _ := totp.Code(secret, totp.Step(now)+1)
+	assert.Nil(t, user.VerifySecondFactor(next, now.Add(totp.Period)))
+
+	// Códigos de recuperação valem uma única vez, com ou sem o hífen
+	assert.Nil(t, user.VerifySecondFactor(strings.ToUpper(strings.ReplaceAll(recoveryCodes[0], "-", "")), now))
+	assert.Len(t, user.RecoveryCodes, RecoveryCodeCount-1)
+	assert.Equal(t, ErrInvalidTOTPCode, user.VerifySecondFactor(recoveryCodes[0], now))
+}
+
+func TestVerifySecondFactorLocksAfterFailures(t *testing.T) {
+	user, _ := NewUser("Fulano de Tal", "joao@detal.com.br", "123456")
+	now := time.Now()
+	secret, recoveryCodes, _ := user.EnrollTOTP()
+	code, _ := totp.Code(secret, totp.Step(now))
+	user.ConfirmTOTP(code, now)
+
+	for i := 0; i < MaxTOTPFailures; i++ {
+		assert.Equal(t, ErrInvalidTOTPCode, user.VerifySecondFactor("wrong", now))
+	}
+
+	assert.Equal(t, ErrTOTPLocked, user.VerifySecondFactor(recoveryCodes[0], now))
+	assert.Nil(t, user.VerifySecondFactor(recoveryCodes[0], now.Add(TOTPLockDuration)))
+}
@@ -0,0 +1,138 @@
+package entity
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"time"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/pkg/entity"
+)
+
+var ErrWishlistItemNotFound = errors.New("wishlist item not found")
+
+// Wishlist é uma lista nomeada de produtos do usuário. ShareToken vazio significa lista privada;
+// com ele preenchido qualquer pessoa com o link consegue ver a lista, sem token JWT
+type Wishlist struct {
+	ID         entity.ID      `json:"id"`
+	UserID     string         `json:"user_id" gorm:"index"`
+	Name       string         `json:"name"`
+	ShareToken string         `json:"-" gorm:"index"`
+	Items      []WishlistItem `json:"items" gorm:"foreignKey:WishlistID;constraint:OnDelete:CASCADE"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+}
+
+// WishlistItem guarda o nome e o preço atuais do produto, mantidos pelas atualizações do catálogo,
+// e o preço de quando o produto entrou na lista
+type WishlistItem struct {
+	ID          entity.ID `json:"id"`
+	WishlistID  entity.ID `json:"-" gorm:"index"`
+	ProductID   entity.ID `json:"product_id" gorm:"index"`
+	ProductName string    `json:"product_name"`
+	Price       int       `json:"price"`
+	AddedPrice  int       `json:"added_price"`
+	AddedAt     time.Time `json:"added_at"`
+}
+
+func NewWishlist(userID, name string) (*Wishlist, error) {
+	if userID == "" {
+		return nil, ErrUserIsRequired
+	}
+
+	if name == "" {
+		return nil, ErrNameIsRequired
+	}
+
+	return &Wishlist{
+		ID:        entity.NewID(),
+		UserID:    userID,
+		Name:      name,
+		Items:     []WishlistItem{},
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}, nil
+}
+
+func (w *Wishlist) Rename(name string) error {
+	if name == "" {
+		return ErrNameIsRequired
+	}
+
+	w.Name = name
+	w.UpdatedAt = time.Now()
+
+	return nil
+}
+
+// AddItem não duplica produtos: adicionar um produto que já está na lista não muda nada
+func (w *Wishlist) AddItem(product *Product) {
+	for _, item := range w.Items {
+		if item.ProductID == product.ID {
+			return
+		}
+	}
+
+	w.Items = append(w.Items, WishlistItem{
+		ID:          entity.NewID(),
+		WishlistID:  w.ID,
+		ProductID:   product.ID,
+		ProductName: product.Name,
+		Price:       product.Price,
+		AddedPrice:  product.Price,
+		AddedAt:     time.Now(),
+	})
+	w.UpdatedAt = time.Now()
+}
+
+// RemoveItem devolve o item removido, usado para montar a notificação quando o produto sai do catálogo
+func (w *Wishlist) RemoveItem(productID entity.ID) (WishlistItem, error) {
+	for i, item := range w.Items {
+		if item.ProductID == productID {
+			w.Items = append(w.Items[:i:i], w.Items[i+1:]...)
+			w.UpdatedAt = time.Now()
+			return item, nil
+		}
+	}
+
+	return WishlistItem{}, ErrWishlistItemNotFound
+}
+
+// UpdateProduct aplica o nome e o preço atuais do produto, devolvendo o preço anterior do item
+func (w *Wishlist) UpdateProduct(product *Product) (int, error) {
+	for i := range w.Items {
+		if w.Items[i].ProductID == product.ID {
+			previous := w.Items[i].Price
+			w.Items[i].ProductName = product.Name
+			w.Items[i].Price = product.Price
+			w.UpdatedAt = time.Now()
+			return previous, nil
+		}
+	}
+
+	return 0, ErrWishlistItemNotFound
+}
+
+// Share gera o token do link público. Uma lista já compartilhada mantém o token, para não quebrar links enviados
+func (w *Wishlist) Share() error {
+	if w.ShareToken != "" {
+		return nil
+	}
+
+	// 256 bits aleatórios: o token não pode ser adivinhado nem enumerado
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return err
+	}
+
+	w.ShareToken = base64.RawURLEncoding.EncodeToString(random)
+	w.UpdatedAt = time.Now()
+
+	return nil
+}
+
+// Unshare invalida o link público. Compartilhar de novo gera um token diferente
+func (w *Wishlist) Unshare() {
+	w.ShareToken = ""
+	w.UpdatedAt = time.Now()
+}
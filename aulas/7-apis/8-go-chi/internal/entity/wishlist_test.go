@@ -0,0 +1,94 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewWishlist(t *testing.T) {
+	wishlist, err := NewWishlist("user-id", "Presentes")
+
+	assert.Nil(t, err)
+	assert.NotEmpty(t, wishlist.ID)
+	assert.Equal(t, "Presentes", wishlist.Name)
+	assert.Empty(t, wishlist.ShareToken)
+
+	_, err = NewWishlist("", "Presentes")
+	assert.Equal(t, ErrUserIsRequired, err)
+
+	_, err = NewWishlist("user-id", "")
+	assert.Equal(t, ErrNameIsRequired, err)
+
+	assert.Equal(t, ErrNameIsRequired, wishlist.Rename(""))
+	assert.Nil(t, wishlist.Rename("Aniversário"))
+	assert.Equal(t, "Aniversário", wishlist.Name)
+}
+
+func TestWishlistItems(t *testing.T) {
+	wishlist, _ := NewWishlist("user-id", "Presentes")
+	product, _ := NewProduct("Product 1", 100)
+	product2, _ := NewProduct("Product 2", 50)
+
+	wishlist.AddItem(product)
+	wishlist.AddItem(product)
+	wishlist.AddItem(product2)
+	assert.Len(t, wishlist.Items, 2)
+
+	product.Name = "Product 1 (novo)"
+	product.Price = 80
+	previous, err := wishlist.UpdateProduct(product)
+	assert.Nil(t, err)
+	assert.Equal(t, 100, previous)
+	assert.Equal(t, 80, wishlist.Items[0].Price)
+	assert.Equal(t, 100, wishlist.Items[0].AddedPrice)
+	assert.Equal(t, "Product 1 (novo)", wishlist.Items[0].ProductName)
+
+	item, err := wishlist.RemoveItem(product2.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, 50, item.Price)
+	assert.Len(t, wishlist.Items, 1)
+
+	_, err = wishlist.RemoveItem(product2.ID)
+	assert.Equal(t, ErrWishlistItemNotFound, err)
+	_, err = wishlist.UpdateProduct(product2)
+	assert.Equal(t, ErrWishlistItemNotFound, err)
+}
+
+func TestWishlistShare(t *testing.T) {
+	wishlist, _ := NewWishlist("user-id", "Presentes")
+
+	assert.Nil(t, wishlist.Share())
+	token := wishlist.ShareToken
+	assert.Len(t, token, 43)
+
+	// Compartilhar de novo mantém o link; depois de revogar, o link novo é outro
+	assert.Nil(t, wishlist.Share())
+	assert.Equal(t, token, wishlist.ShareToken)
+
+	wishlist.Unshare()
+	assert.Empty(t, wishlist.ShareToken)
+	assert.Nil(t, wishlist.Share())
+	assert.NotEqual(t, token, wishlist.ShareToken)
+}
+
+func TestWishlistNotifications(t *testing.T) {
+	wishlist, _ := NewWishlist("user-id", "Presentes")
+	product, _ := NewProduct("Product 1", 100)
+	wishlist.AddItem(product)
+
+	product.Price = 70
+	previous, _ := wishlist.UpdateProduct(product)
+	notification := NewPriceDropNotification(wishlist, wishlist.Items[0], previous)
+	assert.Equal(t, "user-id", notification.UserID)
+	assert.Equal(t, NotificationPriceDrop, notification.Type)
+	assert.Equal(t, 100, notification.OldPrice)
+	assert.Equal(t, 70, notification.NewPrice)
+
+	item, _ := wishlist.RemoveItem(product.ID)
+	notification = NewProductRemovedNotification(wishlist, item)
+	assert.Equal(t, NotificationProductRemoved, notification.Type)
+	assert.Equal(t, 70, notification.OldPrice)
+	assert.Equal(t, 0, notification.NewPrice)
+	assert.Equal(t, wishlist.ID, notification.WishlistID)
+}
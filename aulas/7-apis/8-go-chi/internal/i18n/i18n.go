@@ -0,0 +1,243 @@
+package i18n
+
+import (
+	"sync"
+
+	"golang.org/x/text/language"
+)
+
+// Códigos estáveis dos erros da API. São eles que aparecem nos logs e que os clientes
+// devem usar para tratar erros; a mensagem muda de acordo com o Accept-Language
+const (
+	CodeIDIsRequired             = "id_is_required"
+	CodeInvalidID                = "invalid_id"
+	CodeNameIsRequired           = "name_is_required"
+	CodePriceIsRequired          = "price_is_required"
+	CodeInvalidPrice             = "invalid_price"
+	CodeInvalidCredentials       = "invalid_credentials"
+	CodeInvalidRequestBody       = "invalid_request_body"
+	CodeProductNotFound          = "product_not_found"
+	CodeStreamUnsupported        = "stream_unsupported"
+	CodeInvalidPeriod            = "invalid_period"
+	CodeInvalidQuantity          = "invalid_quantity"
+	CodeCartItemNotFound         = "cart_item_not_found"
+	CodeCartConflict             = "cart_conflict"
+	CodeCouponCodeIsRequired     = "coupon_code_is_required"
+	CodeInvalidDiscountType      = "invalid_discount_type"
+	CodeInvalidDiscountValue     = "invalid_discount_value"
+	CodeInvalidValidity          = "invalid_validity"
+	CodeInvalidUsageLimit        = "invalid_usage_limit"
+	CodeInvalidMinCartValue      = "invalid_min_cart_value"
+	CodePromotionScopeIsRequired = "promotion_scope_is_required"
+	CodeCouponNotActive          = "coupon_not_active"
+	CodeCouponUsageLimitReached  = "coupon_usage_limit_reached"
+	CodeCouponMinCartValue       = "coupon_min_cart_value"
+	CodeItemsAreRequired         = "items_are_required"
+	CodeCouponNotFound           = "coupon_not_found"
+	CodeCouponAlreadyExists      = "coupon_already_exists"
+	CodeInvalidRating            = "invalid_rating"
+	CodeReviewNotFound           = "review_not_found"
+	CodeReviewAlreadyExists      = "review_already_exists"
+	CodeNotAcceptable            = "not_acceptable"
+	CodeUnsupportedMediaType     = "unsupported_media_type"
+	CodeUnsupportedCurrency      = "unsupported_currency"
+	CodeAPIVersionSunset         = "api_version_sunset"
+	CodeTOTPAlreadyEnabled       = "totp_already_enabled"
+	CodeTOTPNotEnrolled          = "totp_not_enrolled"
+	CodeInvalidTOTPCode          = "invalid_totp_code"
+	CodeTOTPLocked               = "totp_locked"
+	CodeInvalidChallengeToken    = "invalid_challenge_token"
+	CodeValidationFailed         = "validation_failed"
+	CodeResponseValidationFailed = "response_validation_failed"
+	CodeJobNotFound              = "job_not_found"
+	CodeJobNotFinished           = "job_not_finished"
+	CodeJobFailed                = "job_failed"
+	CodeRequestBodyTooLarge      = "request_body_too_large"
+	CodeInvalidOIDCState         = "invalid_oidc_state"
+	CodeOIDCLoginFailed          = "oidc_login_failed"
+	CodeOIDCEmailNotVerified     = "oidc_email_not_verified"
+	CodeOIDCAlreadyLinked        = "oidc_already_linked"
+	CodeWishlistNotFound         = "wishlist_not_found"
+	CodeWishlistItemNotFound     = "wishlist_item_not_found"
+	CodeFeatureFlagNotFound      = "feature_flag_not_found"
+	CodeFeatureFlagAlreadyExists = "feature_flag_already_exists"
+	CodeInvalidFlagKey           = "invalid_flag_key"
+	CodeInvalidPercentage        = "invalid_percentage"
+	CodeBadRequest               = "bad_request"
+	CodeUnauthorized             = "unauthorized"
+	CodeForbidden                = "forbidden"
+	CodeNotFound                 = "not_found"
+	CodeInternalError            = "internal_error"
+)
+
+var (
+	English             = language.English
+	BrazilianPortuguese = language.BrazilianPortuguese
+
+	// O primeiro idioma é o padrão quando o cliente não envia Accept-Language ou pede um idioma sem tradução
+	supported = []language.Tag{English, BrazilianPortuguese}
+	matcher   = language.NewMatcher(supported)
+)
+
+// catalogMutex protege o catálogo, já que Register pode rodar enquanto requisições leem as mensagens
+var catalogMutex sync.RWMutex
+
+var catalog = map[language.Tag]map[string]string{
+	English: {
+		CodeIDIsRequired:             "id is required",
+		CodeInvalidID:                "invalid id",
+		CodeNameIsRequired:           "name is required",
+		CodePriceIsRequired:          "price is required",
+		CodeInvalidPrice:             "invalid price",
+		CodeInvalidCredentials:       "invalid credentials",
+		CodeInvalidRequestBody:       "invalid request body",
+		CodeProductNotFound:          "product not found",
+		CodeStreamUnsupported:        "streaming unsupported",
+		CodeInvalidPeriod:            "invalid period, use RFC 3339 dates with from before to",
+		CodeInvalidQuantity:          "quantity must be greater than zero",
+		CodeCartItemNotFound:         "product is not in the cart",
+		CodeCartConflict:             "cart was changed by another request, try again",
+		CodeCouponCodeIsRequired:     "coupon code is required",
+		CodeInvalidDiscountType:      "discount type must be percentage or fixed",
+		CodeInvalidDiscountValue:     "invalid discount value, percentages go from 1 to 100 and fixed values must be positive",
+		CodeInvalidValidity:          "ends_at must be after starts_at",
+		CodeInvalidUsageLimit:        "usage limit cannot be negative",
+		CodeInvalidMinCartValue:      "minimum cart value cannot be negative",
+		CodePromotionScopeIsRequired: "promotion must target a product or a category",
+		CodeCouponNotActive:          "coupon is not active",
+		CodeCouponUsageLimitReached:  "coupon usage limit reached",
+		CodeCouponMinCartValue:       "cart value is below the coupon minimum",
+		CodeItemsAreRequired:         "at least one item is required",
+		CodeCouponNotFound:           "coupon not found",
+		CodeCouponAlreadyExists:      "coupon code already exists",
+		CodeNotAcceptable:            "none of the formats in the Accept header are supported, use application/json, application/xml or application/msgpack",
+		CodeUnsupportedMediaType:     "unsupported Content-Type, use application/json, application/xml or application/msgpack",
+		CodeUnsupportedCurrency:      "unsupported currency, prices are in BRL",
+		CodeAPIVersionSunset:         "this API version was retired, use the version in the Link header",
+		CodeTOTPAlreadyEnabled:       "two-factor authentication is already enabled",
+		CodeTOTPNotEnrolled:          "two-factor authentication is not enrolled, call the enroll endpoint first",
+		CodeInvalidTOTPCode:          "invalid two-factor code",
+		CodeTOTPLocked:               "too many invalid two-factor codes, try again later",
+		CodeInvalidChallengeToken:    "invalid or expired challenge token, log in with your password again",
+		CodeValidationFailed:         "the request does not match the API specification, see details",
+		CodeResponseValidationFailed: "the response does not match the API specification, see details",
+		CodeJobNotFound:              "job not found",
+		CodeJobNotFinished:           "the job has not finished yet, check its status again later",
+		CodeJobFailed:                "the job failed, see last_error in its status",
+		CodeRequestBodyTooLarge:      "the request body is too large",
+		CodeInvalidOIDCState:         "invalid or expired sso login, start the login again",
+		CodeOIDCLoginFailed:          "the sso login failed",
+		CodeOIDCEmailNotVerified:     "the sso account has no verified email",
+		CodeOIDCAlreadyLinked:        "the user with this email is already linked to another sso account",
+		CodeWishlistNotFound:         "wishlist not found",
+		CodeWishlistItemNotFound:     "product is not in the wishlist",
+		CodeFeatureFlagNotFound:      "feature flag not found",
+		CodeFeatureFlagAlreadyExists: "feature flag already exists",
+		CodeInvalidFlagKey:           "flag key must have only lowercase letters, digits, dots, dashes and underscores",
+		CodeInvalidPercentage:        "percentage must be between 0 and 100",
+		CodeBadRequest:               "bad request",
+		CodeUnauthorized:             "unauthorized",
+		CodeForbidden:                "forbidden",
+		CodeNotFound:                 "not found",
+		CodeInternalError:            "internal server error",
+	},
+	BrazilianPortuguese: {
+		CodeIDIsRequired:             "o id é obrigatório",
+		CodeInvalidID:                "id inválido",
+		CodeNameIsRequired:           "o nome é obrigatório",
+		CodePriceIsRequired:          "o preço é obrigatório",
+		CodeInvalidPrice:             "preço inválido",
+		CodeInvalidCredentials:       "credenciais inválidas",
+		CodeInvalidRequestBody:       "corpo da requisição inválido",
+		CodeProductNotFound:          "produto não encontrado",
+		CodeStreamUnsupported:        "streaming não suportado",
+		CodeInvalidPeriod:            "período inválido, use datas RFC 3339 com from anterior a to",
+		CodeInvalidQuantity:          "a quantidade deve ser maior que zero",
+		CodeCartItemNotFound:         "o produto não está no carrinho",
+		CodeCartConflict:             "o carrinho foi alterado por outra requisição, tente de novo",
+		CodeCouponCodeIsRequired:     "o código do cupom é obrigatório",
+		CodeInvalidDiscountType:      "o tipo de desconto deve ser percentage ou fixed",
+		CodeInvalidDiscountValue:     "valor de desconto inválido, porcentagens vão de 1 a 100 e valores fixos devem ser positivos",
+		CodeInvalidValidity:          "ends_at deve ser posterior a starts_at",
+		CodeInvalidUsageLimit:        "o limite de uso não pode ser negativo",
+		CodeInvalidMinCartValue:      "o valor mínimo do carrinho não pode ser negativo",
+		CodePromotionScopeIsRequired: "a promoção deve ter um produto ou uma categoria como alvo",
+		CodeCouponNotActive:          "o cupom não está ativo",
+		CodeCouponUsageLimitReached:  "o cupom atingiu o limite de uso",
+		CodeCouponMinCartValue:       "o valor do carrinho está abaixo do mínimo do cupom",
+		CodeItemsAreRequired:         "informe ao menos um item",
+		CodeCouponNotFound:           "cupom não encontrado",
+		CodeCouponAlreadyExists:      "já existe um cupom com esse código",
+		CodeNotAcceptable:            "nenhum dos formatos do header Accept é suportado, use application/json, application/xml ou application/msgpack",
+		CodeUnsupportedMediaType:     "Content-Type não suportado, use application/json, application/xml ou application/msgpack",
+		CodeUnsupportedCurrency:      "moeda não suportada, os preços são em BRL",
+		CodeAPIVersionSunset:         "esta versão da API foi desativada, use a versão indicada no header Link",
+		CodeTOTPAlreadyEnabled:       "a autenticação em dois fatores já está ativa",
+		CodeTOTPNotEnrolled:          "a autenticação em dois fatores não foi configurada, chame o endpoint de enroll antes",
+		CodeInvalidTOTPCode:          "código de dois fatores inválido",
+		CodeTOTPLocked:               "muitos códigos de dois fatores inválidos, tente novamente mais tarde",
+		CodeInvalidChallengeToken:    "token de desafio inválido ou expirado, faça login com a senha novamente",
+		CodeValidationFailed:         "a requisição não segue a especificação da API, veja os detalhes",
+		CodeResponseValidationFailed: "a resposta não segue a especificação da API, veja os detalhes",
+		CodeJobNotFound:              "job não encontrado",
+		CodeJobNotFinished:           "o job ainda não terminou, consulte o status novamente mais tarde",
+		CodeJobFailed:                "o job falhou, veja last_error no status",
+		CodeRequestBodyTooLarge:      "o corpo da requisição é grande demais",
+		CodeInvalidOIDCState:         "login pelo sso inválido ou expirado, comece o login novamente",
+		CodeOIDCLoginFailed:          "o login pelo sso falhou",
+		CodeOIDCEmailNotVerified:     "a conta do sso não tem um email verificado",
+		CodeOIDCAlreadyLinked:        "o usuário com este email já está vinculado a outra conta do sso",
+		CodeWishlistNotFound:         "lista de desejos não encontrada",
+		CodeWishlistItemNotFound:     "o produto não está na lista de desejos",
+		CodeFeatureFlagNotFound:      "feature flag não encontrada",
+		CodeFeatureFlagAlreadyExists: "feature flag já existe",
+		CodeInvalidFlagKey:           "a chave da flag deve ter apenas letras minúsculas, números, pontos, hífens e sublinhados",
+		CodeInvalidPercentage:        "o percentual deve estar entre 0 e 100",
+		CodeBadRequest:               "requisição inválida",
+		CodeUnauthorized:             "não autorizado",
+		CodeForbidden:                "acesso negado",
+		CodeNotFound:                 "não encontrado",
+		CodeInternalError:            "erro interno do servidor",
+	},
+}
+
+// Register adiciona ou substitui mensagens do catálogo para que outros pacotes registrem seus próprios códigos
+func Register(tag language.Tag, messages map[string]string) {
+	catalogMutex.Lock()
+	defer catalogMutex.Unlock()
+
+	if _, ok := catalog[tag]; !ok {
+		catalog[tag] = make(map[string]string)
+	}
+
+	for code, message := range messages {
+		catalog[tag][code] = message
+	}
+}
+
+// MatchLanguage escolhe o melhor idioma suportado para o valor do header Accept-Language
+func MatchLanguage(acceptLanguage string) language.Tag {
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return supported[0]
+	}
+
+	_, index, _ := matcher.Match(tags...)
+	return supported[index]
+}
+
+// Message devolve a mensagem do código no idioma pedido, caindo para inglês e, por último, para o próprio código
+func Message(tag language.Tag, code string) string {
+	catalogMutex.RLock()
+	defer catalogMutex.RUnlock()
+
+	if message, ok := catalog[tag][code]; ok {
+		return message
+	}
+
+	if message, ok := catalog[English][code]; ok {
+		return message
+	}
+
+	return code
+}
@@ -0,0 +1,73 @@
+package i18n
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/language"
+)
+
+func TestMatchLanguage(t *testing.T) {
+	assert.Equal(t, English, MatchLanguage(""))
+	assert.Equal(t, English, MatchLanguage("en-US,en;q=0.9"))
+	assert.Equal(t, BrazilianPortuguese, MatchLanguage("pt-BR,pt;q=0.9,en;q=0.8"))
+	assert.Equal(t, BrazilianPortuguese, MatchLanguage("pt"))
+	assert.Equal(t, BrazilianPortuguese, MatchLanguage("fr;q=0.9,pt;q=0.8"))
+	assert.Equal(t, English, MatchLanguage("fr"))
+	assert.Equal(t, English, MatchLanguage("invalid;;q=x"))
+}
+
+func TestMessage(t *testing.T) {
+	assert.Equal(t, "name is required", Message(English, CodeNameIsRequired))
+	assert.Equal(t, "o nome é obrigatório", Message(BrazilianPortuguese, CodeNameIsRequired))
+	assert.Equal(t, "unknown_code", Message(BrazilianPortuguese, "unknown_code"))
+}
+
+func TestCatalogIsComplete(t *testing.T) {
+	for code := range catalog[English] {
+		_, ok := catalog[BrazilianPortuguese][code]
+		assert.True(t, ok, "missing pt-BR message for %s", code)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	// O catálogo é global: devolvemos o original para não afetar os outros testes
+	original := make(map[language.Tag]map[string]string, len(catalog))
+	for tag, messages := range catalog {
+		original[tag] = make(map[string]string, len(messages))
+		for code, message := range messages {
+			original[tag][code] = message
+		}
+	}
+	t.Cleanup(func() {
+		catalogMutex.Lock()
+		catalog = original
+		catalogMutex.Unlock()
+	})
+
+	Register(BrazilianPortuguese, map[string]string{"custom_code": "mensagem"})
+
+	assert.Equal(t, "mensagem", Message(BrazilianPortuguese, "custom_code"))
+	assert.Equal(t, "custom_code", Message(English, "custom_code"))
+}
+
+// Rodar com -race: Register pode acontecer enquanto requisições leem as mensagens
+func TestRegisterIsSafeForConcurrentUse(t *testing.T) {
+	t.Cleanup(func() {
+		catalogMutex.Lock()
+		delete(catalog[BrazilianPortuguese], "custom_concurrent")
+		catalogMutex.Unlock()
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			Register(BrazilianPortuguese, map[string]string{"custom_concurrent": "mensagem"})
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		Message(BrazilianPortuguese, CodeNameIsRequired)
+	}
+	<-done
+}
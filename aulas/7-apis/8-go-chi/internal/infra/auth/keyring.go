@@ -0,0 +1,242 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/go-chi/jwtauth"
+	"github.com/lestrrat-go/jwx/jwa"
+	"github.com/lestrrat-go/jwx/jwk"
+)
+
+var (
+	ErrUnsupportedAlgorithm = errors.New("unsupported jwt algorithm")
+	ErrInvalidPEM           = errors.New("invalid pem file")
+	ErrKeyAlgorithmMismatch = errors.New("key type does not match jwt algorithm")
+	ErrSecretIsRequired     = errors.New("jwt secret is required")
+)
+
+// KeyRing guarda a chave usada para assinar os tokens e todas as chaves aceitas na verificação.
+// Durante uma rotação, a chave nova assina e as antigas continuam válidas até expirarem os tokens emitidos com elas
+type KeyRing struct {
+	algorithm  jwa.SignatureAlgorithm
+	signingKey jwk.Key
+	verifyKeys map[string]jwk.Key
+	publicKeys jwk.Set
+	tokenAuth  *jwtauth.JWTAuth
+}
+
+// NewHMACKeyRing mantém o comportamento antigo (HS256 com segredo compartilhado). Não publica chaves no JWKS
+func NewHMACKeyRing(secret string) (*KeyRing, error) {
+	if secret == "" {
+		return nil, ErrSecretIsRequired
+	}
+
+	key, err := jwk.New([]byte(secret))
+	if err != nil {
+		return nil, err
+	}
+
+	return &KeyRing{
+		algorithm:  jwa.HS256,
+		signingKey: key,
+		verifyKeys: map[string]jwk.Key{"": key},
+		publicKeys: jwk.NewSet(),
+		tokenAuth:  jwtauth.New(string(jwa.HS256), []byte(secret), nil),
+	}, nil
+}
+
+// LoadKeyRing carrega a chave privada de assinatura e, opcionalmente, chaves públicas extras
+// (de rotações anteriores ou futuras) a partir de arquivos PEM. O kid de cada chave é o thumbprint RFC 7638
+func LoadKeyRing(algorithm string, signingKeyFile string, verificationKeyFiles []string) (*KeyRing, error) {
+	privateKey, err := readPrivateKey(signingKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("signing key %s: %w", signingKeyFile, err)
+	}
+
+	var publicKeys []crypto.PublicKey
+	for _, file := range verificationKeyFiles {
+		publicKey, err := readPublicKey(file)
+		if err != nil {
+			return nil, fmt.Errorf("verification key %s: %w", file, err)
+		}
+		publicKeys = append(publicKeys, publicKey)
+	}
+
+	return NewKeyRing(algorithm, privateKey, publicKeys...)
+}
+
+func NewKeyRing(algorithm string, privateKey crypto.Signer, extraPublicKeys ...crypto.PublicKey) (*KeyRing, error) {
+	alg := jwa.SignatureAlgorithm(algorithm)
+	if alg != jwa.RS256 && alg != jwa.ES256 {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedAlgorithm, algorithm)
+	}
+
+	if err := checkKeyType(alg, privateKey.Public()); err != nil {
+		return nil, err
+	}
+
+	signingKey, err := newJWK(alg, privateKey, privateKey.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	keyRing := &KeyRing{
+		algorithm:  alg,
+		signingKey: signingKey,
+		verifyKeys: make(map[string]jwk.Key),
+		publicKeys: jwk.NewSet(),
+	}
+
+	for _, publicKey := range append([]crypto.PublicKey{privateKey.Public()}, extraPublicKeys...) {
+		if err := checkKeyType(alg, publicKey); err != nil {
+			return nil, err
+		}
+
+		key, err := newJWK(alg, publicKey, publicKey)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := keyRing.verifyKeys[key.KeyID()]; ok {
+			continue
+		}
+		keyRing.verifyKeys[key.KeyID()] = key
+		keyRing.publicKeys.Add(key)
+	}
+
+	// Passando o jwk.Key (e não a chave crua) o jwx inclui o kid no header dos tokens assinados
+	keyRing.tokenAuth = jwtauth.New(string(alg), signingKey, privateKey.Public())
+
+	return keyRing, nil
+}
+
+func (k *KeyRing) Algorithm() string {
+	return string(k.algorithm)
+}
+
+func (k *KeyRing) KeyID() string {
+	return k.signingKey.KeyID()
+}
+
+// TokenAuth é usado para emitir tokens (Encode) com a chave de assinatura atual
+func (k *KeyRing) TokenAuth() *jwtauth.JWTAuth {
+	return k.tokenAuth
+}
+
+// PublicKeys devolve o conjunto publicado em /.well-known/jwks.json
+func (k *KeyRing) PublicKeys() jwk.Set {
+	return k.publicKeys
+}
+
+func newJWK(alg jwa.SignatureAlgorithm, raw interface{}, publicKey crypto.PublicKey) (jwk.Key, error) {
+	key, err := jwk.New(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	kid, err := thumbprint(publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	key.Set(jwk.KeyIDKey, kid)
+	key.Set(jwk.AlgorithmKey, alg.String())
+	key.Set(jwk.KeyUsageKey, "sig")
+
+	return key, nil
+}
+
+func thumbprint(publicKey crypto.PublicKey) (string, error) {
+	key, err := jwk.New(publicKey)
+	if err != nil {
+		return "", err
+	}
+
+	sum, err := key.Thumbprint(crypto.SHA256)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(sum), nil
+}
+
+func checkKeyType(alg jwa.SignatureAlgorithm, publicKey crypto.PublicKey) error {
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		if alg == jwa.RS256 {
+			return nil
+		}
+	case *ecdsa.PublicKey:
+		if alg == jwa.ES256 && key.Curve == elliptic.P256() {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: %s", ErrKeyAlgorithmMismatch, alg)
+}
+
+func readPrivateKey(file string) (crypto.Signer, error) {
+	block, err := readPEM(file)
+	if err != nil {
+		return nil, err
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, ErrInvalidPEM
+		}
+		return signer, nil
+	}
+
+	return nil, fmt.Errorf("%w: unexpected block %q", ErrInvalidPEM, block.Type)
+}
+
+func readPublicKey(file string) (crypto.PublicKey, error) {
+	block, err := readPEM(file)
+	if err != nil {
+		return nil, err
+	}
+
+	switch block.Type {
+	case "PUBLIC KEY":
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	}
+
+	return nil, fmt.Errorf("%w: unexpected block %q", ErrInvalidPEM, block.Type)
+}
+
+func readPEM(file string) (*pem.Block, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, ErrInvalidPEM
+	}
+
+	return block, nil
+}
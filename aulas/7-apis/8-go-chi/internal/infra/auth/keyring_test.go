@@ -0,0 +1,135 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-chi/jwtauth"
+	"github.com/stretchr/testify/assert"
+)
+
+func writePEM(t *testing.T, name, blockType string, data []byte) string {
+	file := filepath.Join(t.TempDir(), name)
+	err := os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: data}), 0600)
+	assert.Nil(t, err)
+
+	return file
+}
+
+func encode(t *testing.T, keyRing *KeyRing) string {
+	_, tokenString, err := keyRing.TokenAuth().Encode(map[string]interface{}{
+		"sub": "user-id",
+		"exp": time.Now().Add(time.Minute).Unix(),
+	})
+	assert.Nil(t, err)
+
+	return tokenString
+}
+
+func TestLoadKeyRingRS256(t *testing.T) {
+	privateKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	file := writePEM(t, "private.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(privateKey))
+
+	keyRing, err := LoadKeyRing("RS256", file, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "RS256", keyRing.Algorithm())
+	assert.NotEmpty(t, keyRing.KeyID())
+
+	token, err := keyRing.Verify(encode(t, keyRing))
+	assert.Nil(t, err)
+	assert.Equal(t, "user-id", token.Subject())
+
+	assert.Equal(t, 1, keyRing.PublicKeys().Len())
+	key, ok := keyRing.PublicKeys().LookupKeyID(keyRing.KeyID())
+	assert.True(t, ok)
+	assert.Equal(t, "RS256", key.Algorithm())
+}
+
+func TestLoadKeyRingES256(t *testing.T) {
+	privateKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	data, _ := x509.MarshalPKCS8PrivateKey(privateKey)
+	file := writePEM(t, "private.pem", "PRIVATE KEY", data)
+
+	keyRing, err := LoadKeyRing("ES256", file, nil)
+	assert.Nil(t, err)
+
+	_, err = keyRing.Verify(encode(t, keyRing))
+	assert.Nil(t, err)
+}
+
+func TestKeyRingRotation(t *testing.T) {
+	oldKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	newKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	oldKeyRing, err := NewKeyRing("RS256", oldKey)
+	assert.Nil(t, err)
+	oldToken := encode(t, oldKeyRing)
+
+	oldPublic, _ := x509.MarshalPKIXPublicKey(&oldKey.PublicKey)
+	oldPublicFile := writePEM(t, "old.pem", "PUBLIC KEY", oldPublic)
+	newPrivateFile := writePEM(t, "new.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(newKey))
+
+	keyRing, err := LoadKeyRing("RS256", newPrivateFile, []string{oldPublicFile})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, keyRing.PublicKeys().Len())
+	assert.NotEqual(t, oldKeyRing.KeyID(), keyRing.KeyID())
+
+	// Tokens emitidos com a chave antiga continuam válidos
+	_, err = keyRing.Verify(oldToken)
+	assert.Nil(t, err)
+
+	_, err = keyRing.Verify(encode(t, keyRing))
+	assert.Nil(t, err)
+
+	// Depois que a chave antiga sai da lista, os tokens dela são rejeitados
+	rotatedKeyRing, _ := NewKeyRing("RS256", newKey)
+	_, err = rotatedKeyRing.Verify(oldToken)
+	assert.Equal(t, jwtauth.ErrUnauthorized, err)
+}
+
+func TestKeyRingRejectsAlgorithmMismatch(t *testing.T) {
+	privateKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	keyRing, _ := NewKeyRing("RS256", privateKey)
+
+	hmacKeyRing, _ := NewHMACKeyRing("secret")
+	_, err := keyRing.Verify(encode(t, hmacKeyRing))
+	assert.Equal(t, jwtauth.ErrAlgoInvalid, err)
+
+	_, err = NewKeyRing("ES256", privateKey)
+	assert.ErrorIs(t, err, ErrKeyAlgorithmMismatch)
+
+	_, err = NewKeyRing("HS512", privateKey)
+	assert.ErrorIs(t, err, ErrUnsupportedAlgorithm)
+}
+
+func TestHMACKeyRing(t *testing.T) {
+	keyRing, err := NewHMACKeyRing("secret")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, keyRing.PublicKeys().Len())
+
+	_, err = keyRing.Verify(encode(t, keyRing))
+	assert.Nil(t, err)
+
+	otherKeyRing, _ := NewHMACKeyRing("other")
+	_, err = keyRing.Verify(encode(t, otherKeyRing))
+	assert.Equal(t, jwtauth.ErrUnauthorized, err)
+
+	_, err = NewHMACKeyRing("")
+	assert.Equal(t, ErrSecretIsRequired, err)
+}
+
+func TestLoadKeyRingWithInvalidFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "invalid.pem")
+	os.WriteFile(file, []byte("not a pem"), 0600)
+
+	_, err := LoadKeyRing("RS256", file, nil)
+	assert.ErrorIs(t, err, ErrInvalidPEM)
+}
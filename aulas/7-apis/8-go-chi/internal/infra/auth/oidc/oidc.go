@@ -0,0 +1,386 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lestrrat-go/jwx/jwa"
+	"github.com/lestrrat-go/jwx/jwk"
+	"github.com/lestrrat-go/jwx/jws"
+	"github.com/lestrrat-go/jwx/jwt"
+)
+
+const (
+	discoveryPath = "/.well-known/openid-configuration"
+	// Diferença de relógio tolerada entre a API e o issuer na validação do exp/iat
+	clockSkew = time.Minute
+	// Intervalo mínimo entre duas buscas do JWKS por causa de um kid desconhecido
+	jwksRefreshInterval = time.Minute
+)
+
+var (
+	ErrDiscovery       = errors.New("oidc discovery failed")
+	ErrExchange        = errors.New("oidc code exchange failed")
+	ErrInvalidIDToken  = errors.New("invalid oidc id token")
+	ErrIssuerMismatch  = errors.New("oidc issuer mismatch")
+	ErrPKCEUnsupported = errors.New("oidc issuer does not support pkce with S256")
+
+	DefaultScopes = []string{"openid", "email", "profile"}
+
+	// Algoritmos simétricos e o "none" nunca são aceitos no ID token
+	supportedAlgorithms = []jwa.SignatureAlgorithm{jwa.RS256, jwa.ES256}
+)
+
+// Config identifica a API como cliente do issuer. Sem ClientSecret o cliente é público e depende só do PKCE
+type Config struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+	// HTTPClient vazio usa um cliente com timeout de 10s
+	HTTPClient *http.Client
+}
+
+// Claims são os dados do usuário lidos do ID token
+type Claims struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+type metadata struct {
+	Issuer                        string   `json:"issuer"`
+	AuthorizationEndpoint         string   `json:"authorization_endpoint"`
+	TokenEndpoint                 string   `json:"token_endpoint"`
+	JWKSURI                       string   `json:"jwks_uri"`
+	CodeChallengeMethodsSupported []string `json:"code_challenge_methods_supported"`
+}
+
+// Client faz o fluxo authorization code com PKCE contra um único issuer
+type Client struct {
+	config   Config
+	metadata metadata
+
+	mutex       sync.Mutex
+	keys        jwk.Set
+	refreshedAt time.Time
+}
+
+// Discover lê o documento de discovery do issuer e as chaves de assinatura.
+// O issuer do documento precisa ser exatamente o configurado, como exige a especificação
+func Discover(ctx context.Context, config Config) (*Client, error) {
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	if len(config.Scopes) == 0 {
+		config.Scopes = DefaultScopes
+	}
+	config.Issuer = strings.TrimSuffix(config.Issuer, "/")
+
+	client := &Client{config: config}
+	if err := client.getJSON(ctx, config.Issuer+discoveryPath, &client.metadata); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDiscovery, err)
+	}
+
+	if strings.TrimSuffix(client.metadata.Issuer, "/") != config.Issuer {
+		return nil, fmt.Errorf("%w: expected %q, got %q", ErrIssuerMismatch, config.Issuer, client.metadata.Issuer)
+	}
+	if client.metadata.AuthorizationEndpoint == "" || client.metadata.TokenEndpoint == "" || client.metadata.JWKSURI == "" {
+		return nil, fmt.Errorf("%w: incomplete provider metadata", ErrDiscovery)
+	}
+	// Issuers que não anunciam os métodos são aceitos; os que anunciam precisam ter o S256
+	if methods := client.metadata.CodeChallengeMethodsSupported; len(methods) > 0 && !contains(methods, "S256") {
+		return nil, ErrPKCEUnsupported
+	}
+
+	if err := client.refreshKeys(ctx); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDiscovery, err)
+	}
+
+	return client, nil
+}
+
+func (c *Client) Issuer() string {
+	return c.config.Issuer
+}
+
+// AuthCodeURL monta o endereço para onde o navegador é redirecionado no início do login
+func (c *Client) AuthCodeURL(state, nonce, codeVerifier string) string {
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {c.config.ClientID},
+		"redirect_uri":          {c.config.RedirectURL},
+		"scope":                 {strings.Join(c.config.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {CodeChallenge(codeVerifier)},
+		"code_challenge_method": {"S256"},
+	}
+
+	separator := "?"
+	if strings.Contains(c.metadata.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+
+	return c.metadata.AuthorizationEndpoint + separator + query.Encode()
+}
+
+// Exchange troca o code recebido no callback pelo ID token
+func (c *Client) Exchange(ctx context.Context, code, codeVerifier string) (string, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {c.config.RedirectURL},
+		"code_verifier": {codeVerifier},
+	}
+	if c.config.ClientSecret == "" {
+		form.Set("client_id", c.config.ClientID)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, c.metadata.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/json")
+	if c.config.ClientSecret != "" {
+		// client_secret_basic: id e segredo são codificados antes de irem para o Basic (RFC 6749, seção 2.3.1)
+		request.SetBasicAuth(url.QueryEscape(c.config.ClientID), url.QueryEscape(c.config.ClientSecret))
+	}
+
+	response, err := c.config.HTTPClient.Do(request)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrExchange, err)
+	}
+	defer response.Body.Close()
+
+	var output struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(io.LimitReader(response.Body, 1<<20)).Decode(&output); err != nil {
+		return "", fmt.Errorf("%w: status %d: %v", ErrExchange, response.StatusCode, err)
+	}
+	if response.StatusCode != http.StatusOK || output.Error != "" {
+		return "", fmt.Errorf("%w: status %d: %s %s", ErrExchange, response.StatusCode, output.Error, output.ErrorDescription)
+	}
+	if output.IDToken == "" {
+		return "", fmt.Errorf("%w: response without id_token", ErrExchange)
+	}
+
+	return output.IDToken, nil
+}
+
+// VerifyIDToken confere assinatura, issuer, audience, validade e o nonce gerado no início do login
+func (c *Client) VerifyIDToken(ctx context.Context, rawIDToken, nonce string) (*Claims, error) {
+	message, err := jws.ParseString(rawIDToken)
+	if err != nil || len(message.Signatures()) != 1 {
+		return nil, ErrInvalidIDToken
+	}
+
+	headers := message.Signatures()[0].ProtectedHeaders()
+	if !supported(headers.Algorithm()) {
+		return nil, fmt.Errorf("%w: unsupported algorithm %s", ErrInvalidIDToken, headers.Algorithm())
+	}
+
+	key, err := c.key(ctx, headers.KeyID())
+	if err != nil {
+		return nil, err
+	}
+
+	var raw interface{}
+	if err := key.Raw(&raw); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	token, err := jwt.ParseString(rawIDToken, jwt.WithVerify(headers.Algorithm(), raw))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	err = jwt.Validate(token,
+		jwt.WithIssuer(c.config.Issuer),
+		jwt.WithAudience(c.config.ClientID),
+		jwt.WithAcceptableSkew(clockSkew),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+	if token.Expiration().IsZero() || token.Subject() == "" {
+		return nil, fmt.Errorf("%w: missing exp or sub", ErrInvalidIDToken)
+	}
+
+	// Com mais de um audience o token precisa ter sido emitido para este cliente (azp)
+	if len(token.Audience()) > 1 {
+		if azp, _ := token.Get("azp"); azp != c.config.ClientID {
+			return nil, fmt.Errorf("%w: invalid azp", ErrInvalidIDToken)
+		}
+	}
+
+	if value, _ := token.Get("nonce"); value != nonce || nonce == "" {
+		return nil, fmt.Errorf("%w: invalid nonce", ErrInvalidIDToken)
+	}
+
+	claims := &Claims{Subject: token.Subject()}
+	claims.Email, _ = stringClaim(token, "email")
+	claims.Name, _ = stringClaim(token, "name")
+
+	// Alguns issuers mandam o email_verified como texto
+	switch verified, _ := token.Get("email_verified"); value := verified.(type) {
+	case bool:
+		claims.EmailVerified = value
+	case string:
+		claims.EmailVerified = value == "true"
+	}
+
+	return claims, nil
+}
+
+// key procura a chave pelo kid e busca o JWKS de novo quando o issuer rotacionou as chaves
+func (c *Client) key(ctx context.Context, kid string) (jwk.Key, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if key, ok := lookup(c.keys, kid); ok {
+		return key, nil
+	}
+
+	if time.Since(c.refreshedAt) >= jwksRefreshInterval {
+		if err := c.refreshKeysLocked(ctx); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+		}
+		if key, ok := lookup(c.keys, kid); ok {
+			return key, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: unknown key %q", ErrInvalidIDToken, kid)
+}
+
+func (c *Client) refreshKeys(ctx context.Context) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.refreshKeysLocked(ctx)
+}
+
+func (c *Client) refreshKeysLocked(ctx context.Context) error {
+	var data json.RawMessage
+	if err := c.getJSON(ctx, c.metadata.JWKSURI, &data); err != nil {
+		return err
+	}
+
+	keys, err := jwk.Parse(data)
+	if err != nil {
+		return err
+	}
+
+	c.keys = keys
+	c.refreshedAt = time.Now()
+	return nil
+}
+
+func (c *Client) getJSON(ctx context.Context, url string, target interface{}) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Accept", "application/json")
+
+	response, err := c.config.HTTPClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: status %d", url, response.StatusCode)
+	}
+
+	return json.NewDecoder(io.LimitReader(response.Body, 1<<20)).Decode(target)
+}
+
+// NewCodeVerifier gera o segredo do PKCE: 32 bytes aleatórios, 43 caracteres em base64url (RFC 7636)
+func NewCodeVerifier() (string, error) {
+	return randomString(32)
+}
+
+// CodeChallenge é o S256 do verifier, o único método aceito por este cliente
+func CodeChallenge(codeVerifier string) string {
+	sum := sha256.Sum256([]byte(codeVerifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// NewState gera os valores aleatórios usados no state e no nonce
+func NewState() (string, error) {
+	return randomString(24)
+}
+
+func randomString(size int) (string, error) {
+	random := make([]byte, size)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(random), nil
+}
+
+func lookup(keys jwk.Set, kid string) (jwk.Key, bool) {
+	if keys == nil {
+		return nil, false
+	}
+	if kid != "" {
+		return keys.LookupKeyID(kid)
+	}
+
+	// Tokens sem kid só são aceitos quando o issuer publica uma única chave
+	if keys.Len() == 1 {
+		return keys.Get(0)
+	}
+
+	return nil, false
+}
+
+func stringClaim(token jwt.Token, name string) (string, bool) {
+	value, ok := token.Get(name)
+	if !ok {
+		return "", false
+	}
+
+	text, ok := value.(string)
+	return text, ok
+}
+
+func supported(algorithm jwa.SignatureAlgorithm) bool {
+	for _, item := range supportedAlgorithms {
+		if item == algorithm {
+			return true
+		}
+	}
+
+	return false
+}
+
+func contains(items []string, value string) bool {
+	for _, item := range items {
+		if item == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,165 @@
+package oidc_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/auth/oidc"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/auth/oidc/oidctest"
+	"github.com/go-chi/jwtauth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const redirectURL = "http://localhost:8000/v1/users/oidc/callback"
+
+func newClient(t *testing.T) (*oidc.Client, *oidctest.Issuer) {
+	issuer, err := oidctest.NewIssuer()
+	require.NoError(t, err)
+	t.Cleanup(issuer.Close)
+
+	client, err := oidc.Discover(context.Background(), issuer.Config(redirectURL))
+	require.NoError(t, err)
+
+	return client, issuer
+}
+
+// authorize faz o papel do navegador: abre a URL de login e devolve os parâmetros do redirect para o callback
+func authorize(t *testing.T, client *oidc.Client, issuer *oidctest.Issuer, state, nonce, verifier string) url.Values {
+	httpClient := issuer.Server.Client()
+	httpClient.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+
+	response, err := httpClient.Get(client.AuthCodeURL(state, nonce, verifier))
+	require.NoError(t, err)
+	defer response.Body.Close()
+	require.Equal(t, http.StatusFound, response.StatusCode)
+
+	location, err := url.Parse(response.Header.Get("Location"))
+	require.NoError(t, err)
+	assert.Equal(t, "/v1/users/oidc/callback", location.Path)
+
+	return location.Query()
+}
+
+func TestAuthorizationCodeFlowWithPKCE(t *testing.T) {
+	client, issuer := newClient(t)
+	issuer.SetUser(oidctest.User{Subject: "123", Email: "john@email.com", EmailVerified: true, Name: "John"})
+
+	verifier, err := oidc.NewCodeVerifier()
+	require.NoError(t, err)
+	assert.Len(t, verifier, 43)
+
+	callback := authorize(t, client, issuer, "state-1", "nonce-1", verifier)
+	assert.Equal(t, "state-1", callback.Get("state"))
+	require.NotEmpty(t, callback.Get("code"))
+
+	idToken, err := client.Exchange(context.Background(), callback.Get("code"), verifier)
+	require.NoError(t, err)
+
+	claims, err := client.VerifyIDToken(context.Background(), idToken, "nonce-1")
+	require.NoError(t, err)
+	assert.Equal(t, &oidc.Claims{Subject: "123", Email: "john@email.com", EmailVerified: true, Name: "John"}, claims)
+
+	// O nonce de outro login não serve
+	_, err = client.VerifyIDToken(context.Background(), idToken, "nonce-2")
+	assert.ErrorIs(t, err, oidc.ErrInvalidIDToken)
+
+	// O code só vale uma vez
+	_, err = client.Exchange(context.Background(), callback.Get("code"), verifier)
+	assert.ErrorIs(t, err, oidc.ErrExchange)
+}
+
+func TestExchangeRequiresTheCodeVerifier(t *testing.T) {
+	client, issuer := newClient(t)
+
+	verifier, _ := oidc.NewCodeVerifier()
+	callback := authorize(t, client, issuer, "state", "nonce", verifier)
+
+	other, _ := oidc.NewCodeVerifier()
+	_, err := client.Exchange(context.Background(), callback.Get("code"), other)
+	assert.ErrorIs(t, err, oidc.ErrExchange)
+}
+
+func TestVerifyIDTokenRejectsInvalidTokens(t *testing.T) {
+	client, issuer := newClient(t)
+	now := time.Now()
+
+	valid := func() map[string]interface{} {
+		return map[string]interface{}{
+			"iss":   issuer.URL(),
+			"sub":   "123",
+			"aud":   oidctest.ClientID,
+			"iat":   now.Unix(),
+			"exp":   now.Add(time.Minute).Unix(),
+			"nonce": "nonce",
+		}
+	}
+
+	token, err := issuer.IDToken(valid())
+	require.NoError(t, err)
+	_, err = client.VerifyIDToken(context.Background(), token, "nonce")
+	require.NoError(t, err)
+
+	for name, change := range map[string]func(claims map[string]interface{}){
+		"issuer":   func(claims map[string]interface{}) { claims["iss"] = "https://evil.example.com" },
+		"audience": func(claims map[string]interface{}) { claims["aud"] = "other-client" },
+		"expired":  func(claims map[string]interface{}) { claims["exp"] = now.Add(-time.Hour).Unix() },
+		"no exp":   func(claims map[string]interface{}) { delete(claims, "exp") },
+		"no sub":   func(claims map[string]interface{}) { delete(claims, "sub") },
+		"nonce":    func(claims map[string]interface{}) { claims["nonce"] = "other" },
+		"azp": func(claims map[string]interface{}) {
+			claims["aud"] = []string{oidctest.ClientID, "other-client"}
+			claims["azp"] = "other-client"
+		},
+	} {
+		claims := valid()
+		change(claims)
+
+		token, err := issuer.IDToken(claims)
+		require.NoError(t, err, name)
+
+		_, err = client.VerifyIDToken(context.Background(), token, "nonce")
+		assert.ErrorIs(t, err, oidc.ErrInvalidIDToken, name)
+	}
+
+	// Um token HS256 assinado com o client secret não pode passar por um token do issuer
+	_, hmacToken, err := jwtauth.New("HS256", []byte(oidctest.ClientSecret), nil).Encode(valid())
+	require.NoError(t, err)
+	_, err = client.VerifyIDToken(context.Background(), hmacToken, "nonce")
+	assert.ErrorIs(t, err, oidc.ErrInvalidIDToken)
+
+	_, err = client.VerifyIDToken(context.Background(), "not-a-token", "nonce")
+	assert.ErrorIs(t, err, oidc.ErrInvalidIDToken)
+}
+
+func TestDiscoverRejectsAnotherIssuer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if request.URL.Path != "/.well-known/openid-configuration" {
+			http.NotFound(writer, request)
+			return
+		}
+		json.NewEncoder(writer).Encode(map[string]string{
+			"issuer":                 "https://evil.example.com",
+			"authorization_endpoint": "https://evil.example.com/authorize",
+			"token_endpoint":         "https://evil.example.com/token",
+			"jwks_uri":               "https://evil.example.com/jwks",
+		})
+	}))
+	defer server.Close()
+
+	_, err := oidc.Discover(context.Background(), oidc.Config{Issuer: server.URL, ClientID: "client"})
+	assert.ErrorIs(t, err, oidc.ErrIssuerMismatch)
+
+	_, err = oidc.Discover(context.Background(), oidc.Config{Issuer: server.URL + "/missing", ClientID: "client"})
+	assert.ErrorIs(t, err, oidc.ErrDiscovery)
+}
+
+func TestCodeChallenge(t *testing.T) {
+	// Exemplo do apêndice B da RFC 7636
+	assert.Equal(t, "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM", oidc.CodeChallenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"))
+}
@@ -0,0 +1,218 @@
+// Package oidctest sobe um issuer OpenID Connect local para os testes, no mesmo espírito do net/http/httptest.
+// Ele aprova qualquer login automaticamente com o usuário configurado em User
+package oidctest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/auth"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/auth/oidc"
+)
+
+const (
+	ClientID     = "go-expert-api"
+	ClientSecret = "go-expert-secret"
+
+	codeTTL    = time.Minute
+	idTokenTTL = 5 * time.Minute
+)
+
+// User é quem "faz login" no issuer. Error, quando preenchido (ex.: access_denied), é devolvido no redirect
+type User struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	Error         string
+}
+
+type authorization struct {
+	user          User
+	redirectURI   string
+	nonce         string
+	codeChallenge string
+	expiresAt     time.Time
+}
+
+type Issuer struct {
+	Server  *httptest.Server
+	KeyRing *auth.KeyRing
+
+	mutex sync.Mutex
+	user  User
+	codes map[string]authorization
+}
+
+// NewIssuer sobe o servidor com uma chave ES256 nova. Chame Close no fim do teste
+func NewIssuer() (*Issuer, error) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	keyRing, err := auth.NewKeyRing("ES256", privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	issuer := &Issuer{
+		KeyRing: keyRing,
+		user:    User{Subject: "oidc-user", Email: "sso@email.com", EmailVerified: true, Name: "SSO User"},
+		codes:   map[string]authorization{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", issuer.discovery)
+	mux.HandleFunc("/authorize", issuer.authorize)
+	mux.HandleFunc("/token", issuer.token)
+	mux.HandleFunc("/jwks", keyRing.JWKSHandler)
+	issuer.Server = httptest.NewServer(mux)
+
+	return issuer, nil
+}
+
+func (i *Issuer) URL() string {
+	return i.Server.URL
+}
+
+func (i *Issuer) Close() {
+	i.Server.Close()
+}
+
+// Config devolve a configuração do cliente já apontando para este issuer
+func (i *Issuer) Config(redirectURL string) oidc.Config {
+	return oidc.Config{
+		Issuer:       i.URL(),
+		ClientID:     ClientID,
+		ClientSecret: ClientSecret,
+		RedirectURL:  redirectURL,
+		HTTPClient:   i.Server.Client(),
+	}
+}
+
+// SetUser troca o usuário dos próximos logins
+func (i *Issuer) SetUser(user User) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	i.user = user
+}
+
+// IDToken assina um ID token com as claims informadas, para testar tokens inválidos diretamente
+func (i *Issuer) IDToken(claims map[string]interface{}) (string, error) {
+	_, token, err := i.KeyRing.TokenAuth().Encode(claims)
+	return token, err
+}
+
+func (i *Issuer) discovery(writer http.ResponseWriter, request *http.Request) {
+	writer.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(writer).Encode(map[string]interface{}{
+		"issuer":                                i.URL(),
+		"authorization_endpoint":                i.URL() + "/authorize",
+		"token_endpoint":                        i.URL() + "/token",
+		"jwks_uri":                              i.URL() + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"ES256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+func (i *Issuer) authorize(writer http.ResponseWriter, request *http.Request) {
+	query := request.URL.Query()
+	redirectURI, err := url.Parse(query.Get("redirect_uri"))
+	if err != nil || query.Get("redirect_uri") == "" || query.Get("client_id") != ClientID {
+		http.Error(writer, "invalid client or redirect_uri", http.StatusBadRequest)
+		return
+	}
+
+	response := url.Values{"state": {query.Get("state")}}
+
+	i.mutex.Lock()
+	user := i.user
+	switch {
+	case user.Error != "":
+		response.Set("error", user.Error)
+	case query.Get("response_type") != "code" || query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "":
+		response.Set("error", "invalid_request")
+	default:
+		code, _ := oidc.NewState()
+		i.codes[code] = authorization{
+			user:          user,
+			redirectURI:   redirectURI.String(),
+			nonce:         query.Get("nonce"),
+			codeChallenge: query.Get("code_challenge"),
+			expiresAt:     time.Now().Add(codeTTL),
+		}
+		response.Set("code", code)
+	}
+	i.mutex.Unlock()
+
+	redirectURI.RawQuery = response.Encode()
+	http.Redirect(writer, request, redirectURI.String(), http.StatusFound)
+}
+
+func (i *Issuer) token(writer http.ResponseWriter, request *http.Request) {
+	clientID, clientSecret, ok := request.BasicAuth()
+	if !ok || clientID != ClientID || clientSecret != ClientSecret {
+		tokenError(writer, http.StatusUnauthorized, "invalid_client")
+		return
+	}
+
+	if err := request.ParseForm(); err != nil || request.PostForm.Get("grant_type") != "authorization_code" {
+		tokenError(writer, http.StatusBadRequest, "unsupported_grant_type")
+		return
+	}
+
+	// O code só pode ser usado uma vez, mesmo quando a troca falha
+	i.mutex.Lock()
+	code := request.PostForm.Get("code")
+	authorization, ok := i.codes[code]
+	delete(i.codes, code)
+	i.mutex.Unlock()
+
+	if !ok || time.Now().After(authorization.expiresAt) || authorization.redirectURI != request.PostForm.Get("redirect_uri") ||
+		oidc.CodeChallenge(request.PostForm.Get("code_verifier")) != authorization.codeChallenge {
+		tokenError(writer, http.StatusBadRequest, "invalid_grant")
+		return
+	}
+
+	now := time.Now()
+	idToken, err := i.IDToken(map[string]interface{}{
+		"iss":            i.URL(),
+		"sub":            authorization.user.Subject,
+		"aud":            ClientID,
+		"iat":            now.Unix(),
+		"exp":            now.Add(idTokenTTL).Unix(),
+		"nonce":          authorization.nonce,
+		"email":          authorization.user.Email,
+		"email_verified": authorization.user.EmailVerified,
+		"name":           authorization.user.Name,
+	})
+	if err != nil {
+		tokenError(writer, http.StatusInternalServerError, "server_error")
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(writer).Encode(map[string]interface{}{
+		"access_token": "mock-access-token",
+		"token_type":   "Bearer",
+		"expires_in":   int(idTokenTTL / time.Second),
+		"id_token":     idToken,
+	})
+}
+
+func tokenError(writer http.ResponseWriter, status int, code string) {
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(status)
+	json.NewEncoder(writer).Encode(map[string]string{"error": code})
+}
@@ -0,0 +1,51 @@
+package auth
+
+import (
+	"time"
+
+	"github.com/go-chi/jwtauth"
+)
+
+// TokenIssuer emite os tokens do login com o JWTAuth do KeyRing. Implementa o usecase.TokenIssuer
+type TokenIssuer struct {
+	JWT       *jwtauth.JWTAuth
+	ExpiresIn time.Duration
+}
+
+func NewTokenIssuer(jwt *jwtauth.JWTAuth, expiresIn time.Duration) *TokenIssuer {
+	return &TokenIssuer{JWT: jwt, ExpiresIn: expiresIn}
+}
+
+func (t *TokenIssuer) AccessToken(userID, role string) (string, error) {
+	_, token, err := t.JWT.Encode(map[string]interface{}{
+		"sub":  userID,
+		"role": role,
+		"exp":  time.Now().Add(t.ExpiresIn).Unix(),
+	})
+
+	return token, err
+}
+
+func (t *TokenIssuer) ChallengeToken(userID string, ttl time.Duration) (string, error) {
+	_, token, err := t.JWT.Encode(map[string]interface{}{
+		"sub": userID,
+		"aud": ChallengeAudience,
+		"exp": time.Now().Add(ttl).Unix(),
+	})
+
+	return token, err
+}
+
+// ChallengeSubject só aceita tokens de desafio: um token de acesso não serve para pular o segundo fator
+func (t *TokenIssuer) ChallengeSubject(tokenString string) (string, error) {
+	token, err := jwtauth.VerifyToken(t.JWT, tokenString)
+	if err != nil {
+		return "", err
+	}
+
+	if !IsChallenge(token) {
+		return "", jwtauth.ErrUnauthorized
+	}
+
+	return token.Subject(), nil
+}
@@ -0,0 +1,37 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenIssuer(t *testing.T) {
+	keyRing, _ := NewHMACKeyRing("secret")
+	issuer := NewTokenIssuer(keyRing.TokenAuth(), time.Minute)
+
+	accessToken, err := issuer.AccessToken("user-id", "admin")
+	assert.Nil(t, err)
+
+	token, err := keyRing.Verify(accessToken)
+	assert.Nil(t, err)
+	assert.Equal(t, "user-id", token.Subject())
+	role, _ := token.Get("role")
+	assert.Equal(t, "admin", role)
+
+	challengeToken, err := issuer.ChallengeToken("user-id", time.Minute)
+	assert.Nil(t, err)
+
+	subject, err := issuer.ChallengeSubject(challengeToken)
+	assert.Nil(t, err)
+	assert.Equal(t, "user-id", subject)
+
+	// O token de acesso não serve como desafio
+	_, err = issuer.ChallengeSubject(accessToken)
+	assert.NotNil(t, err)
+
+	expired, _ := issuer.ChallengeToken("user-id", -time.Minute)
+	_, err = issuer.ChallengeSubject(expired)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,113 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/jwtauth"
+	"github.com/lestrrat-go/jwx/jwk"
+	"github.com/lestrrat-go/jwx/jws"
+	"github.com/lestrrat-go/jwx/jwt"
+)
+
+const (
+	// ChallengeAudience marca os tokens do segundo passo do login (TOTP). Eles são assinados pelo mesmo KeyRing,
+	// então quem valida os tokens em outro serviço pelo JWKS também precisa recusar esse audience
+	ChallengeAudience = "totp-challenge"
+	// OIDCStateAudience marca o cookie que guarda o state, o nonce e o verifier do PKCE durante o login pelo SSO
+	OIDCStateAudience = "oidc-state"
+)
+
+// Verify valida o token contra todas as chaves do KeyRing, escolhendo a chave pelo kid do header.
+// O algoritmo do header precisa ser o mesmo do KeyRing, evitando ataques de troca de algoritmo
+func (k *KeyRing) Verify(tokenString string) (jwt.Token, error) {
+	message, err := jws.ParseString(tokenString)
+	if err != nil || len(message.Signatures()) != 1 {
+		return nil, jwtauth.ErrUnauthorized
+	}
+
+	headers := message.Signatures()[0].ProtectedHeaders()
+	if headers.Algorithm() != k.algorithm {
+		return nil, jwtauth.ErrAlgoInvalid
+	}
+
+	key, ok := k.verifyKeys[headers.KeyID()]
+	if !ok {
+		// Tokens sem kid só são aceitos quando existe uma única chave possível
+		if headers.KeyID() != "" || len(k.verifyKeys) != 1 {
+			return nil, jwtauth.ErrUnauthorized
+		}
+		key = k.onlyVerifyKey()
+	}
+
+	var raw interface{}
+	if err := key.Raw(&raw); err != nil {
+		return nil, jwtauth.ErrUnauthorized
+	}
+
+	token, err := jwt.ParseString(tokenString, jwt.WithVerify(k.algorithm, raw))
+	if err != nil {
+		return nil, jwtauth.ErrUnauthorized
+	}
+
+	if err := jwt.Validate(token); err != nil {
+		return token, jwtauth.ErrorReason(err)
+	}
+
+	return token, nil
+}
+
+func IsChallenge(token jwt.Token) bool {
+	return HasAudience(token, ChallengeAudience)
+}
+
+func HasAudience(token jwt.Token, audience string) bool {
+	for _, item := range token.Audience() {
+		if item == audience {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (k *KeyRing) onlyVerifyKey() jwk.Key {
+	for _, key := range k.verifyKeys {
+		return key
+	}
+
+	return nil
+}
+
+// Verifier substitui o jwtauth.Verifier: grava o token e o erro no contexto com as mesmas chaves,
+// então jwtauth.Authenticator e jwtauth.FromContext continuam funcionando
+func (k *KeyRing) Verifier(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		tokenString := jwtauth.TokenFromHeader(request)
+		if tokenString == "" {
+			tokenString = jwtauth.TokenFromCookie(request)
+		}
+
+		var token jwt.Token
+		err := jwtauth.ErrNoTokenFound
+		if tokenString != "" {
+			token, err = k.Verify(tokenString)
+		}
+
+		// Tokens de acesso não têm audience. Os que têm (desafio do TOTP, state do SSO) só servem para o seu fluxo
+		if err == nil && len(token.Audience()) > 0 {
+			err = jwtauth.ErrUnauthorized
+		}
+
+		ctx := jwtauth.NewContext(request.Context(), token, err)
+		next.ServeHTTP(writer, request.WithContext(ctx))
+	})
+}
+
+// JWKSHandler publica as chaves públicas de verificação em /.well-known/jwks.json
+func (k *KeyRing) JWKSHandler(writer http.ResponseWriter, request *http.Request) {
+	writer.Header().Set("Content-Type", "application/json")
+	writer.Header().Set("Cache-Control", "public, max-age=300")
+	writer.WriteHeader(http.StatusOK)
+	json.NewEncoder(writer).Encode(k.publicKeys)
+}
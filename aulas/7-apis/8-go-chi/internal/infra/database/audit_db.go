@@ -0,0 +1,46 @@
+package database
+
+import (
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/entity"
+	"gorm.io/gorm"
+)
+
+type Audit struct {
+	DB *gorm.DB
+}
+
+func NewAudit(db *gorm.DB) *Audit {
+	return &Audit{DB: db}
+}
+
+func (a *Audit) Create(entry *entity.AuditEntry) error {
+	return a.DB.Create(entry).Error
+}
+
+func (a *Audit) Find(filter AuditFilter) ([]*entity.AuditEntry, error) {
+	var entries []*entity.AuditEntry
+
+	query := a.DB.Order("created_at desc")
+	if filter.EntityType != "" {
+		query = query.Where("entity_type = ?", filter.EntityType)
+	}
+	if filter.EntityID != "" {
+		query = query.Where("entity_id = ?", filter.EntityID)
+	}
+	if filter.Actor != "" {
+		query = query.Where("actor = ?", filter.Actor)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("created_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("created_at <= ?", filter.To)
+	}
+	if filter.Page != 0 && filter.Limit != 0 {
+		query = query.Limit(filter.Limit).Offset((filter.Page - 1) * filter.Limit)
+	}
+
+	err := query.Find(&entries).Error
+
+	return entries, err
+}
@@ -0,0 +1,74 @@
+package database
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestAuditCreateAndFind(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.AuditEntry{})
+
+	product, _ := entity.NewProduct("Product 1", 10)
+	user, _ := entity.NewUser("John", "john@email.com", "123456")
+	created, _ := entity.NewAuditEntry("user-1", entity.AuditCreate, "product", product.ID.String(), nil, product)
+	deleted, _ := entity.NewAuditEntry("user-2", entity.AuditDelete, "product", product.ID.String(), product, nil)
+	signUp, _ := entity.NewAuditEntry("", entity.AuditCreate, "user", user.ID.String(), nil, user)
+
+	auditDb := NewAudit(db)
+	for _, entry := range []*entity.AuditEntry{created, deleted, signUp} {
+		assert.Nil(t, auditDb.Create(entry))
+	}
+
+	entries, err := auditDb.Find(AuditFilter{EntityType: "product"})
+	assert.Nil(t, err)
+	assert.Len(t, entries, 2)
+
+	entries, _ = auditDb.Find(AuditFilter{Actor: "user-2"})
+	assert.Len(t, entries, 1)
+	assert.Equal(t, entity.AuditDelete, entries[0].Action)
+	assert.Equal(t, "Product 1", entries[0].Changes["name"].Before)
+
+	entries, _ = auditDb.Find(AuditFilter{From: time.Now().Add(time.Hour)})
+	assert.Len(t, entries, 0)
+}
+
+func TestTransactionRollsBackAuditWithTheChange(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.Product{}, &entity.AuditEntry{})
+
+	product, _ := entity.NewProduct("Product 1", 10)
+	errFailed := errors.New("failed")
+
+	err = NewTransaction(db).Run(func(repositories Repositories) error {
+		if err := repositories.Product.Create(product); err != nil {
+			return err
+		}
+
+		entry, _ := entity.NewAuditEntry("user-1", entity.AuditCreate, "product", product.ID.String(), nil, product)
+		if err := repositories.Audit.Create(entry); err != nil {
+			return err
+		}
+
+		return errFailed
+	})
+	assert.Equal(t, errFailed, err)
+
+	_, err = NewProduct(db).FindByID(product.ID.String())
+	assert.Equal(t, gorm.ErrRecordNotFound, err)
+
+	entries, _ := NewAudit(db).Find(AuditFilter{})
+	assert.Len(t, entries, 0)
+}
@@ -0,0 +1,72 @@
+package database
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/entity"
+)
+
+// AuditMemory implementa AuditInterface em memória, útil para testes sem banco de dados
+type AuditMemory struct {
+	mutex   sync.RWMutex
+	entries []entity.AuditEntry
+}
+
+func NewAuditMemory() *AuditMemory {
+	return &AuditMemory{}
+}
+
+func (a *AuditMemory) Create(entry *entity.AuditEntry) error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	a.entries = append(a.entries, *entry)
+	return nil
+}
+
+func (a *AuditMemory) Find(filter AuditFilter) ([]*entity.AuditEntry, error) {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+
+	entries := []*entity.AuditEntry{}
+	for _, entry := range a.entries {
+		if filter.EntityType != "" && entry.EntityType != filter.EntityType {
+			continue
+		}
+		if filter.EntityID != "" && entry.EntityID != filter.EntityID {
+			continue
+		}
+		if filter.Actor != "" && entry.Actor != filter.Actor {
+			continue
+		}
+		if !filter.From.IsZero() && entry.CreatedAt.Before(filter.From) {
+			continue
+		}
+		if !filter.To.IsZero() && entry.CreatedAt.After(filter.To) {
+			continue
+		}
+
+		entry := entry
+		entries = append(entries, &entry)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].CreatedAt.After(entries[j].CreatedAt)
+	})
+
+	if filter.Page != 0 && filter.Limit != 0 {
+		start := (filter.Page - 1) * filter.Limit
+		if start >= len(entries) {
+			return []*entity.AuditEntry{}, nil
+		}
+
+		end := start + filter.Limit
+		if end > len(entries) {
+			end = len(entries)
+		}
+		entries = entries[start:end]
+	}
+
+	return entries, nil
+}
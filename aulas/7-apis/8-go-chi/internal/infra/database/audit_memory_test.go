@@ -0,0 +1,29 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditMemoryFind(t *testing.T) {
+	product, _ := entity.NewProduct("Product 1", 10)
+	created, _ := entity.NewAuditEntry("user-1", entity.AuditCreate, "product", product.ID.String(), nil, product)
+	deleted, _ := entity.NewAuditEntry("user-2", entity.AuditDelete, "product", product.ID.String(), product, nil)
+
+	auditDb := NewAuditMemory()
+	auditDb.Create(created)
+	auditDb.Create(deleted)
+
+	entries, err := auditDb.Find(AuditFilter{EntityID: product.ID.String()})
+	assert.Nil(t, err)
+	assert.Len(t, entries, 2)
+
+	entries, _ = auditDb.Find(AuditFilter{Actor: "user-1"})
+	assert.Len(t, entries, 1)
+	assert.Equal(t, created.ID, entries[0].ID)
+
+	entries, _ = auditDb.Find(AuditFilter{Page: 2, Limit: 1})
+	assert.Len(t, entries, 1)
+}
@@ -0,0 +1,107 @@
+package database
+
+import (
+	"errors"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Cart struct {
+	DB *gorm.DB
+}
+
+func NewCart(db *gorm.DB) *Cart {
+	return &Cart{DB: db}
+}
+
+func (c *Cart) FindByUserID(userID string) (*entity.Cart, error) {
+	var cart entity.Cart
+	err := c.DB.Preload("Items").First(&cart, "user_id = ?", userID).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &cart, nil
+}
+
+// FindOrCreate devolve o carrinho do usuário, criando um vazio na primeira vez. O insert ignora o conflito
+// no índice único de user_id, então requisições simultâneas do mesmo usuário acabam no mesmo carrinho
+func (c *Cart) FindOrCreate(userID string) (*entity.Cart, error) {
+	cart, err := c.FindByUserID(userID)
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return cart, err
+	}
+
+	cart, err = entity.NewCart(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.DB.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "user_id"}}, DoNothing: true}).Omit("Items").Create(cart).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return c.FindByUserID(userID)
+}
+
+func (c *Cart) FindByProductID(productID string) ([]*entity.Cart, error) {
+	var carts []*entity.Cart
+	err := c.DB.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Items").
+		Where("id IN (?)", c.DB.Model(&entity.CartItem{}).Select("cart_id").Where("product_id = ?", productID)).
+		Find(&carts).Error
+
+	return carts, err
+}
+
+// Save grava o carrinho e substitui todos os itens na mesma transação,
+// já que o Save do GORM não remove os itens que saíram do slice.
+// O UPDATE só acontece se a versão ainda for a lida, senão outra requisição gravou no meio tempo
+func (c *Cart) Save(cart *entity.Cart) error {
+	err := c.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&entity.Cart{}).Where("id = ? AND version = ?", cart.ID, cart.Version).
+			Updates(map[string]interface{}{"version": cart.Version + 1, "updated_at": cart.UpdatedAt})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			if cart.Version != 0 {
+				return entity.ErrCartConflict
+			}
+
+			// Carrinho que ainda não foi gravado. Se outro foi criado no meio tempo, o insert não faz nada
+			created := *cart
+			created.Version = 1
+			result = tx.Clauses(clause.OnConflict{DoNothing: true}).Omit("Items").Create(&created)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return entity.ErrCartConflict
+			}
+		}
+
+		if err := tx.Where("cart_id = ?", cart.ID).Delete(&entity.CartItem{}).Error; err != nil {
+			return err
+		}
+
+		for i := range cart.Items {
+			cart.Items[i].CartID = cart.ID
+		}
+
+		if len(cart.Items) == 0 {
+			return nil
+		}
+
+		return tx.Create(&cart.Items).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	cart.Version++
+	return nil
+}
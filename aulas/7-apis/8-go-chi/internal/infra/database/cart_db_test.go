@@ -0,0 +1,115 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestCartSaveAndFindByUserID(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.Cart{}, &entity.CartItem{})
+
+	product, _ := entity.NewProduct("Product 1", 10)
+	product2, _ := entity.NewProduct("Product 2", 20)
+	cart, _ := entity.NewCart("user-id")
+	cart.AddItem(product, 2)
+	cart.AddItem(product2, 1)
+
+	cartDb := NewCart(db)
+	err = cartDb.Save(cart)
+	assert.Nil(t, err)
+
+	cartFound, err := cartDb.FindByUserID("user-id")
+	assert.Nil(t, err)
+	assert.Equal(t, cart.ID, cartFound.ID)
+	assert.Len(t, cartFound.Items, 2)
+	assert.Equal(t, 40, cartFound.Total())
+
+	// Itens removidos do slice também precisam sair do banco
+	cartFound.RemoveItem(product.ID)
+	err = cartDb.Save(cartFound)
+	assert.Nil(t, err)
+
+	cartFound, err = cartDb.FindByUserID("user-id")
+	assert.Nil(t, err)
+	assert.Len(t, cartFound.Items, 1)
+	assert.Equal(t, product2.ID, cartFound.Items[0].ProductID)
+
+	_, err = cartDb.FindByUserID("other-user")
+	assert.Equal(t, gorm.ErrRecordNotFound, err)
+}
+
+func TestCartFindOrCreate(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.Cart{}, &entity.CartItem{})
+
+	cartDb := NewCart(db)
+	created, err := cartDb.FindOrCreate("user-id")
+	assert.Nil(t, err)
+	assert.Empty(t, created.Items)
+
+	// A segunda chamada encontra o carrinho já gravado em vez de criar outro
+	found, err := cartDb.FindOrCreate("user-id")
+	assert.Nil(t, err)
+	assert.Equal(t, created.ID, found.ID)
+
+	// Simula outra requisição gravando o carrinho entre a busca e o insert
+	other, _ := entity.NewCart("other-user")
+	db.Callback().Create().Before("gorm:create").Register("test:race", func(tx *gorm.DB) {
+		if cart, ok := tx.Statement.Dest.(*entity.Cart); ok && cart.ID != other.ID {
+			tx.Session(&gorm.Session{NewDB: true, SkipHooks: true}).Omit("Items").Create(other)
+		}
+	})
+	found, err = cartDb.FindOrCreate("other-user")
+	assert.Nil(t, err)
+	assert.Equal(t, other.ID, found.ID)
+}
+
+func TestCartSaveDetectsConcurrentChanges(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.Cart{}, &entity.CartItem{})
+
+	product, _ := entity.NewProduct("Product 1", 10)
+	product2, _ := entity.NewProduct("Product 2", 20)
+	cartDb := NewCart(db)
+	first, _ := cartDb.FindOrCreate("user-id")
+	second, _ := cartDb.FindOrCreate("user-id")
+
+	first.AddItem(product, 1)
+	assert.Nil(t, cartDb.Save(first))
+	assert.Equal(t, 1, first.Version)
+
+	// A segunda leitura é anterior ao Save da primeira e não pode apagar o item gravado
+	second.AddItem(product2, 1)
+	assert.Equal(t, entity.ErrCartConflict, cartDb.Save(second))
+
+	found, _ := cartDb.FindByUserID("user-id")
+	assert.Len(t, found.Items, 1)
+	found.AddItem(product2, 1)
+	assert.Nil(t, cartDb.Save(found))
+
+	// Um carrinho novo não pode substituir o que já existe para o usuário
+	other, _ := entity.NewCart("user-id")
+	assert.Equal(t, entity.ErrCartConflict, cartDb.Save(other))
+
+	carts, err := cartDb.FindByProductID(product2.ID.String())
+	assert.Nil(t, err)
+	assert.Len(t, carts, 1)
+	assert.Len(t, carts[0].Items, 2)
+
+	carts, _ = cartDb.FindByProductID("missing")
+	assert.Empty(t, carts)
+}
@@ -0,0 +1,86 @@
+package database
+
+import (
+	"sync"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/entity"
+	"gorm.io/gorm"
+)
+
+// CartMemory implementa CartInterface em memória, útil para testes sem banco de dados
+type CartMemory struct {
+	mutex sync.RWMutex
+	carts map[string]entity.Cart
+}
+
+func NewCartMemory() *CartMemory {
+	return &CartMemory{carts: make(map[string]entity.Cart)}
+}
+
+func (c *CartMemory) FindByUserID(userID string) (*entity.Cart, error) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	cart, ok := c.carts[userID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	cart.Items = append([]entity.CartItem{}, cart.Items...)
+	return &cart, nil
+}
+
+func (c *CartMemory) FindOrCreate(userID string) (*entity.Cart, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	cart, ok := c.carts[userID]
+	if !ok {
+		created, err := entity.NewCart(userID)
+		if err != nil {
+			return nil, err
+		}
+		cart = *created
+		c.carts[userID] = cart
+	}
+
+	cart.Items = append([]entity.CartItem{}, cart.Items...)
+	return &cart, nil
+}
+
+func (c *CartMemory) FindByProductID(productID string) ([]*entity.Cart, error) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	carts := []*entity.Cart{}
+	for _, cart := range c.carts {
+		for _, item := range cart.Items {
+			if item.ProductID.String() == productID {
+				cart := cart
+				cart.Items = append([]entity.CartItem{}, cart.Items...)
+				carts = append(carts, &cart)
+				break
+			}
+		}
+	}
+
+	return carts, nil
+}
+
+// Save confere a versão como a versão com GORM: um carrinho que não foi lido só é gravado se ainda não existe
+func (c *CartMemory) Save(cart *entity.Cart) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	current, ok := c.carts[cart.UserID]
+	if (ok && (current.ID != cart.ID || current.Version != cart.Version)) || (!ok && cart.Version != 0) {
+		return entity.ErrCartConflict
+	}
+
+	cart.Version++
+	stored := *cart
+	stored.Items = append([]entity.CartItem{}, cart.Items...)
+	c.carts[cart.UserID] = stored
+
+	return nil
+}
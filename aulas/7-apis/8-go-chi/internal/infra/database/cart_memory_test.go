@@ -0,0 +1,74 @@
+package database
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestCartMemorySaveAndFindByUserID(t *testing.T) {
+	product, _ := entity.NewProduct("Product 1", 10)
+	cart, _ := entity.NewCart("user-id")
+	cart.AddItem(product, 2)
+
+	cartDb := NewCartMemory()
+	assert.Nil(t, cartDb.Save(cart))
+
+	cartFound, err := cartDb.FindByUserID("user-id")
+	assert.Nil(t, err)
+	assert.Len(t, cartFound.Items, 1)
+
+	// Alterar o carrinho retornado não deve alterar o repositório
+	cartFound.Items[0].Quantity = 99
+	cartFound, _ = cartDb.FindByUserID("user-id")
+	assert.Equal(t, 2, cartFound.Items[0].Quantity)
+
+	_, err = cartDb.FindByUserID("other-user")
+	assert.Equal(t, gorm.ErrRecordNotFound, err)
+}
+
+func TestCartMemoryFindOrCreate(t *testing.T) {
+	cartDb := NewCartMemory()
+
+	// Requisições simultâneas do mesmo usuário precisam acabar no mesmo carrinho
+	ids := make(chan string, 10)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cart, err := cartDb.FindOrCreate("user-id")
+			assert.Nil(t, err)
+			ids <- cart.ID.String()
+		}()
+	}
+	wg.Wait()
+	close(ids)
+
+	first := <-ids
+	for id := range ids {
+		assert.Equal(t, first, id)
+	}
+}
+
+func TestCartMemorySaveDetectsConcurrentChanges(t *testing.T) {
+	product, _ := entity.NewProduct("Product 1", 10)
+	cartDb := NewCartMemory()
+	first, _ := cartDb.FindOrCreate("user-id")
+	second, _ := cartDb.FindOrCreate("user-id")
+
+	first.AddItem(product, 1)
+	assert.Nil(t, cartDb.Save(first))
+	second.AddItem(product, 2)
+	assert.Equal(t, entity.ErrCartConflict, cartDb.Save(second))
+
+	other, _ := entity.NewCart("user-id")
+	assert.Equal(t, entity.ErrCartConflict, cartDb.Save(other))
+
+	carts, _ := cartDb.FindByProductID(product.ID.String())
+	assert.Len(t, carts, 1)
+	assert.Equal(t, 1, carts[0].Items[0].Quantity)
+}
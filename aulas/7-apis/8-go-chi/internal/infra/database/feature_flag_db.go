@@ -0,0 +1,53 @@
+package database
+
+import (
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/entity"
+	"gorm.io/gorm"
+)
+
+type FeatureFlag struct {
+	DB *gorm.DB
+}
+
+func NewFeatureFlag(db *gorm.DB) *FeatureFlag {
+	return &FeatureFlag{DB: db}
+}
+
+func (f *FeatureFlag) Create(flag *entity.FeatureFlag) error {
+	return f.DB.Create(flag).Error
+}
+
+func (f *FeatureFlag) FindAll() ([]*entity.FeatureFlag, error) {
+	var flags []*entity.FeatureFlag
+	err := f.DB.Order("key asc").Find(&flags).Error
+
+	return flags, err
+}
+
+func (f *FeatureFlag) FindByKey(key string) (*entity.FeatureFlag, error) {
+	var flag entity.FeatureFlag
+	err := f.DB.First(&flag, "key = ?", key).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &flag, nil
+}
+
+func (f *FeatureFlag) Update(flag *entity.FeatureFlag) error {
+	_, err := f.FindByKey(flag.Key)
+	if err != nil {
+		return err
+	}
+
+	return f.DB.Save(flag).Error
+}
+
+func (f *FeatureFlag) Delete(key string) error {
+	_, err := f.FindByKey(key)
+	if err != nil {
+		return err
+	}
+
+	return f.DB.Delete(&entity.FeatureFlag{}, "key = ?", key).Error
+}
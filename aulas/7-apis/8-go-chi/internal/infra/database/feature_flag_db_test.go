@@ -0,0 +1,47 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestFeatureFlagCRUD(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.FeatureFlag{})
+
+	flag, _ := entity.NewFeatureFlag("new-pricing", "Nova regra de preço", true, 25, []string{"user-1"}, []string{"admin"})
+	other, _ := entity.NewFeatureFlag("checkout-v2", "", false, 0, nil, nil)
+
+	flagDb := NewFeatureFlag(db)
+	assert.Nil(t, flagDb.Create(flag))
+	assert.Nil(t, flagDb.Create(other))
+	assert.Error(t, flagDb.Create(flag))
+
+	flagFound, err := flagDb.FindByKey("new-pricing")
+	assert.Nil(t, err)
+	assert.Equal(t, 25, flagFound.Percentage)
+	assert.Equal(t, entity.StringList{"user-1"}, flagFound.UserIDs)
+	assert.Equal(t, entity.StringList{"admin"}, flagFound.Roles)
+
+	flags, err := flagDb.FindAll()
+	assert.Nil(t, err)
+	assert.Len(t, flags, 2)
+	assert.Equal(t, "checkout-v2", flags[0].Key)
+
+	flagFound.Percentage = 50
+	assert.Nil(t, flagDb.Update(flagFound))
+	flagFound, _ = flagDb.FindByKey("new-pricing")
+	assert.Equal(t, 50, flagFound.Percentage)
+
+	assert.Nil(t, flagDb.Delete("new-pricing"))
+	_, err = flagDb.FindByKey("new-pricing")
+	assert.Equal(t, gorm.ErrRecordNotFound, err)
+	assert.Equal(t, gorm.ErrRecordNotFound, flagDb.Delete("new-pricing"))
+}
@@ -0,0 +1,91 @@
+package database
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/entity"
+	"gorm.io/gorm"
+)
+
+// FeatureFlagMemory implementa FeatureFlagInterface em memória, útil para testes sem banco de dados
+type FeatureFlagMemory struct {
+	mutex sync.RWMutex
+	flags map[string]entity.FeatureFlag
+}
+
+func NewFeatureFlagMemory() *FeatureFlagMemory {
+	return &FeatureFlagMemory{flags: make(map[string]entity.FeatureFlag)}
+}
+
+func (f *FeatureFlagMemory) Create(flag *entity.FeatureFlag) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if _, ok := f.flags[flag.Key]; ok {
+		return gorm.ErrDuplicatedKey
+	}
+
+	f.flags[flag.Key] = copyFeatureFlag(*flag)
+	return nil
+}
+
+func (f *FeatureFlagMemory) FindAll() ([]*entity.FeatureFlag, error) {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	flags := make([]*entity.FeatureFlag, 0, len(f.flags))
+	for _, flag := range f.flags {
+		flag := copyFeatureFlag(flag)
+		flags = append(flags, &flag)
+	}
+
+	sort.Slice(flags, func(i, j int) bool {
+		return flags[i].Key < flags[j].Key
+	})
+
+	return flags, nil
+}
+
+func (f *FeatureFlagMemory) FindByKey(key string) (*entity.FeatureFlag, error) {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	flag, ok := f.flags[key]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	flag = copyFeatureFlag(flag)
+	return &flag, nil
+}
+
+func (f *FeatureFlagMemory) Update(flag *entity.FeatureFlag) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if _, ok := f.flags[flag.Key]; !ok {
+		return gorm.ErrRecordNotFound
+	}
+
+	f.flags[flag.Key] = copyFeatureFlag(*flag)
+	return nil
+}
+
+func (f *FeatureFlagMemory) Delete(key string) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if _, ok := f.flags[key]; !ok {
+		return gorm.ErrRecordNotFound
+	}
+
+	delete(f.flags, key)
+	return nil
+}
+
+func copyFeatureFlag(flag entity.FeatureFlag) entity.FeatureFlag {
+	flag.UserIDs = append(entity.StringList{}, flag.UserIDs...)
+	flag.Roles = append(entity.StringList{}, flag.Roles...)
+	return flag
+}
@@ -0,0 +1,32 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestFeatureFlagMemoryCRUD(t *testing.T) {
+	flag, _ := entity.NewFeatureFlag("new-pricing", "", true, 25, []string{"user-1"}, nil)
+
+	flagDb := NewFeatureFlagMemory()
+	assert.Nil(t, flagDb.Create(flag))
+	assert.Equal(t, gorm.ErrDuplicatedKey, flagDb.Create(flag))
+
+	// Alterar a flag retornada não deve alterar o repositório
+	flagFound, _ := flagDb.FindByKey("new-pricing")
+	flagFound.UserIDs[0] = "user-2"
+	flagFound, _ = flagDb.FindByKey("new-pricing")
+	assert.Equal(t, entity.StringList{"user-1"}, flagFound.UserIDs)
+
+	flagFound.Enabled = false
+	assert.Nil(t, flagDb.Update(flagFound))
+	flags, _ := flagDb.FindAll()
+	assert.False(t, flags[0].Enabled)
+
+	assert.Nil(t, flagDb.Delete("new-pricing"))
+	assert.Equal(t, gorm.ErrRecordNotFound, flagDb.Delete("new-pricing"))
+	assert.Equal(t, gorm.ErrRecordNotFound, flagDb.Update(flag))
+}
@@ -1,16 +1,147 @@
 package database
 
-import "github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/entity"
+import (
+	"time"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/entity"
+)
 
 type UserInterface interface {
 	Create(user *entity.User) error
 	FindByEmail(email string) (*entity.User, error)
+	FindByID(id string) (*entity.User, error)
+	// FindByIDForUpdate bloqueia o usuário até o fim da transação, para ler e gravar contadores sem perder escritas
+	FindByIDForUpdate(id string) (*entity.User, error)
+	// FindByOIDC busca o usuário vinculado à conta do SSO
+	FindByOIDC(issuer, subject string) (*entity.User, error)
+	Update(user *entity.User) error
 }
 
 type ProductInterface interface {
 	Create(product *entity.Product) error
 	FindAll(page, limit int, sort string) ([]*entity.Product, error)
 	FindByID(id string) (*entity.Product, error)
+	// FindByIDForUpdate bloqueia o produto até o fim da transação, para calcular o diff de uma leitura atual
+	FindByIDForUpdate(id string) (*entity.Product, error)
 	Update(product *entity.Product) error
 	Delete(id string) error
 }
+
+// Datas zeradas em from/to significam período aberto naquela ponta
+type PriceHistoryInterface interface {
+	Create(change *entity.PriceChange) error
+	FindByProductID(productID string, from, to time.Time) ([]*entity.PriceChange, error)
+}
+
+type CartInterface interface {
+	FindByUserID(userID string) (*entity.Cart, error)
+	FindOrCreate(userID string) (*entity.Cart, error)
+	// FindByProductID devolve os carrinhos que têm o produto, para aplicar as mudanças do catálogo. Também
+	// bloqueia os carrinhos até o fim da transação
+	FindByProductID(productID string) ([]*entity.Cart, error)
+	// Save devolve entity.ErrCartConflict quando o carrinho foi gravado por outra requisição depois de lido
+	Save(cart *entity.Cart) error
+}
+
+type CouponInterface interface {
+	Create(coupon *entity.Coupon) error
+	FindByCode(code string) (*entity.Coupon, error)
+	// Redeem incrementa o uso de forma atômica, devolvendo entity.ErrCouponUsageLimitReached quando esgotado
+	Redeem(code string) error
+}
+
+type PromotionRuleInterface interface {
+	Create(rule *entity.PromotionRule) error
+	FindActive(now time.Time) ([]*entity.PromotionRule, error)
+}
+
+// As operações de escrita também atualizam o agregado de avaliações do produto
+type ReviewInterface interface {
+	Create(review *entity.Review) error
+	FindByID(id string) (*entity.Review, error)
+	FindByProductAndUser(productID, userID string) (*entity.Review, error)
+	FindByProductID(productID string, page, limit int) ([]*entity.Review, error)
+	Update(review *entity.Review) error
+	Delete(review *entity.Review) error
+	// DeleteByProductID apaga as avaliações de um produto removido, sem mexer no agregado dele
+	DeleteByProductID(productID string) error
+}
+
+// AuditFilter tem todos os campos opcionais. Page e Limit seguem a mesma regra do FindAll de produtos
+type AuditFilter struct {
+	EntityType string
+	EntityID   string
+	Actor      string
+	From       time.Time
+	To         time.Time
+	Page       int
+	Limit      int
+}
+
+type AuditInterface interface {
+	Create(entry *entity.AuditEntry) error
+	Find(filter AuditFilter) ([]*entity.AuditEntry, error)
+}
+
+type JobInterface interface {
+	// Create devolve entity.ErrJobAlreadyEnqueued quando já existe um job com o mesmo UniqueKey
+	Create(job *entity.Job) error
+	FindByID(id string) (*entity.Job, error)
+	// Claim reserva de forma atômica o próximo job vencido: enfileirado com RunAt <= now, ou rodando com o prazo
+	// do worker vencido. O job volta como running, com uma tentativa a mais. Sem jobs pendentes devolve nil, nil
+	Claim(now time.Time, lease time.Duration) (*entity.Job, error)
+	// Update grava o resultado de quem pegou o job no Claim, e attempt é a tentativa devolvida por ele. Se o job
+	// não está mais running nessa tentativa (o prazo venceu e outro worker o pegou), devolve entity.ErrJobLeaseLost
+	Update(job *entity.Job, attempt int) error
+	DeleteFinishedBefore(before time.Time) (int64, error)
+}
+
+type WishlistInterface interface {
+	FindByID(id string) (*entity.Wishlist, error)
+	// FindByIDForUpdate bloqueia a lista até o fim da transação: o Save regrava todos os itens, então quem lê e
+	// grava sem o bloqueio apaga o que outra requisição acabou de gravar
+	FindByIDForUpdate(id string) (*entity.Wishlist, error)
+	FindByUserID(userID string) ([]*entity.Wishlist, error)
+	// FindByShareToken é a busca do link público. Token vazio nunca encontra lista
+	FindByShareToken(token string) (*entity.Wishlist, error)
+	// FindByProductID devolve as listas que têm o produto, para aplicar as mudanças do catálogo. Também bloqueia
+	// as listas até o fim da transação
+	FindByProductID(productID string) ([]*entity.Wishlist, error)
+	Save(wishlist *entity.Wishlist) error
+	Delete(id string) error
+}
+
+// Page e Limit seguem a mesma regra do FindAll de produtos
+type NotificationInterface interface {
+	Create(notification *entity.Notification) error
+	FindByUserID(userID string, page, limit int) ([]*entity.Notification, error)
+}
+
+type FeatureFlagInterface interface {
+	Create(flag *entity.FeatureFlag) error
+	// FindAll devolve as flags ordenadas pela chave
+	FindAll() ([]*entity.FeatureFlag, error)
+	FindByKey(key string) (*entity.FeatureFlag, error)
+	Update(flag *entity.FeatureFlag) error
+	Delete(key string) error
+}
+
+// Repositories agrupa os repositórios que podem participar de uma mesma transação
+type Repositories struct {
+	Product      ProductInterface
+	PriceHistory PriceHistoryInterface
+	User         UserInterface
+	Audit        AuditInterface
+	// As mudanças de preço e as remoções de produto chegam às listas de desejos na mesma transação
+	Wishlist     WishlistInterface
+	Notification NotificationInterface
+	Review       ReviewInterface
+	// Assim como as listas de desejos, os carrinhos recebem as mudanças de preço e as remoções na mesma transação
+	Cart CartInterface
+}
+
+// TransactionInterface executa fn com repositórios ligados a uma única transação.
+// Se fn devolver erro, nada do que foi feito dentro dela é persistido
+type TransactionInterface interface {
+	Run(fn func(repositories Repositories) error) error
+}
@@ -0,0 +1,102 @@
+package database
+
+import (
+	"time"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Job struct {
+	DB *gorm.DB
+}
+
+func NewJob(db *gorm.DB) *Job {
+	return &Job{DB: db}
+}
+
+func (j *Job) Create(job *entity.Job) error {
+	result := j.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(job)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return entity.ErrJobAlreadyEnqueued
+	}
+
+	return nil
+}
+
+func (j *Job) FindByID(id string) (*entity.Job, error) {
+	var job entity.Job
+	err := j.DB.First(&job, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+func (j *Job) Claim(now time.Time, lease time.Duration) (*entity.Job, error) {
+	for {
+		var candidates []entity.Job
+		err := j.DB.
+			Where("(status = ? AND run_at <= ?) OR (status = ? AND locked_until <= ?)", entity.JobQueued, now, entity.JobRunning, now).
+			Order("run_at").
+			Limit(1).
+			Find(&candidates).Error
+		if err != nil {
+			return nil, err
+		}
+		if len(candidates) == 0 {
+			return nil, nil
+		}
+
+		// Outro worker pode ter escolhido o mesmo job. O UPDATE só passa se o job ainda estiver como foi lido,
+		// então apenas um deles fica com ele e os outros tentam o próximo
+		job := candidates[0]
+		result := j.DB.Model(&entity.Job{}).
+			Where("id = ? AND status = ? AND attempts = ?", job.ID, job.Status, job.Attempts).
+			Updates(map[string]interface{}{
+				"status":       entity.JobRunning,
+				"attempts":     gorm.Expr("attempts + 1"),
+				"locked_until": now.Add(lease),
+				"updated_at":   now,
+			})
+		if result.Error != nil {
+			return nil, result.Error
+		}
+
+		if result.RowsAffected == 1 {
+			job.Status = entity.JobRunning
+			job.Attempts++
+			job.LockedUntil = now.Add(lease)
+			job.UpdatedAt = now
+			return &job, nil
+		}
+	}
+}
+
+func (j *Job) Update(job *entity.Job, attempt int) error {
+	result := j.DB.Model(job).
+		Where("status = ? AND attempts = ?", entity.JobRunning, attempt).
+		Select("*").
+		Updates(job)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return entity.ErrJobLeaseLost
+	}
+
+	return nil
+}
+
+func (j *Job) DeleteFinishedBefore(before time.Time) (int64, error) {
+	result := j.DB.Where("status IN ? AND finished_at < ?", []string{entity.JobSucceeded, entity.JobFailed}, before).Delete(&entity.Job{})
+
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,157 @@
+package database
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func newJobDB(t *testing.T) *Job {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	// Cada conexão teria o seu próprio banco em memória. Com uma só, os workers concorrentes ainda se
+	// intercalam entre o SELECT e o UPDATE do Claim
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	db.AutoMigrate(&entity.Job{})
+
+	return NewJob(db)
+}
+
+func TestJobCreateAndFindByID(t *testing.T) {
+	jobDb := newJobDB(t)
+
+	job, _ := entity.NewJob("products.export", map[string]string{"format": "csv"}, time.Time{}, 3)
+	assert.Nil(t, jobDb.Create(job))
+
+	found, err := jobDb.FindByID(job.ID.String())
+	assert.Nil(t, err)
+	assert.Equal(t, entity.JobQueued, found.Status)
+	assert.Equal(t, job.Payload, found.Payload)
+
+	key := "cron:jobs.cleanup:1"
+	first, _ := entity.NewJob("jobs.cleanup", nil, time.Time{}, 1)
+	first.UniqueKey = &key
+	second, _ := entity.NewJob("jobs.cleanup", nil, time.Time{}, 1)
+	second.UniqueKey = &key
+	assert.Nil(t, jobDb.Create(first))
+	assert.Equal(t, entity.ErrJobAlreadyEnqueued, jobDb.Create(second))
+}
+
+func TestJobClaim(t *testing.T) {
+	jobDb := newJobDB(t)
+	now := time.Now()
+
+	later, _ := entity.NewJob("products.export", nil, now.Add(time.Hour), 3)
+	due, _ := entity.NewJob("products.export", nil, now.Add(-time.Minute), 3)
+	jobDb.Create(later)
+	jobDb.Create(due)
+
+	claimed, err := jobDb.Claim(now, time.Minute)
+	assert.Nil(t, err)
+	assert.Equal(t, due.ID, claimed.ID)
+	assert.Equal(t, entity.JobRunning, claimed.Status)
+	assert.Equal(t, 1, claimed.Attempts)
+
+	// O job agendado para depois ainda não está vencido
+	claimed, err = jobDb.Claim(now, time.Minute)
+	assert.Nil(t, err)
+	assert.Nil(t, claimed)
+
+	// Passado o prazo do worker, o job rodando volta a ser elegível
+	claimed, _ = jobDb.Claim(now.Add(2*time.Minute), time.Minute)
+	assert.Equal(t, due.ID, claimed.ID)
+	assert.Equal(t, 2, claimed.Attempts)
+}
+
+func TestJobClaimIsExclusive(t *testing.T) {
+	jobDb := newJobDB(t)
+	for i := 0; i < 10; i++ {
+		job, _ := entity.NewJob("products.export", nil, time.Time{}, 3)
+		jobDb.Create(job)
+	}
+
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	claimed := map[string]int{}
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				job, err := jobDb.Claim(time.Now(), time.Hour)
+				if err != nil || job == nil {
+					return
+				}
+
+				mutex.Lock()
+				claimed[job.ID.String()]++
+				mutex.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Len(t, claimed, 10)
+	for _, count := range claimed {
+		assert.Equal(t, 1, count)
+	}
+}
+
+func TestJobUpdateAndDeleteFinished(t *testing.T) {
+	jobDb := newJobDB(t)
+	now := time.Now()
+
+	finished, _ := entity.NewJob("products.export", nil, now.Add(-time.Minute), 1)
+	jobDb.Create(finished)
+	claimed, _ := jobDb.Claim(now, time.Minute)
+	claimed.Fail(errors.New("boom"), now, now.Add(-48*time.Hour))
+	assert.Nil(t, jobDb.Update(claimed, 1))
+
+	pending, _ := entity.NewJob("products.export", nil, now.Add(time.Hour), 1)
+	jobDb.Create(pending)
+
+	found, _ := jobDb.FindByID(finished.ID.String())
+	assert.Equal(t, entity.JobFailed, found.Status)
+	assert.Equal(t, "boom", found.LastError)
+
+	deleted, err := jobDb.DeleteFinishedBefore(now.Add(-24 * time.Hour))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), deleted)
+
+	_, err = jobDb.FindByID(pending.ID.String())
+	assert.Nil(t, err)
+}
+
+func TestJobUpdateRequiresOwnership(t *testing.T) {
+	jobDb := newJobDB(t)
+	now := time.Now()
+
+	job, _ := entity.NewJob("products.export", nil, now.Add(-time.Minute), 2)
+	jobDb.Create(job)
+	stale, _ := jobDb.Claim(now, time.Minute)
+
+	// O prazo do primeiro worker venceu e outro pegou o job
+	current, _ := jobDb.Claim(now.Add(2*time.Minute), time.Minute)
+	assert.Equal(t, 2, current.Attempts)
+
+	stale.Succeed("stale", "text/plain", now)
+	assert.Equal(t, entity.ErrJobLeaseLost, jobDb.Update(stale, 1))
+
+	current.Succeed("current", "text/plain", now)
+	assert.Nil(t, jobDb.Update(current, 2))
+	// Depois de terminado, nem o próprio worker grava de novo
+	assert.Equal(t, entity.ErrJobLeaseLost, jobDb.Update(current, 2))
+
+	found, _ := jobDb.FindByID(job.ID.String())
+	assert.Equal(t, entity.JobSucceeded, found.Status)
+	assert.Equal(t, "current", found.Result)
+}
@@ -0,0 +1,109 @@
+package database
+
+import (
+	"sync"
+	"time"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/entity"
+	"gorm.io/gorm"
+)
+
+// JobMemory implementa JobInterface em memória, útil para testes sem banco de dados
+type JobMemory struct {
+	mutex sync.Mutex
+	jobs  map[string]entity.Job
+}
+
+func NewJobMemory() *JobMemory {
+	return &JobMemory{jobs: map[string]entity.Job{}}
+}
+
+func (j *JobMemory) Create(job *entity.Job) error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	if job.UniqueKey != nil {
+		for _, existing := range j.jobs {
+			if existing.UniqueKey != nil && *existing.UniqueKey == *job.UniqueKey {
+				return entity.ErrJobAlreadyEnqueued
+			}
+		}
+	}
+
+	j.jobs[job.ID.String()] = *job
+	return nil
+}
+
+func (j *JobMemory) FindByID(id string) (*entity.Job, error) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	job, ok := j.jobs[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	return &job, nil
+}
+
+func (j *JobMemory) Claim(now time.Time, lease time.Duration) (*entity.Job, error) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	var next *entity.Job
+	for _, job := range j.jobs {
+		due := job.Status == entity.JobQueued && !job.RunAt.After(now)
+		expired := job.Status == entity.JobRunning && !job.LockedUntil.After(now)
+		if !due && !expired {
+			continue
+		}
+
+		if next == nil || job.RunAt.Before(next.RunAt) {
+			job := job
+			next = &job
+		}
+	}
+
+	if next == nil {
+		return nil, nil
+	}
+
+	next.Status = entity.JobRunning
+	next.Attempts++
+	next.LockedUntil = now.Add(lease)
+	next.UpdatedAt = now
+	j.jobs[next.ID.String()] = *next
+
+	return next, nil
+}
+
+func (j *JobMemory) Update(job *entity.Job, attempt int) error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	stored, ok := j.jobs[job.ID.String()]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	if stored.Status != entity.JobRunning || stored.Attempts != attempt {
+		return entity.ErrJobLeaseLost
+	}
+
+	j.jobs[job.ID.String()] = *job
+	return nil
+}
+
+func (j *JobMemory) DeleteFinishedBefore(before time.Time) (int64, error) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	var deleted int64
+	for id, job := range j.jobs {
+		if job.Finished() && job.FinishedAt != nil && job.FinishedAt.Before(before) {
+			delete(j.jobs, id)
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
@@ -0,0 +1,51 @@
+package database
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobMemory(t *testing.T) {
+	jobDb := NewJobMemory()
+	now := time.Now()
+
+	later, _ := entity.NewJob("products.export", nil, now.Add(time.Hour), 1)
+	due, _ := entity.NewJob("products.export", nil, now.Add(-time.Minute), 1)
+	jobDb.Create(later)
+	jobDb.Create(due)
+
+	key := "cron:jobs.cleanup:1"
+	unique, _ := entity.NewJob("jobs.cleanup", nil, now.Add(time.Hour), 1)
+	unique.UniqueKey = &key
+	assert.Nil(t, jobDb.Create(unique))
+	assert.Equal(t, entity.ErrJobAlreadyEnqueued, jobDb.Create(unique))
+
+	claimed, err := jobDb.Claim(now, time.Minute)
+	assert.Nil(t, err)
+	assert.Equal(t, due.ID, claimed.ID)
+	assert.Equal(t, 1, claimed.Attempts)
+	stale := *claimed
+
+	claimed, _ = jobDb.Claim(now, time.Minute)
+	assert.Nil(t, claimed)
+
+	claimed, _ = jobDb.Claim(now.Add(2*time.Minute), time.Minute)
+	assert.Equal(t, due.ID, claimed.ID)
+
+	// O primeiro worker perdeu o job quando o prazo venceu
+	stale.Succeed("stale", "text/plain", now)
+	assert.Equal(t, entity.ErrJobLeaseLost, jobDb.Update(&stale, 1))
+
+	claimed.Fail(errors.New("boom"), now, now.Add(-48*time.Hour))
+	assert.Nil(t, jobDb.Update(claimed, 2))
+
+	deleted, _ := jobDb.DeleteFinishedBefore(now.Add(-24 * time.Hour))
+	assert.Equal(t, int64(1), deleted)
+
+	_, err = jobDb.FindByID(due.ID.String())
+	assert.NotNil(t, err)
+}
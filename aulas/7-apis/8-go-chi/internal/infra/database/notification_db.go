@@ -0,0 +1,31 @@
+package database
+
+import (
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/entity"
+	"gorm.io/gorm"
+)
+
+type Notification struct {
+	DB *gorm.DB
+}
+
+func NewNotification(db *gorm.DB) *Notification {
+	return &Notification{DB: db}
+}
+
+func (n *Notification) Create(notification *entity.Notification) error {
+	return n.DB.Create(notification).Error
+}
+
+// FindByUserID devolve as notificações mais recentes primeiro
+func (n *Notification) FindByUserID(userID string, page, limit int) ([]*entity.Notification, error) {
+	var notifications []*entity.Notification
+	query := n.DB.Where("user_id = ?", userID).Order("created_at desc")
+
+	if page != 0 && limit != 0 {
+		query = query.Limit(limit).Offset((page - 1) * limit)
+	}
+
+	err := query.Find(&notifications).Error
+	return notifications, err
+}
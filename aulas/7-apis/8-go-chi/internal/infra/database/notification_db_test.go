@@ -0,0 +1,41 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestNotificationCreateAndFindByUserID(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.Notification{})
+
+	product, _ := entity.NewProduct("Product 1", 100)
+	wishlist, _ := entity.NewWishlist("user-id", "Presentes")
+	wishlist.AddItem(product)
+
+	notificationDb := NewNotification(db)
+	older := entity.NewPriceDropNotification(wishlist, wishlist.Items[0], 120)
+	older.CreatedAt = time.Now().Add(-time.Hour)
+	assert.Nil(t, notificationDb.Create(older))
+	assert.Nil(t, notificationDb.Create(entity.NewProductRemovedNotification(wishlist, wishlist.Items[0])))
+
+	notifications, err := notificationDb.FindByUserID("user-id", 0, 0)
+	assert.Nil(t, err)
+	assert.Len(t, notifications, 2)
+	assert.Equal(t, entity.NotificationProductRemoved, notifications[0].Type)
+
+	notifications, _ = notificationDb.FindByUserID("user-id", 2, 1)
+	assert.Len(t, notifications, 1)
+	assert.Equal(t, older.ID, notifications[0].ID)
+
+	notifications, _ = notificationDb.FindByUserID("other-user", 0, 0)
+	assert.Empty(t, notifications)
+}
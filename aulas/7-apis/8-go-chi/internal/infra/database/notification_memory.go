@@ -0,0 +1,58 @@
+package database
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/entity"
+)
+
+// NotificationMemory implementa NotificationInterface em memória, útil para testes sem banco de dados
+type NotificationMemory struct {
+	mutex         sync.RWMutex
+	notifications []entity.Notification
+}
+
+func NewNotificationMemory() *NotificationMemory {
+	return &NotificationMemory{}
+}
+
+func (n *NotificationMemory) Create(notification *entity.Notification) error {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	n.notifications = append(n.notifications, *notification)
+	return nil
+}
+
+func (n *NotificationMemory) FindByUserID(userID string, page, limit int) ([]*entity.Notification, error) {
+	n.mutex.RLock()
+	defer n.mutex.RUnlock()
+
+	notifications := []*entity.Notification{}
+	for _, notification := range n.notifications {
+		if notification.UserID == userID {
+			notification := notification
+			notifications = append(notifications, &notification)
+		}
+	}
+
+	sort.SliceStable(notifications, func(i, j int) bool {
+		return notifications[i].CreatedAt.After(notifications[j].CreatedAt)
+	})
+
+	if page != 0 && limit != 0 {
+		start := (page - 1) * limit
+		if start >= len(notifications) {
+			return []*entity.Notification{}, nil
+		}
+
+		end := start + limit
+		if end > len(notifications) {
+			end = len(notifications)
+		}
+		notifications = notifications[start:end]
+	}
+
+	return notifications, nil
+}
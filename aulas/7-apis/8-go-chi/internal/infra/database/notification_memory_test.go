@@ -0,0 +1,31 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotificationMemoryFindByUserID(t *testing.T) {
+	product, _ := entity.NewProduct("Product 1", 100)
+	wishlist, _ := entity.NewWishlist("user-id", "Presentes")
+	wishlist.AddItem(product)
+
+	notificationDb := NewNotificationMemory()
+	older := entity.NewPriceDropNotification(wishlist, wishlist.Items[0], 120)
+	older.CreatedAt = time.Now().Add(-time.Hour)
+	assert.Nil(t, notificationDb.Create(older))
+	assert.Nil(t, notificationDb.Create(entity.NewProductRemovedNotification(wishlist, wishlist.Items[0])))
+
+	notifications, _ := notificationDb.FindByUserID("user-id", 0, 0)
+	assert.Len(t, notifications, 2)
+	assert.Equal(t, entity.NotificationProductRemoved, notifications[0].Type)
+
+	notifications, _ = notificationDb.FindByUserID("user-id", 2, 1)
+	assert.Equal(t, older.ID, notifications[0].ID)
+
+	notifications, _ = notificationDb.FindByUserID("user-id", 3, 1)
+	assert.Empty(t, notifications)
+}
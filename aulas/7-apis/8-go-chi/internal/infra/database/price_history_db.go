@@ -0,0 +1,36 @@
+package database
+
+import (
+	"time"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/entity"
+	"gorm.io/gorm"
+)
+
+type PriceHistory struct {
+	DB *gorm.DB
+}
+
+func NewPriceHistory(db *gorm.DB) *PriceHistory {
+	return &PriceHistory{DB: db}
+}
+
+func (p *PriceHistory) Create(change *entity.PriceChange) error {
+	return p.DB.Create(change).Error
+}
+
+func (p *PriceHistory) FindByProductID(productID string, from, to time.Time) ([]*entity.PriceChange, error) {
+	var changes []*entity.PriceChange
+
+	query := p.DB.Where("product_id = ?", productID)
+	if !from.IsZero() {
+		query = query.Where("changed_at >= ?", from)
+	}
+	if !to.IsZero() {
+		query = query.Where("changed_at <= ?", to)
+	}
+
+	err := query.Order("changed_at asc").Find(&changes).Error
+
+	return changes, err
+}
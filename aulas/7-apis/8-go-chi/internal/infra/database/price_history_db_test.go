@@ -0,0 +1,42 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestPriceHistoryFindByProductID(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.PriceChange{})
+
+	product, _ := entity.NewProduct("Product Test", 10)
+	otherProduct, _ := entity.NewProduct("Other Product", 10)
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	priceHistoryDb := NewPriceHistory(db)
+	for i, price := range []int{20, 30, 40} {
+		change := entity.NewPriceChange(product.ID, price-10, price, "user-id")
+		change.ChangedAt = start.Add(time.Duration(i) * 24 * time.Hour)
+		assert.Nil(t, priceHistoryDb.Create(change))
+	}
+	priceHistoryDb.Create(entity.NewPriceChange(otherProduct.ID, 10, 99, "user-id"))
+
+	changes, err := priceHistoryDb.FindByProductID(product.ID.String(), time.Time{}, time.Time{})
+	assert.Nil(t, err)
+	assert.Len(t, changes, 3)
+	assert.Equal(t, 20, changes[0].NewPrice)
+	assert.Equal(t, "user-id", changes[0].ChangedBy)
+
+	changes, err = priceHistoryDb.FindByProductID(product.ID.String(), start.Add(time.Hour), start.Add(36*time.Hour))
+	assert.Nil(t, err)
+	assert.Len(t, changes, 1)
+	assert.Equal(t, 30, changes[0].NewPrice)
+}
@@ -0,0 +1,52 @@
+package database
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/entity"
+)
+
+// PriceHistoryMemory implementa PriceHistoryInterface em memória, útil para testes sem banco de dados
+type PriceHistoryMemory struct {
+	mutex   sync.RWMutex
+	changes []entity.PriceChange
+}
+
+func NewPriceHistoryMemory() *PriceHistoryMemory {
+	return &PriceHistoryMemory{}
+}
+
+func (p *PriceHistoryMemory) Create(change *entity.PriceChange) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.changes = append(p.changes, *change)
+	return nil
+}
+
+func (p *PriceHistoryMemory) FindByProductID(productID string, from, to time.Time) ([]*entity.PriceChange, error) {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	changes := []*entity.PriceChange{}
+	for _, change := range p.changes {
+		if change.ProductID.String() != productID {
+			continue
+		}
+		if !from.IsZero() && change.ChangedAt.Before(from) {
+			continue
+		}
+		if !to.IsZero() && change.ChangedAt.After(to) {
+			continue
+		}
+		changes = append(changes, &change)
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].ChangedAt.Before(changes[j].ChangedAt)
+	})
+
+	return changes, nil
+}
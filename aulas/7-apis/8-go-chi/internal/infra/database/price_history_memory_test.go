@@ -0,0 +1,33 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPriceHistoryMemoryFindByProductID(t *testing.T) {
+	product, _ := entity.NewProduct("Product Test", 10)
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	priceHistoryDb := NewPriceHistoryMemory()
+	for i, price := range []int{40, 30, 20} {
+		change := entity.NewPriceChange(product.ID, price+10, price, "user-id")
+		change.ChangedAt = start.Add(time.Duration(2-i) * 24 * time.Hour)
+		priceHistoryDb.Create(change)
+	}
+
+	changes, err := priceHistoryDb.FindByProductID(product.ID.String(), time.Time{}, time.Time{})
+	assert.Nil(t, err)
+	assert.Len(t, changes, 3)
+	assert.Equal(t, 20, changes[0].NewPrice)
+	assert.Equal(t, 40, changes[2].NewPrice)
+
+	changes, _ = priceHistoryDb.FindByProductID(product.ID.String(), start.Add(24*time.Hour), time.Time{})
+	assert.Len(t, changes, 2)
+
+	changes, _ = priceHistoryDb.FindByProductID("missing", time.Time{}, time.Time{})
+	assert.Empty(t, changes)
+}
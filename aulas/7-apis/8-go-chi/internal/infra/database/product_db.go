@@ -3,6 +3,7 @@ package database
 import (
 	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/entity"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type Product struct {
@@ -27,14 +28,26 @@ func (p *Product) FindByID(id string) (*entity.Product, error) {
 	return &product, nil
 }
 
+// FindByIDForUpdate usa SELECT ... FOR UPDATE. O SQLite ignora o FOR, mas lá a transação de escrita já é serializada
+func (p *Product) FindByIDForUpdate(id string) (*entity.Product, error) {
+	var product entity.Product
+	err := p.DB.Clauses(clause.Locking{Strength: "UPDATE"}).First(&product, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &product, nil
+}
+
 func (p *Product) Update(product *entity.Product) error {
 	_, err := p.FindByID(product.ID.String())
 	if err != nil {
 		return err
 	}
 
-	// Se apenas colocar pra atualizar, sem buscar antes, ele vai criar um novo registro
-	return p.DB.Save(product).Error
+	// Se apenas colocar pra atualizar, sem buscar antes, ele vai criar um novo registro.
+	// O agregado das avaliações é de responsabilidade do repositório de reviews e não é sobrescrito aqui
+	return p.DB.Omit("RatingAverage", "RatingCount", "RatingSum").Save(product).Error
 }
 
 func (p *Product) Delete(id string) error {
@@ -47,6 +47,14 @@ func TestFindProductById(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, product.Name, productFound.Name)
 	assert.Equal(t, product.Price, productFound.Price)
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		productFound, err := NewProduct(tx).FindByIDForUpdate(product.ID.String())
+		assert.Nil(t, err)
+		assert.Equal(t, product.Price, productFound.Price)
+		return nil
+	})
+	assert.Nil(t, err)
 }
 
 func TestFindProductAll(t *testing.T) {
@@ -0,0 +1,123 @@
+package database
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/entity"
+	"gorm.io/gorm"
+)
+
+// ProductMemory implementa ProductInterface em memória, útil para testes sem banco de dados.
+// Os erros seguem o mesmo contrato da implementação com GORM (gorm.ErrRecordNotFound)
+type ProductMemory struct {
+	mutex    sync.RWMutex
+	products map[string]entity.Product
+}
+
+func NewProductMemory() *ProductMemory {
+	return &ProductMemory{products: make(map[string]entity.Product)}
+}
+
+func (p *ProductMemory) Create(product *entity.Product) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.products[product.ID.String()] = *product
+	return nil
+}
+
+func (p *ProductMemory) FindByID(id string) (*entity.Product, error) {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	product, ok := p.products[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	// Devolvemos uma cópia para que alterações fora do repositório não afetem o estado interno
+	return &product, nil
+}
+
+// FindByIDForUpdate não precisa de lock próprio: a TransactionMemory já serializa as transações
+func (p *ProductMemory) FindByIDForUpdate(id string) (*entity.Product, error) {
+	return p.FindByID(id)
+}
+
+func (p *ProductMemory) Update(product *entity.Product) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	current, ok := p.products[product.ID.String()]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+
+	// Assim como no GORM, o agregado das avaliações não é sobrescrito pelo Update
+	updated := *product
+	updated.RatingAverage, updated.RatingCount, updated.RatingSum = current.RatingAverage, current.RatingCount, current.RatingSum
+	p.products[product.ID.String()] = updated
+	return nil
+}
+
+func (p *ProductMemory) applyRating(id string, countDelta, sumDelta int) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	product, ok := p.products[id]
+	if !ok {
+		return
+	}
+
+	product.ApplyRating(countDelta, sumDelta)
+	p.products[id] = product
+}
+
+func (p *ProductMemory) Delete(id string) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if _, ok := p.products[id]; !ok {
+		return gorm.ErrRecordNotFound
+	}
+
+	delete(p.products, id)
+	return nil
+}
+
+func (p *ProductMemory) FindAll(page, limit int, sortOrder string) ([]*entity.Product, error) {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	if sortOrder != "asc" && sortOrder != "desc" {
+		sortOrder = "asc"
+	}
+
+	products := make([]*entity.Product, 0, len(p.products))
+	for _, product := range p.products {
+		products = append(products, &product)
+	}
+
+	sort.SliceStable(products, func(i, j int) bool {
+		if sortOrder == "desc" {
+			return products[i].CreatedAt.After(products[j].CreatedAt)
+		}
+		return products[i].CreatedAt.Before(products[j].CreatedAt)
+	})
+
+	if page != 0 && limit != 0 {
+		start := (page - 1) * limit
+		if start >= len(products) {
+			return []*entity.Product{}, nil
+		}
+
+		end := start + limit
+		if end > len(products) {
+			end = len(products)
+		}
+		products = products[start:end]
+	}
+
+	return products, nil
+}
@@ -0,0 +1,100 @@
+package database
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestProductMemoryCreateAndFindByID(t *testing.T) {
+	productDb := NewProductMemory()
+	product, _ := entity.NewProduct("Product Test", 10)
+
+	err := productDb.Create(product)
+	assert.Nil(t, err)
+
+	productFound, err := productDb.FindByID(product.ID.String())
+	assert.Nil(t, err)
+	assert.Equal(t, product.Name, productFound.Name)
+	assert.Equal(t, product.Price, productFound.Price)
+
+	// Alterar a cópia retornada não deve alterar o repositório
+	productFound.Name = "Changed"
+	productFound, _ = productDb.FindByID(product.ID.String())
+	assert.Equal(t, "Product Test", productFound.Name)
+}
+
+func TestProductMemoryFindAll(t *testing.T) {
+	productDb := NewProductMemory()
+	for i := 1; i < 25; i++ {
+		product, _ := entity.NewProduct(fmt.Sprintf("Product %d", i), 10+i)
+		productDb.Create(product)
+	}
+
+	products, err := productDb.FindAll(1, 10, "asc")
+	assert.Nil(t, err)
+	assert.Len(t, products, 10)
+	assert.Equal(t, "Product 1", products[0].Name)
+	assert.Equal(t, "Product 10", products[9].Name)
+
+	products, err = productDb.FindAll(3, 10, "asc")
+	assert.Nil(t, err)
+	assert.Len(t, products, 4)
+	assert.Equal(t, "Product 21", products[0].Name)
+
+	products, err = productDb.FindAll(0, 0, "desc")
+	assert.Nil(t, err)
+	assert.Len(t, products, 24)
+	assert.Equal(t, "Product 24", products[0].Name)
+
+	products, err = productDb.FindAll(10, 10, "asc")
+	assert.Nil(t, err)
+	assert.Empty(t, products)
+}
+
+func TestProductMemoryUpdateAndDelete(t *testing.T) {
+	productDb := NewProductMemory()
+	product, _ := entity.NewProduct("Product Test", 10)
+	productDb.Create(product)
+
+	product.Name = "Product Test Updated"
+	err := productDb.Update(product)
+	assert.Nil(t, err)
+
+	productFound, _ := productDb.FindByID(product.ID.String())
+	assert.Equal(t, "Product Test Updated", productFound.Name)
+
+	err = productDb.Delete(product.ID.String())
+	assert.Nil(t, err)
+
+	productFound, err = productDb.FindByID(product.ID.String())
+	assert.Nil(t, productFound)
+	assert.Equal(t, gorm.ErrRecordNotFound, err)
+
+	missing, _ := entity.NewProduct("Missing", 10)
+	assert.Equal(t, gorm.ErrRecordNotFound, productDb.Update(missing))
+	assert.Equal(t, gorm.ErrRecordNotFound, productDb.Delete(missing.ID.String()))
+}
+
+func TestProductMemoryConcurrentAccess(t *testing.T) {
+	productDb := NewProductMemory()
+	waitGroup := sync.WaitGroup{}
+
+	for i := 0; i < 50; i++ {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			product, _ := entity.NewProduct("Product", 10)
+			productDb.Create(product)
+			productDb.FindAll(1, 10, "asc")
+		}()
+	}
+	waitGroup.Wait()
+
+	products, _ := productDb.FindAll(0, 0, "asc")
+	assert.Len(t, products, 50)
+}
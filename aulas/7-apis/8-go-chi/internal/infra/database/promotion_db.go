@@ -0,0 +1,75 @@
+package database
+
+import (
+	"time"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/entity"
+	"gorm.io/gorm"
+)
+
+type Coupon struct {
+	DB *gorm.DB
+}
+
+func NewCoupon(db *gorm.DB) *Coupon {
+	return &Coupon{DB: db}
+}
+
+func (c *Coupon) Create(coupon *entity.Coupon) error {
+	return c.DB.Create(coupon).Error
+}
+
+func (c *Coupon) FindByCode(code string) (*entity.Coupon, error) {
+	var coupon entity.Coupon
+	err := c.DB.First(&coupon, "code = ?", entity.NormalizeCouponCode(code)).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &coupon, nil
+}
+
+// Redeem faz a checagem do limite no próprio UPDATE para que dois pedidos simultâneos não passem do máximo
+func (c *Coupon) Redeem(code string) error {
+	code = entity.NormalizeCouponCode(code)
+
+	result := c.DB.Model(&entity.Coupon{}).
+		Where("code = ? AND (max_uses = 0 OR uses < max_uses)", code).
+		UpdateColumn("uses", gorm.Expr("uses + 1"))
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		if _, err := c.FindByCode(code); err != nil {
+			return err
+		}
+		return entity.ErrCouponUsageLimitReached
+	}
+
+	return nil
+}
+
+type PromotionRule struct {
+	DB *gorm.DB
+}
+
+func NewPromotionRule(db *gorm.DB) *PromotionRule {
+	return &PromotionRule{DB: db}
+}
+
+func (p *PromotionRule) Create(rule *entity.PromotionRule) error {
+	return p.DB.Create(rule).Error
+}
+
+func (p *PromotionRule) FindActive(now time.Time) ([]*entity.PromotionRule, error) {
+	var rules []*entity.PromotionRule
+
+	err := p.DB.
+		Where("starts_at IS NULL OR starts_at <= ? OR starts_at = ?", now, time.Time{}).
+		Where("ends_at IS NULL OR ends_at > ? OR ends_at = ?", now, time.Time{}).
+		Order("created_at asc").
+		Find(&rules).Error
+
+	return rules, err
+}
@@ -0,0 +1,64 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestCouponCreateFindAndRedeem(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.Coupon{})
+
+	coupon, _ := entity.NewCoupon("promo", entity.Discount{DiscountType: entity.DiscountFixed, Value: 10}, entity.Validity{}, 2, 0)
+	couponDb := NewCoupon(db)
+	err = couponDb.Create(coupon)
+	assert.Nil(t, err)
+
+	couponFound, err := couponDb.FindByCode(" Promo ")
+	assert.Nil(t, err)
+	assert.Equal(t, coupon.ID, couponFound.ID)
+	assert.Equal(t, entity.DiscountFixed, couponFound.DiscountType)
+
+	assert.Nil(t, couponDb.Redeem("promo"))
+	assert.Nil(t, couponDb.Redeem("promo"))
+	assert.Equal(t, entity.ErrCouponUsageLimitReached, couponDb.Redeem("promo"))
+
+	couponFound, _ = couponDb.FindByCode("PROMO")
+	assert.Equal(t, 2, couponFound.Uses)
+
+	assert.Equal(t, gorm.ErrRecordNotFound, couponDb.Redeem("missing"))
+}
+
+func TestPromotionRuleFindActive(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.PromotionRule{})
+
+	now := time.Now()
+	discount := entity.Discount{DiscountType: entity.DiscountPercentage, Value: 10}
+	open, _ := entity.NewPromotionRule("Open", "", "books", discount, entity.Validity{})
+	current, _ := entity.NewPromotionRule("Current", "", "books", discount, entity.Validity{StartsAt: now.Add(-time.Hour), EndsAt: now.Add(time.Hour)})
+	expired, _ := entity.NewPromotionRule("Expired", "", "books", discount, entity.Validity{EndsAt: now.Add(-time.Hour)})
+	future, _ := entity.NewPromotionRule("Future", "", "books", discount, entity.Validity{StartsAt: now.Add(time.Hour)})
+
+	ruleDb := NewPromotionRule(db)
+	for _, rule := range []*entity.PromotionRule{open, current, expired, future} {
+		assert.Nil(t, ruleDb.Create(rule))
+	}
+
+	rules, err := ruleDb.FindActive(now)
+	assert.Nil(t, err)
+	assert.Len(t, rules, 2)
+	assert.Equal(t, "Open", rules[0].Name)
+	assert.Equal(t, "Current", rules[1].Name)
+}
@@ -0,0 +1,100 @@
+package database
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/entity"
+	"gorm.io/gorm"
+)
+
+// CouponMemory implementa CouponInterface em memória, útil para testes sem banco de dados
+type CouponMemory struct {
+	mutex   sync.RWMutex
+	coupons map[string]entity.Coupon
+}
+
+func NewCouponMemory() *CouponMemory {
+	return &CouponMemory{coupons: make(map[string]entity.Coupon)}
+}
+
+func (c *CouponMemory) Create(coupon *entity.Coupon) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if _, ok := c.coupons[coupon.Code]; ok {
+		return gorm.ErrDuplicatedKey
+	}
+
+	c.coupons[coupon.Code] = *coupon
+	return nil
+}
+
+func (c *CouponMemory) FindByCode(code string) (*entity.Coupon, error) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	coupon, ok := c.coupons[entity.NormalizeCouponCode(code)]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	return &coupon, nil
+}
+
+func (c *CouponMemory) Redeem(code string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	code = entity.NormalizeCouponCode(code)
+	coupon, ok := c.coupons[code]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+
+	if coupon.MaxUses > 0 && coupon.Uses >= coupon.MaxUses {
+		return entity.ErrCouponUsageLimitReached
+	}
+
+	coupon.Uses++
+	c.coupons[code] = coupon
+	return nil
+}
+
+// PromotionRuleMemory implementa PromotionRuleInterface em memória, útil para testes sem banco de dados
+type PromotionRuleMemory struct {
+	mutex sync.RWMutex
+	rules []entity.PromotionRule
+}
+
+func NewPromotionRuleMemory() *PromotionRuleMemory {
+	return &PromotionRuleMemory{}
+}
+
+func (p *PromotionRuleMemory) Create(rule *entity.PromotionRule) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.rules = append(p.rules, *rule)
+	return nil
+}
+
+func (p *PromotionRuleMemory) FindActive(now time.Time) ([]*entity.PromotionRule, error) {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	var rules []*entity.PromotionRule
+	for _, rule := range p.rules {
+		if rule.Validity.Contains(now) {
+			rule := rule
+			rules = append(rules, &rule)
+		}
+	}
+
+	sort.SliceStable(rules, func(i, j int) bool {
+		return rules[i].CreatedAt.Before(rules[j].CreatedAt)
+	})
+
+	return rules, nil
+}
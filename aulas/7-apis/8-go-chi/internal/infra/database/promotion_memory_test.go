@@ -0,0 +1,43 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestCouponMemory(t *testing.T) {
+	coupon, _ := entity.NewCoupon("promo", entity.Discount{DiscountType: entity.DiscountFixed, Value: 10}, entity.Validity{}, 1, 0)
+	couponDb := NewCouponMemory()
+	assert.Nil(t, couponDb.Create(coupon))
+	assert.Equal(t, gorm.ErrDuplicatedKey, couponDb.Create(coupon))
+
+	couponFound, err := couponDb.FindByCode("promo")
+	assert.Nil(t, err)
+	assert.Equal(t, coupon.ID, couponFound.ID)
+
+	assert.Nil(t, couponDb.Redeem("PROMO"))
+	assert.Equal(t, entity.ErrCouponUsageLimitReached, couponDb.Redeem("PROMO"))
+
+	_, err = couponDb.FindByCode("missing")
+	assert.Equal(t, gorm.ErrRecordNotFound, err)
+}
+
+func TestPromotionRuleMemoryFindActive(t *testing.T) {
+	now := time.Now()
+	discount := entity.Discount{DiscountType: entity.DiscountFixed, Value: 10}
+	active, _ := entity.NewPromotionRule("Active", "", "books", discount, entity.Validity{})
+	expired, _ := entity.NewPromotionRule("Expired", "", "books", discount, entity.Validity{EndsAt: now.Add(-time.Hour)})
+
+	ruleDb := NewPromotionRuleMemory()
+	ruleDb.Create(active)
+	ruleDb.Create(expired)
+
+	rules, err := ruleDb.FindActive(now)
+	assert.Nil(t, err)
+	assert.Len(t, rules, 1)
+	assert.Equal(t, active.ID, rules[0].ID)
+}
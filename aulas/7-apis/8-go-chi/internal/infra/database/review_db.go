@@ -0,0 +1,115 @@
+package database
+
+import (
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Review struct {
+	DB *gorm.DB
+}
+
+func NewReview(db *gorm.DB) *Review {
+	return &Review{DB: db}
+}
+
+func (r *Review) Create(review *entity.Review) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(review).Error; err != nil {
+			return err
+		}
+
+		return applyRating(tx, review.ProductID, 1, review.Rating)
+	})
+}
+
+func (r *Review) FindByID(id string) (*entity.Review, error) {
+	var review entity.Review
+	err := r.DB.First(&review, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &review, nil
+}
+
+func (r *Review) FindByProductAndUser(productID, userID string) (*entity.Review, error) {
+	var review entity.Review
+	err := r.DB.First(&review, "product_id = ? AND user_id = ?", productID, userID).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &review, nil
+}
+
+func (r *Review) FindByProductID(productID string, page, limit int) ([]*entity.Review, error) {
+	var reviews []*entity.Review
+
+	query := r.DB.Where("product_id = ?", productID).Order("created_at desc")
+	if page != 0 && limit != 0 {
+		query = query.Limit(limit).Offset((page - 1) * limit)
+	}
+
+	err := query.Find(&reviews).Error
+
+	return reviews, err
+}
+
+// Update e Delete leem a nota gravada dentro da transação, travando a linha, em vez de confiar na nota
+// que o chamador leu antes: com duas edições simultâneas o agregado usaria a mesma nota anterior duas vezes
+func (r *Review) Update(review *entity.Review) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		stored, err := lockReview(tx, review.ID.String())
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Save(review).Error; err != nil {
+			return err
+		}
+
+		return applyRating(tx, review.ProductID, 0, review.Rating-stored.Rating)
+	})
+}
+
+func (r *Review) Delete(review *entity.Review) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		stored, err := lockReview(tx, review.ID.String())
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Delete(&entity.Review{}, "id = ?", review.ID).Error; err != nil {
+			return err
+		}
+
+		return applyRating(tx, stored.ProductID, -1, -stored.Rating)
+	})
+}
+
+func (r *Review) DeleteByProductID(productID string) error {
+	return r.DB.Delete(&entity.Review{}, "product_id = ?", productID).Error
+}
+
+// lockReview usa SELECT ... FOR UPDATE. O SQLite ignora o FOR, mas lá a transação de escrita já é serializada
+func lockReview(tx *gorm.DB, id string) (*entity.Review, error) {
+	var review entity.Review
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&review, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &review, nil
+}
+
+// applyRating atualiza o agregado no próprio UPDATE (o lado direito enxerga os valores antigos),
+// evitando perder incrementos quando duas reviews do mesmo produto chegam ao mesmo tempo
+func applyRating(tx *gorm.DB, productID string, countDelta, sumDelta int) error {
+	return tx.Model(&entity.Product{}).Where("id = ?", productID).UpdateColumns(map[string]interface{}{
+		"rating_count":   gorm.Expr("rating_count + ?", countDelta),
+		"rating_sum":     gorm.Expr("rating_sum + ?", sumDelta),
+		"rating_average": gorm.Expr("CASE WHEN rating_count + ? > 0 THEN CAST(rating_sum + ? AS REAL) / (rating_count + ?) ELSE 0 END", countDelta, sumDelta, countDelta),
+	}).Error
+}
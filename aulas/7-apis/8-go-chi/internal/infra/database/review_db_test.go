@@ -0,0 +1,103 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestReviewMaintainsProductRating(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.Product{}, &entity.Review{})
+
+	product, _ := entity.NewProduct("Product 1", 10)
+	productDb := NewProduct(db)
+	productDb.Create(product)
+
+	reviewDb := NewReview(db)
+	review, _ := entity.NewReview(product.ID.String(), "user-1", 5, "Great")
+	review2, _ := entity.NewReview(product.ID.String(), "user-2", 2, "Meh")
+	assert.Nil(t, reviewDb.Create(review))
+	assert.Nil(t, reviewDb.Create(review2))
+
+	// Só uma review por usuário e produto
+	duplicated, _ := entity.NewReview(product.ID.String(), "user-1", 1, "")
+	assert.Error(t, reviewDb.Create(duplicated))
+
+	productFound, _ := productDb.FindByID(product.ID.String())
+	assert.Equal(t, 2, productFound.RatingCount)
+	assert.Equal(t, 3.5, productFound.RatingAverage)
+
+	review2.Edit(4, "Better than I thought")
+	assert.Nil(t, reviewDb.Update(review2))
+
+	productFound, _ = productDb.FindByID(product.ID.String())
+	assert.Equal(t, 4.5, productFound.RatingAverage)
+
+	// Duas edições a partir da mesma leitura: o ajuste parte da nota gravada, não da que cada uma leu
+	first, _ := reviewDb.FindByID(review2.ID.String())
+	second, _ := reviewDb.FindByID(review2.ID.String())
+	first.Edit(1, "")
+	second.Edit(3, "")
+	assert.Nil(t, reviewDb.Update(first))
+	assert.Nil(t, reviewDb.Update(second))
+
+	productFound, _ = productDb.FindByID(product.ID.String())
+	assert.Equal(t, 8, productFound.RatingSum)
+	assert.Equal(t, 4.0, productFound.RatingAverage)
+	review2 = second
+
+	// Atualizar o produto não pode zerar o agregado
+	productFound.Name = "Product 1 updated"
+	productFound.RatingCount = 0
+	productDb.Update(productFound)
+	productFound, _ = productDb.FindByID(product.ID.String())
+	assert.Equal(t, 2, productFound.RatingCount)
+
+	assert.Nil(t, reviewDb.Delete(review))
+	assert.Nil(t, reviewDb.Delete(review2))
+
+	productFound, _ = productDb.FindByID(product.ID.String())
+	assert.Equal(t, 0, productFound.RatingCount)
+	assert.Equal(t, 0.0, productFound.RatingAverage)
+}
+
+func TestReviewFindByProductID(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.Product{}, &entity.Review{})
+
+	reviewDb := NewReview(db)
+	for _, userID := range []string{"user-1", "user-2", "user-3"} {
+		review, _ := entity.NewReview("product-id", userID, 3, "")
+		reviewDb.Create(review)
+	}
+
+	reviews, err := reviewDb.FindByProductID("product-id", 1, 2)
+	assert.Nil(t, err)
+	assert.Len(t, reviews, 2)
+
+	reviews, _ = reviewDb.FindByProductID("product-id", 2, 2)
+	assert.Len(t, reviews, 1)
+
+	found, err := reviewDb.FindByProductAndUser("product-id", "user-2")
+	assert.Nil(t, err)
+	assert.Equal(t, "user-2", found.UserID)
+
+	other, _ := entity.NewReview("other-product-id", "user-1", 3, "")
+	reviewDb.Create(other)
+	assert.Nil(t, reviewDb.DeleteByProductID("product-id"))
+
+	reviews, _ = reviewDb.FindByProductID("product-id", 0, 0)
+	assert.Empty(t, reviews)
+	reviews, _ = reviewDb.FindByProductID("other-product-id", 0, 0)
+	assert.Len(t, reviews, 1)
+}
@@ -0,0 +1,133 @@
+package database
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/entity"
+	"gorm.io/gorm"
+)
+
+// ReviewMemory implementa ReviewInterface em memória, útil para testes sem banco de dados.
+// Recebe o ProductMemory para manter o agregado de avaliações, como faz a transação da versão com GORM
+type ReviewMemory struct {
+	mutex    sync.RWMutex
+	reviews  map[string]entity.Review
+	products *ProductMemory
+}
+
+func NewReviewMemory(products *ProductMemory) *ReviewMemory {
+	return &ReviewMemory{reviews: make(map[string]entity.Review), products: products}
+}
+
+func (r *ReviewMemory) Create(review *entity.Review) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, existing := range r.reviews {
+		if existing.ProductID == review.ProductID && existing.UserID == review.UserID {
+			return gorm.ErrDuplicatedKey
+		}
+	}
+
+	r.reviews[review.ID.String()] = *review
+	r.products.applyRating(review.ProductID, 1, review.Rating)
+	return nil
+}
+
+func (r *ReviewMemory) FindByID(id string) (*entity.Review, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	review, ok := r.reviews[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	return &review, nil
+}
+
+func (r *ReviewMemory) FindByProductAndUser(productID, userID string) (*entity.Review, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, review := range r.reviews {
+		if review.ProductID == productID && review.UserID == userID {
+			return &review, nil
+		}
+	}
+
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *ReviewMemory) FindByProductID(productID string, page, limit int) ([]*entity.Review, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	reviews := []*entity.Review{}
+	for _, review := range r.reviews {
+		if review.ProductID == productID {
+			review := review
+			reviews = append(reviews, &review)
+		}
+	}
+
+	sort.SliceStable(reviews, func(i, j int) bool {
+		return reviews[i].CreatedAt.After(reviews[j].CreatedAt)
+	})
+
+	if page != 0 && limit != 0 {
+		start := (page - 1) * limit
+		if start >= len(reviews) {
+			return []*entity.Review{}, nil
+		}
+
+		end := start + limit
+		if end > len(reviews) {
+			end = len(reviews)
+		}
+		reviews = reviews[start:end]
+	}
+
+	return reviews, nil
+}
+
+func (r *ReviewMemory) Update(review *entity.Review) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	stored, ok := r.reviews[review.ID.String()]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+
+	r.reviews[review.ID.String()] = *review
+	r.products.applyRating(review.ProductID, 0, review.Rating-stored.Rating)
+	return nil
+}
+
+func (r *ReviewMemory) Delete(review *entity.Review) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	stored, ok := r.reviews[review.ID.String()]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+
+	delete(r.reviews, review.ID.String())
+	r.products.applyRating(stored.ProductID, -1, -stored.Rating)
+	return nil
+}
+
+func (r *ReviewMemory) DeleteByProductID(productID string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for id, review := range r.reviews {
+		if review.ProductID == productID {
+			delete(r.reviews, id)
+		}
+	}
+	return nil
+}
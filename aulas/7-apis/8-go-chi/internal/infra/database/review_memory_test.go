@@ -0,0 +1,60 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestReviewMemory(t *testing.T) {
+	productDb := NewProductMemory()
+	product, _ := entity.NewProduct("Product 1", 10)
+	productDb.Create(product)
+
+	reviewDb := NewReviewMemory(productDb)
+	review, _ := entity.NewReview(product.ID.String(), "user-1", 4, "")
+	assert.Nil(t, reviewDb.Create(review))
+
+	duplicated, _ := entity.NewReview(product.ID.String(), "user-1", 1, "")
+	assert.Equal(t, gorm.ErrDuplicatedKey, reviewDb.Create(duplicated))
+
+	productFound, _ := productDb.FindByID(product.ID.String())
+	assert.Equal(t, 1, productFound.RatingCount)
+	assert.Equal(t, 4.0, productFound.RatingAverage)
+
+	review.Edit(2, "")
+	assert.Nil(t, reviewDb.Update(review))
+
+	productFound, _ = productDb.FindByID(product.ID.String())
+	assert.Equal(t, 2.0, productFound.RatingAverage)
+
+	// Uma cópia desatualizada não pode contar a nota anterior de novo
+	stale, _ := reviewDb.FindByID(review.ID.String())
+	review.Edit(5, "")
+	assert.Nil(t, reviewDb.Update(review))
+	stale.Edit(3, "")
+	assert.Nil(t, reviewDb.Update(stale))
+
+	productFound, _ = productDb.FindByID(product.ID.String())
+	assert.Equal(t, 3.0, productFound.RatingAverage)
+
+	assert.Nil(t, reviewDb.Delete(review))
+	productFound, _ = productDb.FindByID(product.ID.String())
+	assert.Equal(t, 0, productFound.RatingCount)
+
+	_, err := reviewDb.FindByID(review.ID.String())
+	assert.Equal(t, gorm.ErrRecordNotFound, err)
+
+	kept, _ := entity.NewReview("other-product-id", "user-1", 3, "")
+	reviewDb.Create(kept)
+	removed, _ := entity.NewReview(product.ID.String(), "user-2", 3, "")
+	reviewDb.Create(removed)
+	assert.Nil(t, reviewDb.DeleteByProductID(product.ID.String()))
+
+	reviews, _ := reviewDb.FindByProductID(product.ID.String(), 0, 0)
+	assert.Empty(t, reviews)
+	reviews, _ = reviewDb.FindByProductID("other-product-id", 0, 0)
+	assert.Len(t, reviews, 1)
+}
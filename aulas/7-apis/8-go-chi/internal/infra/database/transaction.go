@@ -0,0 +1,48 @@
+package database
+
+import (
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+type Transaction struct {
+	DB *gorm.DB
+}
+
+func NewTransaction(db *gorm.DB) *Transaction {
+	return &Transaction{DB: db}
+}
+
+func (t *Transaction) Run(fn func(repositories Repositories) error) error {
+	return t.DB.Transaction(func(tx *gorm.DB) error {
+		return fn(Repositories{
+			Product:      NewProduct(tx),
+			PriceHistory: NewPriceHistory(tx),
+			User:         NewUser(tx),
+			Audit:        NewAudit(tx),
+			Wishlist:     NewWishlist(tx),
+			Notification: NewNotification(tx),
+			Review:       NewReview(tx),
+			Cart:         NewCart(tx),
+		})
+	})
+}
+
+// TransactionMemory apenas serializa as execuções: não há rollback em memória,
+// então um erro no meio de fn deixa as escritas anteriores aplicadas
+type TransactionMemory struct {
+	mutex        sync.Mutex
+	repositories Repositories
+}
+
+func NewTransactionMemory(repositories Repositories) *TransactionMemory {
+	return &TransactionMemory{repositories: repositories}
+}
+
+func (t *TransactionMemory) Run(fn func(repositories Repositories) error) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	return fn(t.repositories)
+}
@@ -3,6 +3,7 @@ package database
 import (
 	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/entity"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type User struct {
@@ -28,3 +29,51 @@ func (u *User) FindByEmail(email string) (*entity.User, error) {
 
 	return &user, nil
 }
+
+func (u *User) FindByID(id string) (*entity.User, error) {
+	var user entity.User
+
+	err := u.DB.Where("id = ?", id).First(&user).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// FindByIDForUpdate usa SELECT ... FOR UPDATE. O SQLite ignora o FOR, mas lá a transação de escrita já é serializada
+func (u *User) FindByIDForUpdate(id string) (*entity.User, error) {
+	var user entity.User
+
+	err := u.DB.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&user).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+func (u *User) FindByOIDC(issuer, subject string) (*entity.User, error) {
+	// Usuários sem vínculo têm oidc_subject vazio e nunca podem ser encontrados por aqui
+	if subject == "" {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	var user entity.User
+
+	err := u.DB.Where("oidc_subject = ? AND oidc_issuer = ?", subject, issuer).First(&user).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+func (u *User) Update(user *entity.User) error {
+	_, err := u.FindByID(user.ID.String())
+	if err != nil {
+		return err
+	}
+
+	return u.DB.Save(user).Error
+}
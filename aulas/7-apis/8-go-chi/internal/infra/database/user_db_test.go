@@ -55,3 +55,77 @@ func TestFindUserByEmail(t *testing.T) {
 	assert.Equal(t, user.Email, userFound.Email)
 	assert.NotNil(t, userFound.Password)
 }
+
+func TestUpdateUser(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+
+	db.AutoMigrate(&entity.User{})
+
+	user, _ := entity.NewUser("User Test", "john@email.com", "123456")
+	userDb := NewUser(db)
+	userDb.Create(user)
+
+	_, recoveryCodes, _ := user.EnrollTOTP()
+	err = userDb.Update(user)
+	assert.Nil(t, err)
+
+	userFound, err := userDb.FindByID(user.ID.String())
+	assert.Nil(t, err)
+	assert.Equal(t, user.TOTPSecret, userFound.TOTPSecret)
+	assert.Len(t, userFound.RecoveryCodes, len(recoveryCodes))
+
+	missing, _ := entity.NewUser("Missing", "missing@email.com", "123456")
+	assert.Equal(t, gorm.ErrRecordNotFound, userDb.Update(missing))
+}
+
+func TestFindUserByIDForUpdate(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+
+	db.AutoMigrate(&entity.User{})
+
+	user, _ := entity.NewUser("User Test", "john@email.com", "123456")
+	NewUser(db).Create(user)
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		userFound, err := NewUser(tx).FindByIDForUpdate(user.ID.String())
+		assert.Nil(t, err)
+		assert.Equal(t, user.Email, userFound.Email)
+
+		_, err = NewUser(tx).FindByIDForUpdate("0b6e8d8e-5f3c-4b8a-9c1d-2e7f4a6b8c90")
+		assert.Equal(t, gorm.ErrRecordNotFound, err)
+		return nil
+	})
+	assert.Nil(t, err)
+}
+
+func TestFindUserByOIDC(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+
+	db.AutoMigrate(&entity.User{})
+
+	userDb := NewUser(db)
+	user := entity.NewOIDCUser("SSO", "sso@email.com", "https://sso.example.com", "123")
+	assert.Nil(t, userDb.Create(user))
+
+	userFound, err := userDb.FindByOIDC("https://sso.example.com", "123")
+	assert.Nil(t, err)
+	assert.Equal(t, user.ID, userFound.ID)
+	assert.Equal(t, "https://sso.example.com", userFound.OIDCIssuer)
+
+	_, err = userDb.FindByOIDC("https://other.example.com", "123")
+	assert.Equal(t, gorm.ErrRecordNotFound, err)
+
+	password, _ := entity.NewUser("Password", "password@email.com", "123456")
+	userDb.Create(password)
+	_, err = userDb.FindByOIDC("", "")
+	assert.Equal(t, gorm.ErrRecordNotFound, err)
+}
@@ -0,0 +1,81 @@
+package database
+
+import (
+	"sync"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/entity"
+	"gorm.io/gorm"
+)
+
+// UserMemory implementa UserInterface em memória, útil para testes sem banco de dados
+type UserMemory struct {
+	mutex sync.RWMutex
+	users map[string]entity.User
+}
+
+func NewUserMemory() *UserMemory {
+	return &UserMemory{users: make(map[string]entity.User)}
+}
+
+func (u *UserMemory) Create(user *entity.User) error {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+
+	u.users[user.ID.String()] = *user
+	return nil
+}
+
+func (u *UserMemory) FindByEmail(email string) (*entity.User, error) {
+	u.mutex.RLock()
+	defer u.mutex.RUnlock()
+
+	for _, user := range u.users {
+		if user.Email == email {
+			return &user, nil
+		}
+	}
+
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (u *UserMemory) FindByID(id string) (*entity.User, error) {
+	u.mutex.RLock()
+	defer u.mutex.RUnlock()
+
+	user, ok := u.users[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	return &user, nil
+}
+
+// FindByIDForUpdate não precisa de lock próprio: a TransactionMemory já serializa as transações
+func (u *UserMemory) FindByIDForUpdate(id string) (*entity.User, error) {
+	return u.FindByID(id)
+}
+
+func (u *UserMemory) FindByOIDC(issuer, subject string) (*entity.User, error) {
+	u.mutex.RLock()
+	defer u.mutex.RUnlock()
+
+	for _, user := range u.users {
+		if user.OIDCSubject != "" && user.OIDCIssuer == issuer && user.OIDCSubject == subject {
+			return &user, nil
+		}
+	}
+
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (u *UserMemory) Update(user *entity.User) error {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+
+	if _, ok := u.users[user.ID.String()]; !ok {
+		return gorm.ErrRecordNotFound
+	}
+
+	u.users[user.ID.String()] = *user
+	return nil
+}
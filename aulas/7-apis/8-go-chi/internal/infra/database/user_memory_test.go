@@ -0,0 +1,64 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestUserMemoryFindByEmail(t *testing.T) {
+	userDb := NewUserMemory()
+	user, _ := entity.NewUser("User Test", "john@email.com", "123456")
+
+	err := userDb.Create(user)
+	assert.Nil(t, err)
+
+	userFound, err := userDb.FindByEmail("john@email.com")
+	assert.Nil(t, err)
+	assert.Equal(t, user.ID, userFound.ID)
+	assert.Equal(t, user.Name, userFound.Name)
+
+	userFound, err = userDb.FindByEmail("missing@email.com")
+	assert.Nil(t, userFound)
+	assert.Equal(t, gorm.ErrRecordNotFound, err)
+}
+
+func TestUserMemoryUpdate(t *testing.T) {
+	userDb := NewUserMemory()
+	user, _ := entity.NewUser("User Test", "john@email.com", "123456")
+	userDb.Create(user)
+
+	user.EnrollTOTP()
+	assert.Nil(t, userDb.Update(user))
+
+	userFound, err := userDb.FindByID(user.ID.String())
+	assert.Nil(t, err)
+	assert.Equal(t, user.TOTPSecret, userFound.TOTPSecret)
+
+	_, err = userDb.FindByID("missing")
+	assert.Equal(t, gorm.ErrRecordNotFound, err)
+
+	missing, _ := entity.NewUser("Missing", "missing@email.com", "123456")
+	assert.Equal(t, gorm.ErrRecordNotFound, userDb.Update(missing))
+}
+
+func TestUserMemoryFindByOIDC(t *testing.T) {
+	userDb := NewUserMemory()
+	password, _ := entity.NewUser("Password", "password@email.com", "123456")
+	userDb.Create(password)
+	user := entity.NewOIDCUser("SSO", "sso@email.com", "https://sso.example.com", "123")
+	userDb.Create(user)
+
+	userFound, err := userDb.FindByOIDC("https://sso.example.com", "123")
+	assert.Nil(t, err)
+	assert.Equal(t, user.ID, userFound.ID)
+
+	_, err = userDb.FindByOIDC("https://other.example.com", "123")
+	assert.Equal(t, gorm.ErrRecordNotFound, err)
+
+	// Usuários sem vínculo nunca são encontrados, nem com issuer e sub vazios
+	_, err = userDb.FindByOIDC("", "")
+	assert.Equal(t, gorm.ErrRecordNotFound, err)
+}
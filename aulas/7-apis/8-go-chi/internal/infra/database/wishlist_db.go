@@ -0,0 +1,100 @@
+package database
+
+import (
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Wishlist struct {
+	DB *gorm.DB
+}
+
+func NewWishlist(db *gorm.DB) *Wishlist {
+	return &Wishlist{DB: db}
+}
+
+func (w *Wishlist) FindByID(id string) (*entity.Wishlist, error) {
+	var wishlist entity.Wishlist
+	err := w.DB.Preload("Items").First(&wishlist, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &wishlist, nil
+}
+
+// FindByIDForUpdate usa SELECT ... FOR UPDATE. O SQLite ignora o FOR, mas lá a transação de escrita já é serializada
+func (w *Wishlist) FindByIDForUpdate(id string) (*entity.Wishlist, error) {
+	var wishlist entity.Wishlist
+	err := w.DB.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Items").First(&wishlist, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &wishlist, nil
+}
+
+func (w *Wishlist) FindByUserID(userID string) ([]*entity.Wishlist, error) {
+	var wishlists []*entity.Wishlist
+	err := w.DB.Preload("Items").Where("user_id = ?", userID).Order("created_at asc").Find(&wishlists).Error
+
+	return wishlists, err
+}
+
+func (w *Wishlist) FindByShareToken(token string) (*entity.Wishlist, error) {
+	// Sem esse cuidado um token vazio encontraria qualquer lista privada
+	if token == "" {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	var wishlist entity.Wishlist
+	err := w.DB.Preload("Items").First(&wishlist, "share_token = ?", token).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &wishlist, nil
+}
+
+func (w *Wishlist) FindByProductID(productID string) ([]*entity.Wishlist, error) {
+	var wishlists []*entity.Wishlist
+	err := w.DB.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Items").
+		Where("id IN (?)", w.DB.Model(&entity.WishlistItem{}).Select("wishlist_id").Where("product_id = ?", productID)).
+		Find(&wishlists).Error
+
+	return wishlists, err
+}
+
+// Save grava a lista e substitui os itens, pelo mesmo motivo do Save do carrinho
+func (w *Wishlist) Save(wishlist *entity.Wishlist) error {
+	return w.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Items").Save(wishlist).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("wishlist_id = ?", wishlist.ID).Delete(&entity.WishlistItem{}).Error; err != nil {
+			return err
+		}
+
+		for i := range wishlist.Items {
+			wishlist.Items[i].WishlistID = wishlist.ID
+		}
+
+		if len(wishlist.Items) == 0 {
+			return nil
+		}
+
+		return tx.Create(&wishlist.Items).Error
+	})
+}
+
+func (w *Wishlist) Delete(id string) error {
+	return w.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("wishlist_id = ?", id).Delete(&entity.WishlistItem{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Delete(&entity.Wishlist{}, "id = ?", id).Error
+	})
+}
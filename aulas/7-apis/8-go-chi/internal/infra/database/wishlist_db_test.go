@@ -0,0 +1,66 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestWishlistSaveAndFind(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.Wishlist{}, &entity.WishlistItem{})
+
+	product, _ := entity.NewProduct("Product 1", 10)
+	product2, _ := entity.NewProduct("Product 2", 20)
+	wishlist, _ := entity.NewWishlist("user-id", "Presentes")
+	wishlist.AddItem(product)
+	wishlist.AddItem(product2)
+	other, _ := entity.NewWishlist("user-id", "Casa")
+	other.AddItem(product2)
+	other.Share()
+
+	wishlistDb := NewWishlist(db)
+	assert.Nil(t, wishlistDb.Save(wishlist))
+	assert.Nil(t, wishlistDb.Save(other))
+
+	wishlistFound, err := wishlistDb.FindByID(wishlist.ID.String())
+	assert.Nil(t, err)
+	assert.Equal(t, "Presentes", wishlistFound.Name)
+	assert.Len(t, wishlistFound.Items, 2)
+
+	wishlists, err := wishlistDb.FindByUserID("user-id")
+	assert.Nil(t, err)
+	assert.Len(t, wishlists, 2)
+
+	wishlists, err = wishlistDb.FindByProductID(product2.ID.String())
+	assert.Nil(t, err)
+	assert.Len(t, wishlists, 2)
+
+	wishlistFound, err = wishlistDb.FindByShareToken(other.ShareToken)
+	assert.Nil(t, err)
+	assert.Equal(t, other.ID, wishlistFound.ID)
+
+	// A lista privada tem token vazio, que não pode servir de link
+	_, err = wishlistDb.FindByShareToken("")
+	assert.Equal(t, gorm.ErrRecordNotFound, err)
+
+	// Itens removidos do slice também precisam sair do banco
+	wishlist.RemoveItem(product.ID)
+	assert.Nil(t, wishlistDb.Save(wishlist))
+	wishlists, _ = wishlistDb.FindByProductID(product.ID.String())
+	assert.Empty(t, wishlists)
+
+	assert.Nil(t, wishlistDb.Delete(wishlist.ID.String()))
+	_, err = wishlistDb.FindByID(wishlist.ID.String())
+	assert.Equal(t, gorm.ErrRecordNotFound, err)
+
+	var items int64
+	db.Model(&entity.WishlistItem{}).Where("wishlist_id = ?", wishlist.ID).Count(&items)
+	assert.Zero(t, items)
+}
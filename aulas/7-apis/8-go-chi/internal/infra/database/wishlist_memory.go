@@ -0,0 +1,108 @@
+package database
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/entity"
+	"gorm.io/gorm"
+)
+
+// WishlistMemory implementa WishlistInterface em memória, útil para testes sem banco de dados
+type WishlistMemory struct {
+	mutex     sync.RWMutex
+	wishlists map[string]entity.Wishlist
+}
+
+func NewWishlistMemory() *WishlistMemory {
+	return &WishlistMemory{wishlists: make(map[string]entity.Wishlist)}
+}
+
+func (w *WishlistMemory) FindByID(id string) (*entity.Wishlist, error) {
+	w.mutex.RLock()
+	defer w.mutex.RUnlock()
+
+	wishlist, ok := w.wishlists[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	return copyWishlist(wishlist), nil
+}
+
+// FindByIDForUpdate não precisa de lock próprio: a TransactionMemory já serializa as transações
+func (w *WishlistMemory) FindByIDForUpdate(id string) (*entity.Wishlist, error) {
+	return w.FindByID(id)
+}
+
+func (w *WishlistMemory) FindByUserID(userID string) ([]*entity.Wishlist, error) {
+	return w.find(func(wishlist entity.Wishlist) bool {
+		return wishlist.UserID == userID
+	}), nil
+}
+
+func (w *WishlistMemory) FindByShareToken(token string) (*entity.Wishlist, error) {
+	if token == "" {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	wishlists := w.find(func(wishlist entity.Wishlist) bool {
+		return wishlist.ShareToken == token
+	})
+	if len(wishlists) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	return wishlists[0], nil
+}
+
+func (w *WishlistMemory) FindByProductID(productID string) ([]*entity.Wishlist, error) {
+	return w.find(func(wishlist entity.Wishlist) bool {
+		for _, item := range wishlist.Items {
+			if item.ProductID.String() == productID {
+				return true
+			}
+		}
+		return false
+	}), nil
+}
+
+func (w *WishlistMemory) Save(wishlist *entity.Wishlist) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.wishlists[wishlist.ID.String()] = *copyWishlist(*wishlist)
+	return nil
+}
+
+func (w *WishlistMemory) Delete(id string) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	delete(w.wishlists, id)
+	return nil
+}
+
+// find devolve cópias ordenadas pela criação, como o FindByUserID do banco
+func (w *WishlistMemory) find(match func(wishlist entity.Wishlist) bool) []*entity.Wishlist {
+	w.mutex.RLock()
+	defer w.mutex.RUnlock()
+
+	wishlists := []*entity.Wishlist{}
+	for _, wishlist := range w.wishlists {
+		if match(wishlist) {
+			wishlists = append(wishlists, copyWishlist(wishlist))
+		}
+	}
+
+	sort.SliceStable(wishlists, func(i, j int) bool {
+		return wishlists[i].CreatedAt.Before(wishlists[j].CreatedAt)
+	})
+
+	return wishlists
+}
+
+func copyWishlist(wishlist entity.Wishlist) *entity.Wishlist {
+	wishlist.Items = append([]entity.WishlistItem{}, wishlist.Items...)
+	return &wishlist
+}
@@ -0,0 +1,40 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestWishlistMemorySaveAndFind(t *testing.T) {
+	product, _ := entity.NewProduct("Product 1", 10)
+	wishlist, _ := entity.NewWishlist("user-id", "Presentes")
+	wishlist.AddItem(product)
+	wishlist.Share()
+
+	wishlistDb := NewWishlistMemory()
+	assert.Nil(t, wishlistDb.Save(wishlist))
+
+	wishlistFound, err := wishlistDb.FindByShareToken(wishlist.ShareToken)
+	assert.Nil(t, err)
+	assert.Len(t, wishlistFound.Items, 1)
+
+	// Alterar a lista retornada não deve alterar o repositório
+	wishlistFound.Items[0].Price = 99
+	wishlistFound, _ = wishlistDb.FindByID(wishlist.ID.String())
+	assert.Equal(t, 10, wishlistFound.Items[0].Price)
+
+	wishlists, _ := wishlistDb.FindByProductID(product.ID.String())
+	assert.Len(t, wishlists, 1)
+	wishlists, _ = wishlistDb.FindByUserID("other-user")
+	assert.Empty(t, wishlists)
+
+	_, err = wishlistDb.FindByShareToken("")
+	assert.Equal(t, gorm.ErrRecordNotFound, err)
+
+	assert.Nil(t, wishlistDb.Delete(wishlist.ID.String()))
+	_, err = wishlistDb.FindByID(wishlist.ID.String())
+	assert.Equal(t, gorm.ErrRecordNotFound, err)
+}
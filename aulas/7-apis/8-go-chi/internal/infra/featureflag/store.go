@@ -0,0 +1,155 @@
+package featureflag
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/entity"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/database"
+)
+
+const (
+	DefaultRefreshInterval = 30 * time.Second
+	// DefaultLoadRetryInterval espaça as novas tentativas quando o primeiro carregamento falha,
+	// para que um banco fora do ar não receba (e não gere log de) uma leitura por avaliação
+	DefaultLoadRetryInterval = 5 * time.Second
+)
+
+// Subject é quem está sendo avaliado, com o sub e a role do JWT. Vazio representa um usuário anônimo
+type Subject struct {
+	UserID string
+	Role   string
+}
+
+// Store avalia as flags a partir de uma cópia em memória, sem ir ao banco a cada requisição.
+// A cópia é recarregada depois de cada mudança feita nesta instância (Refresh, chamado pelo use case)
+// e de tempos em tempos pelo Run, para receber as mudanças feitas por outras instâncias
+type Store struct {
+	repository        database.FeatureFlagInterface
+	refreshInterval   time.Duration
+	loadRetryInterval time.Duration
+
+	// refreshMutex serializa os Refresh, do FindAll até a troca da cópia: sem ele um FindAll mais
+	// lento poderia terminar depois e sobrescrever uma cópia mais nova. Também protege lastLoadAttempt
+	refreshMutex    sync.Mutex
+	lastLoadAttempt time.Time
+
+	mutex  sync.RWMutex
+	flags  map[string]entity.FeatureFlag
+	loaded bool
+}
+
+// NewStore usa DefaultRefreshInterval quando refreshInterval é zero
+func NewStore(repository database.FeatureFlagInterface, refreshInterval time.Duration) *Store {
+	if refreshInterval <= 0 {
+		refreshInterval = DefaultRefreshInterval
+	}
+
+	return &Store{
+		repository:        repository,
+		refreshInterval:   refreshInterval,
+		loadRetryInterval: DefaultLoadRetryInterval,
+		flags:             map[string]entity.FeatureFlag{},
+	}
+}
+
+// Refresh troca a cópia em memória pelas flags do repositório. Em caso de erro a cópia anterior continua valendo
+func (s *Store) Refresh() error {
+	s.refreshMutex.Lock()
+	defer s.refreshMutex.Unlock()
+
+	return s.refresh()
+}
+
+// refresh exige refreshMutex
+func (s *Store) refresh() error {
+	flags, err := s.repository.FindAll()
+	if err != nil {
+		return err
+	}
+
+	loaded := make(map[string]entity.FeatureFlag, len(flags))
+	for _, flag := range flags {
+		loaded[flag.Key] = *flag
+	}
+
+	s.mutex.Lock()
+	s.flags = loaded
+	s.loaded = true
+	s.mutex.Unlock()
+
+	return nil
+}
+
+// Enabled diz se a flag vale para subject. Flags que não existem valem como desligadas
+func (s *Store) Enabled(key string, subject Subject) bool {
+	s.ensureLoaded()
+
+	s.mutex.RLock()
+	flag, ok := s.flags[key]
+	s.mutex.RUnlock()
+
+	return ok && flag.EnabledFor(subject.UserID, subject.Role)
+}
+
+// Evaluate avalia todas as flags para subject, indexadas pela chave
+func (s *Store) Evaluate(subject Subject) map[string]bool {
+	s.ensureLoaded()
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	result := make(map[string]bool, len(s.flags))
+	for key, flag := range s.flags {
+		result[key] = flag.EnabledFor(subject.UserID, subject.Role)
+	}
+
+	return result
+}
+
+// Run recarrega as flags a cada refreshInterval até o contexto ser cancelado
+func (s *Store) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.refreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Refresh(); err != nil {
+				log.Printf("feature flags: refresh failed: %v", err)
+			}
+		}
+	}
+}
+
+// ensureLoaded carrega as flags na primeira avaliação, caso o Refresh ainda não tenha rodado.
+// Avaliações simultâneas esperam o mesmo carregamento e, depois de uma falha, só há nova tentativa
+// passado loadRetryInterval; até lá as flags valem como desligadas
+func (s *Store) ensureLoaded() {
+	if s.isLoaded() {
+		return
+	}
+
+	s.refreshMutex.Lock()
+	defer s.refreshMutex.Unlock()
+
+	if s.isLoaded() || time.Since(s.lastLoadAttempt) < s.loadRetryInterval {
+		return
+	}
+	s.lastLoadAttempt = time.Now()
+
+	if err := s.refresh(); err != nil {
+		log.Printf("feature flags: load failed: %v", err)
+	}
+}
+
+func (s *Store) isLoaded() bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.loaded
+}
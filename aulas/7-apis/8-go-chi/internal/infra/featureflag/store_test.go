@@ -0,0 +1,154 @@
+package featureflag
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/entity"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingRepository conta as leituras, para conferir que a avaliação usa o cache
+type countingRepository struct {
+	*database.FeatureFlagMemory
+	reads int
+	err   error
+}
+
+func (r *countingRepository) FindAll() ([]*entity.FeatureFlag, error) {
+	r.reads++
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	return r.FeatureFlagMemory.FindAll()
+}
+
+func TestStoreEvaluatesFromCache(t *testing.T) {
+	repository := &countingRepository{FeatureFlagMemory: database.NewFeatureFlagMemory()}
+	flag, _ := entity.NewFeatureFlag("new-pricing", "", true, 0, []string{"user-1"}, []string{"admin"})
+	require.NoError(t, repository.Create(flag))
+
+	store := NewStore(repository, 0)
+	assert.True(t, store.Enabled("new-pricing", Subject{UserID: "user-1", Role: "user"}))
+	assert.True(t, store.Enabled("new-pricing", Subject{UserID: "user-2", Role: "admin"}))
+	assert.False(t, store.Enabled("new-pricing", Subject{UserID: "user-2", Role: "user"}))
+	assert.False(t, store.Enabled("unknown", Subject{UserID: "user-1"}))
+	assert.Equal(t, 1, repository.reads)
+
+	assert.Equal(t, map[string]bool{"new-pricing": false}, store.Evaluate(Subject{}))
+
+	// A mudança só aparece depois do Refresh
+	flag.Enabled = false
+	require.NoError(t, repository.Update(flag))
+	assert.True(t, store.Enabled("new-pricing", Subject{UserID: "user-1"}))
+	require.NoError(t, store.Refresh())
+	assert.False(t, store.Enabled("new-pricing", Subject{UserID: "user-1"}))
+}
+
+func TestStoreKeepsFlagsWhenRefreshFails(t *testing.T) {
+	repository := &countingRepository{FeatureFlagMemory: database.NewFeatureFlagMemory()}
+	flag, _ := entity.NewFeatureFlag("new-pricing", "", true, 100, nil, nil)
+	require.NoError(t, repository.Create(flag))
+
+	store := NewStore(repository, 0)
+	require.NoError(t, store.Refresh())
+
+	repository.err = errors.New("database is down")
+	assert.Error(t, store.Refresh())
+	assert.True(t, store.Enabled("new-pricing", Subject{}))
+}
+
+func TestStoreRunRefreshesPeriodically(t *testing.T) {
+	repository := &countingRepository{FeatureFlagMemory: database.NewFeatureFlagMemory()}
+	store := NewStore(repository, 10*time.Millisecond)
+	assert.False(t, store.Enabled("new-pricing", Subject{}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go store.Run(ctx)
+
+	// Flag criada por "outra instância", direto no repositório
+	flag, _ := entity.NewFeatureFlag("new-pricing", "", true, 100, nil, nil)
+	require.NoError(t, repository.FeatureFlagMemory.Create(flag))
+
+	assert.Eventually(t, func() bool {
+		return store.Enabled("new-pricing", Subject{})
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestStoreRetriesFailedLoadAfterInterval(t *testing.T) {
+	repository := &countingRepository{FeatureFlagMemory: database.NewFeatureFlagMemory(), err: errors.New("database is down")}
+	flag, _ := entity.NewFeatureFlag("new-pricing", "", true, 100, nil, nil)
+	require.NoError(t, repository.FeatureFlagMemory.Create(flag))
+
+	store := NewStore(repository, 0)
+	store.loadRetryInterval = 50 * time.Millisecond
+
+	// Com o banco fora do ar, as avaliações seguintes não voltam ao repositório antes do intervalo
+	assert.False(t, store.Enabled("new-pricing", Subject{}))
+	assert.False(t, store.Enabled("new-pricing", Subject{}))
+	assert.Equal(t, 1, repository.reads)
+
+	repository.err = nil
+	time.Sleep(60 * time.Millisecond)
+	assert.True(t, store.Enabled("new-pricing", Subject{}))
+	assert.Equal(t, 2, repository.reads)
+}
+
+// slowRepository segura o primeiro FindAll depois de ler as flags, simulando uma leitura lenta
+type slowRepository struct {
+	*database.FeatureFlagMemory
+	calls   int32
+	started chan struct{}
+	release chan struct{}
+}
+
+func (r *slowRepository) FindAll() ([]*entity.FeatureFlag, error) {
+	flags, err := r.FeatureFlagMemory.FindAll()
+	if atomic.AddInt32(&r.calls, 1) == 1 {
+		close(r.started)
+		<-r.release
+	}
+
+	return flags, err
+}
+
+func TestStoreRefreshDoesNotOverwriteNewerSnapshot(t *testing.T) {
+	repository := &slowRepository{
+		FeatureFlagMemory: database.NewFeatureFlagMemory(),
+		started:           make(chan struct{}),
+		release:           make(chan struct{}),
+	}
+	flag, _ := entity.NewFeatureFlag("new-pricing", "", false, 100, nil, nil)
+	require.NoError(t, repository.Create(flag))
+	store := NewStore(repository, 0)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		assert.NoError(t, store.Refresh())
+	}()
+	<-repository.started
+
+	// Mudança feita enquanto a leitura antiga ainda não terminou
+	flag.Enabled = true
+	require.NoError(t, repository.Update(flag))
+	go func() {
+		defer wg.Done()
+		assert.NoError(t, store.Refresh())
+	}()
+
+	// Tempo para o segundo Refresh terminar antes do primeiro, caso não estivessem serializados
+	time.Sleep(20 * time.Millisecond)
+	close(repository.release)
+	wg.Wait()
+	assert.True(t, store.Enabled("new-pricing", Subject{}))
+}
@@ -0,0 +1,88 @@
+package grpcserver
+
+import (
+	"context"
+	"strings"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/auth"
+	"github.com/go-chi/jwtauth"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// A reflection fica liberada para as ferramentas descobrirem os serviços sem token
+const reflectionPrefix = "/grpc.reflection."
+
+// UnaryAuth valida o JWT do metadata "authorization: Bearer <token>" com o mesmo KeyRing da API REST
+// e guarda o token no contexto com o jwtauth.NewContext, como faz o Verifier do chi
+func UnaryAuth(keyRing *auth.KeyRing) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := authenticate(ctx, keyRing, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+func StreamAuth(keyRing *auth.KeyRing) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authenticate(stream.Context(), keyRing, info.FullMethod)
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, &authenticatedStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+func authenticate(ctx context.Context, keyRing *auth.KeyRing, method string) (context.Context, error) {
+	if strings.HasPrefix(method, reflectionPrefix) {
+		return ctx, nil
+	}
+
+	tokenString := tokenFromMetadata(ctx)
+	if tokenString == "" {
+		return nil, status.Error(codes.Unauthenticated, jwtauth.ErrNoTokenFound.Error())
+	}
+
+	token, err := keyRing.Verify(tokenString)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	// Assim como no Verifier, tokens com audience (desafio do TOTP, state do SSO) não dão acesso
+	if len(token.Audience()) > 0 {
+		return nil, status.Error(codes.Unauthenticated, jwtauth.ErrUnauthorized.Error())
+	}
+
+	return jwtauth.NewContext(ctx, token, nil), nil
+}
+
+func tokenFromMetadata(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+
+	for _, value := range md.Get("authorization") {
+		if len(value) > 7 && strings.EqualFold(value[:7], "bearer ") {
+			return strings.TrimSpace(value[7:])
+		}
+	}
+
+	return ""
+}
+
+// authenticatedStream troca o contexto do stream pelo contexto com o token
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
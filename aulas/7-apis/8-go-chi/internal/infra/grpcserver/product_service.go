@@ -0,0 +1,129 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/pb"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/usecase"
+	"github.com/go-chi/jwtauth"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ProductService expõe pelo gRPC os mesmos use cases dos handlers REST de produtos
+type ProductService struct {
+	pb.UnimplementedProductServiceServer
+	UseCase *usecase.ProductUseCase
+}
+
+func NewProductService(useCase *usecase.ProductUseCase) *ProductService {
+	return &ProductService{UseCase: useCase}
+}
+
+func (s *ProductService) CreateProduct(ctx context.Context, in *pb.CreateProductRequest) (*pb.Product, error) {
+	output, err := s.UseCase.Create(usecase.ProductInput{
+		Name:     in.GetName(),
+		Price:    int(in.GetPrice()),
+		Category: in.GetCategory(),
+		Actor:    actor(ctx),
+	})
+	if err != nil {
+		return nil, statusFromError(err)
+	}
+
+	return product(output), nil
+}
+
+func (s *ProductService) GetProduct(ctx context.Context, in *pb.GetProductRequest) (*pb.Product, error) {
+	output, err := s.UseCase.Find(in.GetId())
+	if err != nil {
+		return nil, statusFromError(err)
+	}
+
+	return product(output), nil
+}
+
+// ListProducts envia um produto por mensagem, então o cliente processa a lista conforme ela chega
+func (s *ProductService) ListProducts(in *pb.ListProductsRequest, stream pb.ProductService_ListProductsServer) error {
+	products, err := s.UseCase.List(usecase.ListProductsInput{
+		Page:  int(in.GetPage()),
+		Limit: int(in.GetLimit()),
+		Sort:  in.GetSort(),
+	})
+	if err != nil {
+		return statusFromError(err)
+	}
+
+	for _, output := range products {
+		if err := stream.Send(product(output)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *ProductService) UpdateProduct(ctx context.Context, in *pb.UpdateProductRequest) (*pb.Product, error) {
+	output, err := s.UseCase.Update(usecase.UpdateProductInput{
+		ID: in.GetId(),
+		ProductInput: usecase.ProductInput{
+			Name:     in.GetName(),
+			Price:    int(in.GetPrice()),
+			Category: in.GetCategory(),
+			Actor:    actor(ctx),
+		},
+	})
+	if err != nil {
+		return nil, statusFromError(err)
+	}
+
+	return product(output), nil
+}
+
+func (s *ProductService) DeleteProduct(ctx context.Context, in *pb.DeleteProductRequest) (*pb.DeleteProductResponse, error) {
+	err := s.UseCase.Delete(usecase.DeleteProductInput{ID: in.GetId(), Actor: actor(ctx)})
+	if err != nil {
+		return nil, statusFromError(err)
+	}
+
+	return &pb.DeleteProductResponse{}, nil
+}
+
+// actor devolve o "sub" do JWT validado pelo interceptor, usado na auditoria
+func actor(ctx context.Context) string {
+	_, claims, err := jwtauth.FromContext(ctx)
+	if err != nil {
+		return ""
+	}
+
+	sub, _ := claims["sub"].(string)
+	return sub
+}
+
+// statusFromError faz o papel do useCaseStatus dos handlers: erros não classificados viram Internal
+// sem detalhes, para não vazar mensagens do banco
+func statusFromError(err error) error {
+	switch {
+	case errors.Is(err, usecase.ErrInvalidInput):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, usecase.ErrProductNotFound):
+		return status.Error(codes.NotFound, usecase.ErrProductNotFound.Error())
+	default:
+		return status.Error(codes.Internal, "internal error")
+	}
+}
+
+func product(output *usecase.ProductOutput) *pb.Product {
+	return &pb.Product{
+		Id:            output.ID,
+		Name:          output.Name,
+		Price:         int64(output.Price),
+		Category:      output.Category,
+		RatingAverage: output.RatingAverage,
+		RatingCount:   int32(output.RatingCount),
+		CreatedAt:     timestamppb.New(output.CreatedAt),
+		UpdatedAt:     timestamppb.New(output.UpdatedAt),
+	}
+}
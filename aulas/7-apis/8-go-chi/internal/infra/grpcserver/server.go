@@ -0,0 +1,35 @@
+package grpcserver
+
+import (
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/auth"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/database"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/pb"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/usecase"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+)
+
+// Dependencies é o equivalente do webserver.Dependencies para o servidor gRPC.
+// Os repositórios são os mesmos da API REST, então auditoria e histórico de preço valem para as duas
+type Dependencies struct {
+	ProductDB      database.ProductInterface
+	PriceHistoryDB database.PriceHistoryInterface
+	Transaction    database.TransactionInterface
+	KeyRing        *auth.KeyRing
+	// Events recebe as mudanças feitas pelo gRPC (ex.: o broker do SSE da API REST). Pode ser nil
+	Events usecase.EventPublisher
+}
+
+func NewServer(deps Dependencies) *grpc.Server {
+	productUseCase := usecase.NewProductUseCase(deps.ProductDB, deps.PriceHistoryDB, deps.Transaction, deps.Events)
+
+	server := grpc.NewServer(
+		grpc.UnaryInterceptor(UnaryAuth(deps.KeyRing)),
+		grpc.StreamInterceptor(StreamAuth(deps.KeyRing)),
+	)
+	pb.RegisterProductServiceServer(server, NewProductService(productUseCase))
+	// Permite listar os serviços com ferramentas como o grpcurl e o evans
+	reflection.Register(server)
+
+	return server
+}
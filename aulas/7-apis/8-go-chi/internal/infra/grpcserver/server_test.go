@@ -0,0 +1,168 @@
+package grpcserver
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/auth"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/database"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/pb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type testServer struct {
+	Client       pb.ProductServiceClient
+	Tokens       *auth.TokenIssuer
+	Repositories database.Repositories
+}
+
+// newTestServer sobe o servidor em memória com o bufconn, sem abrir porta
+func newTestServer(t *testing.T) *testServer {
+	keyRing, err := auth.NewHMACKeyRing("test-secret-0123456789abcdef0123456789")
+	require.NoError(t, err)
+
+	products := database.NewProductMemory()
+	repositories := database.Repositories{
+		Product:      products,
+		PriceHistory: database.NewPriceHistoryMemory(),
+		Audit:        database.NewAuditMemory(),
+		Wishlist:     database.NewWishlistMemory(),
+		Notification: database.NewNotificationMemory(),
+		Review:       database.NewReviewMemory(products),
+		Cart:         database.NewCartMemory(),
+	}
+	server := NewServer(Dependencies{
+		ProductDB:      repositories.Product,
+		PriceHistoryDB: repositories.PriceHistory,
+		Transaction:    database.NewTransactionMemory(repositories),
+		KeyRing:        keyRing,
+	})
+
+	listener := bufconn.Listen(1024 * 1024)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return &testServer{
+		Client:       pb.NewProductServiceClient(conn),
+		Tokens:       auth.NewTokenIssuer(keyRing.TokenAuth(), time.Minute),
+		Repositories: repositories,
+	}
+}
+
+func (s *testServer) context(t *testing.T, userID string) context.Context {
+	token, err := s.Tokens.AccessToken(userID, "user")
+	require.NoError(t, err)
+
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+}
+
+func TestProductServiceRequiresToken(t *testing.T) {
+	server := newTestServer(t)
+
+	_, err := server.Client.GetProduct(context.Background(), &pb.GetProductRequest{Id: "any"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// O stream passa pelo interceptor de stream
+	stream, err := server.Client.ListProducts(context.Background(), &pb.ListProductsRequest{})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer invalid")
+	_, err = server.Client.GetProduct(ctx, &pb.GetProductRequest{Id: "any"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// O token de desafio do TOTP é assinado pela mesma chave, mas não dá acesso
+	challenge, err := server.Tokens.ChallengeToken("user-1", time.Minute)
+	require.NoError(t, err)
+	ctx = metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+challenge)
+	_, err = server.Client.GetProduct(ctx, &pb.GetProductRequest{Id: "any"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestProductServiceCRUD(t *testing.T) {
+	server := newTestServer(t)
+	ctx := server.context(t, "user-1")
+
+	created, err := server.Client.CreateProduct(ctx, &pb.CreateProductRequest{Name: "Product 1", Price: 100, Category: "books"})
+	require.NoError(t, err)
+	assert.NotEmpty(t, created.Id)
+	assert.Equal(t, "books", created.Category)
+	assert.False(t, created.CreatedAt.AsTime().IsZero())
+
+	found, err := server.Client.GetProduct(ctx, &pb.GetProductRequest{Id: created.Id})
+	require.NoError(t, err)
+	assert.Equal(t, "Product 1", found.Name)
+
+	updated, err := server.Client.UpdateProduct(ctx, &pb.UpdateProductRequest{Id: created.Id, Name: "Product 1", Price: 150})
+	require.NoError(t, err)
+	assert.Equal(t, int64(150), updated.Price)
+
+	// A auditoria e o histórico de preço são os mesmos da API REST, com o sub do token como autor
+	entries, _ := server.Repositories.Audit.Find(database.AuditFilter{EntityID: created.Id})
+	require.Len(t, entries, 2)
+	assert.Equal(t, "user-1", entries[0].Actor)
+	changes, _ := server.Repositories.PriceHistory.FindByProductID(created.Id, time.Time{}, time.Time{})
+	require.Len(t, changes, 1)
+
+	_, err = server.Client.DeleteProduct(ctx, &pb.DeleteProductRequest{Id: created.Id})
+	require.NoError(t, err)
+
+	_, err = server.Client.GetProduct(ctx, &pb.GetProductRequest{Id: created.Id})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestProductServiceListStreamsProducts(t *testing.T) {
+	server := newTestServer(t)
+	ctx := server.context(t, "user-1")
+
+	for _, name := range []string{"Product 1", "Product 2", "Product 3"} {
+		_, err := server.Client.CreateProduct(ctx, &pb.CreateProductRequest{Name: name, Price: 10})
+		require.NoError(t, err)
+	}
+
+	stream, err := server.Client.ListProducts(ctx, &pb.ListProductsRequest{Page: 1, Limit: 2, Sort: "asc"})
+	require.NoError(t, err)
+
+	var names []string
+	for {
+		product, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		names = append(names, product.Name)
+	}
+	assert.Len(t, names, 2)
+}
+
+func TestProductServiceInvalidArgument(t *testing.T) {
+	server := newTestServer(t)
+	ctx := server.context(t, "user-1")
+
+	_, err := server.Client.CreateProduct(ctx, &pb.CreateProductRequest{Name: "", Price: 100})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = server.Client.UpdateProduct(ctx, &pb.UpdateProductRequest{Id: "not-an-id", Name: "Product 1", Price: 100})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = server.Client.GetProduct(ctx, &pb.GetProductRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
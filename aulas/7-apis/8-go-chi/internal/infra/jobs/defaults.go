@@ -0,0 +1,100 @@
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/entity"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/database"
+)
+
+const (
+	TypeExportProducts = "products.export"
+	TypeCleanupJobs    = "jobs.cleanup"
+
+	// Jobs finalizados (e os seus resultados) ficam disponíveis por esse tempo
+	DefaultRetention = 7 * 24 * time.Hour
+)
+
+// ExportProductsPayload segue a mesma ordenação do GET /products
+type ExportProductsPayload struct {
+	Sort string `json:"sort"`
+}
+
+// CleanupPayload usa DefaultRetention quando Retention é zero
+type CleanupPayload struct {
+	Retention time.Duration `json:"retention"`
+}
+
+// RegisterDefaults registra os jobs da API e agenda a limpeza diária dos jobs finalizados
+func RegisterDefaults(runner *Runner, productDB database.ProductInterface) error {
+	runner.Register(TypeExportProducts, ExportProducts(productDB))
+	runner.Register(TypeCleanupJobs, CleanupJobs(runner.repository))
+
+	return runner.Cron("@daily", TypeCleanupJobs, CleanupPayload{Retention: DefaultRetention})
+}
+
+// ExportProducts gera um CSV com todos os produtos
+func ExportProducts(productDB database.ProductInterface) Handler {
+	return func(ctx context.Context, job *entity.Job) (Result, error) {
+		var payload ExportProductsPayload
+		if err := job.Decode(&payload); err != nil {
+			return Result{}, err
+		}
+
+		products, err := productDB.FindAll(0, 0, payload.Sort)
+		if err != nil {
+			return Result{}, err
+		}
+
+		var buffer bytes.Buffer
+		writer := csv.NewWriter(&buffer)
+		writer.Write([]string{"id", "name", "price", "category", "rating_average", "rating_count", "created_at", "updated_at"})
+		for _, product := range products {
+			if err := ctx.Err(); err != nil {
+				return Result{}, err
+			}
+
+			writer.Write([]string{
+				product.ID.String(),
+				product.Name,
+				strconv.Itoa(product.Price),
+				product.Category,
+				strconv.FormatFloat(product.RatingAverage, 'f', 2, 64),
+				strconv.Itoa(product.RatingCount),
+				product.CreatedAt.UTC().Format(time.RFC3339),
+				product.UpdatedAt.UTC().Format(time.RFC3339),
+			})
+		}
+		writer.Flush()
+		if err := writer.Error(); err != nil {
+			return Result{}, err
+		}
+
+		return Result{ContentType: "text/csv", Body: buffer.String()}, nil
+	}
+}
+
+// CleanupJobs apaga os jobs finalizados há mais tempo que a retenção
+func CleanupJobs(jobDB database.JobInterface) Handler {
+	return func(ctx context.Context, job *entity.Job) (Result, error) {
+		var payload CleanupPayload
+		if err := job.Decode(&payload); err != nil {
+			return Result{}, err
+		}
+		if payload.Retention <= 0 {
+			payload.Retention = DefaultRetention
+		}
+
+		deleted, err := jobDB.DeleteFinishedBefore(time.Now().Add(-payload.Retention))
+		if err != nil {
+			return Result{}, err
+		}
+
+		return Result{ContentType: "text/plain", Body: fmt.Sprintf("%d jobs deleted", deleted)}, nil
+	}
+}
@@ -0,0 +1,57 @@
+package jobs
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/entity"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/database"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportProducts(t *testing.T) {
+	productDB := database.NewProductMemory()
+	first, _ := entity.NewProduct("Product 1", 10)
+	second, _ := entity.NewProduct("Product, with comma", 20)
+	productDB.Create(first)
+	productDB.Create(second)
+
+	job, _ := entity.NewJob(TypeExportProducts, ExportProductsPayload{Sort: "asc"}, time.Time{}, 1)
+	result, err := ExportProducts(productDB)(context.Background(), job)
+	assert.Nil(t, err)
+	assert.Equal(t, "text/csv", result.ContentType)
+
+	lines := strings.Split(strings.TrimSpace(result.Body), "\n")
+	assert.Len(t, lines, 3)
+	assert.Equal(t, "id,name,price,category,rating_average,rating_count,created_at,updated_at", lines[0])
+	assert.Contains(t, result.Body, `"Product, with comma",20`)
+}
+
+func TestCleanupJobs(t *testing.T) {
+	jobDB := database.NewJobMemory()
+	old, _ := entity.NewJob(TypeExportProducts, nil, time.Time{}, 1)
+	old.Succeed("", "text/csv", time.Now().Add(-8*24*time.Hour))
+	recent, _ := entity.NewJob(TypeExportProducts, nil, time.Time{}, 1)
+	recent.Succeed("", "text/csv", time.Now())
+	jobDB.Create(old)
+	jobDB.Create(recent)
+
+	job, _ := entity.NewJob(TypeCleanupJobs, CleanupPayload{}, time.Time{}, 1)
+	result, err := CleanupJobs(jobDB)(context.Background(), job)
+	assert.Nil(t, err)
+	assert.Equal(t, "1 jobs deleted", result.Body)
+
+	_, err = jobDB.FindByID(recent.ID.String())
+	assert.Nil(t, err)
+}
+
+func TestRegisterDefaults(t *testing.T) {
+	runner := NewRunner(database.NewJobMemory(), Options{})
+	assert.Nil(t, RegisterDefaults(runner, database.NewProductMemory()))
+
+	_, err := runner.Enqueue(TypeExportProducts, ExportProductsPayload{}, "user-1")
+	assert.Nil(t, err)
+	assert.Len(t, runner.schedules, 1)
+}
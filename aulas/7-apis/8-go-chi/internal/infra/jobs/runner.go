@@ -0,0 +1,353 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/entity"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/database"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/pkg/cron"
+)
+
+var (
+	ErrUnknownJobType = errors.New("unknown job type")
+)
+
+// Result é o que um job produz. Fica guardado no próprio job e é servido em /jobs/{id}/result
+type Result struct {
+	ContentType string
+	Body        string
+}
+
+// Handler executa um job. Um erro (ou panic) agenda uma nova tentativa com backoff exponencial
+type Handler func(ctx context.Context, job *entity.Job) (Result, error)
+
+type Options struct {
+	// Workers é quantos jobs rodam ao mesmo tempo nesta instância
+	Workers int
+	// PollInterval é o intervalo entre as buscas por jobs quando a fila está vazia
+	PollInterval time.Duration
+	// Lease é o tempo máximo de um job. Se o worker morrer, depois disso o job volta para a fila
+	Lease       time.Duration
+	MaxAttempts int
+	// O retry n espera BaseBackoff * 2^(n-1), limitado a MaxBackoff
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+}
+
+func DefaultOptions() Options {
+	return Options{
+		Workers:      4,
+		PollInterval: time.Second,
+		Lease:        5 * time.Minute,
+		MaxAttempts:  entity.DefaultJobMaxAttempts,
+		BaseBackoff:  5 * time.Second,
+		MaxBackoff:   10 * time.Minute,
+	}
+}
+
+type scheduledJob struct {
+	spec     string
+	cron     *cron.Schedule
+	jobType  string
+	payload  interface{}
+	next     time.Time
+	disabled bool
+}
+
+// Runner enfileira e executa os jobs persistidos em database.JobInterface. Várias instâncias podem
+// rodar juntas: o Claim do repositório garante que cada job é executado por um worker só
+type Runner struct {
+	repository database.JobInterface
+	options    Options
+
+	mutex     sync.Mutex
+	handlers  map[string]Handler
+	schedules []*scheduledJob
+
+	// now pode ser trocado nos testes
+	now func() time.Time
+}
+
+// NewRunner completa com DefaultOptions os campos zerados de options
+func NewRunner(repository database.JobInterface, options Options) *Runner {
+	defaults := DefaultOptions()
+	if options.Workers <= 0 {
+		options.Workers = defaults.Workers
+	}
+	if options.PollInterval <= 0 {
+		options.PollInterval = defaults.PollInterval
+	}
+	if options.Lease <= 0 {
+		options.Lease = defaults.Lease
+	}
+	if options.MaxAttempts <= 0 {
+		options.MaxAttempts = defaults.MaxAttempts
+	}
+	if options.BaseBackoff <= 0 {
+		options.BaseBackoff = defaults.BaseBackoff
+	}
+	if options.MaxBackoff <= 0 {
+		options.MaxBackoff = defaults.MaxBackoff
+	}
+
+	return &Runner{
+		repository: repository,
+		options:    options,
+		handlers:   map[string]Handler{},
+		now:        time.Now,
+	}
+}
+
+func (r *Runner) Register(jobType string, handler Handler) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.handlers[jobType] = handler
+}
+
+// Enqueue coloca o job na fila para rodar assim que houver um worker livre
+func (r *Runner) Enqueue(jobType string, payload interface{}, owner string) (*entity.Job, error) {
+	return r.EnqueueAt(jobType, payload, owner, time.Time{})
+}
+
+// EnqueueAt agenda o job para runAt. Um runAt zerado equivale a Enqueue
+func (r *Runner) EnqueueAt(jobType string, payload interface{}, owner string, runAt time.Time) (*entity.Job, error) {
+	return r.enqueue(jobType, payload, owner, runAt, nil)
+}
+
+func (r *Runner) enqueue(jobType string, payload interface{}, owner string, runAt time.Time, uniqueKey *string) (*entity.Job, error) {
+	if r.handler(jobType) == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownJobType, jobType)
+	}
+
+	job, err := entity.NewJob(jobType, payload, runAt, r.options.MaxAttempts)
+	if err != nil {
+		return nil, err
+	}
+	job.Owner = owner
+	job.UniqueKey = uniqueKey
+
+	err = r.repository.Create(job)
+	if err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// Cron enfileira o job a cada disparo da expressão (ex.: "@daily", "*/15 * * * *")
+func (r *Runner) Cron(spec, jobType string, payload interface{}) error {
+	parsed, err := cron.Parse(spec)
+	if err != nil {
+		return err
+	}
+
+	if r.handler(jobType) == nil {
+		return fmt.Errorf("%w: %s", ErrUnknownJobType, jobType)
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.schedules = append(r.schedules, &scheduledJob{
+		spec:    spec,
+		cron:    parsed,
+		jobType: jobType,
+		payload: payload,
+		next:    parsed.Next(r.now()),
+	})
+
+	return nil
+}
+
+// Run inicia os workers e o agendador e bloqueia até ctx ser cancelado e os jobs em andamento terminarem
+func (r *Runner) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+
+	for i := 0; i < r.options.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.work(ctx)
+		}()
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		r.schedule(ctx)
+	}()
+
+	wg.Wait()
+}
+
+// RunPending enfileira os disparos de cron vencidos e executa, no goroutine atual, todos os jobs vencidos.
+// Devolve quantos jobs foram executados. Útil em testes e em comandos que rodam uma vez só
+func (r *Runner) RunPending(ctx context.Context) (int, error) {
+	r.enqueueScheduled()
+
+	processed := 0
+	for ctx.Err() == nil {
+		ok, err := r.processNext(ctx)
+		if err != nil {
+			return processed, err
+		}
+		if !ok {
+			break
+		}
+		processed++
+	}
+
+	return processed, nil
+}
+
+func (r *Runner) work(ctx context.Context) {
+	for ctx.Err() == nil {
+		ok, err := r.processNext(ctx)
+		if err != nil {
+			log.Printf("jobs: %v", err)
+		}
+
+		// Com a fila vazia (ou o banco fora do ar) o worker espera antes de tentar de novo
+		if !ok || err != nil {
+			select {
+			case <-ctx.Done():
+			case <-time.After(r.options.PollInterval):
+			}
+		}
+	}
+}
+
+func (r *Runner) schedule(ctx context.Context) {
+	ticker := time.NewTicker(r.options.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		r.enqueueScheduled()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// enqueueScheduled usa o horário do disparo como UniqueKey, então cada disparo vira um único job
+// mesmo com várias instâncias rodando o mesmo agendamento
+func (r *Runner) enqueueScheduled() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	now := r.now()
+	for _, schedule := range r.schedules {
+		for !schedule.disabled && !schedule.next.After(now) {
+			key := fmt.Sprintf("cron:%s:%s:%d", schedule.jobType, schedule.spec, schedule.next.Unix())
+
+			job, err := entity.NewJob(schedule.jobType, schedule.payload, schedule.next, r.options.MaxAttempts)
+			if err == nil {
+				job.UniqueKey = &key
+				err = r.repository.Create(job)
+			}
+			if err != nil && !errors.Is(err, entity.ErrJobAlreadyEnqueued) {
+				// O disparo é tentado de novo no próximo ciclo
+				log.Printf("jobs: schedule %s %q: %v", schedule.jobType, schedule.spec, err)
+				break
+			}
+
+			schedule.next = schedule.cron.Next(schedule.next)
+			// Expressões que nunca disparam (ex.: 30 de fevereiro) devolvem o tempo zero
+			schedule.disabled = schedule.next.IsZero()
+		}
+	}
+}
+
+// processNext devolve false quando não há job vencido
+func (r *Runner) processNext(ctx context.Context) (bool, error) {
+	job, err := r.repository.Claim(r.now(), r.options.Lease)
+	if err != nil {
+		return false, err
+	}
+	if job == nil {
+		return false, nil
+	}
+
+	attempt := job.Attempts
+
+	// Só acontece quando o worker anterior morreu na última tentativa e o prazo dele venceu
+	if job.Attempts > job.MaxAttempts {
+		job.Fail(entity.ErrJobAttemptsExceeded, r.now(), r.now())
+		return true, r.update(job, attempt)
+	}
+
+	result, err := r.execute(ctx, job)
+
+	now := r.now()
+	if err != nil && ctx.Err() != nil {
+		// O worker está parando: o erro veio do cancelamento, não do job
+		job.Release(now)
+		log.Printf("jobs: %s %s interrupted, back to the queue: %v", job.Type, job.ID, err)
+	} else if err != nil {
+		job.Fail(err, now.Add(r.backoff(job.Attempts)), now)
+		log.Printf("jobs: %s %s attempt %d/%d: %v", job.Type, job.ID, job.Attempts, job.MaxAttempts, err)
+	} else {
+		job.Succeed(result.Body, result.ContentType, now)
+	}
+
+	return true, r.update(job, attempt)
+}
+
+// update descarta o resultado quando o prazo venceu e outro worker já pegou o job: quem vale é ele
+func (r *Runner) update(job *entity.Job, attempt int) error {
+	err := r.repository.Update(job, attempt)
+	if errors.Is(err, entity.ErrJobLeaseLost) {
+		log.Printf("jobs: %s %s attempt %d: lease lost, result discarded", job.Type, job.ID, attempt)
+		return nil
+	}
+
+	return err
+}
+
+func (r *Runner) execute(ctx context.Context, job *entity.Job) (result Result, err error) {
+	handler := r.handler(job.Type)
+	if handler == nil {
+		return result, fmt.Errorf("%w: %s", ErrUnknownJobType, job.Type)
+	}
+
+	// Um panic no job não derruba o worker, vira uma tentativa com falha
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("panic: %v", recovered)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(ctx, r.options.Lease)
+	defer cancel()
+
+	return handler(ctx, job)
+}
+
+func (r *Runner) handler(jobType string) Handler {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.handlers[jobType]
+}
+
+func (r *Runner) backoff(attempt int) time.Duration {
+	backoff := r.options.BaseBackoff
+	for i := 1; i < attempt && backoff < r.options.MaxBackoff; i++ {
+		backoff *= 2
+	}
+
+	if backoff > r.options.MaxBackoff {
+		return r.options.MaxBackoff
+	}
+
+	return backoff
+}
@@ -0,0 +1,239 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/entity"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/database"
+	"github.com/stretchr/testify/assert"
+)
+
+// clock deixa os testes avançarem o tempo sem esperar o backoff de verdade
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func newTestRunner(options Options) (*Runner, *database.JobMemory, *clock) {
+	repository := database.NewJobMemory()
+	runner := NewRunner(repository, options)
+	clock := &clock{now: time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)}
+	runner.now = clock.Now
+
+	return runner, repository, clock
+}
+
+func TestRunnerRunsEnqueuedJobs(t *testing.T) {
+	runner, repository, _ := newTestRunner(Options{})
+	runner.Register("greet", func(ctx context.Context, job *entity.Job) (Result, error) {
+		var payload map[string]string
+		job.Decode(&payload)
+		return Result{ContentType: "text/plain", Body: "hello " + payload["name"]}, nil
+	})
+
+	_, err := runner.Enqueue("unknown", nil, "user-1")
+	assert.True(t, errors.Is(err, ErrUnknownJobType))
+
+	job, err := runner.Enqueue("greet", map[string]string{"name": "John"}, "user-1")
+	assert.Nil(t, err)
+	assert.Equal(t, entity.JobQueued, job.Status)
+	assert.Equal(t, "user-1", job.Owner)
+
+	processed, err := runner.RunPending(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 1, processed)
+
+	job, _ = repository.FindByID(job.ID.String())
+	assert.Equal(t, entity.JobSucceeded, job.Status)
+	assert.Equal(t, "hello John", job.Result)
+	assert.Equal(t, "text/plain", job.ResultType)
+	assert.Equal(t, 1, job.Attempts)
+}
+
+func TestRunnerRetriesWithBackoff(t *testing.T) {
+	runner, repository, clock := newTestRunner(Options{MaxAttempts: 3, BaseBackoff: time.Second, MaxBackoff: time.Minute})
+	runner.Register("flaky", func(ctx context.Context, job *entity.Job) (Result, error) {
+		return Result{}, errors.New("smtp unavailable")
+	})
+
+	job, _ := runner.Enqueue("flaky", nil, "")
+	runner.RunPending(context.Background())
+
+	job, _ = repository.FindByID(job.ID.String())
+	assert.Equal(t, entity.JobQueued, job.Status)
+	assert.Equal(t, "smtp unavailable", job.LastError)
+	assert.Equal(t, clock.now.Add(time.Second), job.RunAt)
+
+	// O retry ainda não venceu
+	processed, _ := runner.RunPending(context.Background())
+	assert.Equal(t, 0, processed)
+
+	clock.now = clock.now.Add(time.Second)
+	runner.RunPending(context.Background())
+	job, _ = repository.FindByID(job.ID.String())
+	assert.Equal(t, clock.now.Add(2*time.Second), job.RunAt)
+
+	clock.now = clock.now.Add(2 * time.Second)
+	runner.RunPending(context.Background())
+	job, _ = repository.FindByID(job.ID.String())
+	assert.Equal(t, entity.JobFailed, job.Status)
+	assert.Equal(t, 3, job.Attempts)
+	assert.NotNil(t, job.FinishedAt)
+}
+
+func TestRunnerReleasesJobsInterruptedByShutdown(t *testing.T) {
+	runner, repository, clock := newTestRunner(Options{MaxAttempts: 1})
+	ctx, cancel := context.WithCancel(context.Background())
+	runner.Register("slow", func(ctx context.Context, job *entity.Job) (Result, error) {
+		cancel()
+		<-ctx.Done()
+		return Result{}, ctx.Err()
+	})
+
+	job, _ := runner.Enqueue("slow", nil, "")
+	_, err := runner.RunPending(ctx)
+	assert.Nil(t, err)
+
+	// Mesmo sendo a última tentativa, o job volta para a fila e pode rodar de novo de imediato
+	job, _ = repository.FindByID(job.ID.String())
+	assert.Equal(t, entity.JobQueued, job.Status)
+	assert.Equal(t, 0, job.Attempts)
+	assert.Empty(t, job.LastError)
+	assert.False(t, job.RunAt.After(clock.now))
+}
+
+func TestRunnerDiscardsResultAfterLosingTheLease(t *testing.T) {
+	runner, repository, clock := newTestRunner(Options{})
+	runner.Register("slow", func(ctx context.Context, job *entity.Job) (Result, error) {
+		// O job demorou mais que o prazo e outro worker já o pegou
+		_, err := repository.Claim(clock.now.Add(time.Hour), time.Minute)
+		return Result{Body: "late"}, err
+	})
+
+	job, _ := runner.Enqueue("slow", nil, "")
+	_, err := runner.RunPending(context.Background())
+	assert.Nil(t, err)
+
+	job, _ = repository.FindByID(job.ID.String())
+	assert.Equal(t, entity.JobRunning, job.Status)
+	assert.Equal(t, 2, job.Attempts)
+	assert.Empty(t, job.Result)
+}
+
+func TestRunnerBackoffIsCapped(t *testing.T) {
+	runner, _, _ := newTestRunner(Options{BaseBackoff: time.Second, MaxBackoff: 10 * time.Second})
+
+	assert.Equal(t, time.Second, runner.backoff(1))
+	assert.Equal(t, 4*time.Second, runner.backoff(3))
+	assert.Equal(t, 10*time.Second, runner.backoff(5))
+	assert.Equal(t, 10*time.Second, runner.backoff(50))
+}
+
+func TestRunnerRecoversFromPanics(t *testing.T) {
+	runner, repository, _ := newTestRunner(Options{MaxAttempts: 1})
+	runner.Register("broken", func(ctx context.Context, job *entity.Job) (Result, error) {
+		panic("nil map")
+	})
+
+	job, _ := runner.Enqueue("broken", nil, "")
+	_, err := runner.RunPending(context.Background())
+	assert.Nil(t, err)
+
+	job, _ = repository.FindByID(job.ID.String())
+	assert.Equal(t, entity.JobFailed, job.Status)
+	assert.Equal(t, "panic: nil map", job.LastError)
+}
+
+func TestRunnerScheduledJobs(t *testing.T) {
+	runner, _, clock := newTestRunner(Options{})
+	var runs int32
+	runner.Register("report", func(ctx context.Context, job *entity.Job) (Result, error) {
+		atomic.AddInt32(&runs, 1)
+		return Result{}, nil
+	})
+
+	_, err := runner.EnqueueAt("report", nil, "", clock.now.Add(time.Hour))
+	assert.Nil(t, err)
+
+	processed, _ := runner.RunPending(context.Background())
+	assert.Equal(t, 0, processed)
+
+	clock.now = clock.now.Add(time.Hour)
+	processed, _ = runner.RunPending(context.Background())
+	assert.Equal(t, 1, processed)
+}
+
+func TestRunnerCronEnqueuesEachFireOnce(t *testing.T) {
+	runner, repository, clock := newTestRunner(Options{})
+	handler := func(ctx context.Context, job *entity.Job) (Result, error) { return Result{}, nil }
+	runner.Register("report", handler)
+
+	assert.NotNil(t, runner.Cron("every minute", "report", nil))
+	assert.True(t, errors.Is(runner.Cron("@hourly", "unknown", nil), ErrUnknownJobType))
+	assert.Nil(t, runner.Cron("*/15 * * * *", "report", nil))
+
+	// Uma segunda instância com o mesmo agendamento e o mesmo banco
+	other := NewRunner(repository, Options{})
+	other.now = clock.Now
+	other.Register("report", handler)
+	other.Cron("*/15 * * * *", "report", nil)
+
+	clock.now = clock.now.Add(31 * time.Minute)
+	processed, _ := runner.RunPending(context.Background())
+	assert.Equal(t, 2, processed)
+
+	processed, _ = other.RunPending(context.Background())
+	assert.Equal(t, 0, processed)
+}
+
+func TestRunnerRunUsesAWorkerPool(t *testing.T) {
+	runner, repository, _ := newTestRunner(Options{Workers: 3, PollInterval: 10 * time.Millisecond})
+	runner.now = time.Now
+
+	var running, maxRunning, done int32
+	runner.Register("slow", func(ctx context.Context, job *entity.Job) (Result, error) {
+		current := atomic.AddInt32(&running, 1)
+		for {
+			previous := atomic.LoadInt32(&maxRunning)
+			if current <= previous || atomic.CompareAndSwapInt32(&maxRunning, previous, current) {
+				break
+			}
+		}
+
+		time.Sleep(20 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		atomic.AddInt32(&done, 1)
+		return Result{}, nil
+	})
+
+	var jobs []*entity.Job
+	for i := 0; i < 6; i++ {
+		job, _ := runner.Enqueue("slow", nil, "")
+		jobs = append(jobs, job)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	finished := make(chan struct{})
+	go func() {
+		runner.Run(ctx)
+		close(finished)
+	}()
+
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&done) == 6 }, 2*time.Second, 10*time.Millisecond)
+	cancel()
+	<-finished
+
+	assert.LessOrEqual(t, maxRunning, int32(3))
+	assert.Greater(t, maxRunning, int32(1))
+	for _, job := range jobs {
+		job, _ = repository.FindByID(job.ID.String())
+		assert.Equal(t, entity.JobSucceeded, job.Status)
+	}
+}
@@ -0,0 +1,131 @@
+package content
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	MIMEJSON        = "application/json"
+	MIMEXML         = "application/xml"
+	MIMEMessagePack = "application/msgpack"
+)
+
+var (
+	ErrNotAcceptable        = errors.New("not acceptable")
+	ErrUnsupportedMediaType = errors.New("unsupported media type")
+)
+
+// Codec serializa as respostas e lê os corpos das requisições em um formato específico
+type Codec interface {
+	ContentType() string
+	Encode(writer io.Writer, value interface{}) error
+	Decode(reader io.Reader, value interface{}) error
+}
+
+var (
+	JSON        Codec = jsonCodec{}
+	XML         Codec = xmlCodec{}
+	MessagePack Codec = msgpackCodec{}
+)
+
+// codecs indexa os codecs pelo media type, incluindo os nomes alternativos do XML e do MessagePack.
+// O JSON como padrão para */* fica no Negotiate
+var codecs = map[string]Codec{
+	MIMEJSON:                  JSON,
+	MIMEXML:                   XML,
+	"text/xml":                XML,
+	MIMEMessagePack:           MessagePack,
+	"application/x-msgpack":   MessagePack,
+	"application/vnd.msgpack": MessagePack,
+}
+
+type acceptedType struct {
+	mediaType string
+	quality   float64
+}
+
+// Negotiate escolhe o codec de resposta a partir do header Accept, respeitando os pesos (q).
+// Accept vazio, */* e application/* resultam em JSON
+func Negotiate(accept string) (Codec, error) {
+	if strings.TrimSpace(accept) == "" {
+		return JSON, nil
+	}
+
+	var accepted []acceptedType
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		quality := 1.0
+		if value, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(value, 64); err != nil {
+				continue
+			}
+		}
+
+		if quality > 0 {
+			accepted = append(accepted, acceptedType{mediaType: mediaType, quality: quality})
+		}
+	}
+
+	sort.SliceStable(accepted, func(i, j int) bool {
+		return accepted[i].quality > accepted[j].quality
+	})
+
+	for _, candidate := range accepted {
+		if candidate.mediaType == "*/*" || candidate.mediaType == "application/*" {
+			return JSON, nil
+		}
+
+		if codec, ok := codecs[candidate.mediaType]; ok {
+			return codec, nil
+		}
+	}
+
+	return nil, ErrNotAcceptable
+}
+
+// ForContentType escolhe o codec de leitura do corpo. Sem Content-Type o corpo é tratado como JSON,
+// mantendo compatibilidade com os clientes que já existiam
+func ForContentType(contentType string) (Codec, error) {
+	if strings.TrimSpace(contentType) == "" {
+		return JSON, nil
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, ErrUnsupportedMediaType
+	}
+
+	codec, ok := codecs[mediaType]
+	if !ok {
+		return nil, ErrUnsupportedMediaType
+	}
+
+	return codec, nil
+}
+
+// Equivalents devolve os outros media types lidos e escritos pelo mesmo codec (ex.: text/xml para application/xml)
+func Equivalents(mediaType string) []string {
+	codec, ok := codecs[mediaType]
+	if !ok {
+		return nil
+	}
+
+	var equivalents []string
+	for candidate, other := range codecs {
+		if candidate != mediaType && other == codec {
+			equivalents = append(equivalents, candidate)
+		}
+	}
+	sort.Strings(equivalents)
+
+	return equivalents
+}
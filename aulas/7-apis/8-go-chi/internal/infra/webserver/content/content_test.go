@@ -0,0 +1,149 @@
+package content
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type sample struct {
+	ID     uuid.UUID         `json:"id"`
+	Name   string            `json:"name" xml:"name"`
+	Price  int               `json:"price" xml:"price"`
+	Secret string            `json:"-" xml:"-"`
+	Tags   []string          `json:"tags" xml:"tags>item"`
+	Extra  map[string]string `json:"extra,omitempty" xml:"-"`
+}
+
+func TestNegotiate(t *testing.T) {
+	tests := map[string]Codec{
+		"":                                 JSON,
+		"*/*":                              JSON,
+		"application/*":                    JSON,
+		"application/json":                 JSON,
+		"text/xml":                         XML,
+		"application/xml;q=0.9, */*;q=0.1": XML,
+		"application/json;q=0.5, application/msgpack": MessagePack,
+		"text/html, application/x-msgpack;q=0.2":      MessagePack,
+	}
+
+	for accept, expected := range tests {
+		codec, err := Negotiate(accept)
+		assert.Nil(t, err, accept)
+		assert.Equal(t, expected, codec, accept)
+	}
+
+	_, err := Negotiate("text/html")
+	assert.Equal(t, ErrNotAcceptable, err)
+
+	_, err = Negotiate("application/json;q=0")
+	assert.Equal(t, ErrNotAcceptable, err)
+}
+
+func TestForContentType(t *testing.T) {
+	codec, err := ForContentType("")
+	assert.Nil(t, err)
+	assert.Equal(t, JSON, codec)
+
+	codec, err = ForContentType("application/xml; charset=utf-8")
+	assert.Nil(t, err)
+	assert.Equal(t, XML, codec)
+
+	_, err = ForContentType("text/plain")
+	assert.Equal(t, ErrUnsupportedMediaType, err)
+
+	_, err = ForContentType("not a media type;;")
+	assert.Equal(t, ErrUnsupportedMediaType, err)
+}
+
+func TestXMLEncodeUsesJSONNames(t *testing.T) {
+	value := sample{ID: uuid.New(), Name: "Product <1>", Price: 10, Secret: "hidden", Tags: []string{"a", "b"}, Extra: map[string]string{"color": "blue"}}
+
+	var buffer bytes.Buffer
+	assert.Nil(t, XML.Encode(&buffer, value))
+
+	body := buffer.String()
+	assert.True(t, strings.HasPrefix(body, "<?xml"))
+	assert.Contains(t, body, "<response>")
+	assert.Contains(t, body, "<id>"+value.ID.String()+"</id>")
+	assert.Contains(t, body, "<name>Product &lt;1&gt;</name>")
+	assert.Contains(t, body, "<tags><item>a</item><item>b</item></tags>")
+	assert.Contains(t, body, "<extra><color>blue</color></extra>")
+	assert.NotContains(t, body, "hidden")
+
+	var list bytes.Buffer
+	assert.Nil(t, XML.Encode(&list, []sample{value, value}))
+	assert.Equal(t, 2, strings.Count(list.String(), "<item><extra>"))
+}
+
+func TestXMLEncodeKeepsNumbersAndEscapesNames(t *testing.T) {
+	value := map[string]interface{}{"price": 1500000, "ratio": 0.25, "bad key": "x", "2fa": true, "xmlns": "y"}
+
+	var buffer bytes.Buffer
+	assert.Nil(t, XML.Encode(&buffer, value))
+
+	body := buffer.String()
+	assert.Contains(t, body, "<price>1500000</price>")
+	assert.Contains(t, body, "<ratio>0.25</ratio>")
+	assert.Contains(t, body, `<entry key="bad key">x</entry>`)
+	assert.Contains(t, body, `<entry key="2fa">true</entry>`)
+	assert.Contains(t, body, `<entry key="xmlns">y</entry>`)
+
+	// O resultado precisa ser XML válido
+	decoder := xml.NewDecoder(strings.NewReader(body))
+	for {
+		_, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		assert.Nil(t, err)
+		if err != nil {
+			break
+		}
+	}
+}
+
+func TestXMLEncodeNil(t *testing.T) {
+	var buffer bytes.Buffer
+	assert.Nil(t, XML.Encode(&buffer, nil))
+	assert.Equal(t, xml.Header+"<response></response>", buffer.String())
+
+	var root struct {
+		XMLName xml.Name
+	}
+	assert.Nil(t, xml.Unmarshal(buffer.Bytes(), &root))
+	assert.Equal(t, "response", root.XMLName.Local)
+}
+
+func TestXMLDecode(t *testing.T) {
+	var value sample
+	err := XML.Decode(strings.NewReader("<product><name>Product 1</name><price>10</price><tags><item>a</item></tags></product>"), &value)
+	assert.Nil(t, err)
+	assert.Equal(t, sample{Name: "Product 1", Price: 10, Tags: []string{"a"}}, value)
+}
+
+func TestMessagePackRoundTrip(t *testing.T) {
+	value := sample{ID: uuid.New(), Name: "Product 1", Price: 10, Secret: "hidden", Tags: []string{"a"}}
+
+	var buffer bytes.Buffer
+	assert.Nil(t, MessagePack.Encode(&buffer, value))
+	assert.Contains(t, buffer.String(), value.ID.String())
+	assert.NotContains(t, buffer.String(), "hidden")
+
+	var decoded sample
+	assert.Nil(t, MessagePack.Decode(&buffer, &decoded))
+	value.Secret = ""
+	assert.Equal(t, value, decoded)
+}
+
+func TestEquivalents(t *testing.T) {
+	assert.Equal(t, []string{MIMEXML}, Equivalents("text/xml"))
+	assert.Equal(t, []string{"application/vnd.msgpack", "application/x-msgpack"}, Equivalents(MIMEMessagePack))
+	assert.Empty(t, Equivalents(MIMEJSON))
+	assert.Nil(t, Equivalents("text/html"))
+}
@@ -0,0 +1,20 @@
+package content
+
+import (
+	"encoding/json"
+	"io"
+)
+
+type jsonCodec struct{}
+
+func (jsonCodec) ContentType() string {
+	return MIMEJSON
+}
+
+func (jsonCodec) Encode(writer io.Writer, value interface{}) error {
+	return json.NewEncoder(writer).Encode(value)
+}
+
+func (jsonCodec) Decode(reader io.Reader, value interface{}) error {
+	return json.NewDecoder(reader).Decode(value)
+}
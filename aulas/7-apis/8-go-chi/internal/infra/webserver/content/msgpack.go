@@ -0,0 +1,53 @@
+package content
+
+import (
+	"io"
+	"reflect"
+
+	"github.com/google/uuid"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// As chaves seguem as tags json, então JSON e MessagePack têm os mesmos nomes de campo
+const msgpackStructTag = "json"
+
+func init() {
+	// Sem isso o uuid seria serializado como 16 bytes (BinaryMarshaler). Preferimos a string, igual ao JSON
+	msgpack.Register(uuid.UUID{},
+		func(encoder *msgpack.Encoder, value reflect.Value) error {
+			return encoder.EncodeString(value.Interface().(uuid.UUID).String())
+		},
+		func(decoder *msgpack.Decoder, value reflect.Value) error {
+			text, err := decoder.DecodeString()
+			if err != nil {
+				return err
+			}
+
+			id, err := uuid.Parse(text)
+			if err != nil {
+				return err
+			}
+
+			value.Set(reflect.ValueOf(id))
+			return nil
+		},
+	)
+}
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) ContentType() string {
+	return MIMEMessagePack
+}
+
+func (msgpackCodec) Encode(writer io.Writer, value interface{}) error {
+	encoder := msgpack.NewEncoder(writer)
+	encoder.SetCustomStructTag(msgpackStructTag)
+	return encoder.Encode(value)
+}
+
+func (msgpackCodec) Decode(reader io.Reader, value interface{}) error {
+	decoder := msgpack.NewDecoder(reader)
+	decoder.SetCustomStructTag(msgpackStructTag)
+	return decoder.Decode(value)
+}
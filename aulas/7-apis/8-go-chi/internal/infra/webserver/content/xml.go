@@ -0,0 +1,131 @@
+package content
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	xmlRootElement  = "response"
+	xmlListElement  = "item"
+	xmlEntryElement = "entry"
+	xmlEntryKey     = "key"
+)
+
+type xmlCodec struct{}
+
+func (xmlCodec) ContentType() string {
+	return MIMEXML
+}
+
+// Encode passa o valor pelo JSON antes de gerar o XML. Assim os nomes dos elementos são os mesmos
+// campos do JSON, sem precisar de tags xml em todas as entidades, e mapas também funcionam.
+// Listas viram elementos <item> repetidos e chaves que não são nomes XML válidos viram <entry key="...">
+func (xmlCodec) Encode(writer io.Writer, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	// Com UseNumber os números ficam como estavam no JSON; como float64, 1500000 sairia 1.5e+06
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var tree interface{}
+	if err := decoder.Decode(&tree); err != nil {
+		return err
+	}
+
+	// Um valor nulo ainda precisa do elemento raiz, senão o documento fica só com a declaração
+	if tree == nil {
+		tree = map[string]interface{}{}
+	}
+
+	if _, err := io.WriteString(writer, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(writer)
+	if err := encodeXMLElement(encoder, xmlRootElement, tree); err != nil {
+		return err
+	}
+
+	return encoder.Flush()
+}
+
+// Decode usa as tags xml dos DTOs de entrada
+func (xmlCodec) Decode(reader io.Reader, value interface{}) error {
+	return xml.NewDecoder(reader).Decode(value)
+}
+
+func encodeXMLElement(encoder *xml.Encoder, name string, value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	if !isXMLName(name) {
+		start = xml.StartElement{
+			Name: xml.Name{Local: xmlEntryElement},
+			Attr: []xml.Attr{{Name: xml.Name{Local: xmlEntryKey}, Value: name}},
+		}
+	}
+	if err := encoder.EncodeToken(start); err != nil {
+		return err
+	}
+
+	switch node := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(node))
+		for key := range node {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			if err := encodeXMLElement(encoder, key, node[key]); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, item := range node {
+			if err := encodeXMLElement(encoder, xmlListElement, item); err != nil {
+				return err
+			}
+		}
+	case json.Number:
+		if err := encoder.EncodeToken(xml.CharData(node.String())); err != nil {
+			return err
+		}
+	default:
+		if err := encoder.EncodeToken(xml.CharData(fmt.Sprint(node))); err != nil {
+			return err
+		}
+	}
+
+	return encoder.EncodeToken(start.End())
+}
+
+// isXMLName aceita letras, dígitos, "-", "_" e ".", começando por letra ou "_". Dois pontos ficam de fora
+// por causa dos namespaces, e nomes começando com "xml" são reservados
+func isXMLName(name string) bool {
+	if name == "" || strings.HasPrefix(strings.ToLower(name), "xml") {
+		return false
+	}
+
+	for i, r := range name {
+		switch {
+		case unicode.IsLetter(r), r == '_':
+		case i > 0 && (unicode.IsDigit(r) || r == '-' || r == '.'):
+		default:
+			return false
+		}
+	}
+	return true
+}
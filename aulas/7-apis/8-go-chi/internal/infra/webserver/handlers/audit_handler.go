@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/database"
+)
+
+type AuditHandler struct {
+	AuditDB database.AuditInterface
+}
+
+func NewAuditHandler(auditDB database.AuditInterface) *AuditHandler {
+	return &AuditHandler{AuditDB: auditDB}
+}
+
+// GetAudit godoc
+// @Summary List audit log
+// @Description List create, update and delete operations, newest first. Admin only
+// @Tags audit
+// @Produce json,xml,application/msgpack
+// @Param entity query string false "entity type" Enums(product, user)
+// @Param entity_id query string false "entity id"
+// @Param actor query string false "user id (JWT sub) that made the change"
+// @Param from query string false "start of the period (RFC 3339)" Format(date-time)
+// @Param to query string false "end of the period (RFC 3339)" Format(date-time)
+// @Param page query int false "page number" minimum(1)
+// @Param limit query int false "limit" minimum(1)
+// @Success 200 {array} entity.AuditEntry
+// @Failure 400 {object} Error
+// @Failure 403 {object} Error
+// @Failure 500 {object} Error
+// @Router /audit [get]
+// @Security ApiKeyAuth
+func (auditHandler *AuditHandler) GetAudit(writer http.ResponseWriter, request *http.Request) {
+	from, to, err := parsePeriod(request)
+	if err != nil {
+		writeError(writer, request, http.StatusBadRequest, err)
+		return
+	}
+
+	query := request.URL.Query()
+	filter := database.AuditFilter{
+		EntityType: query.Get("entity"),
+		EntityID:   query.Get("entity_id"),
+		Actor:      query.Get("actor"),
+		From:       from,
+		To:         to,
+	}
+
+	filter.Page, err = strconv.Atoi(query.Get("page"))
+	if err != nil {
+		filter.Page = 0
+	}
+
+	filter.Limit, err = strconv.Atoi(query.Get("limit"))
+	if err != nil {
+		filter.Limit = 0
+	}
+
+	entries, err := auditHandler.AuditDB.Find(filter)
+	if err != nil {
+		writeError(writer, request, http.StatusInternalServerError, err)
+		return
+	}
+
+	render(writer, request, http.StatusOK, entries)
+}
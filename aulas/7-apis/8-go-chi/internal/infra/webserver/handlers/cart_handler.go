@@ -0,0 +1,264 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/dto"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/entity"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/database"
+	entityPkg "github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/pkg/entity"
+	"github.com/go-chi/chi"
+	"gorm.io/gorm"
+)
+
+// Quantas vezes uma mudança no carrinho é refeita quando outra requisição o grava no meio tempo
+const cartSaveAttempts = 3
+
+type CartHandler struct {
+	CartDB    database.CartInterface
+	ProductDB database.ProductInterface
+}
+
+func NewCartHandler(cartDB database.CartInterface, productDB database.ProductInterface) *CartHandler {
+	return &CartHandler{
+		CartDB:    cartDB,
+		ProductDB: productDB,
+	}
+}
+
+// GetCart godoc
+// @Summary Get cart
+// @Description Get the authenticated user's cart. Prices are re-validated against the catalogue on every read
+// @Tags cart
+// @Produce json,xml,application/msgpack
+// @Success 200 {object} dto.CartOutput
+// @Failure 401 {object} Error
+// @Failure 409 {object} Error
+// @Failure 500 {object} Error
+// @Router /cart [get]
+// @Security ApiKeyAuth
+func (cartHandler *CartHandler) GetCart(writer http.ResponseWriter, request *http.Request) {
+	cartHandler.changeCart(writer, request, nil)
+}
+
+// AddItem godoc
+// @Summary Add product to cart
+// @Description Add a product to the authenticated user's cart. Adding an existing product increases its quantity
+// @Tags cart
+// @Accept json,xml,application/msgpack
+// @Produce json,xml,application/msgpack
+// @Param request body dto.AddCartItemInput true "item"
+// @Success 200 {object} dto.CartOutput
+// @Failure 400 {object} Error
+// @Failure 404 {object} Error
+// @Failure 409 {object} Error
+// @Failure 500 {object} Error
+// @Router /cart/items [post]
+// @Security ApiKeyAuth
+func (cartHandler *CartHandler) AddItem(writer http.ResponseWriter, request *http.Request) {
+	var input dto.AddCartItemInput
+	err := decode(request, &input)
+	if err != nil {
+		writeError(writer, request, http.StatusBadRequest, fmt.Errorf("%w: %v", ErrInvalidRequestBody, err))
+		return
+	}
+
+	product, err := cartHandler.ProductDB.FindByID(input.ProductID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		writeError(writer, request, http.StatusNotFound, fmt.Errorf("%w: %v", ErrProductNotFound, err))
+		return
+	}
+	if err != nil {
+		writeError(writer, request, http.StatusInternalServerError, err)
+		return
+	}
+
+	cartHandler.changeCart(writer, request, func(cart *entity.Cart, output *cartRevalidation) (int, error) {
+		if err := cart.AddItem(product, input.Quantity); err != nil {
+			return http.StatusBadRequest, err
+		}
+		output.names[product.ID.String()] = product.Name
+
+		return http.StatusOK, nil
+	})
+}
+
+// UpdateItem godoc
+// @Summary Update cart item quantity
+// @Tags cart
+// @Accept json,xml,application/msgpack
+// @Produce json,xml,application/msgpack
+// @Param productId path string true "product id" Format(uuid)
+// @Param request body dto.UpdateCartItemInput true "quantity"
+// @Success 200 {object} dto.CartOutput
+// @Failure 400 {object} Error
+// @Failure 404 {object} Error
+// @Failure 409 {object} Error
+// @Failure 500 {object} Error
+// @Router /cart/items/{productId} [put]
+// @Security ApiKeyAuth
+func (cartHandler *CartHandler) UpdateItem(writer http.ResponseWriter, request *http.Request) {
+	productID, err := entityPkg.ParseID(chi.URLParam(request, "productId"))
+	if err != nil {
+		writeError(writer, request, http.StatusBadRequest, entity.ErrInvalidID)
+		return
+	}
+
+	var input dto.UpdateCartItemInput
+	err = decode(request, &input)
+	if err != nil {
+		writeError(writer, request, http.StatusBadRequest, fmt.Errorf("%w: %v", ErrInvalidRequestBody, err))
+		return
+	}
+
+	cartHandler.changeCart(writer, request, func(cart *entity.Cart, output *cartRevalidation) (int, error) {
+		err := cart.UpdateQuantity(productID, input.Quantity)
+		if errors.Is(err, entity.ErrCartItemNotFound) {
+			return http.StatusNotFound, err
+		}
+		if err != nil {
+			return http.StatusBadRequest, err
+		}
+
+		return http.StatusOK, nil
+	})
+}
+
+// RemoveItem godoc
+// @Summary Remove product from cart
+// @Tags cart
+// @Produce json,xml,application/msgpack
+// @Param productId path string true "product id" Format(uuid)
+// @Success 200 {object} dto.CartOutput
+// @Failure 400 {object} Error
+// @Failure 404 {object} Error
+// @Failure 409 {object} Error
+// @Failure 500 {object} Error
+// @Router /cart/items/{productId} [delete]
+// @Security ApiKeyAuth
+func (cartHandler *CartHandler) RemoveItem(writer http.ResponseWriter, request *http.Request) {
+	productID, err := entityPkg.ParseID(chi.URLParam(request, "productId"))
+	if err != nil {
+		writeError(writer, request, http.StatusBadRequest, entity.ErrInvalidID)
+		return
+	}
+
+	cartHandler.changeCart(writer, request, func(cart *entity.Cart, output *cartRevalidation) (int, error) {
+		if err := cart.RemoveItem(productID); err != nil {
+			return http.StatusNotFound, err
+		}
+
+		return http.StatusOK, nil
+	})
+}
+
+// cartRevalidation guarda o resultado da revalidação para montar a resposta
+type cartRevalidation struct {
+	names        map[string]string
+	priceChanges []entity.CartPriceChange
+	removed      []entityPkg.ID
+}
+
+// changeCart carrega o carrinho, aplica change (nil só revalida) e grava quando algo mudou. Se outra requisição
+// gravou o carrinho no meio tempo, tudo recomeça a partir do carrinho atual, até cartSaveAttempts vezes
+func (cartHandler *CartHandler) changeCart(writer http.ResponseWriter, request *http.Request, change func(cart *entity.Cart, output *cartRevalidation) (int, error)) {
+	for attempt := 1; ; attempt++ {
+		cart, output, err := cartHandler.loadCart(request)
+		if err != nil {
+			writeError(writer, request, http.StatusInternalServerError, err)
+			return
+		}
+
+		changed := len(output.priceChanges) > 0 || len(output.removed) > 0
+		if change != nil {
+			if status, err := change(cart, output); err != nil {
+				writeError(writer, request, status, err)
+				return
+			}
+			changed = true
+		}
+
+		if changed {
+			err = cartHandler.CartDB.Save(cart)
+			if errors.Is(err, entity.ErrCartConflict) {
+				if attempt < cartSaveAttempts {
+					continue
+				}
+				writeError(writer, request, http.StatusConflict, err)
+				return
+			}
+			if err != nil {
+				writeError(writer, request, http.StatusInternalServerError, err)
+				return
+			}
+		}
+
+		cartHandler.writeCart(writer, request, cart, output)
+		return
+	}
+}
+
+// loadCart busca (ou cria) o carrinho do usuário e revalida os itens contra o catálogo
+func (cartHandler *CartHandler) loadCart(request *http.Request) (*entity.Cart, *cartRevalidation, error) {
+	userID := userIDFromRequest(request)
+
+	cart, err := cartHandler.CartDB.FindOrCreate(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	output := &cartRevalidation{names: make(map[string]string)}
+	output.priceChanges, output.removed, err = cart.Revalidate(func(productID string) (*entity.Product, error) {
+		product, err := cartHandler.ProductDB.FindByID(productID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		output.names[productID] = product.Name
+		return product, nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return cart, output, nil
+}
+
+func (cartHandler *CartHandler) writeCart(writer http.ResponseWriter, request *http.Request, cart *entity.Cart, output *cartRevalidation) {
+	cartOutput := dto.CartOutput{
+		ID:              cart.ID.String(),
+		Items:           []dto.CartItemOutput{},
+		PriceChanges:    []dto.CartPriceChangeOutput{},
+		RemovedProducts: []string{},
+		Total:           cart.Total(),
+	}
+
+	for _, item := range cart.Items {
+		cartOutput.Items = append(cartOutput.Items, dto.CartItemOutput{
+			ProductID: item.ProductID.String(),
+			Name:      output.names[item.ProductID.String()],
+			Quantity:  item.Quantity,
+			UnitPrice: item.UnitPrice,
+			LineTotal: item.Quantity * item.UnitPrice,
+		})
+	}
+
+	for _, change := range output.priceChanges {
+		cartOutput.PriceChanges = append(cartOutput.PriceChanges, dto.CartPriceChangeOutput{
+			ProductID: change.ProductID.String(),
+			OldPrice:  change.OldPrice,
+			NewPrice:  change.NewPrice,
+		})
+	}
+
+	for _, productID := range output.removed {
+		cartOutput.RemovedProducts = append(cartOutput.RemovedProducts, productID.String())
+	}
+
+	render(writer, request, http.StatusOK, cartOutput)
+}
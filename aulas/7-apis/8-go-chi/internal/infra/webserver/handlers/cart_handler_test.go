@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/entity"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/database"
+	"github.com/stretchr/testify/assert"
+)
+
+// failingProductDB simula uma falha do banco ao buscar produtos
+type failingProductDB struct {
+	*database.ProductMemory
+}
+
+func (failingProductDB) FindByID(id string) (*entity.Product, error) {
+	return nil, errors.New("database is locked")
+}
+
+func TestCartAddItemProductLookupErrors(t *testing.T) {
+	body := `{"product_id":"6b1a7c52-3f0e-4f4b-9a57-7f0f1c1e2d3a","quantity":1}`
+
+	// Produto inexistente continua sendo 404
+	handler := NewCartHandler(database.NewCartMemory(), database.NewProductMemory())
+	request := httptest.NewRequest(http.MethodPost, "/cart/items", strings.NewReader(body))
+	request.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	handler.AddItem(recorder, request)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	// Falhas do banco não podem virar 404
+	handler = NewCartHandler(database.NewCartMemory(), failingProductDB{database.NewProductMemory()})
+	request = httptest.NewRequest(http.MethodPost, "/cart/items", strings.NewReader(body))
+	request.Header.Set("Content-Type", "application/json")
+	recorder = httptest.NewRecorder()
+	handler.AddItem(recorder, request)
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+}
@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/entity"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/i18n"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/webserver/content"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/webserver/openapi"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/usecase"
+)
+
+// Erros que nascem nos use cases. Os nomes continuam aqui porque os outros handlers também os usam
+var (
+	ErrInvalidCredentials   = usecase.ErrInvalidCredentials
+	ErrProductNotFound      = usecase.ErrProductNotFound
+	ErrInvalidChallenge     = usecase.ErrInvalidChallenge
+	ErrOIDCEmailNotVerified = usecase.ErrOIDCEmailNotVerified
+	ErrWishlistNotFound     = usecase.ErrWishlistNotFound
+	ErrFeatureFlagNotFound  = usecase.ErrFeatureFlagNotFound
+	ErrFeatureFlagExists    = usecase.ErrFeatureFlagExists
+)
+
+var (
+	ErrInvalidRequestBody  = errors.New("invalid request body")
+	ErrStreamUnsupported   = errors.New("streaming unsupported")
+	ErrCouponNotFound      = errors.New("coupon not found")
+	ErrCouponExists        = errors.New("coupon already exists")
+	ErrReviewNotFound      = errors.New("review not found")
+	ErrReviewExists        = errors.New("review already exists")
+	ErrForbidden           = errors.New("forbidden")
+	ErrUnsupportedCurrency = errors.New("unsupported currency")
+	ErrAPIVersionSunset    = errors.New("api version sunset")
+	ErrRequestValidation   = errors.New("request does not match the api specification")
+	ErrResponseValidation  = errors.New("response does not match the api specification")
+	ErrJobNotFound         = errors.New("job not found")
+	ErrRequestBodyTooLarge = errors.New("request body too large")
+	// ErrInvalidOIDCState cobre cookie ausente, expirado ou com state diferente do callback (possível CSRF)
+	ErrInvalidOIDCState = errors.New("invalid oidc state")
+	ErrOIDCLoginFailed  = errors.New("oidc login failed")
+)
+
+// Error é o corpo padrão das respostas de erro. Code é estável e Message é traduzida pelo Accept-Language.
+// Details só aparece quando a requisição não segue o spec, com uma entrada por campo inválido
+type Error struct {
+	Code    string              `json:"code"`
+	Message string              `json:"message"`
+	Details []openapi.Violation `json:"details,omitempty"`
+}
+
+// knownError associa um erro ao código do catálogo i18n
+type knownError struct {
+	err  error
+	code string
+}
+
+// errorCodes é uma lista, e não um map, porque a ordem importa: quando o erro envolve mais de um erro
+// conhecido, vale o primeiro da lista. Os erros de RegisterErrorCode entram no fim
+var (
+	errorCodesMutex sync.RWMutex
+	errorCodes      = []knownError{
+		{entity.ErrIDIsRequired, i18n.CodeIDIsRequired},
+		{entity.ErrInvalidID, i18n.CodeInvalidID},
+		{entity.ErrNameIsRequired, i18n.CodeNameIsRequired},
+		{entity.ErrPriceIsRequired, i18n.CodePriceIsRequired},
+		{entity.ErrInvalidPrice, i18n.CodeInvalidPrice},
+		{entity.ErrInvalidPeriod, i18n.CodeInvalidPeriod},
+		{entity.ErrInvalidQuantity, i18n.CodeInvalidQuantity},
+		{entity.ErrCartItemNotFound, i18n.CodeCartItemNotFound},
+		{entity.ErrCartConflict, i18n.CodeCartConflict},
+		{entity.ErrUserIsRequired, i18n.CodeUnauthorized},
+		{entity.ErrCouponCodeIsRequired, i18n.CodeCouponCodeIsRequired},
+		{entity.ErrInvalidDiscountType, i18n.CodeInvalidDiscountType},
+		{entity.ErrInvalidDiscountValue, i18n.CodeInvalidDiscountValue},
+		{entity.ErrInvalidValidity, i18n.CodeInvalidValidity},
+		{entity.ErrInvalidUsageLimit, i18n.CodeInvalidUsageLimit},
+		{entity.ErrInvalidMinCartValue, i18n.CodeInvalidMinCartValue},
+		{entity.ErrPromotionScopeIsRequired, i18n.CodePromotionScopeIsRequired},
+		{entity.ErrCouponNotActive, i18n.CodeCouponNotActive},
+		{entity.ErrCouponUsageLimitReached, i18n.CodeCouponUsageLimitReached},
+		{entity.ErrCouponMinCartValue, i18n.CodeCouponMinCartValue},
+		{entity.ErrInvalidRating, i18n.CodeInvalidRating},
+		{entity.ErrItemsAreRequired, i18n.CodeItemsAreRequired},
+		{entity.ErrTOTPAlreadyEnabled, i18n.CodeTOTPAlreadyEnabled},
+		{entity.ErrTOTPNotEnrolled, i18n.CodeTOTPNotEnrolled},
+		{entity.ErrInvalidTOTPCode, i18n.CodeInvalidTOTPCode},
+		{entity.ErrTOTPLocked, i18n.CodeTOTPLocked},
+		{ErrInvalidCredentials, i18n.CodeInvalidCredentials},
+		{ErrInvalidRequestBody, i18n.CodeInvalidRequestBody},
+		{ErrProductNotFound, i18n.CodeProductNotFound},
+		{ErrStreamUnsupported, i18n.CodeStreamUnsupported},
+		{ErrCouponNotFound, i18n.CodeCouponNotFound},
+		{ErrReviewNotFound, i18n.CodeReviewNotFound},
+		{ErrReviewExists, i18n.CodeReviewAlreadyExists},
+		{ErrForbidden, i18n.CodeForbidden},
+		{ErrCouponExists, i18n.CodeCouponAlreadyExists},
+		{content.ErrNotAcceptable, i18n.CodeNotAcceptable},
+		{content.ErrUnsupportedMediaType, i18n.CodeUnsupportedMediaType},
+		{ErrUnsupportedCurrency, i18n.CodeUnsupportedCurrency},
+		{ErrAPIVersionSunset, i18n.CodeAPIVersionSunset},
+		{ErrInvalidChallenge, i18n.CodeInvalidChallengeToken},
+		{ErrRequestValidation, i18n.CodeValidationFailed},
+		{ErrResponseValidation, i18n.CodeResponseValidationFailed},
+		{ErrJobNotFound, i18n.CodeJobNotFound},
+		{entity.ErrJobNotFinished, i18n.CodeJobNotFinished},
+		{entity.ErrJobFailed, i18n.CodeJobFailed},
+		{ErrRequestBodyTooLarge, i18n.CodeRequestBodyTooLarge},
+		{ErrInvalidOIDCState, i18n.CodeInvalidOIDCState},
+		{ErrOIDCLoginFailed, i18n.CodeOIDCLoginFailed},
+		{ErrOIDCEmailNotVerified, i18n.CodeOIDCEmailNotVerified},
+		{entity.ErrOIDCAlreadyLinked, i18n.CodeOIDCAlreadyLinked},
+		{ErrWishlistNotFound, i18n.CodeWishlistNotFound},
+		{entity.ErrWishlistItemNotFound, i18n.CodeWishlistItemNotFound},
+		{ErrFeatureFlagNotFound, i18n.CodeFeatureFlagNotFound},
+		{ErrFeatureFlagExists, i18n.CodeFeatureFlagAlreadyExists},
+		{entity.ErrInvalidFlagKey, i18n.CodeInvalidFlagKey},
+		{entity.ErrInvalidPercentage, i18n.CodeInvalidPercentage},
+	}
+)
+
+var statusCodes = map[int]string{
+	http.StatusBadRequest:   i18n.CodeBadRequest,
+	http.StatusUnauthorized: i18n.CodeUnauthorized,
+	http.StatusForbidden:    i18n.CodeForbidden,
+	http.StatusNotFound:     i18n.CodeNotFound,
+}
+
+// ErrorCode devolve o código estável do erro. Erros desconhecidos recebem um código genérico do status,
+// assim detalhes internos (ex.: mensagens do banco) não vazam para o cliente
+func ErrorCode(err error, status int) string {
+	errorCodesMutex.RLock()
+	defer errorCodesMutex.RUnlock()
+
+	for _, known := range errorCodes {
+		if errors.Is(err, known.err) {
+			return known.code
+		}
+	}
+
+	if code, ok := statusCodes[status]; ok {
+		return code
+	}
+
+	return i18n.CodeInternalError
+}
+
+// RegisterErrorCode associa um erro a um código do catálogo i18n. Registrar de novo o mesmo erro troca o código
+func RegisterErrorCode(err error, code string) {
+	errorCodesMutex.Lock()
+	defer errorCodesMutex.Unlock()
+
+	for i := range errorCodes {
+		if errorCodes[i].err == err {
+			errorCodes[i].code = code
+			return
+		}
+	}
+
+	errorCodes = append(errorCodes, knownError{err: err, code: code})
+}
+
+// useCaseStatus traduz o erro de um use case para o status HTTP. Erros não classificados são 500
+func useCaseStatus(err error) int {
+	switch {
+	case errors.Is(err, usecase.ErrInvalidInput):
+		return http.StatusBadRequest
+	case errors.Is(err, usecase.ErrProductNotFound), errors.Is(err, usecase.ErrWishlistNotFound),
+		errors.Is(err, entity.ErrWishlistItemNotFound), errors.Is(err, usecase.ErrFeatureFlagNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, entity.ErrTOTPLocked):
+		return http.StatusTooManyRequests
+	// O usuário das operações da própria conta vem do token, então se ele não existe o token não vale
+	case errors.Is(err, usecase.ErrUnauthorized), errors.Is(err, usecase.ErrInvalidCredentials),
+		errors.Is(err, usecase.ErrInvalidChallenge), errors.Is(err, usecase.ErrUserNotFound):
+		return http.StatusUnauthorized
+	case errors.Is(err, usecase.ErrOIDCEmailNotVerified):
+		return http.StatusForbidden
+	case errors.Is(err, usecase.ErrConflict):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func writeError(writer http.ResponseWriter, request *http.Request, status int, err error) {
+	// Corpos sem Content-Length só estouram o BodyLimit durante a leitura, dentro de quem decodifica
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		status, err = http.StatusRequestEntityTooLarge, fmt.Errorf("%w: %v", ErrRequestBodyTooLarge, err)
+	}
+
+	code := ErrorCode(err, status)
+	tag := i18n.MatchLanguage(request.Header.Get("Accept-Language"))
+
+	// O log mantém o código estável e o erro original, independente do idioma do cliente
+	log.Printf("%s %s: status=%d code=%s error=%v", request.Method, request.URL.Path, status, code, err)
+
+	output := Error{Code: code, Message: i18n.Message(tag, code)}
+
+	var validationErr *openapi.ValidationError
+	if errors.As(err, &validationErr) {
+		output.Details = validationErr.Violations
+	}
+
+	writer.Header().Set("Content-Language", tag.String())
+	render(writer, request, status, output)
+}
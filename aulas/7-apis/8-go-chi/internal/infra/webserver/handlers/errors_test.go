@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/entity"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/i18n"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorCode(t *testing.T) {
+	assert.Equal(t, i18n.CodeNameIsRequired, ErrorCode(entity.ErrNameIsRequired, http.StatusBadRequest))
+	assert.Equal(t, i18n.CodeInvalidRequestBody, ErrorCode(fmt.Errorf("%w: unexpected EOF", ErrInvalidRequestBody), http.StatusBadRequest))
+
+	// Erros desconhecidos não expõem a mensagem original
+	assert.Equal(t, i18n.CodeInternalError, ErrorCode(errors.New("database is locked"), http.StatusInternalServerError))
+	assert.Equal(t, i18n.CodeUnauthorized, ErrorCode(errors.New("token expired"), http.StatusUnauthorized))
+}
+
+func TestErrorCodeIsDeterministic(t *testing.T) {
+	// Com dois erros conhecidos no mesmo erro, vale sempre o que vem primeiro na lista
+	err := fmt.Errorf("%w: %w", ErrCouponNotFound, entity.ErrInvalidID)
+	for i := 0; i < 50; i++ {
+		assert.Equal(t, i18n.CodeInvalidID, ErrorCode(err, http.StatusBadRequest))
+	}
+}
+
+func TestRegisterErrorCode(t *testing.T) {
+	errorCodesMutex.RLock()
+	original := append([]knownError{}, errorCodes...)
+	errorCodesMutex.RUnlock()
+	t.Cleanup(func() {
+		errorCodesMutex.Lock()
+		errorCodes = original
+		errorCodesMutex.Unlock()
+	})
+
+	errCustom := errors.New("custom")
+	RegisterErrorCode(errCustom, "custom_code")
+	assert.Equal(t, "custom_code", ErrorCode(errCustom, http.StatusBadRequest))
+
+	RegisterErrorCode(errCustom, "other_code")
+	assert.Equal(t, "other_code", ErrorCode(errCustom, http.StatusBadRequest))
+	assert.Len(t, errorCodes, len(original)+1)
+}
@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/dto"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/featureflag"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/usecase"
+	"github.com/go-chi/chi"
+)
+
+const defaultFlagPercentage = 100
+
+type FeatureFlagHandler struct {
+	UseCase *usecase.FeatureFlagUseCase
+	// Features avalia as flags para o usuário do token, com as flags em cache
+	Features *featureflag.Store
+}
+
+func NewFeatureFlagHandler(useCase *usecase.FeatureFlagUseCase, features *featureflag.Store) *FeatureFlagHandler {
+	return &FeatureFlagHandler{UseCase: useCase, Features: features}
+}
+
+// CreateFeatureFlag godoc
+// @Summary Create feature flag
+// @Description Create a feature flag. Admin only
+// @Tags feature-flags
+// @Accept json,xml,application/msgpack
+// @Produce json,xml,application/msgpack
+// @Param request body dto.CreateFeatureFlagInput true "feature flag"
+// @Success 201 {object} usecase.FeatureFlagOutput
+// @Failure 400 {object} Error
+// @Failure 403 {object} Error
+// @Failure 409 {object} Error
+// @Failure 500 {object} Error
+// @Router /feature-flags [post]
+// @Security ApiKeyAuth
+func (flagHandler *FeatureFlagHandler) CreateFeatureFlag(writer http.ResponseWriter, request *http.Request) {
+	var input dto.CreateFeatureFlagInput
+	err := decode(request, &input)
+	if err != nil {
+		writeError(writer, request, http.StatusBadRequest, fmt.Errorf("%w: %v", ErrInvalidRequestBody, err))
+		return
+	}
+
+	flag, err := flagHandler.UseCase.Create(usecase.FeatureFlagInput{
+		Key:         input.Key,
+		Description: input.Description,
+		Enabled:     input.Enabled,
+		Percentage:  flagPercentage(input.Percentage),
+		UserIDs:     input.UserIDs,
+		Roles:       input.Roles,
+	})
+	if err != nil {
+		writeError(writer, request, useCaseStatus(err), err)
+		return
+	}
+
+	render(writer, request, http.StatusCreated, flag)
+}
+
+// GetFeatureFlags godoc
+// @Summary List feature flags
+// @Description List every feature flag ordered by key. Admin only
+// @Tags feature-flags
+// @Produce json,xml,application/msgpack
+// @Success 200 {array} usecase.FeatureFlagOutput
+// @Failure 403 {object} Error
+// @Failure 500 {object} Error
+// @Router /feature-flags [get]
+// @Security ApiKeyAuth
+func (flagHandler *FeatureFlagHandler) GetFeatureFlags(writer http.ResponseWriter, request *http.Request) {
+	flags, err := flagHandler.UseCase.List()
+	if err != nil {
+		writeError(writer, request, useCaseStatus(err), err)
+		return
+	}
+
+	render(writer, request, http.StatusOK, flags)
+}
+
+// GetFeatureFlag godoc
+// @Summary Get feature flag
+// @Description Admin only
+// @Tags feature-flags
+// @Produce json,xml,application/msgpack
+// @Param key path string true "flag key"
+// @Success 200 {object} usecase.FeatureFlagOutput
+// @Failure 403 {object} Error
+// @Failure 404 {object} Error
+// @Router /feature-flags/{key} [get]
+// @Security ApiKeyAuth
+func (flagHandler *FeatureFlagHandler) GetFeatureFlag(writer http.ResponseWriter, request *http.Request) {
+	flag, err := flagHandler.UseCase.Find(chi.URLParam(request, "key"))
+	if err != nil {
+		writeError(writer, request, useCaseStatus(err), err)
+		return
+	}
+
+	render(writer, request, http.StatusOK, flag)
+}
+
+// UpdateFeatureFlag godoc
+// @Summary Update feature flag
+// @Description Replace the flag rules. The change is applied to the evaluation cache right away. Admin only
+// @Tags feature-flags
+// @Accept json,xml,application/msgpack
+// @Produce json,xml,application/msgpack
+// @Param key path string true "flag key"
+// @Param request body dto.UpdateFeatureFlagInput true "feature flag"
+// @Success 200 {object} usecase.FeatureFlagOutput
+// @Failure 400 {object} Error
+// @Failure 403 {object} Error
+// @Failure 404 {object} Error
+// @Failure 500 {object} Error
+// @Router /feature-flags/{key} [put]
+// @Security ApiKeyAuth
+func (flagHandler *FeatureFlagHandler) UpdateFeatureFlag(writer http.ResponseWriter, request *http.Request) {
+	var input dto.UpdateFeatureFlagInput
+	err := decode(request, &input)
+	if err != nil {
+		writeError(writer, request, http.StatusBadRequest, fmt.Errorf("%w: %v", ErrInvalidRequestBody, err))
+		return
+	}
+
+	flag, err := flagHandler.UseCase.Update(usecase.FeatureFlagInput{
+		Key:         chi.URLParam(request, "key"),
+		Description: input.Description,
+		Enabled:     input.Enabled,
+		Percentage:  flagPercentage(input.Percentage),
+		UserIDs:     input.UserIDs,
+		Roles:       input.Roles,
+	})
+	if err != nil {
+		writeError(writer, request, useCaseStatus(err), err)
+		return
+	}
+
+	render(writer, request, http.StatusOK, flag)
+}
+
+// DeleteFeatureFlag godoc
+// @Summary Delete feature flag
+// @Description A deleted flag is evaluated as disabled. Admin only
+// @Tags feature-flags
+// @Param key path string true "flag key"
+// @Success 200
+// @Failure 403 {object} Error
+// @Failure 404 {object} Error
+// @Failure 500 {object} Error
+// @Router /feature-flags/{key} [delete]
+// @Security ApiKeyAuth
+func (flagHandler *FeatureFlagHandler) DeleteFeatureFlag(writer http.ResponseWriter, request *http.Request) {
+	err := flagHandler.UseCase.Delete(chi.URLParam(request, "key"))
+	if err != nil {
+		writeError(writer, request, useCaseStatus(err), err)
+		return
+	}
+
+	writer.WriteHeader(http.StatusOK)
+}
+
+// GetFeatures godoc
+// @Summary Evaluate feature flags
+// @Description Evaluate every feature flag for the authenticated user, using the user id and role of the token
+// @Tags feature-flags
+// @Produce json,xml,application/msgpack
+// @Success 200 {object} map[string]bool
+// @Failure 401 {object} Error
+// @Router /features [get]
+// @Security ApiKeyAuth
+func (flagHandler *FeatureFlagHandler) GetFeatures(writer http.ResponseWriter, request *http.Request) {
+	render(writer, request, http.StatusOK, flagHandler.Features.Evaluate(featureSubject(request)))
+}
+
+func flagPercentage(percentage *int) int {
+	if percentage == nil {
+		return defaultFlagPercentage
+	}
+
+	return *percentage
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/dto"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/entity"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/database"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/jobs"
+	"github.com/go-chi/chi"
+)
+
+type JobHandler struct {
+	JobDB database.JobInterface
+	Jobs  *jobs.Runner
+}
+
+func NewJobHandler(jobDB database.JobInterface, runner *jobs.Runner) *JobHandler {
+	return &JobHandler{JobDB: jobDB, Jobs: runner}
+}
+
+// ExportProducts godoc
+// @Summary Export products
+// @Description Start a CSV export of every product. The CSV is served by the job result once it succeeds
+// @Tags jobs
+// @Produce json,xml,application/msgpack
+// @Param sort query string false "sort order" Enums(asc, desc)
+// @Success 202 {object} dto.JobAcceptedOutput
+// @Failure 500 {object} Error
+// @Router /products/export [post]
+// @Security ApiKeyAuth
+func (jobHandler *JobHandler) ExportProducts(writer http.ResponseWriter, request *http.Request) {
+	payload := jobs.ExportProductsPayload{Sort: request.URL.Query().Get("sort")}
+
+	job, err := jobHandler.Jobs.Enqueue(jobs.TypeExportProducts, payload, userIDFromRequest(request))
+	if err != nil {
+		writeError(writer, request, http.StatusInternalServerError, err)
+		return
+	}
+
+	// O status é consultado na mesma versão da API em que o job foi pedido
+	prefix := strings.TrimSuffix(strings.TrimSuffix(request.URL.Path, "/"), "/products/export")
+	statusURL := prefix + "/jobs/" + job.ID.String()
+
+	writer.Header().Set("Location", statusURL)
+	render(writer, request, http.StatusAccepted, dto.JobAcceptedOutput{
+		ID:        job.ID.String(),
+		Status:    job.Status,
+		StatusURL: statusURL,
+	})
+}
+
+// GetJob godoc
+// @Summary Get job status
+// @Description Get the status of a background job. Only the user that started it (or an admin) can see it
+// @Tags jobs
+// @Produce json,xml,application/msgpack
+// @Param id path string true "job id" Format(uuid)
+// @Success 200 {object} entity.Job
+// @Failure 404 {object} Error
+// @Router /jobs/{id} [get]
+// @Security ApiKeyAuth
+func (jobHandler *JobHandler) GetJob(writer http.ResponseWriter, request *http.Request) {
+	job, ok := jobHandler.findJob(writer, request)
+	if !ok {
+		return
+	}
+
+	render(writer, request, http.StatusOK, job)
+}
+
+// GetJobResult godoc
+// @Summary Get job result
+// @Description Download what a succeeded job produced (e.g. the CSV of a product export)
+// @Tags jobs
+// @Produce text/csv,plain
+// @Param id path string true "job id" Format(uuid)
+// @Success 200 {string} string
+// @Failure 404 {object} Error
+// @Failure 409 {object} Error
+// @Router /jobs/{id}/result [get]
+// @Security ApiKeyAuth
+func (jobHandler *JobHandler) GetJobResult(writer http.ResponseWriter, request *http.Request) {
+	job, ok := jobHandler.findJob(writer, request)
+	if !ok {
+		return
+	}
+
+	switch job.Status {
+	case entity.JobSucceeded:
+	case entity.JobFailed:
+		writeError(writer, request, http.StatusConflict, entity.ErrJobFailed)
+		return
+	default:
+		writeError(writer, request, http.StatusConflict, entity.ErrJobNotFinished)
+		return
+	}
+
+	writer.Header().Set("Content-Type", job.ResultType)
+	writer.WriteHeader(http.StatusOK)
+	writer.Write([]byte(job.Result))
+}
+
+// findJob responde 404 tanto para jobs inexistentes quanto para jobs de outros usuários,
+// assim um id válido não revela que o job existe
+func (jobHandler *JobHandler) findJob(writer http.ResponseWriter, request *http.Request) (*entity.Job, bool) {
+	job, err := jobHandler.JobDB.FindByID(chi.URLParam(request, "id"))
+	if err != nil {
+		writeError(writer, request, http.StatusNotFound, ErrJobNotFound)
+		return nil, false
+	}
+
+	if job.Owner != userIDFromRequest(request) && !isAdmin(request) {
+		writeError(writer, request, http.StatusNotFound, ErrJobNotFound)
+		return nil, false
+	}
+
+	return job, true
+}
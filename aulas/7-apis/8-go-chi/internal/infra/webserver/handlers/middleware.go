@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/webserver/content"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/usecase"
+	"github.com/go-chi/jwtauth"
+)
+
+// As roles são definidas pelo use case de login, que decide quem é admin
+const (
+	RoleAdmin = usecase.RoleAdmin
+	RoleUser  = usecase.RoleUser
+)
+
+// RequireRole deve vir depois do Verifier e do jwtauth.Authenticator, que garantem um token válido no contexto
+func RequireRole(role string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			_, claims, _ := jwtauth.FromContext(request.Context())
+			if claimRole, _ := claims["role"].(string); claimRole != role {
+				writeError(writer, request, http.StatusForbidden, ErrForbidden)
+				return
+			}
+
+			next.ServeHTTP(writer, request)
+		})
+	}
+}
+
+// Negotiate recusa logo na entrada as requisições cujo Accept não tem formato suportado (406)
+// ou cujo corpo vem em um Content-Type que não sabemos ler (415)
+func Negotiate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if _, err := content.Negotiate(request.Header.Get("Accept")); err != nil {
+			writeError(writer, request, http.StatusNotAcceptable, err)
+			return
+		}
+
+		hasBody := request.ContentLength > 0 || len(request.TransferEncoding) > 0
+		if _, err := content.ForContentType(request.Header.Get("Content-Type")); hasBody && err != nil {
+			writeError(writer, request, http.StatusUnsupportedMediaType, err)
+			return
+		}
+
+		next.ServeHTTP(writer, request)
+	})
+}
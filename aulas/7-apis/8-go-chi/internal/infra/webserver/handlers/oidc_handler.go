@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/auth"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/auth/oidc"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/usecase"
+	"github.com/go-chi/jwtauth"
+)
+
+const (
+	oidcStateCookie = "oidc_state"
+	// Tempo que o usuário tem para concluir o login no issuer
+	oidcStateTTL = 10 * time.Minute
+)
+
+// OIDCHandler faz o login pelo SSO (authorization code com PKCE). O token emitido no final é o mesmo do login
+// por senha, então usa o mesmo use case (role de admin e segundo fator). JWT assina o cookie do state
+type OIDCHandler struct {
+	UseCase *usecase.UserUseCase
+	JWT     *jwtauth.JWTAuth
+	Client  *oidc.Client
+}
+
+func NewOIDCHandler(useCase *usecase.UserUseCase, jwt *jwtauth.JWTAuth, client *oidc.Client) *OIDCHandler {
+	return &OIDCHandler{UseCase: useCase, JWT: jwt, Client: client}
+}
+
+// Login godoc
+// @Summary Log in with SSO
+// @Description Redirect to the OpenID Connect issuer. After the login the issuer redirects to /users/oidc/callback
+// @Tags users
+// @Success 302
+// @Header 302 {string} Location "authorization endpoint of the issuer"
+// @Failure 500 {object} Error
+// @Router /users/oidc/login [get]
+func (oidcHandler *OIDCHandler) Login(writer http.ResponseWriter, request *http.Request) {
+	state, err := oidc.NewState()
+	if err != nil {
+		writeError(writer, request, http.StatusInternalServerError, err)
+		return
+	}
+	nonce, err := oidc.NewState()
+	if err != nil {
+		writeError(writer, request, http.StatusInternalServerError, err)
+		return
+	}
+	verifier, err := oidc.NewCodeVerifier()
+	if err != nil {
+		writeError(writer, request, http.StatusInternalServerError, err)
+		return
+	}
+
+	// O state, o nonce e o verifier ficam em um cookie assinado, assim qualquer instância da API atende o callback
+	_, cookie, err := oidcHandler.JWT.Encode(map[string]interface{}{
+		"aud":      auth.OIDCStateAudience,
+		"exp":      time.Now().Add(oidcStateTTL).Unix(),
+		"state":    state,
+		"nonce":    nonce,
+		"verifier": verifier,
+	})
+	if err != nil {
+		writeError(writer, request, http.StatusInternalServerError, err)
+		return
+	}
+
+	setOIDCStateCookie(writer, request, cookie, int(oidcStateTTL/time.Second))
+	http.Redirect(writer, request, oidcHandler.Client.AuthCodeURL(state, nonce, verifier), http.StatusFound)
+}
+
+// Callback godoc
+// @Summary SSO callback
+// @Description Finish the SSO login. The user is created on the first login, or linked to the account with the same verified email
+// @Tags users
+// @Produce json,xml,application/msgpack
+// @Param code query string false "authorization code"
+// @Param state query string true "state sent in the login"
+// @Param error query string false "error returned by the issuer"
+// @Success 200 {object} dto.GetJWTOutput
+// @Success 202 {object} dto.TOTPChallengeOutput
+// @Failure 400 {object} Error
+// @Failure 401 {object} Error
+// @Failure 403 {object} Error
+// @Failure 409 {object} Error
+// @Failure 500 {object} Error
+// @Router /users/oidc/callback [get]
+func (oidcHandler *OIDCHandler) Callback(writer http.ResponseWriter, request *http.Request) {
+	query := request.URL.Query()
+
+	cookie, err := request.Cookie(oidcStateCookie)
+	// Cada state vale para um único callback, então o cookie é apagado mesmo quando o login falha
+	setOIDCStateCookie(writer, request, "", -1)
+	if err != nil {
+		writeError(writer, request, http.StatusBadRequest, ErrInvalidOIDCState)
+		return
+	}
+
+	token, err := jwtauth.VerifyToken(oidcHandler.JWT, cookie.Value)
+	if err != nil || !auth.HasAudience(token, auth.OIDCStateAudience) {
+		writeError(writer, request, http.StatusBadRequest, ErrInvalidOIDCState)
+		return
+	}
+
+	state, nonce, verifier := claim(token.PrivateClaims(), "state"), claim(token.PrivateClaims(), "nonce"), claim(token.PrivateClaims(), "verifier")
+	if state == "" || subtle.ConstantTimeCompare([]byte(state), []byte(query.Get("state"))) != 1 {
+		writeError(writer, request, http.StatusBadRequest, ErrInvalidOIDCState)
+		return
+	}
+
+	if issuerErr := query.Get("error"); issuerErr != "" {
+		writeError(writer, request, http.StatusUnauthorized, fmt.Errorf("%w: %s", ErrOIDCLoginFailed, issuerErr))
+		return
+	}
+
+	idToken, err := oidcHandler.Client.Exchange(request.Context(), query.Get("code"), verifier)
+	if err != nil {
+		writeError(writer, request, http.StatusUnauthorized, fmt.Errorf("%w: %v", ErrOIDCLoginFailed, err))
+		return
+	}
+
+	claims, err := oidcHandler.Client.VerifyIDToken(request.Context(), idToken, nonce)
+	if err != nil {
+		writeError(writer, request, http.StatusUnauthorized, fmt.Errorf("%w: %v", ErrOIDCLoginFailed, err))
+		return
+	}
+
+	output, err := oidcHandler.UseCase.LoginWithOIDC(usecase.OIDCLoginInput{
+		Issuer:        oidcHandler.Client.Issuer(),
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+		Name:          claims.Name,
+	})
+	renderLogin(writer, request, output, err)
+}
+
+func setOIDCStateCookie(writer http.ResponseWriter, request *http.Request, value string, maxAge int) {
+	http.SetCookie(writer, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   request.TLS != nil,
+		// Lax é necessário: o callback chega por um redirect vindo do domínio do issuer
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func claim(claims map[string]interface{}, name string) string {
+	value, _ := claims[name].(string)
+	return value
+}
@@ -1,24 +1,30 @@
 package handlers
 
 import (
-	"encoding/json"
+	"fmt"
 	"net/http"
-	"strconv"
+	"time"
 
 	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/dto"
 	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/entity"
-	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/database"
-	entityPkg "github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/pkg/entity"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/webserver/sse"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/usecase"
 	"github.com/go-chi/chi"
 )
 
+const defaultHeartbeatInterval = 15 * time.Second
+
 type ProductHandler struct {
-	ProductDB database.ProductInterface
+	UseCase           *usecase.ProductUseCase
+	Broker            *sse.Broker
+	HeartbeatInterval time.Duration
 }
 
-func NewProductHandler(db database.ProductInterface) *ProductHandler {
+func NewProductHandler(useCase *usecase.ProductUseCase, broker *sse.Broker) *ProductHandler {
 	return &ProductHandler{
-		ProductDB: db,
+		UseCase:           useCase,
+		Broker:            broker,
+		HeartbeatInterval: defaultHeartbeatInterval,
 	}
 }
 
@@ -26,8 +32,8 @@ func NewProductHandler(db database.ProductInterface) *ProductHandler {
 // @Summary Create product
 // @Description Create product
 // @Tags products
-// @Accept json
-// @Produce json
+// @Accept json,xml,application/msgpack
+// @Produce json,xml,application/msgpack
 // @Param product body dto.CreateProductInput true "product request"
 // @Success 201
 // @Failure 400 {object} Error
@@ -36,130 +42,253 @@ func NewProductHandler(db database.ProductInterface) *ProductHandler {
 // @Security ApiKeyAuth
 func (productHandler *ProductHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
 	var productDto dto.CreateProductInput
-	err := json.NewDecoder(r.Body).Decode(&productDto)
+	err := decode(r, &productDto)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-
-		error := Error{Message: err.Error()}
-		json.NewEncoder(w).Encode(error)
-
+		writeError(w, r, http.StatusBadRequest, fmt.Errorf("%w: %v", ErrInvalidRequestBody, err))
 		return
 	}
 
-	// Fazer diretamente acesso da entidade no coração não é comum. Em vez disso, usaremos no futuro um use case(clean arch)
-	product, err := entity.NewProduct(productDto.Name, productDto.Price)
+	_, err = productHandler.UseCase.Create(productInput(r, productDto))
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-
-		error := Error{Message: err.Error()}
-		json.NewEncoder(w).Encode(error)
-
-		return
-	}
-
-	err = productHandler.ProductDB.Create(product)
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-
-		error := Error{Message: err.Error()}
-		json.NewEncoder(w).Encode(error)
-
+		writeError(w, r, useCaseStatus(err), err)
 		return
 	}
 
 	w.WriteHeader(http.StatusCreated)
 }
 
+// GetProduct godoc
+// @Summary Get product
+// @Description Get a product by id
+// @Tags products
+// @Produce json,xml,application/msgpack
+// @Param id path string true "product id" Format(uuid)
+// @Success 200 {object} usecase.ProductOutput
+// @Failure 400 {object} Error
+// @Failure 404 {object} Error
+// @Router /products/{id} [get]
+// @Security ApiKeyAuth
 func (productHandler *ProductHandler) GetProduct(writer http.ResponseWriter, request *http.Request) {
-	id := chi.URLParam(request, "id")
-	if id == "" {
-		writer.WriteHeader(http.StatusBadRequest)
-		return
-	}
-
-	product, err := productHandler.ProductDB.FindByID(id)
+	product, err := productHandler.UseCase.Find(chi.URLParam(request, "id"))
 	if err != nil {
-		writer.WriteHeader(http.StatusNotFound)
+		writeError(writer, request, useCaseStatus(err), err)
 		return
 	}
 
-	writer.Header().Set("Content-Type", "application/json")
-	writer.WriteHeader(http.StatusOK)
-
-	//Aqui o json.NewEncoder já escreve diretamente no response writer
-	//O .Encode faz o encode do objeto passado como parâmetro
-	//Outra forma seria usar o json.Marshal(product) e depois w.Write(). Exemplo: data, _ := json.Marshal(product) w.Write(data)
-	json.NewEncoder(writer).Encode(product)
+	//O render escolhe o formato (JSON, XML ou MessagePack) pelo header Accept e escreve direto no response writer
+	render(writer, request, http.StatusOK, product)
 }
 
+// UpdateProduct godoc
+// @Summary Update product
+// @Description Replace the name, price and category of a product
+// @Tags products
+// @Accept json,xml,application/msgpack
+// @Produce json,xml,application/msgpack
+// @Param id path string true "product id" Format(uuid)
+// @Param product body dto.CreateProductInput true "product request"
+// @Success 200
+// @Failure 400 {object} Error
+// @Failure 404 {object} Error
+// @Failure 500 {object} Error
+// @Router /products/{id} [put]
+// @Security ApiKeyAuth
 func (productHandler *ProductHandler) UpdateProduct(writer http.ResponseWriter, request *http.Request) {
 	id := chi.URLParam(request, "id")
 	if id == "" {
-		writer.WriteHeader(http.StatusBadRequest)
+		writeError(writer, request, http.StatusBadRequest, entity.ErrIDIsRequired)
 		return
 	}
 
-	var product entity.Product
-
-	err := json.NewDecoder(request.Body).Decode(&product)
+	var input dto.CreateProductInput
+	err := decode(request, &input)
 	if err != nil {
-		writer.WriteHeader(http.StatusBadRequest)
+		writeError(writer, request, http.StatusBadRequest, fmt.Errorf("%w: %v", ErrInvalidRequestBody, err))
 		return
 	}
 
-	product.ID, err = entityPkg.ParseID(id)
+	_, err = productHandler.UseCase.Update(usecase.UpdateProductInput{ID: id, ProductInput: productInput(request, input)})
 	if err != nil {
-		writer.WriteHeader(http.StatusBadRequest)
+		writeError(writer, request, useCaseStatus(err), err)
 		return
 	}
 
-	err = productHandler.ProductDB.Update(&product)
+	writer.WriteHeader(http.StatusOK)
+}
+
+// DeleteProduct godoc
+// @Summary Delete product
+// @Description Delete a product
+// @Tags products
+// @Produce json,xml,application/msgpack
+// @Param id path string true "product id" Format(uuid)
+// @Success 200
+// @Failure 404 {object} Error
+// @Failure 500 {object} Error
+// @Router /products/{id} [delete]
+// @Security ApiKeyAuth
+func (productHandler *ProductHandler) DeleteProduct(writer http.ResponseWriter, request *http.Request) {
+	err := productHandler.UseCase.Delete(usecase.DeleteProductInput{ID: chi.URLParam(request, "id"), Actor: userIDFromRequest(request)})
 	if err != nil {
-		writer.WriteHeader(http.StatusInternalServerError)
+		writeError(writer, request, useCaseStatus(err), err)
 		return
 	}
 
 	writer.WriteHeader(http.StatusOK)
 }
 
-func (productHandler *ProductHandler) DeleteProduct(writer http.ResponseWriter, request *http.Request) {
-	id := chi.URLParam(request, "id")
-	if id == "" {
-		writer.WriteHeader(http.StatusBadRequest)
+// GetProducts godoc
+// @Summary List products
+// @Description List products with pagination
+// @Tags products
+// @Produce json,xml,application/msgpack
+// @Param page query int false "page number" minimum(1)
+// @Param limit query int false "limit" minimum(1)
+// @Param sort query string false "sort order" Enums(asc, desc)
+// @Success 200 {array} usecase.ProductOutput
+// @Failure 500 {object} Error
+// @Router /products [get]
+// @Security ApiKeyAuth
+func (productHandler *ProductHandler) GetProducts(writer http.ResponseWriter, request *http.Request) {
+	products, err := productHandler.UseCase.List(listProductsInput(request))
+	if err != nil {
+		writeError(writer, request, useCaseStatus(err), err)
 		return
 	}
 
-	err := productHandler.ProductDB.Delete(id)
-	if err != nil {
-		writer.WriteHeader(http.StatusInternalServerError)
-		return
+	render(writer, request, http.StatusOK, products)
+}
+
+// productInput converte o corpo da requisição (igual em todas as versões depois do decode) para a entrada do use case
+func productInput(request *http.Request, input dto.CreateProductInput) usecase.ProductInput {
+	return usecase.ProductInput{
+		Name:     input.Name,
+		Price:    input.Price,
+		Category: input.Category,
+		Actor:    userIDFromRequest(request),
 	}
+}
 
-	writer.WriteHeader(http.StatusOK)
+func listProductsInput(request *http.Request) usecase.ListProductsInput {
+	page, limit := pagination(request)
+
+	return usecase.ListProductsInput{Page: page, Limit: limit, Sort: request.URL.Query().Get("sort")}
 }
 
-func (productHandler *ProductHandler) GetProducts(writer http.ResponseWriter, request *http.Request) {
-	pageString := request.URL.Query().Get("page")
-	limitString := request.URL.Query().Get("limit")
-	sort := request.URL.Query().Get("sort")
+// GetProductPrices godoc
+// @Summary List product price history
+// @Description List every price change of a product, optionally filtered by period
+// @Tags prices
+// @Produce json,xml,application/msgpack
+// @Param id path string true "product id" Format(uuid)
+// @Param from query string false "start of the period (RFC 3339)" Format(date-time)
+// @Param to query string false "end of the period (RFC 3339)" Format(date-time)
+// @Success 200 {array} entity.PriceChange
+// @Failure 400 {object} Error
+// @Failure 404 {object} Error
+// @Failure 500 {object} Error
+// @Router /products/{id}/prices [get]
+// @Security ApiKeyAuth
+func (productHandler *ProductHandler) GetProductPrices(writer http.ResponseWriter, request *http.Request) {
+	from, to, err := parsePeriod(request)
+	if err != nil {
+		writeError(writer, request, http.StatusBadRequest, err)
+		return
+	}
 
-	pageInt, err := strconv.Atoi(pageString)
+	changes, err := productHandler.UseCase.PriceHistory(usecase.PriceHistoryInput{ProductID: chi.URLParam(request, "id"), From: from, To: to})
 	if err != nil {
-		pageInt = 0
+		writeError(writer, request, useCaseStatus(err), err)
+		return
 	}
 
-	limitInt, err := strconv.Atoi(limitString)
+	render(writer, request, http.StatusOK, changes)
+}
+
+// GetProductPriceStats godoc
+// @Summary Product price statistics
+// @Description Min, max and time-weighted average price of a product over a period. Defaults to the last 30 days
+// @Tags prices
+// @Produce json,xml,application/msgpack
+// @Param id path string true "product id" Format(uuid)
+// @Param from query string false "start of the period (RFC 3339)" Format(date-time)
+// @Param to query string false "end of the period (RFC 3339)" Format(date-time)
+// @Success 200 {object} entity.PriceSummary
+// @Failure 400 {object} Error
+// @Failure 404 {object} Error
+// @Failure 500 {object} Error
+// @Router /products/{id}/prices/stats [get]
+// @Security ApiKeyAuth
+func (productHandler *ProductHandler) GetProductPriceStats(writer http.ResponseWriter, request *http.Request) {
+	from, to, err := parsePeriod(request)
 	if err != nil {
-		limitInt = 0
+		writeError(writer, request, http.StatusBadRequest, err)
+		return
 	}
 
-	products, err := productHandler.ProductDB.FindAll(pageInt, limitInt, sort)
+	summary, err := productHandler.UseCase.PriceStats(usecase.PriceHistoryInput{ProductID: chi.URLParam(request, "id"), From: from, To: to})
 	if err != nil {
-		writer.WriteHeader(http.StatusInternalServerError)
+		writeError(writer, request, useCaseStatus(err), err)
+		return
+	}
+
+	render(writer, request, http.StatusOK, summary)
+}
+
+// StreamProducts godoc
+// @Summary Stream product changes
+// @Description Server-Sent Events stream with created, updated and deleted product events. Supports resuming through the Last-Event-ID header; when the id cannot be resumed a single reset event is sent and the client should reload the products
+// @Tags stream
+// @Produce text/event-stream
+// @Param Last-Event-ID header string false "last event id received by the client"
+// @Success 200
+// @Failure 500 {object} Error
+// @Router /products/stream [get]
+// @Security ApiKeyAuth
+func (productHandler *ProductHandler) StreamProducts(writer http.ResponseWriter, request *http.Request) {
+	flusher, ok := writer.(http.Flusher)
+	if !ok || productHandler.Broker == nil {
+		writeError(writer, request, http.StatusInternalServerError, ErrStreamUnsupported)
 		return
 	}
 
-	writer.Header().Set("Content-Type", "application/json")
+	lastEventID := sse.ParseLastEventID(request.Header.Get("Last-Event-ID"))
+	subscription, replay := productHandler.Broker.Subscribe(lastEventID)
+	defer productHandler.Broker.Unsubscribe(subscription)
+
+	writer.Header().Set("Content-Type", "text/event-stream")
+	writer.Header().Set("Cache-Control", "no-cache")
+	writer.Header().Set("Connection", "keep-alive")
 	writer.WriteHeader(http.StatusOK)
-	json.NewEncoder(writer).Encode(products)
+
+	for _, event := range replay {
+		if sse.WriteEvent(writer, event) != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(productHandler.HeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-request.Context().Done():
+			return
+		case event, ok := <-subscription.Events:
+			// Canal fechado pelo broker: o cliente ficou para trás e deve reconectar
+			if !ok {
+				return
+			}
+			if sse.WriteEvent(writer, event) != nil {
+				return
+			}
+			flusher.Flush()
+		case <-heartbeat.C:
+			if sse.WriteHeartbeat(writer) != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
 }
@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/dto"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/usecase"
+	"github.com/go-chi/chi"
+)
+
+// ProductV2Handler expõe os produtos no formato da v2. As regras (auditoria, histórico de preço e eventos)
+// ficam no usecase.ProductUseCase, aqui só traduzimos a entrada e a saída
+type ProductV2Handler struct {
+	*ProductHandler
+}
+
+func NewProductV2Handler(productHandler *ProductHandler) *ProductV2Handler {
+	return &ProductV2Handler{ProductHandler: productHandler}
+}
+
+// CreateProduct godoc
+// @Summary Create product
+// @Description Create a product and return it. The price is an amount with its currency
+// @Tags products-v2
+// @Accept json,xml,application/msgpack
+// @Produce json,xml,application/msgpack
+// @Param product body dto.CreateProductV2Input true "product request"
+// @Success 201 {object} dto.ProductV2Output
+// @Failure 400 {object} Error
+// @Failure 500 {object} Error
+// @Router /products [post]
+// @Security ApiKeyAuth
+func (productHandler *ProductV2Handler) CreateProduct(writer http.ResponseWriter, request *http.Request) {
+	input, status, err := decodeProductV2(request)
+	if err != nil {
+		writeError(writer, request, status, err)
+		return
+	}
+
+	product, err := productHandler.UseCase.Create(productInput(request, input))
+	if err != nil {
+		writeError(writer, request, useCaseStatus(err), err)
+		return
+	}
+
+	render(writer, request, http.StatusCreated, productV2Output(product))
+}
+
+// GetProduct godoc
+// @Summary Get product
+// @Description Get a product by id
+// @Tags products-v2
+// @Produce json,xml,application/msgpack
+// @Param id path string true "product id" Format(uuid)
+// @Success 200 {object} dto.ProductV2Output
+// @Failure 400 {object} Error
+// @Failure 404 {object} Error
+// @Router /products/{id} [get]
+// @Security ApiKeyAuth
+func (productHandler *ProductV2Handler) GetProduct(writer http.ResponseWriter, request *http.Request) {
+	product, err := productHandler.UseCase.Find(chi.URLParam(request, "id"))
+	if err != nil {
+		writeError(writer, request, useCaseStatus(err), err)
+		return
+	}
+
+	render(writer, request, http.StatusOK, productV2Output(product))
+}
+
+// GetProducts godoc
+// @Summary List products
+// @Description List products wrapped in a paginated envelope
+// @Tags products-v2
+// @Produce json,xml,application/msgpack
+// @Param page query int false "page number" minimum(1)
+// @Param limit query int false "limit" minimum(1)
+// @Param sort query string false "sort order" Enums(asc, desc)
+// @Success 200 {object} dto.ProductListV2Output
+// @Failure 500 {object} Error
+// @Router /products [get]
+// @Security ApiKeyAuth
+func (productHandler *ProductV2Handler) GetProducts(writer http.ResponseWriter, request *http.Request) {
+	input := listProductsInput(request)
+
+	products, err := productHandler.UseCase.List(input)
+	if err != nil {
+		writeError(writer, request, useCaseStatus(err), err)
+		return
+	}
+
+	output := dto.ProductListV2Output{Data: []dto.ProductV2Output{}, Page: input.Page, Limit: input.Limit}
+	for _, product := range products {
+		output.Data = append(output.Data, productV2Output(product))
+	}
+
+	render(writer, request, http.StatusOK, output)
+}
+
+// UpdateProduct godoc
+// @Summary Update product
+// @Description Replace the name, price and category of a product and return it
+// @Tags products-v2
+// @Accept json,xml,application/msgpack
+// @Produce json,xml,application/msgpack
+// @Param id path string true "product id" Format(uuid)
+// @Param product body dto.CreateProductV2Input true "product request"
+// @Success 200 {object} dto.ProductV2Output
+// @Failure 400 {object} Error
+// @Failure 404 {object} Error
+// @Failure 500 {object} Error
+// @Router /products/{id} [put]
+// @Security ApiKeyAuth
+func (productHandler *ProductV2Handler) UpdateProduct(writer http.ResponseWriter, request *http.Request) {
+	input, status, err := decodeProductV2(request)
+	if err != nil {
+		writeError(writer, request, status, err)
+		return
+	}
+
+	product, err := productHandler.UseCase.Update(usecase.UpdateProductInput{ID: chi.URLParam(request, "id"), ProductInput: productInput(request, input)})
+	if err != nil {
+		writeError(writer, request, useCaseStatus(err), err)
+		return
+	}
+
+	render(writer, request, http.StatusOK, productV2Output(product))
+}
+
+// DeleteProduct godoc
+// @Summary Delete product
+// @Description Delete a product
+// @Tags products-v2
+// @Param id path string true "product id" Format(uuid)
+// @Success 204
+// @Failure 404 {object} Error
+// @Failure 500 {object} Error
+// @Router /products/{id} [delete]
+// @Security ApiKeyAuth
+func (productHandler *ProductV2Handler) DeleteProduct(writer http.ResponseWriter, request *http.Request) {
+	err := productHandler.UseCase.Delete(usecase.DeleteProductInput{ID: chi.URLParam(request, "id"), Actor: userIDFromRequest(request)})
+	if err != nil {
+		writeError(writer, request, useCaseStatus(err), err)
+		return
+	}
+
+	writer.WriteHeader(http.StatusNoContent)
+}
+
+// decodeProductV2 lê o corpo da v2 e converte para o mesmo formato da v1
+func decodeProductV2(request *http.Request) (dto.CreateProductInput, int, error) {
+	var input dto.CreateProductV2Input
+	err := decode(request, &input)
+	if err != nil {
+		return dto.CreateProductInput{}, http.StatusBadRequest, fmt.Errorf("%w: %v", ErrInvalidRequestBody, err)
+	}
+
+	if input.Price.Currency != "" && input.Price.Currency != dto.DefaultCurrency {
+		return dto.CreateProductInput{}, http.StatusBadRequest, ErrUnsupportedCurrency
+	}
+
+	return dto.CreateProductInput{
+		Name:     input.Name,
+		Price:    input.Price.Amount,
+		Category: input.Category,
+	}, http.StatusOK, nil
+}
+
+func productV2Output(product *usecase.ProductOutput) dto.ProductV2Output {
+	return dto.ProductV2Output{
+		ID:       product.ID,
+		Name:     product.Name,
+		Price:    dto.Money{Amount: product.Price, Currency: dto.DefaultCurrency},
+		Category: product.Category,
+		Rating: dto.RatingOutput{
+			Average: product.RatingAverage,
+			Count:   product.RatingCount,
+		},
+		CreatedAt: product.CreatedAt,
+		UpdatedAt: product.UpdatedAt,
+	}
+}
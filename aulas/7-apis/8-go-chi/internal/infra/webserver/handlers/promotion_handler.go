@@ -0,0 +1,276 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/dto"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/entity"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/database"
+	"github.com/go-chi/chi"
+	"gorm.io/gorm"
+)
+
+type PromotionHandler struct {
+	CouponDB        database.CouponInterface
+	PromotionRuleDB database.PromotionRuleInterface
+	ProductDB       database.ProductInterface
+}
+
+func NewPromotionHandler(couponDB database.CouponInterface, promotionRuleDB database.PromotionRuleInterface, productDB database.ProductInterface) *PromotionHandler {
+	return &PromotionHandler{
+		CouponDB:        couponDB,
+		PromotionRuleDB: promotionRuleDB,
+		ProductDB:       productDB,
+	}
+}
+
+// CreateCoupon godoc
+// @Summary Create coupon
+// @Description Create a discount coupon. max_uses and min_cart_value equal to zero mean no limit. Admin only
+// @Tags promotions
+// @Accept json,xml,application/msgpack
+// @Produce json,xml,application/msgpack
+// @Param request body dto.CreateCouponInput true "coupon"
+// @Success 201 {object} entity.Coupon
+// @Failure 400 {object} Error
+// @Failure 403 {object} Error
+// @Failure 409 {object} Error
+// @Failure 500 {object} Error
+// @Router /coupons [post]
+// @Security ApiKeyAuth
+func (promotionHandler *PromotionHandler) CreateCoupon(writer http.ResponseWriter, request *http.Request) {
+	var input dto.CreateCouponInput
+	err := decode(request, &input)
+	if err != nil {
+		writeError(writer, request, http.StatusBadRequest, fmt.Errorf("%w: %v", ErrInvalidRequestBody, err))
+		return
+	}
+
+	coupon, err := entity.NewCoupon(
+		input.Code,
+		entity.Discount{DiscountType: input.DiscountType, Value: input.Value},
+		entity.Validity{StartsAt: input.StartsAt, EndsAt: input.EndsAt},
+		input.MaxUses,
+		input.MinCartValue,
+	)
+	if err != nil {
+		writeError(writer, request, http.StatusBadRequest, err)
+		return
+	}
+
+	_, err = promotionHandler.CouponDB.FindByCode(coupon.Code)
+	if err == nil {
+		writeError(writer, request, http.StatusConflict, ErrCouponExists)
+		return
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		writeError(writer, request, http.StatusInternalServerError, err)
+		return
+	}
+
+	err = promotionHandler.CouponDB.Create(coupon)
+	if err != nil {
+		writeError(writer, request, http.StatusInternalServerError, err)
+		return
+	}
+
+	render(writer, request, http.StatusCreated, coupon)
+}
+
+// GetCoupon godoc
+// @Summary Get coupon
+// @Tags promotions
+// @Produce json,xml,application/msgpack
+// @Param code path string true "coupon code"
+// @Success 200 {object} entity.Coupon
+// @Failure 404 {object} Error
+// @Router /coupons/{code} [get]
+// @Security ApiKeyAuth
+func (promotionHandler *PromotionHandler) GetCoupon(writer http.ResponseWriter, request *http.Request) {
+	coupon, err := promotionHandler.CouponDB.FindByCode(chi.URLParam(request, "code"))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		writeError(writer, request, http.StatusNotFound, fmt.Errorf("%w: %v", ErrCouponNotFound, err))
+		return
+	}
+	if err != nil {
+		writeError(writer, request, http.StatusInternalServerError, err)
+		return
+	}
+
+	render(writer, request, http.StatusOK, coupon)
+}
+
+// CreatePromotionRule godoc
+// @Summary Create promotion rule
+// @Description Create an automatic promotion scoped to a product or a category. Fixed discounts apply per unit. Admin only
+// @Tags promotions
+// @Accept json,xml,application/msgpack
+// @Produce json,xml,application/msgpack
+// @Param request body dto.CreatePromotionRuleInput true "promotion rule"
+// @Success 201 {object} entity.PromotionRule
+// @Failure 400 {object} Error
+// @Failure 403 {object} Error
+// @Failure 500 {object} Error
+// @Router /promotions [post]
+// @Security ApiKeyAuth
+func (promotionHandler *PromotionHandler) CreatePromotionRule(writer http.ResponseWriter, request *http.Request) {
+	var input dto.CreatePromotionRuleInput
+	err := decode(request, &input)
+	if err != nil {
+		writeError(writer, request, http.StatusBadRequest, fmt.Errorf("%w: %v", ErrInvalidRequestBody, err))
+		return
+	}
+
+	rule, err := entity.NewPromotionRule(
+		input.Name,
+		input.ProductID,
+		input.Category,
+		entity.Discount{DiscountType: input.DiscountType, Value: input.Value},
+		entity.Validity{StartsAt: input.StartsAt, EndsAt: input.EndsAt},
+	)
+	if err != nil {
+		writeError(writer, request, http.StatusBadRequest, err)
+		return
+	}
+
+	err = promotionHandler.PromotionRuleDB.Create(rule)
+	if err != nil {
+		writeError(writer, request, http.StatusInternalServerError, err)
+		return
+	}
+
+	render(writer, request, http.StatusCreated, rule)
+}
+
+// GetPromotionRules godoc
+// @Summary List active promotion rules
+// @Tags promotions
+// @Produce json,xml,application/msgpack
+// @Success 200 {array} entity.PromotionRule
+// @Failure 500 {object} Error
+// @Router /promotions [get]
+// @Security ApiKeyAuth
+func (promotionHandler *PromotionHandler) GetPromotionRules(writer http.ResponseWriter, request *http.Request) {
+	rules, err := promotionHandler.PromotionRuleDB.FindActive(time.Now())
+	if err != nil {
+		writeError(writer, request, http.StatusInternalServerError, err)
+		return
+	}
+
+	if rules == nil {
+		rules = []*entity.PromotionRule{}
+	}
+
+	render(writer, request, http.StatusOK, rules)
+}
+
+// Evaluate godoc
+// @Summary Evaluate promotions
+// @Description Apply the active promotion rules and an optional coupon to a set of products. Nothing is persisted
+// @Tags promotions
+// @Accept json,xml,application/msgpack
+// @Produce json,xml,application/msgpack
+// @Param request body dto.EvaluatePromotionsInput true "items and coupon"
+// @Success 200 {object} entity.Evaluation
+// @Failure 400 {object} Error
+// @Failure 404 {object} Error
+// @Failure 500 {object} Error
+// @Router /promotions/evaluate [post]
+// @Security ApiKeyAuth
+func (promotionHandler *PromotionHandler) Evaluate(writer http.ResponseWriter, request *http.Request) {
+	evaluation, status, err := promotionHandler.evaluate(request)
+	if err != nil {
+		writeError(writer, request, status, err)
+		return
+	}
+
+	render(writer, request, http.StatusOK, evaluation)
+}
+
+// Redeem godoc
+// @Summary Redeem coupon
+// @Description Same as evaluate, but the coupon is required and its usage counter is incremented. Admin only
+// @Tags promotions
+// @Accept json,xml,application/msgpack
+// @Produce json,xml,application/msgpack
+// @Param request body dto.EvaluatePromotionsInput true "items and coupon"
+// @Success 200 {object} entity.Evaluation
+// @Failure 400 {object} Error
+// @Failure 403 {object} Error
+// @Failure 404 {object} Error
+// @Failure 500 {object} Error
+// @Router /promotions/redeem [post]
+// @Security ApiKeyAuth
+func (promotionHandler *PromotionHandler) Redeem(writer http.ResponseWriter, request *http.Request) {
+	evaluation, status, err := promotionHandler.evaluate(request)
+	if err != nil {
+		writeError(writer, request, status, err)
+		return
+	}
+
+	if evaluation.CouponCode == "" {
+		writeError(writer, request, http.StatusBadRequest, entity.ErrCouponCodeIsRequired)
+		return
+	}
+
+	// A avaliação já conferiu o limite, mas outro pedido pode ter usado o cupom nesse meio tempo
+	err = promotionHandler.CouponDB.Redeem(evaluation.CouponCode)
+	if errors.Is(err, entity.ErrCouponUsageLimitReached) {
+		writeError(writer, request, http.StatusBadRequest, err)
+		return
+	}
+	if err != nil {
+		writeError(writer, request, http.StatusInternalServerError, err)
+		return
+	}
+
+	render(writer, request, http.StatusOK, evaluation)
+}
+
+// evaluate lê o corpo, busca produtos, promoções ativas e o cupom, e devolve o status HTTP em caso de erro
+func (promotionHandler *PromotionHandler) evaluate(request *http.Request) (*entity.Evaluation, int, error) {
+	var input dto.EvaluatePromotionsInput
+	err := decode(request, &input)
+	if err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("%w: %v", ErrInvalidRequestBody, err)
+	}
+
+	items := make([]entity.PricedItem, 0, len(input.Items))
+	for _, item := range input.Items {
+		product, err := promotionHandler.ProductDB.FindByID(item.ProductID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, http.StatusNotFound, fmt.Errorf("%w: %v", ErrProductNotFound, err)
+		}
+		if err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+		items = append(items, entity.PricedItem{Product: product, Quantity: item.Quantity})
+	}
+
+	var coupon *entity.Coupon
+	if input.CouponCode != "" {
+		coupon, err = promotionHandler.CouponDB.FindByCode(input.CouponCode)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, http.StatusNotFound, fmt.Errorf("%w: %v", ErrCouponNotFound, err)
+		}
+		if err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+	}
+
+	now := time.Now()
+	rules, err := promotionHandler.PromotionRuleDB.FindActive(now)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	evaluation, err := entity.Evaluate(items, rules, coupon, now)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	return evaluation, http.StatusOK, nil
+}
@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/database"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvaluateProductLookupErrors(t *testing.T) {
+	body := `{"items":[{"product_id":"6b1a7c52-3f0e-4f4b-9a57-7f0f1c1e2d3a","quantity":1}]}`
+
+	// Produto inexistente continua sendo 404
+	handler := NewPromotionHandler(database.NewCouponMemory(), database.NewPromotionRuleMemory(), database.NewProductMemory())
+	request := httptest.NewRequest(http.MethodPost, "/promotions/evaluate", strings.NewReader(body))
+	request.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	handler.Evaluate(recorder, request)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	// Falhas do banco não podem virar 404
+	handler = NewPromotionHandler(database.NewCouponMemory(), database.NewPromotionRuleMemory(), failingProductDB{database.NewProductMemory()})
+	request = httptest.NewRequest(http.MethodPost, "/promotions/evaluate", strings.NewReader(body))
+	request.Header.Set("Content-Type", "application/json")
+	recorder = httptest.NewRecorder()
+	handler.Evaluate(recorder, request)
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+}
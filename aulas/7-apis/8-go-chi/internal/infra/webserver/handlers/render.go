@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/webserver/content"
+)
+
+// render escreve value no formato pedido pelo Accept. O middleware Negotiate já recusou Accepts
+// sem formato suportado; aqui caímos para JSON caso o handler seja usado sem ele (ex.: respostas de erro 406)
+func render(writer http.ResponseWriter, request *http.Request, status int, value interface{}) {
+	codec, err := content.Negotiate(request.Header.Get("Accept"))
+	if err != nil {
+		codec = content.JSON
+	}
+
+	writer.Header().Set("Content-Type", codec.ContentType())
+	writer.WriteHeader(status)
+	codec.Encode(writer, value)
+}
+
+// decode lê o corpo da requisição de acordo com o Content-Type
+func decode(request *http.Request, value interface{}) error {
+	codec, err := content.ForContentType(request.Header.Get("Content-Type"))
+	if err != nil {
+		return err
+	}
+
+	return codec.Decode(request.Body, value)
+}
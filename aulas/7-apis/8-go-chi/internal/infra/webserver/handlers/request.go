@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/entity"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/featureflag"
+	"github.com/go-chi/jwtauth"
+)
+
+// userIDFromRequest devolve o "sub" do JWT validado pelo middleware, ou vazio para rotas públicas
+func userIDFromRequest(request *http.Request) string {
+	_, claims, err := jwtauth.FromContext(request.Context())
+	if err != nil {
+		return ""
+	}
+
+	sub, _ := claims["sub"].(string)
+	return sub
+}
+
+// isAdmin confere a role do JWT, para rotas que aceitam o dono do recurso ou um admin
+func isAdmin(request *http.Request) bool {
+	_, claims, _ := jwtauth.FromContext(request.Context())
+	role, _ := claims["role"].(string)
+	return role == RoleAdmin
+}
+
+// featureSubject monta quem é avaliado nas feature flags a partir do JWT. Sem token o usuário é anônimo
+func featureSubject(request *http.Request) featureflag.Subject {
+	_, claims, _ := jwtauth.FromContext(request.Context())
+	role, _ := claims["role"].(string)
+
+	return featureflag.Subject{UserID: userIDFromRequest(request), Role: role}
+}
+
+// parsePeriod lê os parâmetros from e to (RFC 3339). Parâmetros ausentes ficam com a data zerada
+func parsePeriod(request *http.Request) (from, to time.Time, err error) {
+	if value := request.URL.Query().Get("from"); value != "" {
+		if from, err = time.Parse(time.RFC3339, value); err != nil {
+			return from, to, entity.ErrInvalidPeriod
+		}
+	}
+
+	if value := request.URL.Query().Get("to"); value != "" {
+		if to, err = time.Parse(time.RFC3339, value); err != nil {
+			return from, to, entity.ErrInvalidPeriod
+		}
+	}
+
+	if !from.IsZero() && !to.IsZero() && to.Before(from) {
+		return from, to, entity.ErrInvalidPeriod
+	}
+
+	return from, to, nil
+}
+
+// pagination lê os parâmetros page e limit. Valores ausentes ou inválidos viram 0, que o repositório trata como "sem paginação"
+func pagination(request *http.Request) (page, limit int) {
+	page, err := strconv.Atoi(request.URL.Query().Get("page"))
+	if err != nil {
+		page = 0
+	}
+
+	limit, err = strconv.Atoi(request.URL.Query().Get("limit"))
+	if err != nil {
+		limit = 0
+	}
+
+	return page, limit
+}
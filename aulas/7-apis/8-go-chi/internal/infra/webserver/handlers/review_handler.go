@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/dto"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/entity"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/database"
+	"github.com/go-chi/chi"
+	"gorm.io/gorm"
+)
+
+type ReviewHandler struct {
+	ReviewDB  database.ReviewInterface
+	ProductDB database.ProductInterface
+}
+
+func NewReviewHandler(reviewDB database.ReviewInterface, productDB database.ProductInterface) *ReviewHandler {
+	return &ReviewHandler{
+		ReviewDB:  reviewDB,
+		ProductDB: productDB,
+	}
+}
+
+// GetReviews godoc
+// @Summary List product reviews
+// @Description List the reviews of a product, newest first
+// @Tags reviews
+// @Produce json,xml,application/msgpack
+// @Param id path string true "product id" Format(uuid)
+// @Param page query int false "page number" minimum(1)
+// @Param limit query int false "limit" minimum(1)
+// @Success 200 {array} entity.Review
+// @Failure 404 {object} Error
+// @Failure 500 {object} Error
+// @Router /products/{id}/reviews [get]
+// @Security ApiKeyAuth
+func (reviewHandler *ReviewHandler) GetReviews(writer http.ResponseWriter, request *http.Request) {
+	id := chi.URLParam(request, "id")
+
+	page, err := strconv.Atoi(request.URL.Query().Get("page"))
+	if err != nil {
+		page = 0
+	}
+
+	limit, err := strconv.Atoi(request.URL.Query().Get("limit"))
+	if err != nil {
+		limit = 0
+	}
+
+	_, err = reviewHandler.ProductDB.FindByID(id)
+	if err != nil {
+		writeError(writer, request, http.StatusNotFound, fmt.Errorf("%w: %v", ErrProductNotFound, err))
+		return
+	}
+
+	reviews, err := reviewHandler.ReviewDB.FindByProductID(id, page, limit)
+	if err != nil {
+		writeError(writer, request, http.StatusInternalServerError, err)
+		return
+	}
+
+	render(writer, request, http.StatusOK, reviews)
+}
+
+// CreateReview godoc
+// @Summary Review a product
+// @Description Post a 1-5 rating for a product. Each user can review a product only once
+// @Tags reviews
+// @Accept json,xml,application/msgpack
+// @Produce json,xml,application/msgpack
+// @Param id path string true "product id" Format(uuid)
+// @Param request body dto.ReviewInput true "review"
+// @Success 201 {object} entity.Review
+// @Failure 400 {object} Error
+// @Failure 404 {object} Error
+// @Failure 409 {object} Error
+// @Failure 500 {object} Error
+// @Router /products/{id}/reviews [post]
+// @Security ApiKeyAuth
+func (reviewHandler *ReviewHandler) CreateReview(writer http.ResponseWriter, request *http.Request) {
+	id := chi.URLParam(request, "id")
+	userID := userIDFromRequest(request)
+
+	var input dto.ReviewInput
+	err := decode(request, &input)
+	if err != nil {
+		writeError(writer, request, http.StatusBadRequest, fmt.Errorf("%w: %v", ErrInvalidRequestBody, err))
+		return
+	}
+
+	_, err = reviewHandler.ProductDB.FindByID(id)
+	if err != nil {
+		writeError(writer, request, http.StatusNotFound, fmt.Errorf("%w: %v", ErrProductNotFound, err))
+		return
+	}
+
+	review, err := entity.NewReview(id, userID, input.Rating, input.Comment)
+	if err != nil {
+		writeError(writer, request, http.StatusBadRequest, err)
+		return
+	}
+
+	_, err = reviewHandler.ReviewDB.FindByProductAndUser(id, userID)
+	if err == nil {
+		writeError(writer, request, http.StatusConflict, ErrReviewExists)
+		return
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		writeError(writer, request, http.StatusInternalServerError, err)
+		return
+	}
+
+	err = reviewHandler.ReviewDB.Create(review)
+	if err != nil {
+		writeError(writer, request, http.StatusInternalServerError, err)
+		return
+	}
+
+	render(writer, request, http.StatusCreated, review)
+}
+
+// UpdateReview godoc
+// @Summary Edit a review
+// @Description Edit your own review
+// @Tags reviews
+// @Accept json,xml,application/msgpack
+// @Produce json,xml,application/msgpack
+// @Param id path string true "product id" Format(uuid)
+// @Param reviewId path string true "review id" Format(uuid)
+// @Param request body dto.ReviewInput true "review"
+// @Success 200 {object} entity.Review
+// @Failure 400 {object} Error
+// @Failure 403 {object} Error
+// @Failure 404 {object} Error
+// @Failure 500 {object} Error
+// @Router /products/{id}/reviews/{reviewId} [put]
+// @Security ApiKeyAuth
+func (reviewHandler *ReviewHandler) UpdateReview(writer http.ResponseWriter, request *http.Request) {
+	var input dto.ReviewInput
+	err := decode(request, &input)
+	if err != nil {
+		writeError(writer, request, http.StatusBadRequest, fmt.Errorf("%w: %v", ErrInvalidRequestBody, err))
+		return
+	}
+
+	review, status, err := reviewHandler.findOwnReview(request)
+	if err != nil {
+		writeError(writer, request, status, err)
+		return
+	}
+
+	err = review.Edit(input.Rating, input.Comment)
+	if err != nil {
+		writeError(writer, request, http.StatusBadRequest, err)
+		return
+	}
+
+	err = reviewHandler.ReviewDB.Update(review)
+	if err != nil {
+		writeError(writer, request, http.StatusInternalServerError, err)
+		return
+	}
+
+	render(writer, request, http.StatusOK, review)
+}
+
+// DeleteReview godoc
+// @Summary Delete a review
+// @Description Delete your own review
+// @Tags reviews
+// @Param id path string true "product id" Format(uuid)
+// @Param reviewId path string true "review id" Format(uuid)
+// @Success 200
+// @Failure 403 {object} Error
+// @Failure 404 {object} Error
+// @Failure 500 {object} Error
+// @Router /products/{id}/reviews/{reviewId} [delete]
+// @Security ApiKeyAuth
+func (reviewHandler *ReviewHandler) DeleteReview(writer http.ResponseWriter, request *http.Request) {
+	review, status, err := reviewHandler.findOwnReview(request)
+	if err != nil {
+		writeError(writer, request, status, err)
+		return
+	}
+
+	err = reviewHandler.ReviewDB.Delete(review)
+	if err != nil {
+		writeError(writer, request, http.StatusInternalServerError, err)
+		return
+	}
+
+	writer.WriteHeader(http.StatusOK)
+}
+
+// findOwnReview busca a review da URL garantindo que pertence ao produto e ao usuário autenticado
+func (reviewHandler *ReviewHandler) findOwnReview(request *http.Request) (*entity.Review, int, error) {
+	review, err := reviewHandler.ReviewDB.FindByID(chi.URLParam(request, "reviewId"))
+	if err != nil {
+		return nil, http.StatusNotFound, fmt.Errorf("%w: %v", ErrReviewNotFound, err)
+	}
+
+	if review.ProductID != chi.URLParam(request, "id") {
+		return nil, http.StatusNotFound, ErrReviewNotFound
+	}
+
+	if review.UserID != userIDFromRequest(request) {
+		return nil, http.StatusForbidden, ErrForbidden
+	}
+
+	return review, http.StatusOK, nil
+}
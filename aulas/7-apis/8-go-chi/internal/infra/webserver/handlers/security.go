@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultContentSecurityPolicy serve para uma API que só devolve dados: nada pode ser carregado ou embutido
+	DefaultContentSecurityPolicy = "default-src 'none'; frame-ancestors 'none'"
+	DefaultMaxBodyBytes          = 1 << 20
+)
+
+var (
+	defaultCORSMethods = []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete}
+	defaultCORSHeaders = []string{"Accept", "Accept-Language", "Authorization", "Content-Type"}
+	// Headers que o JavaScript do navegador só consegue ler se forem expostos
+	defaultCORSExposedHeaders = []string{"Location", "Content-Language", "Deprecation", "Sunset", "Link"}
+)
+
+// CORSOptions libera a API para as origens listadas. Sem AllowedOrigins nenhum header CORS é enviado.
+// Uma origem pode ser "*" ou ter curinga no subdomínio (ex.: https://*.minhaloja.com.br).
+// As origens liberadas só pelo "*" nunca recebem credenciais, mesmo com AllowCredentials
+type CORSOptions struct {
+	AllowedOrigins []string
+	// Campos vazios usam os métodos e headers que a API realmente aceita
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	// MaxAge é por quanto tempo o navegador pode guardar a resposta do preflight
+	MaxAge time.Duration
+}
+
+// allowOrigin devolve o valor do Access-Control-Allow-Origin para origin, vazio quando ela não é liberada.
+// As origens listadas voltam explicitamente; as que só casam com "*" recebem "*"
+func (options CORSOptions) allowOrigin(origin string) string {
+	wildcard := false
+	for _, allowed := range options.AllowedOrigins {
+		if allowed == "*" {
+			wildcard = true
+			continue
+		}
+		if strings.EqualFold(allowed, origin) {
+			return origin
+		}
+
+		// https://*.minhaloja.com.br aceita https://www.minhaloja.com.br, mas não https://minhaloja.com.br
+		if prefix, suffix, ok := strings.Cut(allowed, "*"); ok {
+			origin := strings.ToLower(origin)
+			if strings.HasPrefix(origin, strings.ToLower(prefix)) && strings.HasSuffix(origin, strings.ToLower(suffix)) &&
+				len(origin) > len(prefix)+len(suffix) {
+				return origin
+			}
+		}
+	}
+
+	if wildcard {
+		return "*"
+	}
+
+	return ""
+}
+
+// CORS precisa ficar antes da autenticação: o preflight (OPTIONS) do navegador não leva o token
+func CORS(options CORSOptions) func(http.Handler) http.Handler {
+	if len(options.AllowedMethods) == 0 {
+		options.AllowedMethods = defaultCORSMethods
+	}
+	if len(options.AllowedHeaders) == 0 {
+		options.AllowedHeaders = defaultCORSHeaders
+	}
+	if len(options.ExposedHeaders) == 0 {
+		options.ExposedHeaders = defaultCORSExposedHeaders
+	}
+
+	return func(next http.Handler) http.Handler {
+		if len(options.AllowedOrigins) == 0 {
+			return next
+		}
+
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			origin := request.Header.Get("Origin")
+			preflight := request.Method == http.MethodOptions && request.Header.Get("Access-Control-Request-Method") != ""
+
+			// A resposta muda conforme a origem, então caches intermediários não podem reaproveitá-la entre origens
+			writer.Header().Add("Vary", "Origin")
+			allowOrigin := ""
+			if origin != "" {
+				allowOrigin = options.allowOrigin(origin)
+			}
+			if allowOrigin == "" {
+				if preflight {
+					// Sem os headers CORS o navegador bloqueia a requisição de verdade
+					writer.WriteHeader(http.StatusNoContent)
+					return
+				}
+
+				next.ServeHTTP(writer, request)
+				return
+			}
+
+			writer.Header().Set("Access-Control-Allow-Origin", allowOrigin)
+			// Devolver a origem de qualquer site junto com credenciais deixaria qualquer página fazer
+			// requisições autenticadas (o token também vem do cookie), então "*" fica sem credenciais
+			if options.AllowCredentials && allowOrigin != "*" {
+				writer.Header().Set("Access-Control-Allow-Credentials", "true")
+			}
+
+			if !preflight {
+				writer.Header().Set("Access-Control-Expose-Headers", strings.Join(options.ExposedHeaders, ", "))
+				next.ServeHTTP(writer, request)
+				return
+			}
+
+			writer.Header().Add("Vary", "Access-Control-Request-Method")
+			writer.Header().Add("Vary", "Access-Control-Request-Headers")
+			writer.Header().Set("Access-Control-Allow-Methods", strings.Join(options.AllowedMethods, ", "))
+			writer.Header().Set("Access-Control-Allow-Headers", strings.Join(options.AllowedHeaders, ", "))
+			if options.MaxAge > 0 {
+				writer.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(options.MaxAge.Seconds())))
+			}
+			writer.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
+
+// SecurityOptions controla os headers de proteção enviados em todas as respostas.
+// X-Content-Type-Options, X-Frame-Options e Referrer-Policy são sempre enviados
+type SecurityOptions struct {
+	// HSTSMaxAge zerado não envia o Strict-Transport-Security (ex.: desenvolvimento sem HTTPS)
+	HSTSMaxAge            time.Duration
+	HSTSIncludeSubdomains bool
+	// ContentSecurityPolicy vazio usa DefaultContentSecurityPolicy
+	ContentSecurityPolicy string
+	// CSPExemptPaths são prefixos que ficam sem CSP, como o Swagger UI, que depende de scripts e estilos inline
+	CSPExemptPaths []string
+}
+
+func SecurityHeaders(options SecurityOptions) func(http.Handler) http.Handler {
+	if options.ContentSecurityPolicy == "" {
+		options.ContentSecurityPolicy = DefaultContentSecurityPolicy
+	}
+
+	hsts := ""
+	if options.HSTSMaxAge > 0 {
+		hsts = fmt.Sprintf("max-age=%d", int(options.HSTSMaxAge.Seconds()))
+		if options.HSTSIncludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			header := writer.Header()
+			header.Set("X-Content-Type-Options", "nosniff")
+			header.Set("X-Frame-Options", "DENY")
+			header.Set("Referrer-Policy", "no-referrer")
+			if hsts != "" {
+				header.Set("Strict-Transport-Security", hsts)
+			}
+			if !hasAnyPrefix(request.URL.Path, options.CSPExemptPaths) {
+				header.Set("Content-Security-Policy", options.ContentSecurityPolicy)
+			}
+
+			next.ServeHTTP(writer, request)
+		})
+	}
+}
+
+// BodyLimit recusa com 413 corpos maiores que maxBytes. Zero desliga o limite
+func BodyLimit(maxBytes int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if maxBytes <= 0 {
+			return next
+		}
+
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			if request.ContentLength > maxBytes {
+				writeError(writer, request, http.StatusRequestEntityTooLarge, ErrRequestBodyTooLarge)
+				return
+			}
+
+			// Sem Content-Length (chunked) o limite é aplicado durante a leitura
+			request.Body = http.MaxBytesReader(writer, request.Body, maxBytes)
+			next.ServeHTTP(writer, request)
+		})
+	}
+}
+
+func hasAnyPrefix(path string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/i18n"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func ok(writer http.ResponseWriter, request *http.Request) {
+	writer.WriteHeader(http.StatusOK)
+}
+
+func TestCORSAllowsListedOrigins(t *testing.T) {
+	cors := CORS(CORSOptions{AllowedOrigins: []string{"https://loja.com.br", "https://*.loja.com.br"}})(http.HandlerFunc(ok))
+
+	for origin, allowed := range map[string]bool{
+		"https://loja.com.br":      true,
+		"https://LOJA.com.br":      true,
+		"https://www.loja.com.br":  true,
+		"https://.loja.com.br":     false,
+		"https://evil.com":         false,
+		"https://loja.com.br.evil": false,
+	} {
+		request := httptest.NewRequest(http.MethodGet, "/products", nil)
+		request.Header.Set("Origin", origin)
+		recorder := httptest.NewRecorder()
+		cors.ServeHTTP(recorder, request)
+
+		assert.Equal(t, http.StatusOK, recorder.Code, origin)
+		assert.Contains(t, recorder.Header().Values("Vary"), "Origin", origin)
+		if allowed {
+			assert.Equal(t, origin, recorder.Header().Get("Access-Control-Allow-Origin"), origin)
+			assert.Contains(t, recorder.Header().Get("Access-Control-Expose-Headers"), "Location", origin)
+		} else {
+			assert.Empty(t, recorder.Header().Get("Access-Control-Allow-Origin"), origin)
+		}
+	}
+}
+
+func TestCORSPreflight(t *testing.T) {
+	called := false
+	handler := func(writer http.ResponseWriter, request *http.Request) { called = true }
+	cors := CORS(CORSOptions{AllowedOrigins: []string{"https://loja.com.br"}, AllowCredentials: true, MaxAge: 10 * time.Minute})(http.HandlerFunc(handler))
+
+	request := httptest.NewRequest(http.MethodOptions, "/products", nil)
+	request.Header.Set("Origin", "https://loja.com.br")
+	request.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	recorder := httptest.NewRecorder()
+	cors.ServeHTTP(recorder, request)
+
+	assert.False(t, called)
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+	assert.Equal(t, "https://loja.com.br", recorder.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "true", recorder.Header().Get("Access-Control-Allow-Credentials"))
+	assert.Equal(t, "GET, POST, PUT, DELETE", recorder.Header().Get("Access-Control-Allow-Methods"))
+	assert.Contains(t, recorder.Header().Get("Access-Control-Allow-Headers"), "Authorization")
+	assert.Equal(t, "600", recorder.Header().Get("Access-Control-Max-Age"))
+}
+
+func TestCORSWildcardNeverAllowsCredentials(t *testing.T) {
+	cors := CORS(CORSOptions{AllowedOrigins: []string{"https://loja.com.br", "*"}, AllowCredentials: true})(http.HandlerFunc(ok))
+
+	request := httptest.NewRequest(http.MethodGet, "/products", nil)
+	request.Header.Set("Origin", "https://evil.com")
+	recorder := httptest.NewRecorder()
+	cors.ServeHTTP(recorder, request)
+
+	assert.Equal(t, "*", recorder.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, recorder.Header().Get("Access-Control-Allow-Credentials"))
+
+	// Origens listadas continuam com credenciais
+	request.Header.Set("Origin", "https://loja.com.br")
+	recorder = httptest.NewRecorder()
+	cors.ServeHTTP(recorder, request)
+
+	assert.Equal(t, "https://loja.com.br", recorder.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "true", recorder.Header().Get("Access-Control-Allow-Credentials"))
+}
+
+func TestCORSWithoutOriginsIsDisabled(t *testing.T) {
+	request := httptest.NewRequest(http.MethodGet, "/products", nil)
+	request.Header.Set("Origin", "https://loja.com.br")
+	recorder := httptest.NewRecorder()
+	CORS(CORSOptions{})(http.HandlerFunc(ok)).ServeHTTP(recorder, request)
+
+	assert.Empty(t, recorder.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, recorder.Header().Get("Vary"))
+}
+
+func TestSecurityHeaders(t *testing.T) {
+	security := SecurityHeaders(SecurityOptions{
+		HSTSMaxAge:            365 * 24 * time.Hour,
+		HSTSIncludeSubdomains: true,
+		CSPExemptPaths:        []string{"/docs/"},
+	})(http.HandlerFunc(ok))
+
+	recorder := httptest.NewRecorder()
+	security.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/products", nil))
+	assert.Equal(t, "nosniff", recorder.Header().Get("X-Content-Type-Options"))
+	assert.Equal(t, "DENY", recorder.Header().Get("X-Frame-Options"))
+	assert.Equal(t, "no-referrer", recorder.Header().Get("Referrer-Policy"))
+	assert.Equal(t, "max-age=31536000; includeSubDomains", recorder.Header().Get("Strict-Transport-Security"))
+	assert.Equal(t, DefaultContentSecurityPolicy, recorder.Header().Get("Content-Security-Policy"))
+
+	recorder = httptest.NewRecorder()
+	security.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/docs/index.html", nil))
+	assert.Empty(t, recorder.Header().Get("Content-Security-Policy"))
+	assert.Equal(t, "nosniff", recorder.Header().Get("X-Content-Type-Options"))
+
+	// Sem HSTSMaxAge o header não é enviado
+	recorder = httptest.NewRecorder()
+	SecurityHeaders(SecurityOptions{})(http.HandlerFunc(ok)).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/products", nil))
+	assert.Empty(t, recorder.Header().Get("Strict-Transport-Security"))
+}
+
+func TestBodyLimit(t *testing.T) {
+	reader := func(writer http.ResponseWriter, request *http.Request) {
+		if _, err := io.ReadAll(request.Body); err != nil {
+			writeError(writer, request, http.StatusBadRequest, err)
+			return
+		}
+		writer.WriteHeader(http.StatusOK)
+	}
+	limit := BodyLimit(8)(http.HandlerFunc(reader))
+
+	recorder := httptest.NewRecorder()
+	limit.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/products", strings.NewReader("12345678")))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	limit.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/products", strings.NewReader("123456789")))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+
+	var output Error
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &output))
+	assert.Equal(t, i18n.CodeRequestBodyTooLarge, output.Code)
+
+	// Sem Content-Length o limite só aparece durante a leitura
+	request := httptest.NewRequest(http.MethodPost, "/products", strings.NewReader("123456789"))
+	request.ContentLength = -1
+	recorder = httptest.NewRecorder()
+	limit.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+}
@@ -1,96 +1,150 @@
 package handlers
 
 import (
-	"encoding/json"
+	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/dto"
-	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/entity"
-	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/database"
-	"github.com/go-chi/jwtauth"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/usecase"
 )
 
-type Error struct {
-	Message string `json:"message"`
-}
-
 type UserHandler struct {
-	UserDB       database.UserInterface
-	JWT          *jwtauth.JWTAuth
-	JWTExpiresIn int
+	UseCase *usecase.UserUseCase
 }
 
-func NewUserHandler(db database.UserInterface, jwt *jwtauth.JWTAuth, jwtExpiresIn int) *UserHandler {
-	return &UserHandler{
-		UserDB:       db,
-		JWT:          jwt,
-		JWTExpiresIn: jwtExpiresIn,
-	}
+func NewUserHandler(useCase *usecase.UserUseCase) *UserHandler {
+	return &UserHandler{UseCase: useCase}
 }
 
 // GetJWT godoc
 // @Summary Get a user JWT
-// @Description Get a user JWT
+// @Description Get a user JWT. Users with two-factor authentication receive a challenge token (202) to be exchanged in /users/generate-token/totp
 // @Tags users
-// @Accept json
-// @Produce json
+// @Accept json,xml,application/msgpack
+// @Produce json,xml,application/msgpack
 // @Param request body dto.GetJWTInput true "user credentials"
 // @Success 200 {object} dto.GetJWTOutput
+// @Success 202 {object} dto.TOTPChallengeOutput
 // @Failure 400 {object} Error
 // @Failure 401 {object} Error
 // @Router /users/generate-token [post]
 func (userHandler *UserHandler) GetJWT(writer http.ResponseWriter, request *http.Request) {
 	var userJwtDto dto.GetJWTInput
-	err := json.NewDecoder(request.Body).Decode(&userJwtDto)
+	err := decode(request, &userJwtDto)
 	if err != nil {
-		writer.WriteHeader(http.StatusBadRequest)
+		writeError(writer, request, http.StatusBadRequest, fmt.Errorf("%w: %v", ErrInvalidRequestBody, err))
+		return
+	}
 
-		error := Error{Message: err.Error()}
-		json.NewEncoder(writer).Encode(error)
+	output, err := userHandler.UseCase.Login(usecase.LoginInput{Email: userJwtDto.Email, Password: userJwtDto.Password})
+	renderLogin(writer, request, output, err)
+}
 
+// renderLogin responde 200 com o token de acesso ou 202 com o desafio do segundo fator
+func renderLogin(writer http.ResponseWriter, request *http.Request, output *usecase.LoginOutput, err error) {
+	if err != nil {
+		writeError(writer, request, useCaseStatus(err), err)
 		return
 	}
 
-	// Buscar o user pelo email
-	user, err := userHandler.UserDB.FindByEmail(userJwtDto.Email)
-	if err != nil {
-		writer.WriteHeader(http.StatusUnauthorized)
+	if output.ChallengeToken != "" {
+		render(writer, request, http.StatusAccepted, dto.TOTPChallengeOutput{
+			ChallengeToken: output.ChallengeToken,
+			ExpiresIn:      int(output.ChallengeExpiresIn / time.Second),
+		})
+		return
+	}
 
-		error := Error{Message: err.Error()}
-		json.NewEncoder(writer).Encode(error)
+	render(writer, request, http.StatusOK, dto.GetJWTOutput{AccessToken: output.AccessToken})
+}
 
+// GetJWTWithTOTP godoc
+// @Summary Get a user JWT with a two-factor code
+// @Description Exchange the challenge token returned by /users/generate-token and a TOTP or recovery code for a user JWT
+// @Tags users
+// @Accept json,xml,application/msgpack
+// @Produce json,xml,application/msgpack
+// @Param request body dto.TOTPLoginInput true "challenge token and code"
+// @Success 200 {object} dto.GetJWTOutput
+// @Failure 400 {object} Error
+// @Failure 401 {object} Error
+// @Failure 429 {object} Error
+// @Router /users/generate-token/totp [post]
+func (userHandler *UserHandler) GetJWTWithTOTP(writer http.ResponseWriter, request *http.Request) {
+	var input dto.TOTPLoginInput
+	err := decode(request, &input)
+	if err != nil {
+		writeError(writer, request, http.StatusBadRequest, fmt.Errorf("%w: %v", ErrInvalidRequestBody, err))
 		return
 	}
 
-	// Validar a senha
-	if !user.IsPasswordValid(userJwtDto.Password) {
-		writer.WriteHeader(http.StatusUnauthorized)
-
-		error := Error{Message: err.Error()}
-		json.NewEncoder(writer).Encode(error)
+	output, err := userHandler.UseCase.LoginWithTOTP(usecase.TOTPLoginInput{ChallengeToken: input.ChallengeToken, Code: input.Code})
+	renderLogin(writer, request, output, err)
+}
 
+// EnrollTOTP godoc
+// @Summary Enroll in two-factor authentication
+// @Description Generate a TOTP secret and recovery codes for the authenticated user. The recovery codes are shown only once. Confirm with /users/totp/confirm to enable it
+// @Tags users
+// @Produce json,xml,application/msgpack
+// @Success 200 {object} dto.TOTPEnrollmentOutput
+// @Failure 401 {object} Error
+// @Failure 409 {object} Error
+// @Failure 500 {object} Error
+// @Router /users/totp/enroll [post]
+// @Security ApiKeyAuth
+func (userHandler *UserHandler) EnrollTOTP(writer http.ResponseWriter, request *http.Request) {
+	output, err := userHandler.UseCase.EnrollTOTP(userIDFromRequest(request))
+	if err != nil {
+		writeError(writer, request, useCaseStatus(err), err)
 		return
 	}
 
-	_, tokenString, _ := userHandler.JWT.Encode(map[string]interface{}{
-		"sub": user.ID.String(),
-		"exp": time.Now().Add(time.Second * time.Duration(userHandler.JWTExpiresIn)).Unix(),
+	render(writer, request, http.StatusOK, dto.TOTPEnrollmentOutput{
+		Secret:          output.Secret,
+		ProvisioningURI: output.ProvisioningURI,
+		RecoveryCodes:   output.RecoveryCodes,
 	})
+}
 
-	accessToken := dto.GetJWTOutput{AccessToken: tokenString}
+// ConfirmTOTP godoc
+// @Summary Confirm two-factor authentication
+// @Description Enable two-factor authentication with a code generated by the authenticator app
+// @Tags users
+// @Accept json,xml,application/msgpack
+// @Produce json,xml,application/msgpack
+// @Param request body dto.TOTPCodeInput true "code from the authenticator app"
+// @Success 200
+// @Failure 400 {object} Error
+// @Failure 401 {object} Error
+// @Failure 409 {object} Error
+// @Failure 500 {object} Error
+// @Router /users/totp/confirm [post]
+// @Security ApiKeyAuth
+func (userHandler *UserHandler) ConfirmTOTP(writer http.ResponseWriter, request *http.Request) {
+	var input dto.TOTPCodeInput
+	err := decode(request, &input)
+	if err != nil {
+		writeError(writer, request, http.StatusBadRequest, fmt.Errorf("%w: %v", ErrInvalidRequestBody, err))
+		return
+	}
+
+	err = userHandler.UseCase.ConfirmTOTP(usecase.ConfirmTOTPInput{UserID: userIDFromRequest(request), Code: input.Code})
+	if err != nil {
+		writeError(writer, request, useCaseStatus(err), err)
+		return
+	}
 
-	writer.Header().Set("Content-Type", "application/json")
 	writer.WriteHeader(http.StatusOK)
-	json.NewEncoder(writer).Encode(accessToken)
 }
 
 // CreateUser godoc
 // @Summary Create user
 // @Description Create user
 // @Tags users
-// @Accept json
-// @Produce json
+// @Accept json,xml,application/msgpack
+// @Produce json,xml,application/msgpack
 // @Param request body dto.CreateUserInput true "user request"
 // @Success 201
 // @Failure 400 {object} Error
@@ -100,35 +154,20 @@ func (userHandler *UserHandler) CreateUser(writer http.ResponseWriter, request *
 	// Lê o body da request
 	// Converte o body para um struct dto
 	var userDto dto.CreateUserInput
-	err := json.NewDecoder(request.Body).Decode(&userDto)
+	err := decode(request, &userDto)
 	if err != nil {
-		writer.WriteHeader(http.StatusBadRequest)
-
-		error := Error{Message: err.Error()}
-		json.NewEncoder(writer).Encode(error)
-
+		writeError(writer, request, http.StatusBadRequest, fmt.Errorf("%w: %v", ErrInvalidRequestBody, err))
 		return
 	}
 
-	// Fazer diretamente acesso da entidade no coração não é comum. Em vez disso, usaremos no futuro um use case(clean arch)
-	user, err := entity.NewUser(userDto.Name, userDto.Email, userDto.Password)
-	if err != nil {
-		writer.WriteHeader(http.StatusBadRequest)
-
-		error := Error{Message: err.Error()}
-		json.NewEncoder(writer).Encode(error)
-
-		return
-	}
-
-	// Persistir o user
-	err = userHandler.UserDB.Create(user)
+	_, err = userHandler.UseCase.Create(usecase.CreateUserInput{
+		Name:     userDto.Name,
+		Email:    userDto.Email,
+		Password: userDto.Password,
+		Actor:    userIDFromRequest(request),
+	})
 	if err != nil {
-		writer.WriteHeader(http.StatusInternalServerError)
-
-		error := Error{Message: err.Error()}
-		json.NewEncoder(writer).Encode(error)
-
+		writeError(writer, request, useCaseStatus(err), err)
 		return
 	}
 
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/webserver/content"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/webserver/openapi"
+)
+
+// ValidateSpec recusa com 400 as requisições que não seguem o spec da versão (corpo, query e path).
+// Rotas que não estão no spec passam direto. Com ValidateResponses, usado nos testes, a resposta também
+// é conferida e, se quebrar o contrato, é trocada por um 500 com as violações
+func ValidateSpec(validator *openapi.Validator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if validator == nil {
+			return next
+		}
+
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			operation := validator.FindOperation(request)
+			if operation == nil {
+				next.ServeHTTP(writer, request)
+				return
+			}
+
+			// Sem Content-Type o corpo é lido como JSON (ver content.ForContentType), e o spec precisa saber disso
+			hasBody := request.ContentLength > 0 || len(request.TransferEncoding) > 0
+			if hasBody && request.Header.Get("Content-Type") == "" {
+				request.Header.Set("Content-Type", content.MIMEJSON)
+			}
+
+			// O corpo é lido aqui para que um estouro do BodyLimit vire 413, e não uma violação do spec
+			if hasBody {
+				data, err := io.ReadAll(request.Body)
+				if err != nil {
+					writeError(writer, request, http.StatusBadRequest, fmt.Errorf("%w: %w", ErrInvalidRequestBody, err))
+					return
+				}
+				request.Body = io.NopCloser(bytes.NewReader(data))
+			}
+
+			if err := operation.ValidateRequest(request.Context()); err != nil {
+				writeError(writer, request, http.StatusBadRequest, fmt.Errorf("%w: %w", ErrRequestValidation, err))
+				return
+			}
+
+			if !validator.ValidateResponses() {
+				next.ServeHTTP(writer, request)
+				return
+			}
+
+			recorder := newResponseRecorder()
+			next.ServeHTTP(recorder, request)
+
+			if err := operation.ValidateResponse(request.Context(), recorder.status, recorder.header, recorder.body.Bytes()); err != nil {
+				writeError(writer, request, http.StatusInternalServerError, fmt.Errorf("%w: %w", ErrResponseValidation, err))
+				return
+			}
+
+			recorder.flush(writer)
+		})
+	}
+}
+
+// responseRecorder guarda a resposta em memória para que ela possa ser validada antes de ir para o cliente
+type responseRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func newResponseRecorder() *responseRecorder {
+	return &responseRecorder{header: http.Header{}, status: http.StatusOK}
+}
+
+func (r *responseRecorder) Header() http.Header {
+	return r.header
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	r.status = status
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	return r.body.Write(data)
+}
+
+func (r *responseRecorder) flush(writer http.ResponseWriter) {
+	for key, values := range r.header {
+		writer.Header()[key] = values
+	}
+
+	writer.WriteHeader(r.status)
+	writer.Write(r.body.Bytes())
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/i18n"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/webserver/openapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const itemsSpec = `{
+	"swagger": "2.0",
+	"info": {"title": "Items", "version": "1.0"},
+	"paths": {
+		"/items": {
+			"post": {
+				"consumes": ["application/json"],
+				"produces": ["application/json"],
+				"parameters": [{"name": "item", "in": "body", "required": true, "schema": {
+					"type": "object", "required": ["name"], "properties": {"name": {"type": "string", "minLength": 1}}
+				}}],
+				"responses": {"201": {"description": "Created", "schema": {
+					"type": "object", "required": ["id"], "properties": {"id": {"type": "string"}}
+				}}}
+			}
+		}
+	}
+}`
+
+func serveWithSpec(t *testing.T, options openapi.Options, handler http.HandlerFunc, request *http.Request) *httptest.ResponseRecorder {
+	validator, err := openapi.NewValidator(itemsSpec, options)
+	require.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	ValidateSpec(validator)(handler).ServeHTTP(recorder, request)
+	return recorder
+}
+
+func created(body string) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Type", "application/json")
+		writer.WriteHeader(http.StatusCreated)
+		writer.Write([]byte(body))
+	}
+}
+
+func TestValidateSpecRejectsInvalidRequests(t *testing.T) {
+	called := false
+	handler := func(writer http.ResponseWriter, request *http.Request) { called = true }
+
+	// Sem Content-Type o corpo é tratado como JSON
+	recorder := serveWithSpec(t, openapi.Options{}, handler, httptest.NewRequest(http.MethodPost, "/items", strings.NewReader(`{"name": ""}`)))
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.False(t, called)
+
+	var body Error
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+	assert.Equal(t, i18n.CodeValidationFailed, body.Code)
+	assert.Equal(t, []openapi.Violation{{In: openapi.InBody, Field: "name", Reason: "minimum string length is 1"}}, body.Details)
+
+	// Rotas fora do spec não são validadas
+	recorder = serveWithSpec(t, openapi.Options{}, handler, httptest.NewRequest(http.MethodGet, "/health", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.True(t, called)
+}
+
+func TestValidateSpecResponses(t *testing.T) {
+	request := func() *http.Request {
+		return httptest.NewRequest(http.MethodPost, "/items", strings.NewReader(`{"name": "Item"}`))
+	}
+
+	recorder := serveWithSpec(t, openapi.Options{ValidateResponses: true}, created(`{"id": "1"}`), request())
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"id": "1"}`, recorder.Body.String())
+
+	recorder = serveWithSpec(t, openapi.Options{ValidateResponses: true}, created(`{}`), request())
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+
+	var body Error
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+	assert.Equal(t, i18n.CodeResponseValidationFailed, body.Code)
+	assert.Equal(t, []openapi.Violation{{In: openapi.InBody, Field: "id", Reason: `property "id" is missing`}}, body.Details)
+
+	// Fora do modo de teste a resposta não é conferida
+	recorder = serveWithSpec(t, openapi.Options{}, created(`{}`), request())
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+}
+
+func TestValidateSpecWithoutValidator(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	ValidateSpec(nil)(created(`{}`)).ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/items", nil))
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// DeprecationPolicy descreve o ciclo de vida de uma versão da API. Sem DeprecatedAt a versão está ativa
+type DeprecationPolicy struct {
+	DeprecatedAt time.Time
+	Sunset       time.Time
+	// Successor é o caminho da versão que substitui esta (ex.: /v2), enviado no header Link
+	Successor string
+}
+
+func (policy DeprecationPolicy) Deprecated() bool {
+	return !policy.DeprecatedAt.IsZero()
+}
+
+// Deprecation avisa os clientes de uma versão depreciada pelos headers Deprecation (RFC 9745) e Sunset (RFC 8594).
+// Passada a data do Sunset a versão deixa de atender e responde 410
+func Deprecation(policy DeprecationPolicy) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if !policy.Deprecated() {
+			return next
+		}
+
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			writer.Header().Set("Deprecation", fmt.Sprintf("@%d", policy.DeprecatedAt.Unix()))
+			if policy.Successor != "" {
+				writer.Header().Add("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", policy.Successor))
+			}
+
+			if !policy.Sunset.IsZero() {
+				writer.Header().Set("Sunset", policy.Sunset.UTC().Format(http.TimeFormat))
+
+				if !time.Now().Before(policy.Sunset) {
+					writeError(writer, request, http.StatusGone, ErrAPIVersionSunset)
+					return
+				}
+			}
+
+			next.ServeHTTP(writer, request)
+		})
+	}
+}
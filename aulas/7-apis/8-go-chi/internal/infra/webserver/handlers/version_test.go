@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/i18n"
+	"github.com/stretchr/testify/assert"
+)
+
+func serveWithPolicy(policy DeprecationPolicy) *httptest.ResponseRecorder {
+	handler := Deprecation(policy)(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusOK)
+	}))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/v1/products", nil))
+	return recorder
+}
+
+func TestDeprecationActiveVersion(t *testing.T) {
+	recorder := serveWithPolicy(DeprecationPolicy{})
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Empty(t, recorder.Header().Get("Deprecation"))
+	assert.Empty(t, recorder.Header().Get("Sunset"))
+}
+
+func TestDeprecationHeaders(t *testing.T) {
+	deprecatedAt := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	sunset := time.Now().Add(24 * time.Hour)
+
+	recorder := serveWithPolicy(DeprecationPolicy{DeprecatedAt: deprecatedAt, Sunset: sunset, Successor: "/v2"})
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "@1767225600", recorder.Header().Get("Deprecation"))
+	assert.Equal(t, sunset.UTC().Format(http.TimeFormat), recorder.Header().Get("Sunset"))
+	assert.Equal(t, `</v2>; rel="successor-version"`, recorder.Header().Get("Link"))
+}
+
+func TestDeprecationAfterSunset(t *testing.T) {
+	recorder := serveWithPolicy(DeprecationPolicy{
+		DeprecatedAt: time.Now().Add(-48 * time.Hour),
+		Sunset:       time.Now().Add(-time.Hour),
+		Successor:    "/v2",
+	})
+	assert.Equal(t, http.StatusGone, recorder.Code)
+	assert.NotEmpty(t, recorder.Header().Get("Sunset"))
+
+	var output Error
+	assert.Nil(t, json.NewDecoder(recorder.Body).Decode(&output))
+	assert.Equal(t, i18n.CodeAPIVersionSunset, output.Code)
+}
@@ -0,0 +1,276 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/dto"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/usecase"
+	"github.com/go-chi/chi"
+)
+
+type WishlistHandler struct {
+	UseCase *usecase.WishlistUseCase
+}
+
+func NewWishlistHandler(useCase *usecase.WishlistUseCase) *WishlistHandler {
+	return &WishlistHandler{UseCase: useCase}
+}
+
+// CreateWishlist godoc
+// @Summary Create wishlist
+// @Description Create a named wishlist for the authenticated user
+// @Tags wishlists
+// @Accept json,xml,application/msgpack
+// @Produce json,xml,application/msgpack
+// @Param request body dto.WishlistInput true "wishlist"
+// @Success 201 {object} usecase.WishlistOutput
+// @Failure 400 {object} Error
+// @Failure 500 {object} Error
+// @Router /wishlists [post]
+// @Security ApiKeyAuth
+func (wishlistHandler *WishlistHandler) CreateWishlist(writer http.ResponseWriter, request *http.Request) {
+	var input dto.WishlistInput
+	err := decode(request, &input)
+	if err != nil {
+		writeError(writer, request, http.StatusBadRequest, fmt.Errorf("%w: %v", ErrInvalidRequestBody, err))
+		return
+	}
+
+	wishlist, err := wishlistHandler.UseCase.Create(usecase.CreateWishlistInput{UserID: userIDFromRequest(request), Name: input.Name})
+	if err != nil {
+		writeError(writer, request, useCaseStatus(err), err)
+		return
+	}
+
+	render(writer, request, http.StatusCreated, wishlist)
+}
+
+// GetWishlists godoc
+// @Summary List wishlists
+// @Description List the authenticated user's wishlists
+// @Tags wishlists
+// @Produce json,xml,application/msgpack
+// @Success 200 {array} usecase.WishlistOutput
+// @Failure 500 {object} Error
+// @Router /wishlists [get]
+// @Security ApiKeyAuth
+func (wishlistHandler *WishlistHandler) GetWishlists(writer http.ResponseWriter, request *http.Request) {
+	wishlists, err := wishlistHandler.UseCase.List(userIDFromRequest(request))
+	if err != nil {
+		writeError(writer, request, useCaseStatus(err), err)
+		return
+	}
+
+	render(writer, request, http.StatusOK, wishlists)
+}
+
+// GetWishlist godoc
+// @Summary Get wishlist
+// @Tags wishlists
+// @Produce json,xml,application/msgpack
+// @Param id path string true "wishlist id" Format(uuid)
+// @Success 200 {object} usecase.WishlistOutput
+// @Failure 404 {object} Error
+// @Failure 500 {object} Error
+// @Router /wishlists/{id} [get]
+// @Security ApiKeyAuth
+func (wishlistHandler *WishlistHandler) GetWishlist(writer http.ResponseWriter, request *http.Request) {
+	wishlist, err := wishlistHandler.UseCase.Find(wishlistInput(request))
+	if err != nil {
+		writeError(writer, request, useCaseStatus(err), err)
+		return
+	}
+
+	render(writer, request, http.StatusOK, wishlist)
+}
+
+// RenameWishlist godoc
+// @Summary Rename wishlist
+// @Tags wishlists
+// @Accept json,xml,application/msgpack
+// @Produce json,xml,application/msgpack
+// @Param id path string true "wishlist id" Format(uuid)
+// @Param request body dto.WishlistInput true "wishlist"
+// @Success 200 {object} usecase.WishlistOutput
+// @Failure 400 {object} Error
+// @Failure 404 {object} Error
+// @Failure 500 {object} Error
+// @Router /wishlists/{id} [put]
+// @Security ApiKeyAuth
+func (wishlistHandler *WishlistHandler) RenameWishlist(writer http.ResponseWriter, request *http.Request) {
+	var input dto.WishlistInput
+	err := decode(request, &input)
+	if err != nil {
+		writeError(writer, request, http.StatusBadRequest, fmt.Errorf("%w: %v", ErrInvalidRequestBody, err))
+		return
+	}
+
+	wishlist, err := wishlistHandler.UseCase.Rename(usecase.RenameWishlistInput{WishlistInput: wishlistInput(request), Name: input.Name})
+	if err != nil {
+		writeError(writer, request, useCaseStatus(err), err)
+		return
+	}
+
+	render(writer, request, http.StatusOK, wishlist)
+}
+
+// DeleteWishlist godoc
+// @Summary Delete wishlist
+// @Tags wishlists
+// @Param id path string true "wishlist id" Format(uuid)
+// @Success 200
+// @Failure 404 {object} Error
+// @Failure 500 {object} Error
+// @Router /wishlists/{id} [delete]
+// @Security ApiKeyAuth
+func (wishlistHandler *WishlistHandler) DeleteWishlist(writer http.ResponseWriter, request *http.Request) {
+	err := wishlistHandler.UseCase.Delete(wishlistInput(request))
+	if err != nil {
+		writeError(writer, request, useCaseStatus(err), err)
+		return
+	}
+
+	writer.WriteHeader(http.StatusOK)
+}
+
+// AddWishlistItem godoc
+// @Summary Add product to wishlist
+// @Description Add a product to the wishlist. Adding a product that is already on the list changes nothing
+// @Tags wishlists
+// @Accept json,xml,application/msgpack
+// @Produce json,xml,application/msgpack
+// @Param id path string true "wishlist id" Format(uuid)
+// @Param request body dto.AddWishlistItemInput true "item"
+// @Success 200 {object} usecase.WishlistOutput
+// @Failure 400 {object} Error
+// @Failure 404 {object} Error
+// @Failure 500 {object} Error
+// @Router /wishlists/{id}/items [post]
+// @Security ApiKeyAuth
+func (wishlistHandler *WishlistHandler) AddItem(writer http.ResponseWriter, request *http.Request) {
+	var input dto.AddWishlistItemInput
+	err := decode(request, &input)
+	if err != nil {
+		writeError(writer, request, http.StatusBadRequest, fmt.Errorf("%w: %v", ErrInvalidRequestBody, err))
+		return
+	}
+
+	wishlist, err := wishlistHandler.UseCase.AddItem(usecase.WishlistItemInput{WishlistInput: wishlistInput(request), ProductID: input.ProductID})
+	if err != nil {
+		writeError(writer, request, useCaseStatus(err), err)
+		return
+	}
+
+	render(writer, request, http.StatusOK, wishlist)
+}
+
+// RemoveWishlistItem godoc
+// @Summary Remove product from wishlist
+// @Tags wishlists
+// @Produce json,xml,application/msgpack
+// @Param id path string true "wishlist id" Format(uuid)
+// @Param productId path string true "product id" Format(uuid)
+// @Success 200 {object} usecase.WishlistOutput
+// @Failure 400 {object} Error
+// @Failure 404 {object} Error
+// @Failure 500 {object} Error
+// @Router /wishlists/{id}/items/{productId} [delete]
+// @Security ApiKeyAuth
+func (wishlistHandler *WishlistHandler) RemoveItem(writer http.ResponseWriter, request *http.Request) {
+	wishlist, err := wishlistHandler.UseCase.RemoveItem(usecase.WishlistItemInput{WishlistInput: wishlistInput(request), ProductID: chi.URLParam(request, "productId")})
+	if err != nil {
+		writeError(writer, request, useCaseStatus(err), err)
+		return
+	}
+
+	render(writer, request, http.StatusOK, wishlist)
+}
+
+// ShareWishlist godoc
+// @Summary Share wishlist
+// @Description Create the public link of the wishlist. The share_token in the response is used in GET /wishlists/shared/{token}. Sharing an already shared list keeps the same token
+// @Tags wishlists
+// @Produce json,xml,application/msgpack
+// @Param id path string true "wishlist id" Format(uuid)
+// @Success 200 {object} usecase.WishlistOutput
+// @Failure 404 {object} Error
+// @Failure 500 {object} Error
+// @Router /wishlists/{id}/share [post]
+// @Security ApiKeyAuth
+func (wishlistHandler *WishlistHandler) ShareWishlist(writer http.ResponseWriter, request *http.Request) {
+	wishlist, err := wishlistHandler.UseCase.Share(wishlistInput(request))
+	if err != nil {
+		writeError(writer, request, useCaseStatus(err), err)
+		return
+	}
+
+	render(writer, request, http.StatusOK, wishlist)
+}
+
+// UnshareWishlist godoc
+// @Summary Stop sharing wishlist
+// @Description Revoke the public link. Sharing the list again creates a new token
+// @Tags wishlists
+// @Produce json,xml,application/msgpack
+// @Param id path string true "wishlist id" Format(uuid)
+// @Success 200 {object} usecase.WishlistOutput
+// @Failure 404 {object} Error
+// @Failure 500 {object} Error
+// @Router /wishlists/{id}/share [delete]
+// @Security ApiKeyAuth
+func (wishlistHandler *WishlistHandler) UnshareWishlist(writer http.ResponseWriter, request *http.Request) {
+	wishlist, err := wishlistHandler.UseCase.Unshare(wishlistInput(request))
+	if err != nil {
+		writeError(writer, request, useCaseStatus(err), err)
+		return
+	}
+
+	render(writer, request, http.StatusOK, wishlist)
+}
+
+// GetSharedWishlist godoc
+// @Summary Get shared wishlist
+// @Description Public view of a shared wishlist. No authentication is required
+// @Tags wishlists
+// @Produce json,xml,application/msgpack
+// @Param token path string true "share token"
+// @Success 200 {object} usecase.WishlistOutput
+// @Failure 404 {object} Error
+// @Router /wishlists/shared/{token} [get]
+func (wishlistHandler *WishlistHandler) GetSharedWishlist(writer http.ResponseWriter, request *http.Request) {
+	wishlist, err := wishlistHandler.UseCase.FindShared(chi.URLParam(request, "token"))
+	if err != nil {
+		writeError(writer, request, useCaseStatus(err), err)
+		return
+	}
+
+	render(writer, request, http.StatusOK, wishlist)
+}
+
+// GetNotifications godoc
+// @Summary List notifications
+// @Description List the authenticated user's notifications, newest first. Price drops and removed products of the wishlists are notified
+// @Tags wishlists
+// @Produce json,xml,application/msgpack
+// @Param page query int false "page number" minimum(1)
+// @Param limit query int false "limit" minimum(1)
+// @Success 200 {array} usecase.NotificationOutput
+// @Failure 500 {object} Error
+// @Router /notifications [get]
+// @Security ApiKeyAuth
+func (wishlistHandler *WishlistHandler) GetNotifications(writer http.ResponseWriter, request *http.Request) {
+	page, limit := pagination(request)
+
+	notifications, err := wishlistHandler.UseCase.Notifications(usecase.ListNotificationsInput{UserID: userIDFromRequest(request), Page: page, Limit: limit})
+	if err != nil {
+		writeError(writer, request, useCaseStatus(err), err)
+		return
+	}
+
+	render(writer, request, http.StatusOK, notifications)
+}
+
+func wishlistInput(request *http.Request) usecase.WishlistInput {
+	return usecase.WishlistInput{ID: chi.URLParam(request, "id"), UserID: userIDFromRequest(request)}
+}
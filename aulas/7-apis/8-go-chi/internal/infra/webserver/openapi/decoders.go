@@ -0,0 +1,115 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/webserver/content"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+)
+
+// O kin-openapi só sabe ler JSON. Registramos os outros formatos da negociação de conteúdo
+// para que corpos em XML e MessagePack passem pela mesma validação
+func init() {
+	for _, contentType := range []string{content.MIMEXML, "text/xml"} {
+		openapi3filter.RegisterBodyDecoder(contentType, decodeXML)
+	}
+
+	for _, contentType := range []string{content.MIMEMessagePack, "application/x-msgpack", "application/vnd.msgpack"} {
+		openapi3filter.RegisterBodyDecoder(contentType, decodeMessagePack)
+	}
+}
+
+// decodeMessagePack passa o valor pelo JSON para normalizar os tipos (int8, uint16...) em float64, como o validador espera
+func decodeMessagePack(body io.Reader, _ http.Header, _ *openapi3.SchemaRef, _ openapi3filter.EncodingFn) (any, error) {
+	var value any
+	if err := content.MessagePack.Decode(body, &value); err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var normalized any
+	err = json.NewDecoder(bytes.NewReader(data)).Decode(&normalized)
+	return normalized, err
+}
+
+type xmlNode struct {
+	XMLName  xml.Name
+	Children []xmlNode `xml:",any"`
+	Text     string    `xml:",chardata"`
+}
+
+// decodeXML usa o schema para saber o tipo de cada elemento, já que no XML tudo é texto
+func decodeXML(body io.Reader, _ http.Header, schema *openapi3.SchemaRef, _ openapi3filter.EncodingFn) (any, error) {
+	var root xmlNode
+	if err := xml.NewDecoder(body).Decode(&root); err != nil {
+		return nil, err
+	}
+
+	return xmlValue(root, schema), nil
+}
+
+func xmlValue(node xmlNode, schemaRef *openapi3.SchemaRef) any {
+	var schema *openapi3.Schema
+	if schemaRef != nil {
+		schema = schemaRef.Value
+	}
+
+	// Listas chegam como elementos filhos repetidos, normalmente <item>
+	if schema != nil && schema.Type.Is(openapi3.TypeArray) {
+		items := []any{}
+		for _, child := range node.Children {
+			items = append(items, xmlValue(child, schema.Items))
+		}
+		return items
+	}
+
+	if (schema != nil && schema.Type.Is(openapi3.TypeObject)) || len(node.Children) > 0 {
+		object := map[string]any{}
+		for _, child := range node.Children {
+			object[child.XMLName.Local] = xmlValue(child, propertySchema(schema, child.XMLName.Local))
+		}
+		return object
+	}
+
+	text := strings.TrimSpace(node.Text)
+	if schema == nil {
+		return text
+	}
+
+	// Valores que não convertem continuam texto, e o validador aponta o tipo errado
+	switch {
+	case schema.Type.Is(openapi3.TypeInteger), schema.Type.Is(openapi3.TypeNumber):
+		if number, err := strconv.ParseFloat(text, 64); err == nil {
+			return number
+		}
+	case schema.Type.Is(openapi3.TypeBoolean):
+		if boolean, err := strconv.ParseBool(text); err == nil {
+			return boolean
+		}
+	}
+
+	return text
+}
+
+func propertySchema(schema *openapi3.Schema, name string) *openapi3.SchemaRef {
+	if schema == nil {
+		return nil
+	}
+
+	if property, ok := schema.Properties[name]; ok {
+		return property
+	}
+
+	return schema.AdditionalProperties.Schema
+}
@@ -0,0 +1,260 @@
+package openapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/webserver/content"
+	"github.com/getkin/kin-openapi/openapi2"
+	"github.com/getkin/kin-openapi/openapi2conv"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/legacy"
+)
+
+// Onde está o valor que quebrou o contrato
+const (
+	InBody   = "body"
+	InQuery  = "query"
+	InPath   = "path"
+	InHeader = "header"
+)
+
+func init() {
+	// O swag gera Format(uuid) para os ids, mas o kin-openapi só valida formatos registrados
+	openapi3.DefineStringFormatValidator("uuid", uuidFormat{})
+}
+
+// uuidFormat evita que a violação traga a regex inteira como motivo
+type uuidFormat struct{}
+
+var uuidPattern = regexp.MustCompile(openapi3.FormatOfStringForUUIDOfRFC4122)
+
+func (uuidFormat) Validate(value string) error {
+	if !uuidPattern.MatchString(value) {
+		return errors.New("must be a valid uuid")
+	}
+
+	return nil
+}
+
+// Violation é uma quebra do contrato. Field usa notação de ponto para campos aninhados (ex.: price.amount)
+type Violation struct {
+	In     string `json:"in"`
+	Field  string `json:"field,omitempty"`
+	Reason string `json:"reason"`
+}
+
+// ValidationError reúne todas as violações de uma requisição ou resposta
+type ValidationError struct {
+	Violations []Violation
+}
+
+func (err *ValidationError) Error() string {
+	reasons := make([]string, 0, len(err.Violations))
+	for _, violation := range err.Violations {
+		reasons = append(reasons, fmt.Sprintf("%s %s: %s", violation.In, violation.Field, violation.Reason))
+	}
+
+	return "openapi validation failed: " + strings.Join(reasons, "; ")
+}
+
+type Options struct {
+	// ValidateResponses também confere as respostas. É caro (a resposta fica em memória) e serve para os testes
+	ValidateResponses bool
+}
+
+// Validator confere requisições e respostas contra o swagger.json gerado pelo swag para uma versão da API
+type Validator struct {
+	router   routers.Router
+	basePath string
+	options  Options
+}
+
+// NewValidator recebe o documento Swagger 2.0 do swag (ex.: SwaggerInfov1.ReadDoc()) e o converte para OpenAPI 3
+func NewValidator(doc string, options Options) (*Validator, error) {
+	var doc2 openapi2.T
+	if err := json.Unmarshal([]byte(doc), &doc2); err != nil {
+		return nil, fmt.Errorf("parse swagger: %w", err)
+	}
+
+	doc3, err := openapi2conv.ToV3(&doc2)
+	if err != nil {
+		return nil, fmt.Errorf("convert swagger: %w", err)
+	}
+
+	// O mesmo spec atende /v1/... e as rotas sem versão, então o prefixo é removido antes de procurar a rota
+	doc3.Servers = nil
+	addEquivalentMediaTypes(doc3)
+
+	router, err := legacy.NewRouter(doc3)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Validator{router: router, basePath: strings.TrimSuffix(doc2.BasePath, "/"), options: options}, nil
+}
+
+func (v *Validator) ValidateResponses() bool {
+	return v.options.ValidateResponses
+}
+
+// Operation é uma requisição já associada a uma operação do spec
+type Operation struct {
+	input *openapi3filter.RequestValidationInput
+}
+
+// FindOperation devolve nil para rotas fora do spec, que passam sem validação
+func (v *Validator) FindOperation(request *http.Request) *Operation {
+	lookup := request.Clone(request.Context())
+	if v.basePath != "" && strings.HasPrefix(lookup.URL.Path, v.basePath+"/") {
+		lookup.URL.Path = strings.TrimPrefix(lookup.URL.Path, v.basePath)
+	}
+
+	route, pathParams, err := v.router.FindRoute(lookup)
+	if err != nil {
+		return nil
+	}
+
+	return &Operation{input: &openapi3filter.RequestValidationInput{
+		Request:    request,
+		PathParams: pathParams,
+		Route:      route,
+		Options: &openapi3filter.Options{
+			MultiError: true,
+			// A autenticação é feita pelos middlewares de JWT, não pelo spec
+			AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+		},
+	}}
+}
+
+// ValidateRequest lê e restaura o corpo da requisição, então o handler continua podendo decodificá-lo
+func (operation *Operation) ValidateRequest(ctx context.Context) error {
+	err := openapi3filter.ValidateRequest(ctx, operation.input)
+	if err == nil {
+		return nil
+	}
+
+	return &ValidationError{Violations: violations(err, InBody)}
+}
+
+// ValidateResponse confere status, Content-Type e corpo de uma resposta já escrita
+func (operation *Operation) ValidateResponse(ctx context.Context, status int, header http.Header, body []byte) error {
+	input := &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: operation.input,
+		Status:                 status,
+		Header:                 header,
+		Body:                   io.NopCloser(bytes.NewReader(body)),
+		Options:                operation.input.Options,
+	}
+
+	err := openapi3filter.ValidateResponse(ctx, input)
+	if err == nil {
+		return nil
+	}
+
+	return &ValidationError{Violations: violations(err, InBody)}
+}
+
+// violations achata os erros aninhados do kin-openapi em uma lista simples. O tipo é conferido nível a nível
+// (e não com errors.As) porque um RequestError de parâmetro embrulha um MultiError e perderia o nome do parâmetro
+func violations(err error, in string) []Violation {
+	switch err := err.(type) {
+	case openapi3.MultiError:
+		var result []Violation
+		for _, item := range err {
+			result = append(result, violations(item, in)...)
+		}
+		return result
+	case *openapi3filter.RequestError:
+		if err.Parameter != nil {
+			return parameterViolations(err)
+		}
+		if err.Err != nil {
+			return violations(err.Err, InBody)
+		}
+		return []Violation{{In: InBody, Reason: err.Reason}}
+	case *openapi3filter.ResponseError:
+		if err.Err != nil {
+			return violations(err.Err, InBody)
+		}
+		return []Violation{{In: InBody, Reason: err.Reason}}
+	case *openapi3.SchemaError:
+		return []Violation{{In: in, Field: strings.Join(err.JSONPointer(), "."), Reason: err.Reason}}
+	case *openapi3filter.ParseError:
+		reason := err.Reason
+		if reason == "" && err.Cause != nil {
+			reason = err.Cause.Error()
+		}
+		return []Violation{{In: in, Field: fieldPath(err.Path()), Reason: reason}}
+	}
+
+	if unwrapped := errors.Unwrap(err); unwrapped != nil {
+		return violations(unwrapped, in)
+	}
+
+	return []Violation{{In: in, Reason: err.Error()}}
+}
+
+func parameterViolations(err *openapi3filter.RequestError) []Violation {
+	in := err.Parameter.In
+	nested := []Violation{{In: in, Reason: err.Reason}}
+	if err.Err != nil {
+		nested = violations(err.Err, in)
+	}
+
+	// Para parâmetros o nome vem do spec, não do caminho dentro do valor
+	for i := range nested {
+		nested[i].In = in
+		nested[i].Field = err.Parameter.Name
+	}
+
+	return nested
+}
+
+func fieldPath(path []any) string {
+	parts := make([]string, 0, len(path))
+	for _, part := range path {
+		parts = append(parts, fmt.Sprint(part))
+	}
+
+	return strings.Join(parts, ".")
+}
+
+// O swag documenta um media type por formato (ex.: text/xml), mas a negociação de conteúdo aceita os equivalentes
+func addEquivalentMediaTypes(doc *openapi3.T) {
+	for _, pathItem := range doc.Paths.Map() {
+		for _, operation := range pathItem.Operations() {
+			if operation.RequestBody != nil && operation.RequestBody.Value != nil {
+				addEquivalents(operation.RequestBody.Value.Content)
+			}
+
+			if operation.Responses == nil {
+				continue
+			}
+			for _, response := range operation.Responses.Map() {
+				if response.Value != nil {
+					addEquivalents(response.Value.Content)
+				}
+			}
+		}
+	}
+}
+
+func addEquivalents(mediaTypes openapi3.Content) {
+	for mediaType, value := range mediaTypes {
+		for _, equivalent := range content.Equivalents(mediaType) {
+			if _, ok := mediaTypes[equivalent]; !ok {
+				mediaTypes[equivalent] = value
+			}
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package openapi
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const spec = `{
+	"swagger": "2.0",
+	"info": {"title": "Items", "version": "1.0"},
+	"basePath": "/v1",
+	"paths": {
+		"/items": {
+			"get": {
+				"produces": ["application/json"],
+				"parameters": [
+					{"name": "page", "in": "query", "type": "integer", "minimum": 1},
+					{"name": "sort", "in": "query", "type": "string", "enum": ["asc", "desc"]}
+				],
+				"responses": {"200": {"description": "OK", "schema": {"type": "array", "items": {"$ref": "#/definitions/Item"}}}}
+			},
+			"post": {
+				"consumes": ["application/json", "application/xml"],
+				"parameters": [{"name": "item", "in": "body", "required": true, "schema": {"$ref": "#/definitions/ItemInput"}}],
+				"responses": {"201": {"description": "Created"}}
+			}
+		},
+		"/items/{id}": {
+			"get": {
+				"produces": ["application/json"],
+				"parameters": [{"name": "id", "in": "path", "required": true, "type": "string", "format": "uuid"}],
+				"responses": {"200": {"description": "OK", "schema": {"$ref": "#/definitions/Item"}}}
+			}
+		}
+	},
+	"definitions": {
+		"Item": {
+			"type": "object",
+			"required": ["id", "name"],
+			"properties": {"id": {"type": "string"}, "name": {"type": "string"}}
+		},
+		"ItemInput": {
+			"type": "object",
+			"required": ["name", "price"],
+			"properties": {
+				"name": {"type": "string", "minLength": 1},
+				"price": {"type": "object", "required": ["amount"], "properties": {"amount": {"type": "integer", "minimum": 1}}}
+			}
+		}
+	}
+}`
+
+func newValidator(t *testing.T) *Validator {
+	validator, err := NewValidator(spec, Options{ValidateResponses: true})
+	require.NoError(t, err)
+
+	return validator
+}
+
+func validate(t *testing.T, validator *Validator, request *http.Request) []Violation {
+	operation := validator.FindOperation(request)
+	require.NotNil(t, operation)
+
+	err := operation.ValidateRequest(request.Context())
+	if err == nil {
+		return nil
+	}
+
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr))
+
+	return validationErr.Violations
+}
+
+func TestFindOperationIgnoresRoutesOutsideTheSpec(t *testing.T) {
+	validator := newValidator(t)
+
+	assert.Nil(t, validator.FindOperation(httptest.NewRequest(http.MethodGet, "/users", nil)))
+	assert.Nil(t, validator.FindOperation(httptest.NewRequest(http.MethodDelete, "/items", nil)))
+
+	// Com e sem o prefixo da versão
+	assert.NotNil(t, validator.FindOperation(httptest.NewRequest(http.MethodGet, "/items", nil)))
+	assert.NotNil(t, validator.FindOperation(httptest.NewRequest(http.MethodGet, "/v1/items", nil)))
+}
+
+func TestValidateRequestBody(t *testing.T) {
+	validator := newValidator(t)
+
+	request := httptest.NewRequest(http.MethodPost, "/v1/items", strings.NewReader(`{"name": "Item", "price": {"amount": 10}}`))
+	request.Header.Set("Content-Type", "application/json")
+	assert.Empty(t, validate(t, validator, request))
+
+	request = httptest.NewRequest(http.MethodPost, "/v1/items", strings.NewReader(`{"name": "", "price": {"amount": 0}}`))
+	request.Header.Set("Content-Type", "application/json")
+	assert.ElementsMatch(t, []Violation{
+		{In: InBody, Field: "name", Reason: "minimum string length is 1"},
+		{In: InBody, Field: "price.amount", Reason: "number must be at least 1"},
+	}, validate(t, validator, request))
+}
+
+func TestValidateRequestBodyKeepsTheBodyForTheHandler(t *testing.T) {
+	validator := newValidator(t)
+
+	request := httptest.NewRequest(http.MethodPost, "/items", strings.NewReader(`{"name": "Item", "price": {"amount": 10}}`))
+	request.Header.Set("Content-Type", "application/json")
+	assert.Empty(t, validate(t, validator, request))
+
+	body, err := io.ReadAll(request.Body)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"name": "Item", "price": {"amount": 10}}`, string(body))
+}
+
+func TestValidateRequestXMLBody(t *testing.T) {
+	validator := newValidator(t)
+
+	request := httptest.NewRequest(http.MethodPost, "/items", strings.NewReader(`<item><name>Item</name><price><amount>10</amount></price></item>`))
+	request.Header.Set("Content-Type", "application/xml")
+	assert.Empty(t, validate(t, validator, request))
+
+	// text/xml é equivalente a application/xml mesmo não estando no spec
+	request = httptest.NewRequest(http.MethodPost, "/items", strings.NewReader(`<item><name>Item</name><price><amount>0</amount></price></item>`))
+	request.Header.Set("Content-Type", "text/xml")
+	assert.Equal(t, []Violation{{In: InBody, Field: "price.amount", Reason: "number must be at least 1"}}, validate(t, validator, request))
+}
+
+func TestValidateRequestParameters(t *testing.T) {
+	validator := newValidator(t)
+
+	assert.Empty(t, validate(t, validator, httptest.NewRequest(http.MethodGet, "/items?page=2&sort=asc", nil)))
+
+	violations := validate(t, validator, httptest.NewRequest(http.MethodGet, "/items?page=0&sort=random", nil))
+	assert.Len(t, violations, 2)
+	assert.Equal(t, InQuery, violations[0].In)
+	assert.ElementsMatch(t, []string{"page", "sort"}, []string{violations[0].Field, violations[1].Field})
+
+	violations = validate(t, validator, httptest.NewRequest(http.MethodGet, "/items/not-a-uuid", nil))
+	assert.Equal(t, []Violation{{In: InPath, Field: "id", Reason: `string doesn't match the format "uuid" (must be a valid uuid)`}}, violations)
+
+	assert.Empty(t, validate(t, validator, httptest.NewRequest(http.MethodGet, "/items/0b6e8d8e-5f3c-4b8a-9c1d-2e7f4a6b8c90", nil)))
+}
+
+func TestValidateResponse(t *testing.T) {
+	validator := newValidator(t)
+	request := httptest.NewRequest(http.MethodGet, "/items", nil)
+	operation := validator.FindOperation(request)
+	require.NotNil(t, operation)
+
+	header := http.Header{"Content-Type": {"application/json"}}
+	assert.NoError(t, operation.ValidateResponse(request.Context(), http.StatusOK, header, []byte(`[{"id": "1", "name": "Item"}]`)))
+
+	err := operation.ValidateResponse(request.Context(), http.StatusOK, header, []byte(`[{"id": "1"}]`))
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, []Violation{{In: InBody, Field: "0.name", Reason: `property "name" is missing`}}, validationErr.Violations)
+}
@@ -0,0 +1,354 @@
+package webserver
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/auth"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/auth/oidc"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/database"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/featureflag"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/jobs"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/webserver/handlers"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/webserver/openapi"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/webserver/sse"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/usecase"
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/go-chi/jwtauth"
+	httpSwagger "github.com/swaggo/http-swagger"
+)
+
+// Dependencies reúne tudo o que o router precisa para montar os handlers.
+// Tanto o cmd/server quanto os testes end-to-end usam o mesmo NewRouter
+type Dependencies struct {
+	ProductDB      database.ProductInterface
+	PriceHistoryDB database.PriceHistoryInterface
+	UserDB         database.UserInterface
+	CartDB         database.CartInterface
+	CouponDB       database.CouponInterface
+	PromotionDB    database.PromotionRuleInterface
+	ReviewDB       database.ReviewInterface
+	AuditDB        database.AuditInterface
+	JobDB          database.JobInterface
+	WishlistDB     database.WishlistInterface
+	NotificationDB database.NotificationInterface
+	Transaction    database.TransactionInterface
+	KeyRing        *auth.KeyRing
+	JWTExpiresIn   int
+	ProductBroker  *sse.Broker
+	AdminEmails    []string
+	// Jobs executa em segundo plano o trabalho que não cabe em uma requisição (ex.: exportações)
+	Jobs *jobs.Runner
+	// Deprecations guarda a política de cada versão depreciada, indexada por APIV1, APIV2...
+	Deprecations map[string]handlers.DeprecationPolicy
+	// Validators confere as requisições contra o spec de cada versão. Versões sem validator não são validadas
+	Validators map[string]*openapi.Validator
+	// CORS, Security e MaxBodyBytes valem para todas as rotas. MaxBodyBytes zerado não limita o corpo
+	CORS         handlers.CORSOptions
+	Security     handlers.SecurityOptions
+	MaxBodyBytes int64
+
+	// OIDC habilita o login pelo SSO. Nil mantém só o login por email e senha
+	OIDC *oidc.Client
+
+	// Features avalia as feature flags de FeatureFlagDB em memória. Nil monta um store sem atualização periódica
+	FeatureFlagDB database.FeatureFlagInterface
+	Features      *featureflag.Store
+}
+
+const (
+	APIV1 = "v1"
+	APIV2 = "v2"
+)
+
+// docsPaths são as rotas do Swagger UI, que não funcionam com o CSP restritivo da API
+var docsPaths = []string{"/docs/", "/" + APIV1 + "/docs/", "/" + APIV2 + "/docs/"}
+
+// API agrupa os handlers e monta as rotas de cada versão da API
+type API struct {
+	deps             Dependencies
+	productHandler   *handlers.ProductHandler
+	productV2Handler *handlers.ProductV2Handler
+	userHandler      *handlers.UserHandler
+	cartHandler      *handlers.CartHandler
+	promotionHandler *handlers.PromotionHandler
+	reviewHandler    *handlers.ReviewHandler
+	auditHandler     *handlers.AuditHandler
+	jobHandler       *handlers.JobHandler
+	oidcHandler      *handlers.OIDCHandler
+	wishlistHandler  *handlers.WishlistHandler
+	flagHandler      *handlers.FeatureFlagHandler
+}
+
+func NewRouter(deps Dependencies) *chi.Mux {
+	// Um *sse.Broker nil dentro da interface não seria nil, então só publicamos quando existe broker
+	var events usecase.EventPublisher
+	if deps.ProductBroker != nil {
+		events = deps.ProductBroker
+	}
+
+	tokens := auth.NewTokenIssuer(deps.KeyRing.TokenAuth(), time.Duration(deps.JWTExpiresIn)*time.Second)
+	productUseCase := usecase.NewProductUseCase(deps.ProductDB, deps.PriceHistoryDB, deps.Transaction, events)
+	userUseCase := usecase.NewUserUseCase(deps.UserDB, deps.Transaction, tokens, deps.AdminEmails)
+
+	features := deps.Features
+	if features == nil {
+		features = featureflag.NewStore(deps.FeatureFlagDB, 0)
+	}
+
+	productHandler := handlers.NewProductHandler(productUseCase, deps.ProductBroker)
+	userHandler := handlers.NewUserHandler(userUseCase)
+	api := &API{
+		deps:             deps,
+		productHandler:   productHandler,
+		productV2Handler: handlers.NewProductV2Handler(productHandler),
+		userHandler:      userHandler,
+		cartHandler:      handlers.NewCartHandler(deps.CartDB, deps.ProductDB),
+		promotionHandler: handlers.NewPromotionHandler(deps.CouponDB, deps.PromotionDB, deps.ProductDB),
+		reviewHandler:    handlers.NewReviewHandler(deps.ReviewDB, deps.ProductDB),
+		auditHandler:     handlers.NewAuditHandler(deps.AuditDB),
+		jobHandler:       handlers.NewJobHandler(deps.JobDB, deps.Jobs),
+		wishlistHandler:  handlers.NewWishlistHandler(usecase.NewWishlistUseCase(deps.WishlistDB, deps.ProductDB, deps.NotificationDB, deps.Transaction)),
+		flagHandler:      handlers.NewFeatureFlagHandler(usecase.NewFeatureFlagUseCase(deps.FeatureFlagDB, features), features),
+	}
+	if deps.OIDC != nil {
+		api.oidcHandler = handlers.NewOIDCHandler(userUseCase, deps.KeyRing.TokenAuth(), deps.OIDC)
+	}
+
+	security := deps.Security
+	security.CSPExemptPaths = append(append([]string{}, security.CSPExemptPaths...), docsPaths...)
+
+	router := chi.NewRouter()
+	router.Use(middleware.Logger)
+	router.Use(middleware.Recoverer)
+	// O CORS vem antes de qualquer rota para responder o preflight, que não tem token nem rota OPTIONS
+	router.Use(handlers.CORS(deps.CORS))
+	router.Use(handlers.SecurityHeaders(security))
+	router.Use(handlers.BodyLimit(deps.MaxBodyBytes))
+
+	router.Route("/v1", func(router chi.Router) {
+		router.Use(handlers.Deprecation(deps.Deprecations[APIV1]))
+		api.v1(router)
+		router.Get("/docs/*", httpSwagger.Handler(httpSwagger.URL("http://localhost:8000/v1/docs/doc.json"), httpSwagger.InstanceName(APIV1)))
+	})
+
+	router.Route("/v2", func(router chi.Router) {
+		router.Use(handlers.Deprecation(deps.Deprecations[APIV2]))
+		api.v2(router)
+		router.Get("/docs/*", httpSwagger.Handler(httpSwagger.URL("http://localhost:8000/v2/docs/doc.json"), httpSwagger.InstanceName(APIV2)))
+	})
+
+	// As rotas sem versão continuam respondendo como a v1 para não quebrar os clientes que já existem
+	router.Group(func(router chi.Router) {
+		router.Use(handlers.Deprecation(deps.Deprecations[APIV1]))
+		api.v1(router)
+	})
+
+	router.Get("/.well-known/jwks.json", deps.KeyRing.JWKSHandler)
+
+	router.Get("/docs/*", httpSwagger.Handler(httpSwagger.URL("http://localhost:8000/docs/doc.json"), httpSwagger.InstanceName(APIV1)))
+
+	return router
+}
+
+func (api *API) v1(router chi.Router) {
+	api.products(router, APIV1, func(router chi.Router) {
+		router.Post("/", api.productHandler.CreateProduct)
+		router.Get("/", api.productHandler.GetProducts)
+		router.Get("/{id}", api.productHandler.GetProduct)
+		router.Put("/{id}", api.productHandler.UpdateProduct)
+		router.Delete("/{id}", api.productHandler.DeleteProduct)
+	})
+	api.shared(router, APIV1)
+}
+
+// v2 muda apenas o formato dos produtos; o restante é igual ao da v1
+func (api *API) v2(router chi.Router) {
+	api.products(router, APIV2, func(router chi.Router) {
+		router.Post("/", api.productV2Handler.CreateProduct)
+		router.Get("/", api.productV2Handler.GetProducts)
+		router.Get("/{id}", api.productV2Handler.GetProduct)
+		router.Put("/{id}", api.productV2Handler.UpdateProduct)
+		router.Delete("/{id}", api.productV2Handler.DeleteProduct)
+	})
+	api.shared(router, APIV2)
+}
+
+// request junta a negociação de conteúdo e a validação pelo spec da versão, nessa ordem:
+// um Content-Type desconhecido é 415 antes de virar erro de validação
+func (api *API) request(version string) func(http.Handler) http.Handler {
+	validate := handlers.ValidateSpec(api.deps.Validators[version])
+
+	return func(next http.Handler) http.Handler {
+		return handlers.Negotiate(validate(next))
+	}
+}
+
+// products monta o grupo /products com o CRUD da versão e as rotas de preço, stream e reviews
+func (api *API) products(router chi.Router, version string, crud func(router chi.Router)) {
+	deps := api.deps
+	productHandler := api.productHandler
+	reviewHandler := api.reviewHandler
+	jobHandler := api.jobHandler
+	request := api.request(version)
+
+	router.Route("/products", func(router chi.Router) {
+		// Middleware para verificar o token JWT em todas as rotas deste grupo.
+		// O KeyRing aceita todas as chaves ativas, permitindo a rotação sem invalidar tokens emitidos
+		router.Use(deps.KeyRing.Verifier)
+
+		// Middleware que exige autenticação para todas as rotas dentro deste grupo
+		router.Use(jwtauth.Authenticator)
+
+		// O stream fala text/event-stream e por isso fica fora da negociação de conteúdo
+		router.Get("/stream", productHandler.StreamProducts)
+
+		router.Group(func(router chi.Router) {
+			router.Use(request)
+
+			crud(router)
+			router.Post("/export", jobHandler.ExportProducts)
+			router.Get("/{id}/prices", productHandler.GetProductPrices)
+			router.Get("/{id}/prices/stats", productHandler.GetProductPriceStats)
+			router.Get("/{id}/reviews", reviewHandler.GetReviews)
+			router.Post("/{id}/reviews", reviewHandler.CreateReview)
+			router.Put("/{id}/reviews/{reviewId}", reviewHandler.UpdateReview)
+			router.Delete("/{id}/reviews/{reviewId}", reviewHandler.DeleteReview)
+		})
+	})
+}
+
+// shared monta as rotas que têm o mesmo formato em todas as versões
+func (api *API) shared(router chi.Router, version string) {
+	deps := api.deps
+	cartHandler := api.cartHandler
+	promotionHandler := api.promotionHandler
+	auditHandler := api.auditHandler
+	jobHandler := api.jobHandler
+	userHandler := api.userHandler
+	wishlistHandler := api.wishlistHandler
+	flagHandler := api.flagHandler
+	request := api.request(version)
+
+	router.Route("/cart", func(router chi.Router) {
+		router.Use(deps.KeyRing.Verifier)
+		router.Use(jwtauth.Authenticator)
+		router.Use(request)
+
+		router.Get("/", cartHandler.GetCart)
+		router.Post("/items", cartHandler.AddItem)
+		router.Put("/items/{productId}", cartHandler.UpdateItem)
+		router.Delete("/items/{productId}", cartHandler.RemoveItem)
+	})
+
+	router.Route("/wishlists", func(router chi.Router) {
+		router.Use(request)
+
+		// O link compartilhado é público: o token da lista já é a autorização
+		router.Get("/shared/{token}", wishlistHandler.GetSharedWishlist)
+
+		router.Group(func(router chi.Router) {
+			router.Use(deps.KeyRing.Verifier)
+			router.Use(jwtauth.Authenticator)
+
+			router.Post("/", wishlistHandler.CreateWishlist)
+			router.Get("/", wishlistHandler.GetWishlists)
+			router.Get("/{id}", wishlistHandler.GetWishlist)
+			router.Put("/{id}", wishlistHandler.RenameWishlist)
+			router.Delete("/{id}", wishlistHandler.DeleteWishlist)
+			router.Post("/{id}/items", wishlistHandler.AddItem)
+			router.Delete("/{id}/items/{productId}", wishlistHandler.RemoveItem)
+			router.Post("/{id}/share", wishlistHandler.ShareWishlist)
+			router.Delete("/{id}/share", wishlistHandler.UnshareWishlist)
+		})
+	})
+
+	router.Route("/notifications", func(router chi.Router) {
+		router.Use(deps.KeyRing.Verifier)
+		router.Use(jwtauth.Authenticator)
+		router.Use(request)
+
+		router.Get("/", wishlistHandler.GetNotifications)
+	})
+
+	router.Route("/coupons", func(router chi.Router) {
+		router.Use(deps.KeyRing.Verifier)
+		router.Use(jwtauth.Authenticator)
+		router.Use(request)
+
+		// Só admins criam descontos; consultar e aplicar fica liberado para qualquer usuário autenticado
+		router.With(handlers.RequireRole(handlers.RoleAdmin)).Post("/", promotionHandler.CreateCoupon)
+		router.Get("/{code}", promotionHandler.GetCoupon)
+	})
+
+	router.Route("/promotions", func(router chi.Router) {
+		router.Use(deps.KeyRing.Verifier)
+		router.Use(jwtauth.Authenticator)
+		router.Use(request)
+
+		router.With(handlers.RequireRole(handlers.RoleAdmin)).Post("/", promotionHandler.CreatePromotionRule)
+		router.Get("/", promotionHandler.GetPromotionRules)
+		router.Post("/evaluate", promotionHandler.Evaluate)
+		// Ainda não há pedidos na API para amarrar o resgate, então só o admin registra o uso do cupom
+		router.With(handlers.RequireRole(handlers.RoleAdmin)).Post("/redeem", promotionHandler.Redeem)
+	})
+
+	router.Route("/audit", func(router chi.Router) {
+		router.Use(deps.KeyRing.Verifier)
+		router.Use(jwtauth.Authenticator)
+		router.Use(handlers.RequireRole(handlers.RoleAdmin))
+		router.Use(request)
+
+		router.Get("/", auditHandler.GetAudit)
+	})
+
+	router.Route("/feature-flags", func(router chi.Router) {
+		router.Use(deps.KeyRing.Verifier)
+		router.Use(jwtauth.Authenticator)
+		router.Use(handlers.RequireRole(handlers.RoleAdmin))
+		router.Use(request)
+
+		router.Post("/", flagHandler.CreateFeatureFlag)
+		router.Get("/", flagHandler.GetFeatureFlags)
+		router.Get("/{key}", flagHandler.GetFeatureFlag)
+		router.Put("/{key}", flagHandler.UpdateFeatureFlag)
+		router.Delete("/{key}", flagHandler.DeleteFeatureFlag)
+	})
+
+	router.Route("/features", func(router chi.Router) {
+		router.Use(deps.KeyRing.Verifier)
+		router.Use(jwtauth.Authenticator)
+		router.Use(request)
+
+		router.Get("/", flagHandler.GetFeatures)
+	})
+
+	router.Route("/jobs/{id}", func(router chi.Router) {
+		router.Use(deps.KeyRing.Verifier)
+		router.Use(jwtauth.Authenticator)
+
+		router.With(request).Get("/", jobHandler.GetJob)
+		// O resultado sai no formato que o job produziu (ex.: text/csv), por isso fica fora da negociação
+		router.Get("/result", jobHandler.GetJobResult)
+	})
+
+	router.With(request).Post("/users", userHandler.CreateUser)
+	router.With(request).Post("/users/generate-token", userHandler.GetJWT)
+	router.With(request).Post("/users/generate-token/totp", userHandler.GetJWTWithTOTP)
+
+	if api.oidcHandler != nil {
+		// O login é só um redirect para o issuer, por isso fica fora da negociação de conteúdo
+		router.Get("/users/oidc/login", api.oidcHandler.Login)
+		router.With(request).Get("/users/oidc/callback", api.oidcHandler.Callback)
+	}
+
+	router.Route("/users/totp", func(router chi.Router) {
+		router.Use(deps.KeyRing.Verifier)
+		router.Use(jwtauth.Authenticator)
+		router.Use(request)
+
+		router.Post("/enroll", userHandler.EnrollTOTP)
+		router.Post("/confirm", userHandler.ConfirmTOTP)
+	})
+}
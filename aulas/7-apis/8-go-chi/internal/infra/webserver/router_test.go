@@ -0,0 +1,1407 @@
+package webserver_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/dto"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/entity"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/auth"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/auth/oidc/oidctest"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/database"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/webserver/handlers"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/webserver/openapi"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/usecase"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/pkg/totp"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/test/harness"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// missingID é um uuid válido que não existe nos repositórios; ids fora do formato são barrados pelo spec com 400
+const missingID = "0b6e8d8e-5f3c-4b8a-9c1d-2e7f4a6b8c90"
+
+func TestProductsRequireAuthentication(t *testing.T) {
+	h := harness.New(t)
+
+	response := h.Do(http.MethodGet, "/products", nil, "")
+	assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+
+	response = h.Do(http.MethodGet, "/products", nil, "invalid-token")
+	assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+}
+
+func TestCreateProduct(t *testing.T) {
+	h := harness.New(t)
+	_, token := h.SeedUser("John", "john@email.com", "123456")
+
+	response := h.Do(http.MethodPost, "/products", dto.CreateProductInput{Name: "Product 1", Price: 100}, token)
+	assert.Equal(t, http.StatusCreated, response.StatusCode)
+
+	products, _ := h.ProductDB.FindAll(0, 0, "asc")
+	assert.Len(t, products, 1)
+	assert.Equal(t, "Product 1", products[0].Name)
+	assert.Equal(t, 100, products[0].Price)
+}
+
+func TestCreateProductWithInvalidInput(t *testing.T) {
+	h := harness.New(t)
+	_, token := h.SeedUser("John", "john@email.com", "123456")
+
+	response := h.Do(http.MethodPost, "/products", "{invalid", token)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+
+	response = h.Do(http.MethodPost, "/products", dto.CreateProductInput{Price: 100}, token)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+
+	// O spec recusa antes do handler e aponta o campo que quebrou o contrato
+	var body handlers.Error
+	response.JSON(t, &body)
+	assert.Equal(t, "validation_failed", body.Code)
+	assert.Equal(t, []openapi.Violation{{In: openapi.InBody, Field: "name", Reason: "minimum string length is 1"}}, body.Details)
+
+	response = h.Do(http.MethodPost, "/products", dto.CreateProductInput{Name: "Product 1", Price: -1}, token)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+	response.JSON(t, &body)
+	assert.Equal(t, []openapi.Violation{{In: openapi.InBody, Field: "price", Reason: "number must be at least 1"}}, body.Details)
+}
+
+func TestGetProduct(t *testing.T) {
+	h := harness.New(t)
+	_, token := h.SeedUser("John", "john@email.com", "123456")
+	product := h.SeedProduct("Product 1", 100)
+
+	response := h.Do(http.MethodGet, "/products/"+product.ID.String(), nil, token)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, "application/json", response.Header.Get("Content-Type"))
+
+	var body map[string]interface{}
+	response.JSON(t, &body)
+	assert.Equal(t, product.ID.String(), body["id"])
+	assert.Equal(t, "Product 1", body["name"])
+	assert.Equal(t, float64(100), body["price"])
+	assert.Contains(t, body, "created_at")
+	assert.Contains(t, body, "updated_at")
+
+	response = h.Do(http.MethodGet, "/products/"+missingID, nil, token)
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+}
+
+func TestGetProducts(t *testing.T) {
+	h := harness.New(t)
+	_, token := h.SeedUser("John", "john@email.com", "123456")
+	for i := 1; i <= 15; i++ {
+		h.SeedProduct(fmt.Sprintf("Product %d", i), 10+i)
+	}
+
+	response := h.Do(http.MethodGet, "/products?page=2&limit=10&sort=asc", nil, token)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	var products []entity.Product
+	response.JSON(t, &products)
+	assert.Len(t, products, 5)
+	assert.Equal(t, "Product 11", products[0].Name)
+
+	response = h.Do(http.MethodGet, "/products", nil, token)
+	response.JSON(t, &products)
+	assert.Len(t, products, 15)
+}
+
+func TestUpdateProduct(t *testing.T) {
+	h := harness.New(t)
+	_, token := h.SeedUser("John", "john@email.com", "123456")
+	product := h.SeedProduct("Product 1", 100)
+
+	response := h.Do(http.MethodPut, "/products/"+product.ID.String(), map[string]interface{}{"name": "Product Updated", "price": 200}, token)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	productFound, _ := h.ProductDB.FindByID(product.ID.String())
+	assert.Equal(t, "Product Updated", productFound.Name)
+	assert.Equal(t, 200, productFound.Price)
+
+	response = h.Do(http.MethodPut, "/products/not-a-uuid", map[string]interface{}{"name": "X", "price": 1}, token)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+
+	missing, _ := entity.NewProduct("Missing", 10)
+	response = h.Do(http.MethodPut, "/products/"+missing.ID.String(), map[string]interface{}{"name": "X", "price": 1}, token)
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+}
+
+func TestDeleteProduct(t *testing.T) {
+	h := harness.New(t)
+	_, token := h.SeedUser("John", "john@email.com", "123456")
+	product := h.SeedProduct("Product 1", 100)
+
+	response := h.Do(http.MethodDelete, "/products/"+product.ID.String(), nil, token)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	_, err := h.ProductDB.FindByID(product.ID.String())
+	assert.NotNil(t, err)
+
+	response = h.Do(http.MethodDelete, "/products/"+product.ID.String(), nil, token)
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+}
+
+func TestCreateUserAndGenerateToken(t *testing.T) {
+	h := harness.New(t)
+
+	response := h.Do(http.MethodPost, "/users", dto.CreateUserInput{Name: "John", Email: "john@email.com", Password: "123456"}, "")
+	assert.Equal(t, http.StatusCreated, response.StatusCode)
+
+	response = h.Do(http.MethodPost, "/users/generate-token", dto.GetJWTInput{Email: "john@email.com", Password: "123456"}, "")
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	var output dto.GetJWTOutput
+	response.JSON(t, &output)
+	assert.NotEmpty(t, output.AccessToken)
+
+	response = h.Do(http.MethodGet, "/products", nil, output.AccessToken)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+}
+
+func TestGenerateTokenWithInvalidCredentials(t *testing.T) {
+	h := harness.New(t)
+	h.SeedUser("John", "john@email.com", "123456")
+
+	response := h.Do(http.MethodPost, "/users/generate-token", dto.GetJWTInput{Email: "john@email.com", Password: "wrong"}, "")
+	assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+
+	var body handlers.Error
+	response.JSON(t, &body)
+	assert.Equal(t, handlers.ErrInvalidCredentials.Error(), body.Message)
+
+	response = h.Do(http.MethodPost, "/users/generate-token", dto.GetJWTInput{Email: "missing@email.com", Password: "123456"}, "")
+	assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+
+	response = h.Do(http.MethodPost, "/users/generate-token", "{invalid", "")
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+}
+
+func TestStreamProductsResumesFromLastEventID(t *testing.T) {
+	h := harness.New(t)
+	_, token := h.SeedUser("John", "john@email.com", "123456")
+
+	h.Do(http.MethodPost, "/products", dto.CreateProductInput{Name: "Product 1", Price: 100}, token)
+	h.Do(http.MethodPost, "/products", dto.CreateProductInput{Name: "Product 2", Price: 200}, token)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	request, _ := http.NewRequestWithContext(ctx, http.MethodGet, h.Server.URL+"/products/stream", nil)
+	request.Header.Set("Authorization", "Bearer "+token)
+	request.Header.Set("Last-Event-ID", "1")
+
+	response, err := h.Server.Client().Do(request)
+	assert.Nil(t, err)
+	defer response.Body.Close()
+
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, "text/event-stream", response.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(response.Body)
+	var lines []string
+	for {
+		line, err := reader.ReadString('\n')
+		assert.Nil(t, err)
+		if line == "\n" {
+			break
+		}
+		lines = append(lines, strings.TrimSuffix(line, "\n"))
+	}
+
+	assert.Equal(t, "id: 2", lines[0])
+	assert.Equal(t, "event: created", lines[1])
+	assert.Contains(t, lines[2], `"name":"Product 2"`)
+}
+
+func TestRS256TokensAndJWKS(t *testing.T) {
+	privateKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	keyRing, err := auth.NewKeyRing("RS256", privateKey)
+	assert.Nil(t, err)
+
+	h := harness.NewWithKeyRing(t, keyRing)
+	_, token := h.SeedUser("John", "john@email.com", "123456")
+
+	response := h.Do(http.MethodGet, "/products", nil, token)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	// Um token HS256 não deve ser aceito por um servidor configurado com RS256
+	hmacKeyRing, _ := auth.NewHMACKeyRing("secret")
+	_, hmacToken, _ := hmacKeyRing.TokenAuth().Encode(map[string]interface{}{"sub": "user-id"})
+	response = h.Do(http.MethodGet, "/products", nil, hmacToken)
+	assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+
+	response = h.Do(http.MethodGet, "/.well-known/jwks.json", nil, "")
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	var jwks struct {
+		Keys []map[string]interface{} `json:"keys"`
+	}
+	response.JSON(t, &jwks)
+	assert.Len(t, jwks.Keys, 1)
+	assert.Equal(t, keyRing.KeyID(), jwks.Keys[0]["kid"])
+	assert.Equal(t, "RSA", jwks.Keys[0]["kty"])
+	assert.NotContains(t, jwks.Keys[0], "d")
+}
+
+func TestErrorsAreLocalizedByAcceptLanguage(t *testing.T) {
+	h := harness.New(t)
+	_, token := h.SeedUser("John", "john@email.com", "123456")
+
+	request, _ := http.NewRequest(http.MethodPost, h.Server.URL+"/products", strings.NewReader(`{"price": 10}`))
+	request.Header.Set("Authorization", "Bearer "+token)
+	request.Header.Set("Accept-Language", "pt-BR,pt;q=0.9,en;q=0.8")
+
+	response := h.Send(request)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+	assert.Equal(t, "pt-BR", response.Header.Get("Content-Language"))
+
+	var body handlers.Error
+	response.JSON(t, &body)
+	assert.Equal(t, "validation_failed", body.Code)
+	assert.Equal(t, "a requisição não segue a especificação da API, veja os detalhes", body.Message)
+	assert.Equal(t, []openapi.Violation{{In: openapi.InBody, Field: "name", Reason: `property "name" is missing`}}, body.Details)
+
+	request, _ = http.NewRequest(http.MethodGet, h.Server.URL+"/products/"+missingID, nil)
+	request.Header.Set("Authorization", "Bearer "+token)
+
+	response = h.Send(request)
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+	response.JSON(t, &body)
+	assert.Equal(t, "product_not_found", body.Code)
+	assert.Equal(t, "product not found", body.Message)
+}
+
+func TestProductPriceHistory(t *testing.T) {
+	h := harness.New(t)
+	user, token := h.SeedUser("John", "john@email.com", "123456")
+	product := h.SeedProduct("Product 1", 100)
+	path := "/products/" + product.ID.String()
+
+	h.Do(http.MethodPut, path, map[string]interface{}{"name": "Product 1", "price": 150}, token)
+	h.Do(http.MethodPut, path, map[string]interface{}{"name": "Product 1 renamed", "price": 150}, token)
+	h.Do(http.MethodPut, path, map[string]interface{}{"name": "Product 1", "price": 120}, token)
+
+	response := h.Do(http.MethodGet, path+"/prices", nil, token)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	var changes []entity.PriceChange
+	response.JSON(t, &changes)
+	assert.Len(t, changes, 2)
+	assert.Equal(t, 100, changes[0].OldPrice)
+	assert.Equal(t, 150, changes[0].NewPrice)
+	assert.Equal(t, user.ID.String(), changes[0].ChangedBy)
+	assert.Equal(t, 120, changes[1].NewPrice)
+
+	future := time.Now().Add(time.Hour).Format(time.RFC3339)
+	response = h.Do(http.MethodGet, path+"/prices?from="+future, nil, token)
+	response.JSON(t, &changes)
+	assert.Empty(t, changes)
+
+	response = h.Do(http.MethodGet, path+"/prices/stats", nil, token)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	var summary entity.PriceSummary
+	response.JSON(t, &summary)
+	assert.Equal(t, 100, summary.Min)
+	assert.Equal(t, 150, summary.Max)
+	assert.Equal(t, 2, summary.Changes)
+
+	response = h.Do(http.MethodGet, path+"/prices?from=yesterday", nil, token)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+
+	response = h.Do(http.MethodGet, "/products/"+missingID+"/prices/stats", nil, token)
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+}
+
+func TestCart(t *testing.T) {
+	h := harness.New(t)
+	user, token := h.SeedUser("John", "john@email.com", "123456")
+	_, otherToken := h.SeedUser("Mary", "mary@email.com", "123456")
+	product := h.SeedProduct("Product 1", 100)
+	product2 := h.SeedProduct("Product 2", 30)
+
+	response := h.Do(http.MethodGet, "/cart", nil, token)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	var cart dto.CartOutput
+	response.JSON(t, &cart)
+	assert.Empty(t, cart.Items)
+	assert.Equal(t, 0, cart.Total)
+
+	h.Do(http.MethodPost, "/cart/items", dto.AddCartItemInput{ProductID: product.ID.String(), Quantity: 2}, token)
+	response = h.Do(http.MethodPost, "/cart/items", dto.AddCartItemInput{ProductID: product2.ID.String(), Quantity: 1}, token)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	response.JSON(t, &cart)
+	assert.Len(t, cart.Items, 2)
+	assert.Equal(t, "Product 1", cart.Items[0].Name)
+	assert.Equal(t, 200, cart.Items[0].LineTotal)
+	assert.Equal(t, 230, cart.Total)
+
+	response = h.Do(http.MethodPut, "/cart/items/"+product2.ID.String(), dto.UpdateCartItemInput{Quantity: 3}, token)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	response.JSON(t, &cart)
+	assert.Equal(t, 290, cart.Total)
+
+	// Cada usuário tem o seu próprio carrinho
+	response = h.Do(http.MethodGet, "/cart", nil, otherToken)
+	response.JSON(t, &cart)
+	assert.Empty(t, cart.Items)
+
+	// Alteração de preço e exclusão de produto chegam ao carrinho na hora, e o aviso vem na próxima leitura
+	h.Do(http.MethodPut, "/products/"+product.ID.String(), map[string]interface{}{"name": "Product 1", "price": 80}, token)
+	h.Do(http.MethodDelete, "/products/"+product2.ID.String(), nil, token)
+
+	stored, err := h.CartDB.FindByUserID(user.ID.String())
+	require.NoError(t, err)
+	assert.Equal(t, 80, stored.Items[0].UnitPrice)
+	assert.True(t, stored.Items[1].Removed)
+
+	response = h.Do(http.MethodGet, "/cart", nil, token)
+	response.JSON(t, &cart)
+	assert.Len(t, cart.Items, 1)
+	assert.Equal(t, 160, cart.Total)
+	assert.Equal(t, []dto.CartPriceChangeOutput{{ProductID: product.ID.String(), OldPrice: 100, NewPrice: 80}}, cart.PriceChanges)
+	assert.Equal(t, []string{product2.ID.String()}, cart.RemovedProducts)
+
+	// Os avisos aparecem apenas uma vez, pois o carrinho revalidado é salvo
+	response = h.Do(http.MethodGet, "/cart", nil, token)
+	response.JSON(t, &cart)
+	assert.Empty(t, cart.PriceChanges)
+	assert.Empty(t, cart.RemovedProducts)
+
+	response = h.Do(http.MethodDelete, "/cart/items/"+product.ID.String(), nil, token)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	response.JSON(t, &cart)
+	assert.Empty(t, cart.Items)
+}
+
+func TestCartConcurrentChanges(t *testing.T) {
+	h := harness.New(t)
+	_, token := h.SeedUser("John", "john@email.com", "123456")
+
+	// Nenhuma mudança aceita se perde: cada resposta 200 corresponde a um item no carrinho
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	accepted := 0
+	for i := 0; i < 5; i++ {
+		product := h.SeedProduct("Product", 10)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			response := h.Do(http.MethodPost, "/cart/items", dto.AddCartItemInput{ProductID: product.ID.String(), Quantity: 1}, token)
+			assert.Contains(t, []int{http.StatusOK, http.StatusConflict}, response.StatusCode)
+			if response.StatusCode == http.StatusOK {
+				mutex.Lock()
+				accepted++
+				mutex.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	var cart dto.CartOutput
+	h.Do(http.MethodGet, "/cart", nil, token).JSON(t, &cart)
+	assert.Len(t, cart.Items, accepted)
+}
+
+func TestCartErrors(t *testing.T) {
+	h := harness.New(t)
+	_, token := h.SeedUser("John", "john@email.com", "123456")
+	product := h.SeedProduct("Product 1", 100)
+
+	response := h.Do(http.MethodGet, "/cart", nil, "")
+	assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+
+	response = h.Do(http.MethodPost, "/cart/items", dto.AddCartItemInput{ProductID: missingID, Quantity: 1}, token)
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+
+	response = h.Do(http.MethodPost, "/cart/items", dto.AddCartItemInput{ProductID: product.ID.String(), Quantity: 0}, token)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+
+	response = h.Do(http.MethodPut, "/cart/items/"+product.ID.String(), dto.UpdateCartItemInput{Quantity: 1}, token)
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+
+	response = h.Do(http.MethodDelete, "/cart/items/not-a-uuid", nil, token)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+}
+
+func TestWishlists(t *testing.T) {
+	h := harness.New(t)
+	_, token := h.SeedUser("John", "john@email.com", "123456")
+	_, otherToken := h.SeedUser("Mary", "mary@email.com", "123456")
+	product := h.SeedProduct("Product 1", 100)
+	product2 := h.SeedProduct("Product 2", 30)
+
+	response := h.Do(http.MethodPost, "/wishlists", dto.WishlistInput{Name: "Presentes"}, token)
+	assert.Equal(t, http.StatusCreated, response.StatusCode)
+
+	var wishlist usecase.WishlistOutput
+	response.JSON(t, &wishlist)
+	path := "/wishlists/" + wishlist.ID
+
+	h.Do(http.MethodPost, path+"/items", dto.AddWishlistItemInput{ProductID: product.ID.String()}, token)
+	response = h.Do(http.MethodPost, path+"/items", dto.AddWishlistItemInput{ProductID: product2.ID.String()}, token)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	response.JSON(t, &wishlist)
+	assert.Len(t, wishlist.Items, 2)
+
+	response = h.Do(http.MethodPut, path, dto.WishlistInput{Name: "Aniversário"}, token)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	var wishlists []usecase.WishlistOutput
+	h.Do(http.MethodGet, "/wishlists", nil, token).JSON(t, &wishlists)
+	require.Len(t, wishlists, 1)
+	assert.Equal(t, "Aniversário", wishlists[0].Name)
+
+	// A lista é privada até ser compartilhada, e outro usuário não a enxerga
+	response = h.Do(http.MethodGet, path, nil, otherToken)
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+
+	response = h.Do(http.MethodPost, path+"/share", nil, token)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	response.JSON(t, &wishlist)
+	require.NotEmpty(t, wishlist.ShareToken)
+
+	// O link compartilhado funciona sem token
+	var shared usecase.WishlistOutput
+	response = h.Do(http.MethodGet, "/wishlists/shared/"+wishlist.ShareToken, nil, "")
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	response.JSON(t, &shared)
+	assert.Equal(t, "Aniversário", shared.Name)
+	assert.Empty(t, shared.ShareToken)
+
+	// Queda de preço e exclusão de produto chegam à lista e viram notificações para o dono
+	h.Do(http.MethodPut, "/products/"+product.ID.String(), map[string]interface{}{"name": "Product 1", "price": 80}, otherToken)
+	h.Do(http.MethodDelete, "/products/"+product2.ID.String(), nil, otherToken)
+
+	h.Do(http.MethodGet, path, nil, token).JSON(t, &wishlist)
+	require.Len(t, wishlist.Items, 1)
+	assert.Equal(t, 80, wishlist.Items[0].Price)
+	assert.Equal(t, 100, wishlist.Items[0].AddedPrice)
+
+	var notifications []usecase.NotificationOutput
+	response = h.Do(http.MethodGet, "/notifications", nil, token)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	response.JSON(t, &notifications)
+	require.Len(t, notifications, 2)
+	assert.Equal(t, entity.NotificationProductRemoved, notifications[0].Type)
+	assert.Equal(t, entity.NotificationPriceDrop, notifications[1].Type)
+
+	h.Do(http.MethodGet, "/notifications", nil, otherToken).JSON(t, &notifications)
+	assert.Empty(t, notifications)
+
+	response = h.Do(http.MethodDelete, path+"/share", nil, token)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	response = h.Do(http.MethodGet, "/wishlists/shared/"+wishlist.ShareToken, nil, "")
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+
+	response = h.Do(http.MethodDelete, path, nil, token)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	response = h.Do(http.MethodGet, path, nil, token)
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+}
+
+func TestWishlistErrors(t *testing.T) {
+	h := harness.New(t)
+	_, token := h.SeedUser("John", "john@email.com", "123456")
+	product := h.SeedProduct("Product 1", 100)
+
+	response := h.Do(http.MethodGet, "/wishlists", nil, "")
+	assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+
+	response = h.Do(http.MethodPost, "/wishlists", dto.WishlistInput{}, token)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+
+	var wishlist usecase.WishlistOutput
+	h.Do(http.MethodPost, "/wishlists", dto.WishlistInput{Name: "Presentes"}, token).JSON(t, &wishlist)
+
+	response = h.Do(http.MethodPost, "/wishlists/"+wishlist.ID+"/items", dto.AddWishlistItemInput{ProductID: missingID}, token)
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+
+	response = h.Do(http.MethodDelete, "/wishlists/"+wishlist.ID+"/items/"+product.ID.String(), nil, token)
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+
+	var body handlers.Error
+	response.JSON(t, &body)
+	assert.Equal(t, "wishlist_item_not_found", body.Code)
+
+	response = h.Do(http.MethodPost, "/wishlists/"+missingID+"/share", nil, token)
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+
+	response = h.Do(http.MethodGet, "/wishlists/shared/unknown-token", nil, "")
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+}
+
+func TestFeatureFlags(t *testing.T) {
+	h := harness.New(t)
+	user, token := h.SeedUser("John", "john@email.com", "123456")
+	adminToken := h.AdminToken(missingID)
+	zero := 0
+
+	response := h.Do(http.MethodPost, "/feature-flags", dto.CreateFeatureFlagInput{Key: "new-checkout", Enabled: true}, adminToken)
+	assert.Equal(t, http.StatusCreated, response.StatusCode)
+
+	var flag usecase.FeatureFlagOutput
+	response.JSON(t, &flag)
+	assert.Equal(t, "new-checkout", flag.Key)
+	assert.Equal(t, 100, flag.Percentage)
+
+	// Percentual zero liga a flag só para quem está na lista de usuários ou de roles
+	h.Do(http.MethodPost, "/feature-flags", dto.CreateFeatureFlagInput{Key: "beta.reports", Enabled: true, Percentage: &zero, UserIDs: []string{user.ID.String()}}, adminToken)
+	h.Do(http.MethodPost, "/feature-flags", dto.CreateFeatureFlagInput{Key: "admin-panel", Enabled: true, Percentage: &zero, Roles: []string{handlers.RoleAdmin}}, adminToken)
+
+	var flags []usecase.FeatureFlagOutput
+	response = h.Do(http.MethodGet, "/feature-flags", nil, adminToken)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	response.JSON(t, &flags)
+	require.Len(t, flags, 3)
+	assert.Equal(t, "admin-panel", flags[0].Key)
+
+	var features map[string]bool
+	response = h.Do(http.MethodGet, "/features", nil, token)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	response.JSON(t, &features)
+	assert.Equal(t, map[string]bool{"new-checkout": true, "beta.reports": true, "admin-panel": false}, features)
+
+	h.Do(http.MethodGet, "/features", nil, adminToken).JSON(t, &features)
+	assert.Equal(t, map[string]bool{"new-checkout": true, "beta.reports": false, "admin-panel": true}, features)
+
+	// A alteração vale na hora para a própria instância, sem esperar a próxima releitura
+	response = h.Do(http.MethodPut, "/feature-flags/new-checkout", dto.UpdateFeatureFlagInput{Description: "Checkout em uma página"}, adminToken)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	response.JSON(t, &flag)
+	assert.False(t, flag.Enabled)
+	assert.Equal(t, "Checkout em uma página", flag.Description)
+
+	h.Do(http.MethodGet, "/features", nil, token).JSON(t, &features)
+	assert.False(t, features["new-checkout"])
+
+	response = h.Do(http.MethodDelete, "/feature-flags/beta.reports", nil, adminToken)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	features = nil
+	h.Do(http.MethodGet, "/features", nil, token).JSON(t, &features)
+	assert.NotContains(t, features, "beta.reports")
+
+	response = h.Do(http.MethodGet, "/feature-flags/beta.reports", nil, adminToken)
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+}
+
+func TestFeatureFlagErrors(t *testing.T) {
+	h := harness.New(t)
+	_, token := h.SeedUser("John", "john@email.com", "123456")
+	adminToken := h.AdminToken(missingID)
+	tooMuch := 150
+
+	response := h.Do(http.MethodGet, "/features", nil, "")
+	assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+
+	// Só admins mexem nas flags
+	response = h.Do(http.MethodPost, "/feature-flags", dto.CreateFeatureFlagInput{Key: "new-checkout"}, token)
+	assert.Equal(t, http.StatusForbidden, response.StatusCode)
+	response = h.Do(http.MethodGet, "/feature-flags", nil, token)
+	assert.Equal(t, http.StatusForbidden, response.StatusCode)
+
+	response = h.Do(http.MethodPost, "/feature-flags", dto.CreateFeatureFlagInput{Key: "new-checkout", Percentage: &tooMuch}, adminToken)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+
+	var body handlers.Error
+	response = h.Do(http.MethodPost, "/feature-flags", dto.CreateFeatureFlagInput{Key: "New Checkout"}, adminToken)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+
+	h.Do(http.MethodPost, "/feature-flags", dto.CreateFeatureFlagInput{Key: "new-checkout"}, adminToken)
+	response = h.Do(http.MethodPost, "/feature-flags", dto.CreateFeatureFlagInput{Key: "new-checkout"}, adminToken)
+	assert.Equal(t, http.StatusConflict, response.StatusCode)
+	response.JSON(t, &body)
+	assert.Equal(t, "feature_flag_already_exists", body.Code)
+
+	response = h.Do(http.MethodPut, "/feature-flags/unknown", dto.UpdateFeatureFlagInput{}, adminToken)
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+	response.JSON(t, &body)
+	assert.Equal(t, "feature_flag_not_found", body.Code)
+}
+
+func TestPromotions(t *testing.T) {
+	h := harness.New(t)
+	_, token := h.SeedUser("John", "john@email.com", "123456")
+	adminToken := h.AdminToken(missingID)
+	book := h.SeedProduct("Book", 100)
+	book.Category = "books"
+	h.ProductDB.Update(book)
+	pen := h.SeedProduct("Pen", 20)
+
+	response := h.Do(http.MethodPost, "/promotions", dto.CreatePromotionRuleInput{
+		Name: "Books 10%", Category: "books", DiscountType: entity.DiscountPercentage, Value: 10,
+	}, adminToken)
+	assert.Equal(t, http.StatusCreated, response.StatusCode)
+
+	response = h.Do(http.MethodPost, "/coupons", dto.CreateCouponInput{
+		Code: "welcome", DiscountType: entity.DiscountFixed, Value: 50, MaxUses: 1, MinCartValue: 100,
+	}, adminToken)
+	assert.Equal(t, http.StatusCreated, response.StatusCode)
+
+	var coupon entity.Coupon
+	response.JSON(t, &coupon)
+	assert.Equal(t, "WELCOME", coupon.Code)
+
+	response = h.Do(http.MethodPost, "/coupons", dto.CreateCouponInput{Code: "WELCOME", DiscountType: entity.DiscountFixed, Value: 5}, adminToken)
+	assert.Equal(t, http.StatusConflict, response.StatusCode)
+
+	input := dto.EvaluatePromotionsInput{
+		Items: []dto.EvaluateItemInput{
+			{ProductID: book.ID.String(), Quantity: 2},
+			{ProductID: pen.ID.String(), Quantity: 1},
+		},
+		CouponCode: "welcome",
+	}
+
+	response = h.Do(http.MethodPost, "/promotions/evaluate", input, token)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	var evaluation entity.Evaluation
+	response.JSON(t, &evaluation)
+	assert.Equal(t, 220, evaluation.Subtotal)
+	assert.Equal(t, 20, evaluation.PromotionDiscount)
+	assert.Equal(t, "Books 10%", evaluation.Items[0].Promotion)
+	assert.Equal(t, 50, evaluation.CouponDiscount)
+	assert.Equal(t, 150, evaluation.Total)
+
+	// Avaliar não consome o cupom; resgatar sim, e só o admin resgata
+	response = h.Do(http.MethodPost, "/promotions/redeem", input, token)
+	assert.Equal(t, http.StatusForbidden, response.StatusCode)
+
+	response = h.Do(http.MethodPost, "/promotions/redeem", input, adminToken)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	response = h.Do(http.MethodPost, "/promotions/redeem", input, adminToken)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+
+	var errorOutput handlers.Error
+	response.JSON(t, &errorOutput)
+	assert.Equal(t, "coupon_usage_limit_reached", errorOutput.Code)
+}
+
+func TestPromotionErrors(t *testing.T) {
+	h := harness.New(t)
+	_, token := h.SeedUser("John", "john@email.com", "123456")
+	adminToken := h.AdminToken(missingID)
+	pen := h.SeedProduct("Pen", 20)
+
+	// Usuários comuns não criam cupons nem promoções
+	response := h.Do(http.MethodPost, "/coupons", dto.CreateCouponInput{Code: "FREE", DiscountType: entity.DiscountPercentage, Value: 100}, token)
+	assert.Equal(t, http.StatusForbidden, response.StatusCode)
+	response = h.Do(http.MethodPost, "/promotions", dto.CreatePromotionRuleInput{Name: "All free", Category: "books", DiscountType: entity.DiscountPercentage, Value: 100}, token)
+	assert.Equal(t, http.StatusForbidden, response.StatusCode)
+
+	response = h.Do(http.MethodPost, "/promotions", dto.CreatePromotionRuleInput{Name: "No scope", DiscountType: entity.DiscountFixed, Value: 5}, adminToken)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+
+	response = h.Do(http.MethodPost, "/coupons", dto.CreateCouponInput{Code: "X", DiscountType: entity.DiscountPercentage, Value: 150}, adminToken)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+
+	response = h.Do(http.MethodGet, "/coupons/missing", nil, token)
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+
+	items := []dto.EvaluateItemInput{{ProductID: pen.ID.String(), Quantity: 1}}
+
+	response = h.Do(http.MethodPost, "/promotions/evaluate", dto.EvaluatePromotionsInput{Items: items, CouponCode: "missing"}, token)
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+
+	response = h.Do(http.MethodPost, "/promotions/evaluate", dto.EvaluatePromotionsInput{}, token)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+
+	response = h.Do(http.MethodPost, "/promotions/evaluate", dto.EvaluatePromotionsInput{Items: []dto.EvaluateItemInput{{ProductID: missingID, Quantity: 1}}}, token)
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+
+	response = h.Do(http.MethodPost, "/promotions/redeem", dto.EvaluatePromotionsInput{Items: items}, adminToken)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+
+	h.Do(http.MethodPost, "/coupons", dto.CreateCouponInput{Code: "BIG", DiscountType: entity.DiscountFixed, Value: 5, MinCartValue: 1000}, adminToken)
+	response = h.Do(http.MethodPost, "/promotions/evaluate", dto.EvaluatePromotionsInput{Items: items, CouponCode: "big"}, token)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+}
+
+func TestReviews(t *testing.T) {
+	h := harness.New(t)
+	_, token := h.SeedUser("John", "john@email.com", "123456")
+	_, otherToken := h.SeedUser("Mary", "mary@email.com", "123456")
+	product := h.SeedProduct("Product 1", 100)
+	reviewsPath := "/products/" + product.ID.String() + "/reviews"
+
+	response := h.Do(http.MethodPost, reviewsPath, dto.ReviewInput{Rating: 5, Comment: "Great"}, token)
+	assert.Equal(t, http.StatusCreated, response.StatusCode)
+
+	var review entity.Review
+	response.JSON(t, &review)
+
+	response = h.Do(http.MethodPost, reviewsPath, dto.ReviewInput{Rating: 2}, otherToken)
+	assert.Equal(t, http.StatusCreated, response.StatusCode)
+
+	response = h.Do(http.MethodPost, reviewsPath, dto.ReviewInput{Rating: 1}, token)
+	assert.Equal(t, http.StatusConflict, response.StatusCode)
+
+	response = h.Do(http.MethodPost, reviewsPath, dto.ReviewInput{Rating: 6}, token)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+
+	var productOutput entity.Product
+	h.Do(http.MethodGet, "/products/"+product.ID.String(), nil, token).JSON(t, &productOutput)
+	assert.Equal(t, 2, productOutput.RatingCount)
+	assert.Equal(t, 3.5, productOutput.RatingAverage)
+
+	var reviews []entity.Review
+	h.Do(http.MethodGet, reviewsPath+"?page=1&limit=1", nil, token).JSON(t, &reviews)
+	assert.Len(t, reviews, 1)
+
+	// Apenas o autor pode editar ou apagar a própria review
+	response = h.Do(http.MethodPut, reviewsPath+"/"+review.ID.String(), dto.ReviewInput{Rating: 1}, otherToken)
+	assert.Equal(t, http.StatusForbidden, response.StatusCode)
+
+	response = h.Do(http.MethodDelete, reviewsPath+"/"+review.ID.String(), nil, otherToken)
+	assert.Equal(t, http.StatusForbidden, response.StatusCode)
+
+	response = h.Do(http.MethodPut, reviewsPath+"/"+review.ID.String(), dto.ReviewInput{Rating: 4, Comment: "Good"}, token)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	h.Do(http.MethodGet, "/products/"+product.ID.String(), nil, token).JSON(t, &productOutput)
+	assert.Equal(t, 3.0, productOutput.RatingAverage)
+
+	response = h.Do(http.MethodDelete, reviewsPath+"/"+review.ID.String(), nil, token)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	h.Do(http.MethodGet, "/products/"+product.ID.String(), nil, token).JSON(t, &productOutput)
+	assert.Equal(t, 1, productOutput.RatingCount)
+	assert.Equal(t, 2.0, productOutput.RatingAverage)
+
+	response = h.Do(http.MethodDelete, reviewsPath+"/"+review.ID.String(), nil, token)
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+
+	response = h.Do(http.MethodGet, "/products/"+missingID+"/reviews", nil, token)
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+}
+
+func TestAuditLog(t *testing.T) {
+	h := harness.New(t)
+	user, token := h.SeedUser("John", "john@email.com", "123456")
+
+	response := h.Do(http.MethodPost, "/users", dto.CreateUserInput{Name: "Admin", Email: harness.AdminEmail, Password: "123456"}, "")
+	assert.Equal(t, http.StatusCreated, response.StatusCode)
+
+	var jwtOutput dto.GetJWTOutput
+	h.Do(http.MethodPost, "/users/generate-token", dto.GetJWTInput{Email: harness.AdminEmail, Password: "123456"}, "").JSON(t, &jwtOutput)
+	adminToken := jwtOutput.AccessToken
+
+	h.Do(http.MethodPost, "/products", dto.CreateProductInput{Name: "Product 1", Price: 100}, token)
+	products, _ := h.ProductDB.FindAll(0, 0, "asc")
+	productID := products[0].ID.String()
+	h.Do(http.MethodPut, "/products/"+productID, map[string]interface{}{"name": "Product 1", "price": 150}, token)
+	h.Do(http.MethodDelete, "/products/"+productID, nil, token)
+
+	// Apenas admins consultam a auditoria
+	response = h.Do(http.MethodGet, "/audit", nil, token)
+	assert.Equal(t, http.StatusForbidden, response.StatusCode)
+
+	response = h.Do(http.MethodGet, "/audit?entity=product&actor="+user.ID.String(), nil, adminToken)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	var entries []entity.AuditEntry
+	response.JSON(t, &entries)
+	assert.Len(t, entries, 3)
+
+	actions := map[string]entity.AuditEntry{}
+	for _, entry := range entries {
+		assert.Equal(t, productID, entry.EntityID)
+		actions[entry.Action] = entry
+	}
+	assert.Equal(t, entity.AuditChange{Before: 100.0, After: 150.0}, actions[entity.AuditUpdate].Changes["price"])
+	assert.Equal(t, "Product 1", actions[entity.AuditDelete].Changes["name"].Before)
+
+	// O cadastro é público, então o autor fica como anonymous e a senha não aparece
+	h.Do(http.MethodGet, "/audit?entity=user", nil, adminToken).JSON(t, &entries)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, entity.AnonymousActor, entries[0].Actor)
+	assert.Equal(t, harness.AdminEmail, entries[0].Changes["email"].After)
+	_, ok := entries[0].Changes["password"]
+	assert.False(t, ok)
+
+	response = h.Do(http.MethodGet, "/audit?from=invalid", nil, adminToken)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+}
+
+func newRequest(t *testing.T, h *harness.Harness, method, path, contentType, accept string, body []byte, token string) *http.Request {
+	request, err := http.NewRequest(method, h.Server.URL+path, bytes.NewReader(body))
+	assert.Nil(t, err)
+
+	if contentType != "" {
+		request.Header.Set("Content-Type", contentType)
+	}
+	request.Header.Set("Accept", accept)
+	request.Header.Set("Authorization", "Bearer "+token)
+
+	return request
+}
+
+func TestContentNegotiationXML(t *testing.T) {
+	h := harness.New(t)
+	_, token := h.SeedUser("John", "john@email.com", "123456")
+
+	body := []byte("<product><name>Product 1</name><price>100</price><category>books</category></product>")
+	response := h.Send(newRequest(t, h, http.MethodPost, "/products", "application/xml", "application/xml", body, token))
+	assert.Equal(t, http.StatusCreated, response.StatusCode)
+
+	products, _ := h.ProductDB.FindAll(0, 0, "asc")
+	assert.Len(t, products, 1)
+	assert.Equal(t, "books", products[0].Category)
+
+	response = h.Send(newRequest(t, h, http.MethodGet, "/products/"+products[0].ID.String(), "", "text/html;q=0.9, application/xml", nil, token))
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, "application/xml", response.Header.Get("Content-Type"))
+	assert.Contains(t, string(response.Body), "<name>Product 1</name>")
+	assert.Contains(t, string(response.Body), "<id>"+products[0].ID.String()+"</id>")
+
+	// Os erros também seguem o formato negociado
+	response = h.Send(newRequest(t, h, http.MethodGet, "/products/"+missingID, "", "application/xml", nil, token))
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+	assert.Contains(t, string(response.Body), "<code>product_not_found</code>")
+}
+
+func TestContentNegotiationMessagePack(t *testing.T) {
+	h := harness.New(t)
+	_, token := h.SeedUser("John", "john@email.com", "123456")
+
+	body, _ := msgpack.Marshal(map[string]interface{}{"name": "Product 1", "price": 100})
+	response := h.Send(newRequest(t, h, http.MethodPost, "/products", "application/msgpack", "application/msgpack", body, token))
+	assert.Equal(t, http.StatusCreated, response.StatusCode)
+
+	response = h.Send(newRequest(t, h, http.MethodGet, "/products", "", "application/msgpack", nil, token))
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, "application/msgpack", response.Header.Get("Content-Type"))
+
+	var products []map[string]interface{}
+	assert.Nil(t, msgpack.Unmarshal(response.Body, &products))
+	assert.Len(t, products, 1)
+	assert.Equal(t, "Product 1", products[0]["name"])
+	assert.IsType(t, "", products[0]["id"])
+}
+
+func TestContentNegotiationErrors(t *testing.T) {
+	h := harness.New(t)
+	_, token := h.SeedUser("John", "john@email.com", "123456")
+
+	response := h.Send(newRequest(t, h, http.MethodGet, "/products", "", "text/html", nil, token))
+	assert.Equal(t, http.StatusNotAcceptable, response.StatusCode)
+	assert.Equal(t, "application/json", response.Header.Get("Content-Type"))
+
+	var errorOutput handlers.Error
+	response.JSON(t, &errorOutput)
+	assert.Equal(t, "not_acceptable", errorOutput.Code)
+
+	response = h.Send(newRequest(t, h, http.MethodPost, "/products", "text/plain", "application/json", []byte("Product 1"), token))
+	assert.Equal(t, http.StatusUnsupportedMediaType, response.StatusCode)
+	response.JSON(t, &errorOutput)
+	assert.Equal(t, "unsupported_media_type", errorOutput.Code)
+
+	// Sem Content-Type o corpo continua sendo lido como JSON
+	response = h.Send(newRequest(t, h, http.MethodPost, "/products", "", "", []byte(`{"name":"Product 1","price":10}`), token))
+	assert.Equal(t, http.StatusCreated, response.StatusCode)
+}
+
+func TestVersionedRoutes(t *testing.T) {
+	h := harness.New(t)
+	_, token := h.SeedUser("John", "john@email.com", "123456")
+	product := h.SeedProduct("Product 1", 100)
+
+	// A v1 e as rotas sem versão mantêm o formato atual
+	for _, path := range []string{"/products/", "/v1/products/"} {
+		response := h.Do(http.MethodGet, path+product.ID.String(), nil, token)
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+		assert.Empty(t, response.Header.Get("Deprecation"))
+
+		var output entity.Product
+		response.JSON(t, &output)
+		assert.Equal(t, 100, output.Price)
+	}
+
+	response := h.Do(http.MethodGet, "/v2/products/"+product.ID.String(), nil, token)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	var output dto.ProductV2Output
+	response.JSON(t, &output)
+	assert.Equal(t, dto.Money{Amount: 100, Currency: "BRL"}, output.Price)
+	assert.Equal(t, product.ID.String(), output.ID)
+
+	// Rotas sem mudança de formato também existem na v2
+	response = h.Do(http.MethodGet, "/v2/products/"+product.ID.String()+"/prices", nil, token)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+}
+
+func TestProductsV2(t *testing.T) {
+	h := harness.New(t)
+	_, token := h.SeedUser("John", "john@email.com", "123456")
+
+	input := map[string]interface{}{"name": "Product 1", "price": map[string]interface{}{"amount": 100, "currency": "BRL"}, "category": "books"}
+	response := h.Do(http.MethodPost, "/v2/products", input, token)
+	assert.Equal(t, http.StatusCreated, response.StatusCode)
+
+	var created dto.ProductV2Output
+	response.JSON(t, &created)
+	assert.Equal(t, "Product 1", created.Name)
+	assert.Equal(t, 100, created.Price.Amount)
+	assert.Equal(t, dto.RatingOutput{}, created.Rating)
+
+	input["price"] = map[string]interface{}{"amount": 150}
+	response = h.Do(http.MethodPut, "/v2/products/"+created.ID, input, token)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	var updated dto.ProductV2Output
+	response.JSON(t, &updated)
+	assert.Equal(t, dto.Money{Amount: 150, Currency: "BRL"}, updated.Price)
+
+	// O histórico de preço é registrado igual à v1
+	changes, _ := h.PriceHistoryDB.FindByProductID(created.ID, time.Time{}, time.Time{})
+	assert.Len(t, changes, 1)
+
+	response = h.Do(http.MethodGet, "/v2/products?page=1&limit=10", nil, token)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	var list dto.ProductListV2Output
+	response.JSON(t, &list)
+	assert.Len(t, list.Data, 1)
+	assert.Equal(t, 1, list.Page)
+	assert.Equal(t, 10, list.Limit)
+
+	response = h.Do(http.MethodDelete, "/v2/products/"+created.ID, nil, token)
+	assert.Equal(t, http.StatusNoContent, response.StatusCode)
+
+	response = h.Do(http.MethodGet, "/v2/products/"+created.ID, nil, token)
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+}
+
+func TestProductsV2Errors(t *testing.T) {
+	h := harness.New(t)
+	_, token := h.SeedUser("John", "john@email.com", "123456")
+
+	// O formato da v1 não é aceito na v2
+	response := h.Do(http.MethodPost, "/v2/products", map[string]interface{}{"name": "Product 1", "price": 100}, token)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+
+	response = h.Do(http.MethodPost, "/v2/products", map[string]interface{}{"name": "Product 1", "price": map[string]interface{}{"amount": 100, "currency": "USD"}}, token)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+
+	var errorOutput handlers.Error
+	response.JSON(t, &errorOutput)
+	assert.Equal(t, "unsupported_currency", errorOutput.Code)
+
+	response = h.Do(http.MethodGet, "/v2/products", nil, "")
+	assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+}
+
+func TestTOTPEnrollmentAndLogin(t *testing.T) {
+	h := harness.New(t)
+	user, token := h.SeedUser("John", "john@email.com", "123456")
+	credentials := dto.GetJWTInput{Email: "john@email.com", Password: "123456"}
+
+	response := h.Do(http.MethodPost, "/users/totp/enroll", nil, token)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	var enrollment dto.TOTPEnrollmentOutput
+	response.JSON(t, &enrollment)
+	assert.NotEmpty(t, enrollment.Secret)
+	assert.True(t, strings.HasPrefix(enrollment.ProvisioningURI, "otpauth://totp/"))
+	assert.Len(t, enrollment.RecoveryCodes, entity.RecoveryCodeCount)
+
+	// Enquanto não confirmar, o login continua só com a senha
+	response = h.Do(http.MethodPost, "/users/generate-token", credentials, "")
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	response = h.Do(http.MethodPost, "/users/totp/confirm", dto.TOTPCodeInput{Code: "000000"}, token)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+
+	code, _ := totp.Code(enrollment.Secret, totp.Step(time.Now()))
+	response = h.Do(http.MethodPost, "/users/totp/confirm", dto.TOTPCodeInput{Code: code}, token)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	entries, _ := h.AuditDB.Find(database.AuditFilter{EntityID: user.ID.String()})
+	assert.Len(t, entries, 1)
+	assert.Equal(t, entity.AuditChange{Before: false, After: true}, entries[0].Changes["totp_enabled"])
+
+	response = h.Do(http.MethodPost, "/users/totp/enroll", nil, token)
+	assert.Equal(t, http.StatusConflict, response.StatusCode)
+
+	response = h.Do(http.MethodPost, "/users/generate-token", credentials, "")
+	assert.Equal(t, http.StatusAccepted, response.StatusCode)
+
+	var challenge dto.TOTPChallengeOutput
+	response.JSON(t, &challenge)
+	assert.NotEmpty(t, challenge.ChallengeToken)
+
+	// O token de desafio não dá acesso às rotas
+	response = h.Do(http.MethodGet, "/products", nil, challenge.ChallengeToken)
+	assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+
+	// O código usado na confirmação não vale de novo
+	response = h.Do(http.MethodPost, "/users/generate-token/totp", dto.TOTPLoginInput{ChallengeToken: challenge.ChallengeToken, Code: code}, "")
+	assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+
+	var errorOutput handlers.Error
+	response.JSON(t, &errorOutput)
+	assert.Equal(t, "invalid_totp_code", errorOutput.Code)
+
+	response = h.Do(http.MethodPost, "/users/generate-token/totp", dto.TOTPLoginInput{ChallengeToken: challenge.ChallengeToken, Code: enrollment.RecoveryCodes[0]}, "")
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	var output dto.GetJWTOutput
+	response.JSON(t, &output)
+	response = h.Do(http.MethodGet, "/products", nil, output.AccessToken)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+}
+
+func TestTOTPLoginErrors(t *testing.T) {
+	h := harness.New(t)
+	user, token := h.SeedUser("John", "john@email.com", "123456")
+
+	// Um token de acesso não serve como desafio
+	response := h.Do(http.MethodPost, "/users/generate-token/totp", dto.TOTPLoginInput{ChallengeToken: token, Code: "123456"}, "")
+	assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+
+	var errorOutput handlers.Error
+	response.JSON(t, &errorOutput)
+	assert.Equal(t, "invalid_challenge_token", errorOutput.Code)
+
+	secret, _, _ := user.EnrollTOTP()
+	code, _ := totp.Code(secret, totp.Step(time.Now()))
+	user.ConfirmTOTP(code, time.Now())
+	h.UserDB.Update(user)
+
+	response = h.Do(http.MethodPost, "/users/generate-token", dto.GetJWTInput{Email: "john@email.com", Password: "123456"}, "")
+	var challenge dto.TOTPChallengeOutput
+	response.JSON(t, &challenge)
+
+	for i := 0; i < entity.MaxTOTPFailures; i++ {
+		response = h.Do(http.MethodPost, "/users/generate-token/totp", dto.TOTPLoginInput{ChallengeToken: challenge.ChallengeToken, Code: "000000"}, "")
+		assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+	}
+
+	response = h.Do(http.MethodPost, "/users/generate-token/totp", dto.TOTPLoginInput{ChallengeToken: challenge.ChallengeToken, Code: "000000"}, "")
+	assert.Equal(t, http.StatusTooManyRequests, response.StatusCode)
+	response.JSON(t, &errorOutput)
+	assert.Equal(t, "totp_locked", errorOutput.Code)
+}
+
+func TestRequestsAreValidatedAgainstTheSpec(t *testing.T) {
+	h := harness.New(t)
+	_, token := h.SeedUser("John", "john@email.com", "123456")
+	h.SeedProduct("Product 1", 100)
+
+	var body handlers.Error
+
+	// Query
+	response := h.Do(http.MethodGet, "/v1/products?page=0&sort=random", nil, token)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+	response.JSON(t, &body)
+	assert.Equal(t, "validation_failed", body.Code)
+	assert.ElementsMatch(t, []string{"page", "sort"}, []string{body.Details[0].Field, body.Details[1].Field})
+	assert.Equal(t, openapi.InQuery, body.Details[0].In)
+
+	response = h.Do(http.MethodGet, "/products?page=1&limit=10&sort=desc", nil, token)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	// Path
+	response = h.Do(http.MethodGet, "/v1/products/not-a-uuid/prices", nil, token)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+	response.JSON(t, &body)
+	assert.Equal(t, []openapi.Violation{{In: openapi.InPath, Field: "id", Reason: `string doesn't match the format "uuid" (must be a valid uuid)`}}, body.Details)
+
+	// Corpo aninhado, validado pelo spec da v2
+	response = h.Do(http.MethodPost, "/v2/products", map[string]interface{}{"name": "Product 2", "price": map[string]interface{}{"amount": 0, "currency": "BRL"}}, token)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+	response.JSON(t, &body)
+	assert.Equal(t, []openapi.Violation{{In: openapi.InBody, Field: "price.amount", Reason: "number must be at least 1"}}, body.Details)
+
+	// Corpos em XML passam pela mesma validação
+	response = h.Send(newRequest(t, h, http.MethodPost, "/products", "application/xml", "application/xml", []byte(`<product><price>10</price></product>`), token))
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+	assert.Contains(t, string(response.Body), "<code>validation_failed</code>")
+	assert.Contains(t, string(response.Body), "<field>name</field>")
+
+	products, _ := h.ProductDB.FindAll(0, 0, "asc")
+	assert.Len(t, products, 1)
+}
+
+func TestExportProductsRunsInBackground(t *testing.T) {
+	h := harness.New(t)
+	user, token := h.SeedUser("John", "john@email.com", "123456")
+	h.SeedProduct("Product 1", 100)
+	h.SeedProduct("Product 2", 200)
+
+	response := h.Do(http.MethodPost, "/v2/products/export?sort=desc", nil, token)
+	assert.Equal(t, http.StatusAccepted, response.StatusCode)
+
+	var accepted dto.JobAcceptedOutput
+	response.JSON(t, &accepted)
+	assert.Equal(t, entity.JobQueued, accepted.Status)
+	assert.Equal(t, "/v2/jobs/"+accepted.ID, accepted.StatusURL)
+	assert.Equal(t, accepted.StatusURL, response.Header.Get("Location"))
+
+	// Enquanto o job não roda, o resultado ainda não existe
+	response = h.Do(http.MethodGet, accepted.StatusURL+"/result", nil, token)
+	assert.Equal(t, http.StatusConflict, response.StatusCode)
+
+	var body handlers.Error
+	response.JSON(t, &body)
+	assert.Equal(t, "job_not_finished", body.Code)
+
+	processed, err := h.Jobs.RunPending(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 1, processed)
+
+	response = h.Do(http.MethodGet, accepted.StatusURL, nil, token)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	var job entity.Job
+	response.JSON(t, &job)
+	assert.Equal(t, entity.JobSucceeded, job.Status)
+	assert.Equal(t, 1, job.Attempts)
+	assert.NotNil(t, job.FinishedAt)
+
+	response = h.Do(http.MethodGet, accepted.StatusURL+"/result", nil, token)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, "text/csv", response.Header.Get("Content-Type"))
+	lines := strings.Split(strings.TrimSpace(string(response.Body)), "\n")
+	assert.Len(t, lines, 3)
+	assert.Contains(t, lines[1], "Product 2")
+
+	// Só o dono e os admins enxergam o job
+	response = h.Do(http.MethodGet, accepted.StatusURL, nil, h.Token("other-user"))
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+
+	response = h.Do(http.MethodGet, "/jobs/"+accepted.ID, nil, h.AdminToken("admin"))
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	response = h.Do(http.MethodGet, "/jobs/"+missingID, nil, token)
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+	response.JSON(t, &body)
+	assert.Equal(t, "job_not_found", body.Code)
+
+	stored, _ := h.JobDB.FindByID(accepted.ID)
+	assert.Equal(t, user.ID.String(), stored.Owner)
+}
+
+func TestCORSAndSecurityHeaders(t *testing.T) {
+	h := harness.New(t)
+
+	// O preflight é respondido antes da autenticação e das rotas
+	request, _ := http.NewRequest(http.MethodOptions, h.Server.URL+"/v2/products", nil)
+	request.Header.Set("Origin", harness.StorefrontOrigin)
+	request.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	request.Header.Set("Access-Control-Request-Headers", "authorization,content-type")
+	response := h.Send(request)
+	assert.Equal(t, http.StatusNoContent, response.StatusCode)
+	assert.Equal(t, harness.StorefrontOrigin, response.Header.Get("Access-Control-Allow-Origin"))
+	assert.Contains(t, response.Header.Get("Access-Control-Allow-Methods"), http.MethodPost)
+
+	request, _ = http.NewRequest(http.MethodGet, h.Server.URL+"/v2/products", nil)
+	request.Header.Set("Origin", "https://evil.example.com")
+	request.Header.Set("Authorization", "Bearer "+h.Token("user"))
+	response = h.Send(request)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Empty(t, response.Header.Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "nosniff", response.Header.Get("X-Content-Type-Options"))
+	assert.Equal(t, handlers.DefaultContentSecurityPolicy, response.Header.Get("Content-Security-Policy"))
+
+	// O Swagger UI usa scripts inline e fica sem CSP
+	for _, path := range []string{"/docs/index.html", "/v1/docs/index.html", "/v2/docs/index.html"} {
+		response = h.Do(http.MethodGet, path, nil, "")
+		assert.Equal(t, http.StatusOK, response.StatusCode, path)
+		assert.Empty(t, response.Header.Get("Content-Security-Policy"), path)
+		assert.Equal(t, "nosniff", response.Header.Get("X-Content-Type-Options"), path)
+	}
+}
+
+func TestRequestBodyLimit(t *testing.T) {
+	h := harness.New(t)
+	_, token := h.SeedUser("John", "john@email.com", "123456")
+	name := strings.Repeat("a", harness.MaxBodyBytes)
+
+	response := h.Do(http.MethodPost, "/v1/products", map[string]interface{}{"name": name, "price": 100}, token)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, response.StatusCode)
+
+	var body handlers.Error
+	response.JSON(t, &body)
+	assert.Equal(t, "request_body_too_large", body.Code)
+
+	// Sem Content-Length (chunked) o limite é aplicado durante a leitura do corpo
+	data, _ := json.Marshal(map[string]interface{}{"name": name, "price": 100})
+	request, _ := http.NewRequest(http.MethodPost, h.Server.URL+"/v1/products", io.MultiReader(bytes.NewReader(data)))
+	request.Header.Set("Authorization", "Bearer "+token)
+	request.Header.Set("Content-Type", "application/json")
+	response = h.Send(request)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, response.StatusCode)
+	response.JSON(t, &body)
+	assert.Equal(t, "request_body_too_large", body.Code)
+
+	products, _ := h.ProductDB.FindAll(0, 0, "")
+	assert.Empty(t, products)
+}
+
+// ssoLogin faz o papel do navegador no login pelo SSO: segue os redirects até o callback guardando os cookies
+func ssoLogin(t *testing.T, h *harness.Harness) *harness.Response {
+	t.Helper()
+
+	jar, _ := cookiejar.New(nil)
+	client := &http.Client{Jar: jar}
+
+	response, err := client.Get(h.Server.URL + "/v1/users/oidc/login")
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	data, err := io.ReadAll(response.Body)
+	require.NoError(t, err)
+
+	return &harness.Response{StatusCode: response.StatusCode, Header: response.Header, Body: data}
+}
+
+func TestOIDCLogin(t *testing.T) {
+	h := harness.New(t)
+
+	// O login redireciona para o issuer com PKCE e guarda o state em um cookie assinado
+	client := *h.Server.Client()
+	client.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+	response, err := client.Get(h.Server.URL + "/v1/users/oidc/login")
+	require.NoError(t, err)
+	response.Body.Close()
+	assert.Equal(t, http.StatusFound, response.StatusCode)
+
+	location, _ := url.Parse(response.Header.Get("Location"))
+	assert.True(t, strings.HasPrefix(location.String(), h.Issuer.URL()))
+	assert.Equal(t, "S256", location.Query().Get("code_challenge_method"))
+	assert.NotEmpty(t, location.Query().Get("state"))
+
+	var stateCookie *http.Cookie
+	for _, cookie := range response.Cookies() {
+		if cookie.Name == "oidc_state" {
+			stateCookie = cookie
+		}
+	}
+	require.NotNil(t, stateCookie)
+	assert.True(t, stateCookie.HttpOnly)
+
+	// O cookie do state não serve como token de acesso
+	result := h.Do(http.MethodGet, "/products", nil, stateCookie.Value)
+	assert.Equal(t, http.StatusUnauthorized, result.StatusCode)
+
+	// Primeiro login: o usuário é criado sem senha
+	result = ssoLogin(t, h)
+	assert.Equal(t, http.StatusOK, result.StatusCode)
+
+	var output dto.GetJWTOutput
+	result.JSON(t, &output)
+	result = h.Do(http.MethodGet, "/products", nil, output.AccessToken)
+	assert.Equal(t, http.StatusOK, result.StatusCode)
+
+	user, err := h.UserDB.FindByOIDC(h.Issuer.URL(), "oidc-user")
+	require.NoError(t, err)
+	assert.Equal(t, "sso@email.com", user.Email)
+	assert.False(t, user.IsPasswordValid(""))
+
+	entries, _ := h.AuditDB.Find(database.AuditFilter{EntityID: user.ID.String()})
+	assert.Len(t, entries, 1)
+
+	// Segundo login: encontra o mesmo usuário pelo vínculo
+	result = ssoLogin(t, h)
+	assert.Equal(t, http.StatusOK, result.StatusCode)
+	found, _ := h.UserDB.FindByEmail("sso@email.com")
+	assert.Equal(t, user.ID, found.ID)
+}
+
+func TestOIDCLoginLinksExistingUser(t *testing.T) {
+	h := harness.New(t)
+	user, _ := h.SeedUser("John", "john@email.com", "123456")
+	h.Issuer.SetUser(oidctest.User{Subject: "john-sso", Email: "john@email.com", EmailVerified: true, Name: "John"})
+
+	response := ssoLogin(t, h)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	linked, err := h.UserDB.FindByOIDC(h.Issuer.URL(), "john-sso")
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, linked.ID)
+	// A senha continua valendo
+	assert.True(t, linked.IsPasswordValid("123456"))
+
+	// Outra conta do SSO com o mesmo email não toma o usuário já vinculado
+	h.Issuer.SetUser(oidctest.User{Subject: "other-sso", Email: "john@email.com", EmailVerified: true})
+	response = ssoLogin(t, h)
+	assert.Equal(t, http.StatusConflict, response.StatusCode)
+
+	var body handlers.Error
+	response.JSON(t, &body)
+	assert.Equal(t, "oidc_already_linked", body.Code)
+
+	// Com o segundo fator ativo o SSO também recebe o desafio
+	secret, _, _ := linked.EnrollTOTP()
+	code, _ := totp.Code(secret, totp.Step(time.Now()))
+	linked.ConfirmTOTP(code, time.Now())
+	h.UserDB.Update(linked)
+
+	h.Issuer.SetUser(oidctest.User{Subject: "john-sso", Email: "john@email.com", EmailVerified: true})
+	response = ssoLogin(t, h)
+	assert.Equal(t, http.StatusAccepted, response.StatusCode)
+}
+
+func TestOIDCLoginErrors(t *testing.T) {
+	h := harness.New(t)
+	h.SeedUser("John", "john@email.com", "123456")
+	var body handlers.Error
+
+	// Email não verificado não pode ser usado para vincular nem criar contas
+	h.Issuer.SetUser(oidctest.User{Subject: "attacker", Email: "john@email.com", EmailVerified: false})
+	response := ssoLogin(t, h)
+	assert.Equal(t, http.StatusForbidden, response.StatusCode)
+	response.JSON(t, &body)
+	assert.Equal(t, "oidc_email_not_verified", body.Code)
+
+	h.Issuer.SetUser(oidctest.User{Subject: "denied", Error: "access_denied"})
+	response = ssoLogin(t, h)
+	assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+	response.JSON(t, &body)
+	assert.Equal(t, "oidc_login_failed", body.Code)
+
+	// Callback sem o cookie do login
+	response = h.Do(http.MethodGet, "/v1/users/oidc/callback?code=abc&state=xyz", nil, "")
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+	response.JSON(t, &body)
+	assert.Equal(t, "invalid_oidc_state", body.Code)
+
+	// Callback com o cookie de outro login
+	client := *h.Server.Client()
+	client.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+	login, err := client.Get(h.Server.URL + "/v1/users/oidc/login")
+	require.NoError(t, err)
+	login.Body.Close()
+
+	request, _ := http.NewRequest(http.MethodGet, h.Server.URL+"/v1/users/oidc/callback?code=abc&state=other", nil)
+	for _, cookie := range login.Cookies() {
+		request.AddCookie(cookie)
+	}
+	response = h.Send(request)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+	response.JSON(t, &body)
+	assert.Equal(t, "invalid_oidc_state", body.Code)
+
+	_, err = h.UserDB.FindByOIDC(h.Issuer.URL(), "attacker")
+	assert.Error(t, err)
+}
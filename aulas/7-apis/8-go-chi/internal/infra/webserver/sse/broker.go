@@ -0,0 +1,114 @@
+package sse
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+const (
+	DefaultReplaySize       = 256
+	defaultSubscriberBuffer = 16
+)
+
+type Event struct {
+	ID   uint64
+	Type string
+	Data []byte
+}
+
+type Subscription struct {
+	Events <-chan Event
+	events chan Event
+}
+
+// Broker distribui os eventos para todos os clientes conectados e guarda os
+// últimos eventos publicados para permitir a retomada via Last-Event-ID
+type Broker struct {
+	mutex       sync.Mutex
+	lastID      uint64
+	buffer      []Event
+	replaySize  int
+	subscribers map[*Subscription]struct{}
+}
+
+func NewBroker(replaySize int) *Broker {
+	if replaySize <= 0 {
+		replaySize = DefaultReplaySize
+	}
+
+	return &Broker{
+		buffer:      make([]Event, 0, replaySize),
+		replaySize:  replaySize,
+		subscribers: make(map[*Subscription]struct{}),
+	}
+}
+
+func (b *Broker) Publish(eventType string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.lastID++
+	event := Event{ID: b.lastID, Type: eventType, Data: data}
+
+	// Buffer circular: ao atingir o limite, descartamos o evento mais antigo
+	if len(b.buffer) == b.replaySize {
+		copy(b.buffer, b.buffer[1:])
+		b.buffer = b.buffer[:len(b.buffer)-1]
+	}
+	b.buffer = append(b.buffer, event)
+
+	for subscription := range b.subscribers {
+		select {
+		case subscription.events <- event:
+		default:
+			// Cliente lento: encerramos a conexão para que ele reconecte usando o Last-Event-ID
+			delete(b.subscribers, subscription)
+			close(subscription.events)
+		}
+	}
+
+	return nil
+}
+
+// Subscribe registra um novo cliente e devolve os eventos do buffer posteriores a lastEventID.
+// Quando não dá para retomar, porque lastEventID é maior que o último id (os ids recomeçam quando o
+// processo reinicia) ou os eventos seguintes já saíram do buffer, devolve só um evento reset com o id atual.
+// O snapshot e o registro acontecem sob o mesmo lock para que nenhum evento seja perdido entre eles
+func (b *Broker) Subscribe(lastEventID uint64) (*Subscription, []Event) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	var replay []Event
+	switch {
+	case lastEventID == 0:
+	case lastEventID > b.lastID, len(b.buffer) > 0 && lastEventID+1 < b.buffer[0].ID:
+		replay = []Event{{ID: b.lastID, Type: EventReset, Data: []byte("{}")}}
+	default:
+		for _, event := range b.buffer {
+			if event.ID > lastEventID {
+				replay = append(replay, event)
+			}
+		}
+	}
+
+	events := make(chan Event, defaultSubscriberBuffer)
+	subscription := &Subscription{Events: events, events: events}
+	b.subscribers[subscription] = struct{}{}
+
+	return subscription, replay
+}
+
+func (b *Broker) Unsubscribe(subscription *Subscription) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if _, ok := b.subscribers[subscription]; ok {
+		delete(b.subscribers, subscription)
+		close(subscription.events)
+	}
+}
@@ -0,0 +1,105 @@
+package sse
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBrokerPublishToSubscribers(t *testing.T) {
+	broker := NewBroker(10)
+	subscription, replay := broker.Subscribe(0)
+	defer broker.Unsubscribe(subscription)
+
+	assert.Empty(t, replay)
+
+	err := broker.Publish(EventCreated, map[string]string{"id": "1"})
+	assert.Nil(t, err)
+
+	event := <-subscription.Events
+	assert.Equal(t, uint64(1), event.ID)
+	assert.Equal(t, EventCreated, event.Type)
+	assert.JSONEq(t, `{"id":"1"}`, string(event.Data))
+}
+
+func TestBrokerReplayFromLastEventID(t *testing.T) {
+	broker := NewBroker(10)
+	for i := 0; i < 5; i++ {
+		broker.Publish(EventUpdated, i)
+	}
+
+	subscription, replay := broker.Subscribe(3)
+	defer broker.Unsubscribe(subscription)
+
+	assert.Len(t, replay, 2)
+	assert.Equal(t, uint64(4), replay[0].ID)
+	assert.Equal(t, uint64(5), replay[1].ID)
+}
+
+func TestBrokerReplayIsBounded(t *testing.T) {
+	broker := NewBroker(3)
+	for i := 0; i < 10; i++ {
+		broker.Publish(EventUpdated, i)
+	}
+
+	subscription, replay := broker.Subscribe(7)
+	defer broker.Unsubscribe(subscription)
+
+	assert.Len(t, replay, 3)
+	assert.Equal(t, uint64(8), replay[0].ID)
+	assert.Equal(t, uint64(10), replay[2].ID)
+
+	// Os eventos 2 a 7 saíram do buffer: o cliente precisa recarregar
+	subscription, replay = broker.Subscribe(1)
+	defer broker.Unsubscribe(subscription)
+
+	assert.Equal(t, []Event{{ID: 10, Type: EventReset, Data: []byte("{}")}}, replay)
+}
+
+func TestBrokerResetsUnknownLastEventID(t *testing.T) {
+	// Depois de reiniciar, os ids recomeçam e o cliente chega com um id maior que o último
+	broker := NewBroker(10)
+	broker.Publish(EventUpdated, 1)
+
+	subscription, replay := broker.Subscribe(42)
+	defer broker.Unsubscribe(subscription)
+
+	assert.Equal(t, []Event{{ID: 1, Type: EventReset, Data: []byte("{}")}}, replay)
+
+	subscription, replay = broker.Subscribe(1)
+	defer broker.Unsubscribe(subscription)
+	assert.Empty(t, replay)
+}
+
+func TestBrokerDropsSlowSubscriber(t *testing.T) {
+	broker := NewBroker(100)
+	subscription, _ := broker.Subscribe(0)
+
+	for i := 0; i < defaultSubscriberBuffer+1; i++ {
+		broker.Publish(EventCreated, i)
+	}
+
+	received := 0
+	for range subscription.Events {
+		received++
+	}
+	assert.Equal(t, defaultSubscriberBuffer, received)
+
+	// Unsubscribe depois do descarte não deve entrar em pânico
+	broker.Unsubscribe(subscription)
+}
+
+func TestWriteEvent(t *testing.T) {
+	var buffer bytes.Buffer
+
+	err := WriteEvent(&buffer, Event{ID: 7, Type: EventDeleted, Data: []byte(`{"id":"1"}`)})
+	assert.Nil(t, err)
+	assert.Equal(t, "id: 7\nevent: deleted\ndata: {\"id\":\"1\"}\n\n", buffer.String())
+}
+
+func TestParseLastEventID(t *testing.T) {
+	assert.Equal(t, uint64(42), ParseLastEventID("42"))
+	assert.Equal(t, uint64(0), ParseLastEventID(""))
+	assert.Equal(t, uint64(0), ParseLastEventID("abc"))
+}
@@ -0,0 +1,48 @@
+package sse
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	EventCreated = "created"
+	EventUpdated = "updated"
+	EventDeleted = "deleted"
+	// EventReset avisa que a retomada não é possível (ex.: o servidor reiniciou ou os eventos saíram do buffer)
+	// e que o cliente deve recarregar a lista de produtos
+	EventReset = "reset"
+)
+
+// WriteEvent escreve o evento no formato text/event-stream:
+// id, event e uma linha data para cada linha do payload, terminando com uma linha em branco
+func WriteEvent(writer io.Writer, event Event) error {
+	var builder strings.Builder
+
+	fmt.Fprintf(&builder, "id: %d\n", event.ID)
+	fmt.Fprintf(&builder, "event: %s\n", event.Type)
+	for _, line := range strings.Split(string(event.Data), "\n") {
+		fmt.Fprintf(&builder, "data: %s\n", line)
+	}
+	builder.WriteString("\n")
+
+	_, err := io.WriteString(writer, builder.String())
+	return err
+}
+
+// WriteHeartbeat envia um comentário, ignorado pelo EventSource, apenas para manter a conexão viva
+func WriteHeartbeat(writer io.Writer) error {
+	_, err := io.WriteString(writer, ": heartbeat\n\n")
+	return err
+}
+
+func ParseLastEventID(value string) uint64 {
+	id, err := strconv.ParseUint(strings.TrimSpace(value), 10, 64)
+	if err != nil {
+		return 0
+	}
+
+	return id
+}
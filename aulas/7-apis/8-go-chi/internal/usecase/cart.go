@@ -0,0 +1,45 @@
+package usecase
+
+import (
+	"errors"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/entity"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/database"
+)
+
+// updateCarts aplica o preço novo do produto nos carrinhos. O comprador é avisado na próxima leitura do carrinho.
+// Deve ser chamado dentro de Transaction.Run, junto com a atualização do produto
+func updateCarts(repositories database.Repositories, product *entity.Product) error {
+	return changeCarts(repositories, product.ID.String(), func(cart *entity.Cart) bool {
+		return cart.ApplyProduct(product)
+	})
+}
+
+// removeFromCarts marca o produto excluído nos carrinhos. Deve ser chamado dentro de Transaction.Run
+func removeFromCarts(repositories database.Repositories, product *entity.Product) error {
+	return changeCarts(repositories, product.ID.String(), func(cart *entity.Cart) bool {
+		return cart.RemoveProduct(product.ID)
+	})
+}
+
+func changeCarts(repositories database.Repositories, productID string, change func(cart *entity.Cart) bool) error {
+	carts, err := repositories.Cart.FindByProductID(productID)
+	if err != nil {
+		return err
+	}
+
+	for _, cart := range carts {
+		if !change(cart) {
+			continue
+		}
+
+		// Com o banco os carrinhos estão travados e o conflito não acontece. Se acontecer, quem gravou antes
+		// leu o carrinho sem a mudança, e a revalidação na leitura seguinte compara com o catálogo de qualquer forma
+		err := repositories.Cart.Save(cart)
+		if err != nil && !errors.Is(err, entity.ErrCartConflict) {
+			return err
+		}
+	}
+
+	return nil
+}
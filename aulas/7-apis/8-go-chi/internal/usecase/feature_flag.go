@@ -0,0 +1,162 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/entity"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/database"
+	"gorm.io/gorm"
+)
+
+// FeatureFlagInput é usado tanto na criação quanto na atualização, que substitui a flag inteira
+type FeatureFlagInput struct {
+	Key         string
+	Description string
+	Enabled     bool
+	Percentage  int
+	UserIDs     []string
+	Roles       []string
+}
+
+type FeatureFlagOutput struct {
+	Key         string    `json:"key"`
+	Description string    `json:"description"`
+	Enabled     bool      `json:"enabled"`
+	Percentage  int       `json:"percentage"`
+	UserIDs     []string  `json:"user_ids"`
+	Roles       []string  `json:"roles"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// FlagCache é a cópia das flags usada na avaliação (ex.: featureflag.Store), recarregada depois de cada mudança
+type FlagCache interface {
+	Refresh() error
+}
+
+type FeatureFlagUseCase struct {
+	FlagDB database.FeatureFlagInterface
+	// Cache pode ser nil quando ninguém avalia as flags neste processo
+	Cache FlagCache
+}
+
+func NewFeatureFlagUseCase(db database.FeatureFlagInterface, cache FlagCache) *FeatureFlagUseCase {
+	return &FeatureFlagUseCase{FlagDB: db, Cache: cache}
+}
+
+func (u *FeatureFlagUseCase) Create(input FeatureFlagInput) (*FeatureFlagOutput, error) {
+	flag, err := entity.NewFeatureFlag(input.Key, input.Description, input.Enabled, input.Percentage, input.UserIDs, input.Roles)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidInput, err)
+	}
+
+	_, err = u.FlagDB.FindByKey(flag.Key)
+	if err == nil {
+		return nil, fmt.Errorf("%w: %w", ErrConflict, ErrFeatureFlagExists)
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if err := u.FlagDB.Create(flag); err != nil {
+		return nil, err
+	}
+	u.refresh()
+
+	return featureFlagOutput(flag), nil
+}
+
+func (u *FeatureFlagUseCase) List() ([]*FeatureFlagOutput, error) {
+	flags, err := u.FlagDB.FindAll()
+	if err != nil {
+		return nil, err
+	}
+
+	output := make([]*FeatureFlagOutput, 0, len(flags))
+	for _, flag := range flags {
+		output = append(output, featureFlagOutput(flag))
+	}
+
+	return output, nil
+}
+
+func (u *FeatureFlagUseCase) Find(key string) (*FeatureFlagOutput, error) {
+	flag, err := u.find(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return featureFlagOutput(flag), nil
+}
+
+func (u *FeatureFlagUseCase) Update(input FeatureFlagInput) (*FeatureFlagOutput, error) {
+	current, err := u.find(input.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	flag := *current
+	flag.Description = input.Description
+	flag.Enabled = input.Enabled
+	flag.Percentage = input.Percentage
+	flag.UserIDs = input.UserIDs
+	flag.Roles = input.Roles
+	flag.UpdatedAt = time.Now()
+
+	if err := flag.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidInput, err)
+	}
+
+	if err := u.FlagDB.Update(&flag); err != nil {
+		return nil, err
+	}
+	u.refresh()
+
+	return featureFlagOutput(&flag), nil
+}
+
+func (u *FeatureFlagUseCase) Delete(key string) error {
+	if _, err := u.find(key); err != nil {
+		return err
+	}
+
+	if err := u.FlagDB.Delete(key); err != nil {
+		return err
+	}
+	u.refresh()
+
+	return nil
+}
+
+func (u *FeatureFlagUseCase) find(key string) (*entity.FeatureFlag, error) {
+	flag, err := u.FlagDB.FindByKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrFeatureFlagNotFound, err)
+	}
+
+	return flag, nil
+}
+
+// refresh ignora o erro: a mudança já foi gravada e o refresh periódico do cache tenta de novo
+func (u *FeatureFlagUseCase) refresh() {
+	if u.Cache == nil {
+		return
+	}
+
+	u.Cache.Refresh()
+}
+
+func featureFlagOutput(flag *entity.FeatureFlag) *FeatureFlagOutput {
+	return &FeatureFlagOutput{
+		Key:         flag.Key,
+		Description: flag.Description,
+		Enabled:     flag.Enabled,
+		Percentage:  flag.Percentage,
+		UserIDs:     append([]string{}, flag.UserIDs...),
+		Roles:       append([]string{}, flag.Roles...),
+		CreatedAt:   flag.CreatedAt,
+		UpdatedAt:   flag.UpdatedAt,
+	}
+}
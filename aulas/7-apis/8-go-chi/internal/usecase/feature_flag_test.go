@@ -0,0 +1,55 @@
+package usecase
+
+import (
+	"testing"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/entity"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type countingCache struct {
+	refreshes int
+}
+
+func (c *countingCache) Refresh() error {
+	c.refreshes++
+	return nil
+}
+
+func TestFeatureFlagLifecycle(t *testing.T) {
+	cache := &countingCache{}
+	useCase := NewFeatureFlagUseCase(database.NewFeatureFlagMemory(), cache)
+
+	flag, err := useCase.Create(FeatureFlagInput{Key: "new-pricing", Enabled: true, Percentage: 10, Roles: []string{RoleAdmin}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{RoleAdmin}, flag.Roles)
+	assert.Equal(t, []string{}, flag.UserIDs)
+
+	_, err = useCase.Create(FeatureFlagInput{Key: "new-pricing"})
+	assert.ErrorIs(t, err, ErrConflict)
+	assert.ErrorIs(t, err, ErrFeatureFlagExists)
+
+	_, err = useCase.Create(FeatureFlagInput{Key: "new-dto", Percentage: 150})
+	assert.ErrorIs(t, err, ErrInvalidInput)
+	assert.ErrorIs(t, err, entity.ErrInvalidPercentage)
+
+	flag, err = useCase.Update(FeatureFlagInput{Key: "new-pricing", Enabled: true, Percentage: 50})
+	require.NoError(t, err)
+	assert.Equal(t, 50, flag.Percentage)
+	assert.Empty(t, flag.Roles)
+
+	_, err = useCase.Update(FeatureFlagInput{Key: "unknown"})
+	assert.ErrorIs(t, err, ErrFeatureFlagNotFound)
+
+	flags, _ := useCase.List()
+	assert.Len(t, flags, 1)
+
+	require.NoError(t, useCase.Delete("new-pricing"))
+	_, err = useCase.Find("new-pricing")
+	assert.ErrorIs(t, err, ErrFeatureFlagNotFound)
+
+	// Cada mudança gravada recarrega o cache; as que falharam não
+	assert.Equal(t, 3, cache.refreshes)
+}
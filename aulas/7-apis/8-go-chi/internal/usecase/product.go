@@ -0,0 +1,299 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/entity"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/database"
+	entityPkg "github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/pkg/entity"
+	"gorm.io/gorm"
+)
+
+const defaultPriceStatsWindow = 30 * 24 * time.Hour
+
+// Actor é o id do usuário que faz a mudança, gravado na auditoria e no histórico de preço
+type ProductInput struct {
+	Name     string
+	Price    int
+	Category string
+	Actor    string
+}
+
+type UpdateProductInput struct {
+	ID string
+	ProductInput
+}
+
+type DeleteProductInput struct {
+	ID    string
+	Actor string
+}
+
+type ListProductsInput struct {
+	Page  int
+	Limit int
+	Sort  string
+}
+
+// Datas zeradas em From/To significam período aberto. Nas estatísticas o padrão são os últimos 30 dias
+type PriceHistoryInput struct {
+	ProductID string
+	From      time.Time
+	To        time.Time
+}
+
+// ProductOutput tem os mesmos campos JSON da entidade, sem os campos internos do agregado de avaliações
+type ProductOutput struct {
+	ID            string    `json:"id"`
+	Name          string    `json:"name"`
+	Price         int       `json:"price"`
+	Category      string    `json:"category,omitempty"`
+	RatingAverage float64   `json:"rating_average"`
+	RatingCount   int       `json:"rating_count"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+type ProductUseCase struct {
+	ProductDB      database.ProductInterface
+	PriceHistoryDB database.PriceHistoryInterface
+	Transaction    database.TransactionInterface
+	// Events pode ser nil quando ninguém acompanha as mudanças
+	Events EventPublisher
+}
+
+func NewProductUseCase(db database.ProductInterface, priceHistoryDB database.PriceHistoryInterface, transaction database.TransactionInterface, events EventPublisher) *ProductUseCase {
+	return &ProductUseCase{
+		ProductDB:      db,
+		PriceHistoryDB: priceHistoryDB,
+		Transaction:    transaction,
+		Events:         events,
+	}
+}
+
+func (u *ProductUseCase) Create(input ProductInput) (*ProductOutput, error) {
+	product, err := entity.NewProduct(input.Name, input.Price)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidInput, err)
+	}
+	product.Category = input.Category
+
+	// O registro de auditoria é gravado na mesma transação: ou os dois existem, ou nenhum
+	err = u.Transaction.Run(func(repositories database.Repositories) error {
+		if err := repositories.Product.Create(product); err != nil {
+			return err
+		}
+
+		return recordAudit(repositories, input.Actor, entity.AuditCreate, auditProduct, product.ID.String(), nil, product)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	output := productOutput(product)
+	u.publish(EventProductCreated, output)
+
+	return output, nil
+}
+
+func (u *ProductUseCase) Find(id string) (*ProductOutput, error) {
+	product, err := u.find(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return productOutput(product), nil
+}
+
+func (u *ProductUseCase) List(input ListProductsInput) ([]*ProductOutput, error) {
+	products, err := u.ProductDB.FindAll(input.Page, input.Limit, input.Sort)
+	if err != nil {
+		return nil, err
+	}
+
+	output := make([]*ProductOutput, 0, len(products))
+	for _, product := range products {
+		output = append(output, productOutput(product))
+	}
+
+	return output, nil
+}
+
+func (u *ProductUseCase) Update(input UpdateProductInput) (*ProductOutput, error) {
+	if _, err := entityPkg.ParseID(input.ID); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidInput, entity.ErrInvalidID)
+	}
+
+	var product entity.Product
+	err := u.Transaction.Run(func(repositories database.Repositories) error {
+		// O estado anterior (preço antigo, diff da auditoria e queda de preço das listas) vem da leitura travada:
+		// lido antes da transação, duas edições simultâneas partiriam do mesmo produto
+		current, err := findForUpdate(repositories, input.ID)
+		if err != nil {
+			return err
+		}
+
+		product = *current
+		product.Name = input.Name
+		product.Price = input.Price
+		product.Category = input.Category
+		product.UpdatedAt = time.Now()
+
+		if err := product.Validate(); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidInput, err)
+		}
+
+		if err := repositories.Product.Update(&product); err != nil {
+			return err
+		}
+
+		if current.Price != product.Price {
+			change := entity.NewPriceChange(product.ID, current.Price, product.Price, input.Actor)
+			if err := repositories.PriceHistory.Create(change); err != nil {
+				return err
+			}
+
+			if err := updateCarts(repositories, &product); err != nil {
+				return err
+			}
+		}
+
+		if current.Price != product.Price || current.Name != product.Name {
+			if err := updateWishlists(repositories, &product); err != nil {
+				return err
+			}
+		}
+
+		return recordAudit(repositories, input.Actor, entity.AuditUpdate, auditProduct, input.ID, current, &product)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	output := productOutput(&product)
+	u.publish(EventProductUpdated, output)
+
+	return output, nil
+}
+
+func (u *ProductUseCase) Delete(input DeleteProductInput) error {
+	err := u.Transaction.Run(func(repositories database.Repositories) error {
+		current, err := findForUpdate(repositories, input.ID)
+		if err != nil {
+			return err
+		}
+
+		if err := repositories.Product.Delete(input.ID); err != nil {
+			return err
+		}
+
+		if err := repositories.Review.DeleteByProductID(input.ID); err != nil {
+			return err
+		}
+
+		if err := removeFromWishlists(repositories, current); err != nil {
+			return err
+		}
+
+		if err := removeFromCarts(repositories, current); err != nil {
+			return err
+		}
+
+		return recordAudit(repositories, input.Actor, entity.AuditDelete, auditProduct, input.ID, current, nil)
+	})
+	if err != nil {
+		return err
+	}
+
+	u.publish(EventProductDeleted, map[string]string{"id": input.ID})
+
+	return nil
+}
+
+func (u *ProductUseCase) PriceHistory(input PriceHistoryInput) ([]*entity.PriceChange, error) {
+	if _, err := u.find(input.ProductID); err != nil {
+		return nil, err
+	}
+
+	return u.PriceHistoryDB.FindByProductID(input.ProductID, input.From, input.To)
+}
+
+func (u *ProductUseCase) PriceStats(input PriceHistoryInput) (*entity.PriceSummary, error) {
+	if input.To.IsZero() {
+		input.To = time.Now()
+	}
+	if input.From.IsZero() {
+		input.From = input.To.Add(-defaultPriceStatsWindow)
+	}
+
+	product, err := u.find(input.ProductID)
+	if err != nil {
+		return nil, err
+	}
+
+	// O histórico completo é necessário para descobrir o preço vigente no início do período
+	changes, err := u.PriceHistoryDB.FindByProductID(input.ProductID, time.Time{}, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+
+	summary, err := entity.SummarizePrices(product.Price, changes, input.From, input.To)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidInput, err)
+	}
+
+	return summary, nil
+}
+
+func (u *ProductUseCase) find(id string) (*entity.Product, error) {
+	if id == "" {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidInput, entity.ErrIDIsRequired)
+	}
+
+	product, err := u.ProductDB.FindByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrProductNotFound, err)
+	}
+
+	return product, nil
+}
+
+// findForUpdate lê o produto travado dentro da transação. Só a ausência do produto vira ErrProductNotFound
+func findForUpdate(repositories database.Repositories, id string) (*entity.Product, error) {
+	if id == "" {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidInput, entity.ErrIDIsRequired)
+	}
+
+	product, err := repositories.Product.FindByIDForUpdate(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("%w: %v", ErrProductNotFound, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return product, nil
+}
+
+func (u *ProductUseCase) publish(eventType string, payload interface{}) {
+	if u.Events == nil {
+		return
+	}
+
+	u.Events.Publish(eventType, payload)
+}
+
+func productOutput(product *entity.Product) *ProductOutput {
+	return &ProductOutput{
+		ID:            product.ID.String(),
+		Name:          product.Name,
+		Price:         product.Price,
+		Category:      product.Category,
+		RatingAverage: product.RatingAverage,
+		RatingCount:   product.RatingCount,
+		CreatedAt:     product.CreatedAt,
+		UpdatedAt:     product.UpdatedAt,
+	}
+}
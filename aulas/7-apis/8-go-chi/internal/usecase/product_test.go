@@ -0,0 +1,207 @@
+package usecase
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/entity"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type publishedEvent struct {
+	Type    string
+	Payload interface{}
+}
+
+type recordingPublisher struct {
+	mutex  sync.Mutex
+	events []publishedEvent
+}
+
+func (p *recordingPublisher) Publish(eventType string, payload interface{}) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.events = append(p.events, publishedEvent{Type: eventType, Payload: payload})
+	return nil
+}
+
+func newProductUseCase() (*ProductUseCase, database.Repositories, *recordingPublisher) {
+	products := database.NewProductMemory()
+	repositories := database.Repositories{
+		Product:      products,
+		PriceHistory: database.NewPriceHistoryMemory(),
+		User:         database.NewUserMemory(),
+		Audit:        database.NewAuditMemory(),
+		Wishlist:     database.NewWishlistMemory(),
+		Notification: database.NewNotificationMemory(),
+		Review:       database.NewReviewMemory(products),
+		Cart:         database.NewCartMemory(),
+	}
+	events := &recordingPublisher{}
+
+	return NewProductUseCase(repositories.Product, repositories.PriceHistory, database.NewTransactionMemory(repositories), events), repositories, events
+}
+
+func TestCreateProduct(t *testing.T) {
+	useCase, repositories, events := newProductUseCase()
+
+	output, err := useCase.Create(ProductInput{Name: "Product 1", Price: 100, Category: "books", Actor: "user-1"})
+	require.NoError(t, err)
+	assert.NotEmpty(t, output.ID)
+	assert.Equal(t, "books", output.Category)
+
+	stored, err := repositories.Product.FindByID(output.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 100, stored.Price)
+
+	entries, _ := repositories.Audit.Find(database.AuditFilter{EntityID: output.ID})
+	require.Len(t, entries, 1)
+	assert.Equal(t, "user-1", entries[0].Actor)
+	assert.Equal(t, entity.AuditCreate, entries[0].Action)
+
+	require.Len(t, events.events, 1)
+	assert.Equal(t, EventProductCreated, events.events[0].Type)
+
+	// O erro da entidade continua acessível, junto com a classificação do use case
+	_, err = useCase.Create(ProductInput{Name: "", Price: 100})
+	assert.ErrorIs(t, err, ErrInvalidInput)
+	assert.ErrorIs(t, err, entity.ErrNameIsRequired)
+	assert.Len(t, events.events, 1)
+}
+
+func TestUpdateProductRecordsPriceChange(t *testing.T) {
+	useCase, repositories, events := newProductUseCase()
+	created, _ := useCase.Create(ProductInput{Name: "Product 1", Price: 100})
+
+	output, err := useCase.Update(UpdateProductInput{ID: created.ID, ProductInput: ProductInput{Name: "Product 1", Price: 150, Actor: "user-1"}})
+	require.NoError(t, err)
+	assert.Equal(t, 150, output.Price)
+
+	changes, _ := repositories.PriceHistory.FindByProductID(created.ID, time.Time{}, time.Time{})
+	require.Len(t, changes, 1)
+	assert.Equal(t, 100, changes[0].OldPrice)
+	assert.Equal(t, "user-1", changes[0].ChangedBy)
+	assert.Equal(t, EventProductUpdated, events.events[1].Type)
+
+	// Sem mudança de preço não há histórico novo
+	_, err = useCase.Update(UpdateProductInput{ID: created.ID, ProductInput: ProductInput{Name: "Renamed", Price: 150}})
+	require.NoError(t, err)
+	changes, _ = useCase.PriceHistory(PriceHistoryInput{ProductID: created.ID})
+	assert.Len(t, changes, 1)
+
+	_, err = useCase.Update(UpdateProductInput{ID: created.ID, ProductInput: ProductInput{Name: "Product 1", Price: -1}})
+	assert.ErrorIs(t, err, ErrInvalidInput)
+	assert.ErrorIs(t, err, entity.ErrInvalidPrice)
+
+	_, err = useCase.Update(UpdateProductInput{ID: "invalid", ProductInput: ProductInput{Name: "Product 1", Price: 10}})
+	assert.ErrorIs(t, err, entity.ErrInvalidID)
+
+	_, err = useCase.Update(UpdateProductInput{ID: "0b6e8d8e-5f3c-4b8a-9c1d-2e7f4a6b8c90", ProductInput: ProductInput{Name: "Product 1", Price: 10}})
+	assert.ErrorIs(t, err, ErrProductNotFound)
+}
+
+func TestUpdateProductConcurrently(t *testing.T) {
+	useCase, repositories, _ := newProductUseCase()
+	created, _ := useCase.Create(ProductInput{Name: "Product 1", Price: 100})
+
+	var wg sync.WaitGroup
+	for i := 1; i <= 10; i++ {
+		wg.Add(1)
+		go func(price int) {
+			defer wg.Done()
+			useCase.Update(UpdateProductInput{ID: created.ID, ProductInput: ProductInput{Name: "Product 1", Price: price}})
+		}(100 + i)
+	}
+	wg.Wait()
+
+	// Cada mudança parte do preço gravado pela anterior, sem duas mudanças com o mesmo preço antigo
+	changes, _ := repositories.PriceHistory.FindByProductID(created.ID, time.Time{}, time.Time{})
+	require.Len(t, changes, 10)
+	oldPrices := map[int]bool{}
+	for _, change := range changes {
+		assert.False(t, oldPrices[change.OldPrice], change.OldPrice)
+		oldPrices[change.OldPrice] = true
+	}
+	assert.True(t, oldPrices[100])
+}
+
+func TestProductChangesReachCarts(t *testing.T) {
+	useCase, repositories, _ := newProductUseCase()
+	created, _ := useCase.Create(ProductInput{Name: "Product 1", Price: 100})
+	product, _ := repositories.Product.FindByID(created.ID)
+
+	cart, _ := repositories.Cart.FindOrCreate("user-1")
+	cart.AddItem(product, 1)
+	require.NoError(t, repositories.Cart.Save(cart))
+
+	_, err := useCase.Update(UpdateProductInput{ID: created.ID, ProductInput: ProductInput{Name: "Product 1", Price: 80}})
+	require.NoError(t, err)
+
+	cart, _ = repositories.Cart.FindByUserID("user-1")
+	assert.Equal(t, 80, cart.Items[0].UnitPrice)
+	assert.Equal(t, 100, cart.Items[0].PreviousPrice)
+
+	require.NoError(t, useCase.Delete(DeleteProductInput{ID: created.ID}))
+
+	cart, _ = repositories.Cart.FindByUserID("user-1")
+	assert.True(t, cart.Items[0].Removed)
+}
+
+func TestDeleteProduct(t *testing.T) {
+	useCase, repositories, events := newProductUseCase()
+	created, _ := useCase.Create(ProductInput{Name: "Product 1", Price: 100})
+	review, _ := entity.NewReview(created.ID, "user-1", 5, "")
+	require.NoError(t, repositories.Review.Create(review))
+
+	require.NoError(t, useCase.Delete(DeleteProductInput{ID: created.ID, Actor: "user-1"}))
+
+	_, err := useCase.Find(created.ID)
+	assert.ErrorIs(t, err, ErrProductNotFound)
+
+	// As avaliações saem junto com o produto
+	_, err = repositories.Review.FindByID(review.ID.String())
+	assert.Error(t, err)
+
+	entries, _ := repositories.Audit.Find(database.AuditFilter{EntityID: created.ID})
+	assert.Len(t, entries, 2)
+	assert.Equal(t, EventProductDeleted, events.events[1].Type)
+
+	err = useCase.Delete(DeleteProductInput{ID: created.ID})
+	assert.ErrorIs(t, err, ErrProductNotFound)
+
+	_, err = useCase.Find("")
+	assert.ErrorIs(t, err, entity.ErrIDIsRequired)
+}
+
+func TestListProducts(t *testing.T) {
+	useCase, _, _ := newProductUseCase()
+	useCase.Create(ProductInput{Name: "Product 1", Price: 100})
+	useCase.Create(ProductInput{Name: "Product 2", Price: 200})
+
+	products, err := useCase.List(ListProductsInput{Page: 1, Limit: 1})
+	require.NoError(t, err)
+	assert.Len(t, products, 1)
+
+	products, err = useCase.List(ListProductsInput{})
+	require.NoError(t, err)
+	assert.Len(t, products, 2)
+}
+
+func TestPriceStats(t *testing.T) {
+	useCase, _, _ := newProductUseCase()
+	created, _ := useCase.Create(ProductInput{Name: "Product 1", Price: 100})
+
+	summary, err := useCase.PriceStats(PriceHistoryInput{ProductID: created.ID})
+	require.NoError(t, err)
+	assert.Equal(t, 100, summary.Min)
+	assert.Equal(t, 100, summary.Max)
+
+	now := time.Now()
+	_, err = useCase.PriceStats(PriceHistoryInput{ProductID: created.ID, From: now, To: now.Add(-time.Hour)})
+	assert.ErrorIs(t, err, ErrInvalidInput)
+	assert.ErrorIs(t, err, entity.ErrInvalidPeriod)
+}
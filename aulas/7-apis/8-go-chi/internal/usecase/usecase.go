@@ -0,0 +1,75 @@
+package usecase
+
+import (
+	"errors"
+	"time"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/entity"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/database"
+)
+
+// Os use cases concentram as regras de cada operação (validação, transação, auditoria e eventos).
+// Quem chama (handler HTTP, CLI, gRPC ou consumer) só converte a entrada e a saída
+
+const (
+	RoleAdmin = "admin"
+	RoleUser  = "user"
+
+	// Tipos dos eventos publicados nas mudanças de produto, os mesmos do stream SSE
+	EventProductCreated = "created"
+	EventProductUpdated = "updated"
+	EventProductDeleted = "deleted"
+
+	// Tempo para o usuário digitar o código depois de acertar a senha
+	TOTPChallengeTTL = 5 * time.Minute
+)
+
+// Tipos de entidade gravados na auditoria, usados no filtro ?entity= da listagem
+const (
+	auditProduct = "product"
+	auditUser    = "user"
+)
+
+// Os erros abaixo classificam a falha para quem chama. Eles envolvem o erro original,
+// então errors.Is continua encontrando o erro da entidade (ex.: entity.ErrNameIsRequired)
+var (
+	ErrInvalidInput = errors.New("invalid input")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrConflict     = errors.New("conflict")
+)
+
+var (
+	ErrProductNotFound    = errors.New("product not found")
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrInvalidChallenge   = errors.New("invalid challenge token")
+	// ErrUserNotFound aparece nas operações do próprio usuário, quando o sub do token não existe mais
+	ErrUserNotFound         = errors.New("user not found")
+	ErrOIDCEmailNotVerified = errors.New("oidc email not verified")
+	// ErrWishlistNotFound também cobre as listas de outros usuários e os links de compartilhamento revogados
+	ErrWishlistNotFound    = errors.New("wishlist not found")
+	ErrFeatureFlagNotFound = errors.New("feature flag not found")
+	ErrFeatureFlagExists   = errors.New("feature flag already exists")
+)
+
+// EventPublisher recebe as mudanças depois do commit da transação (ex.: o broker do SSE)
+type EventPublisher interface {
+	Publish(eventType string, payload interface{}) error
+}
+
+// TokenIssuer assina os tokens do login. A implementação com JWT fica em infra/auth
+type TokenIssuer interface {
+	AccessToken(userID, role string) (string, error)
+	ChallengeToken(userID string, ttl time.Duration) (string, error)
+	// ChallengeSubject valida o token de desafio e devolve o id do usuário
+	ChallengeSubject(token string) (string, error)
+}
+
+// recordAudit grava a auditoria em nome de actor. Deve ser chamado dentro de Transaction.Run
+func recordAudit(repositories database.Repositories, actor, action, entityType, entityID string, before, after interface{}) error {
+	entry, err := entity.NewAuditEntry(actor, action, entityType, entityID, before, after)
+	if err != nil {
+		return err
+	}
+
+	return repositories.Audit.Create(entry)
+}
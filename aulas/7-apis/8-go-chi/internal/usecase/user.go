@@ -0,0 +1,309 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/entity"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/database"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/pkg/totp"
+	"gorm.io/gorm"
+)
+
+const totpIssuer = "Go Expert API"
+
+type CreateUserInput struct {
+	Name     string
+	Email    string
+	Password string
+	Actor    string
+}
+
+type UserOutput struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+type LoginInput struct {
+	Email    string
+	Password string
+}
+
+// TOTPLoginInput aceita em Code tanto o código do app autenticador quanto um código de recuperação
+type TOTPLoginInput struct {
+	ChallengeToken string
+	Code           string
+}
+
+// OIDCLoginInput são as claims do ID token já verificado
+type OIDCLoginInput struct {
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// LoginOutput traz o AccessToken, ou o ChallengeToken quando o usuário tem o segundo fator ativo
+type LoginOutput struct {
+	AccessToken        string
+	ChallengeToken     string
+	ChallengeExpiresIn time.Duration
+}
+
+type ConfirmTOTPInput struct {
+	UserID string
+	Code   string
+}
+
+// TOTPEnrollmentOutput é a única vez em que os códigos de recuperação aparecem em texto puro
+type TOTPEnrollmentOutput struct {
+	Secret          string
+	ProvisioningURI string
+	RecoveryCodes   []string
+}
+
+type UserUseCase struct {
+	UserDB      database.UserInterface
+	Transaction database.TransactionInterface
+	Tokens      TokenIssuer
+	// Usuários com esses emails recebem a role admin no token
+	AdminEmails []string
+}
+
+func NewUserUseCase(db database.UserInterface, transaction database.TransactionInterface, tokens TokenIssuer, adminEmails []string) *UserUseCase {
+	return &UserUseCase{
+		UserDB:      db,
+		Transaction: transaction,
+		Tokens:      tokens,
+		AdminEmails: adminEmails,
+	}
+}
+
+func (u *UserUseCase) Create(input CreateUserInput) (*UserOutput, error) {
+	user, err := entity.NewUser(input.Name, input.Email, input.Password)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidInput, err)
+	}
+
+	// Persistir o user junto com o registro de auditoria
+	err = u.Transaction.Run(func(repositories database.Repositories) error {
+		if err := repositories.User.Create(user); err != nil {
+			return err
+		}
+
+		return recordAudit(repositories, input.Actor, entity.AuditCreate, auditUser, user.ID.String(), nil, user)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &UserOutput{ID: user.ID.String(), Name: user.Name, Email: user.Email}, nil
+}
+
+// Login autentica por email e senha. Usamos o mesmo erro para email e senha para não revelar quais emails estão cadastrados
+func (u *UserUseCase) Login(input LoginInput) (*LoginOutput, error) {
+	user, err := u.UserDB.FindByEmail(input.Email)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+
+	if !user.IsPasswordValid(input.Password) {
+		return nil, ErrInvalidCredentials
+	}
+
+	return u.login(user)
+}
+
+// LoginWithTOTP troca o token de desafio e o código pelo token de acesso
+func (u *UserUseCase) LoginWithTOTP(input TOTPLoginInput) (*LoginOutput, error) {
+	userID, err := u.Tokens.ChallengeSubject(input.ChallengeToken)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidChallenge, err)
+	}
+
+	// Ler, conferir e gravar com o usuário bloqueado: sem isso, requisições simultâneas aceitariam o mesmo código
+	// ou código de recuperação duas vezes e perderiam incrementos do contador de tentativas
+	var user *entity.User
+	var verifyErr error
+	err = u.Transaction.Run(func(repositories database.Repositories) error {
+		var err error
+		user, err = repositories.User.FindByIDForUpdate(userID)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidChallenge, err)
+		}
+
+		verifyErr = user.VerifySecondFactor(input.Code, time.Now())
+
+		// Salvamos mesmo quando o código é inválido: o contador de tentativas e o código de recuperação usado ficam no usuário
+		return repositories.User.Update(user)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if errors.Is(verifyErr, entity.ErrTOTPLocked) {
+		return nil, verifyErr
+	}
+	if verifyErr != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnauthorized, verifyErr)
+	}
+
+	return u.accessToken(user)
+}
+
+// LoginWithOIDC procura o usuário pelo vínculo com o SSO. No primeiro login vincula a conta com o mesmo email
+// ou cria um usuário novo. O email só é usado quando o issuer diz que ele foi verificado, senão bastaria criar
+// no SSO uma conta com o email de outra pessoa para entrar na conta dela
+func (u *UserUseCase) LoginWithOIDC(input OIDCLoginInput) (*LoginOutput, error) {
+	user, err := u.UserDB.FindByOIDC(input.Issuer, input.Subject)
+	if err == nil {
+		return u.login(user)
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if input.Email == "" || !input.EmailVerified {
+		return nil, ErrOIDCEmailNotVerified
+	}
+
+	user, err = u.UserDB.FindByEmail(input.Email)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	// O login não tem usuário autenticado, então a auditoria fica com o autor anônimo
+	if user != nil {
+		before := *user
+		if err := user.LinkOIDC(input.Issuer, input.Subject); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrConflict, err)
+		}
+
+		err = u.Transaction.Run(func(repositories database.Repositories) error {
+			if err := repositories.User.Update(user); err != nil {
+				return err
+			}
+
+			return recordAudit(repositories, "", entity.AuditUpdate, auditUser, user.ID.String(), &before, user)
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return u.login(user)
+	}
+
+	name := input.Name
+	if name == "" {
+		name = input.Email
+	}
+	user = entity.NewOIDCUser(name, input.Email, input.Issuer, input.Subject)
+
+	err = u.Transaction.Run(func(repositories database.Repositories) error {
+		if err := repositories.User.Create(user); err != nil {
+			return err
+		}
+
+		return recordAudit(repositories, "", entity.AuditCreate, auditUser, user.ID.String(), nil, user)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return u.login(user)
+}
+
+// EnrollTOTP gera um novo segredo para o usuário. O segundo fator só passa a valer depois do ConfirmTOTP
+func (u *UserUseCase) EnrollTOTP(userID string) (*TOTPEnrollmentOutput, error) {
+	user, err := u.findUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, recoveryCodes, err := user.EnrollTOTP()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrConflict, err)
+	}
+
+	if err := u.UserDB.Update(user); err != nil {
+		return nil, err
+	}
+
+	return &TOTPEnrollmentOutput{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(totpIssuer, user.Email, secret),
+		RecoveryCodes:   recoveryCodes,
+	}, nil
+}
+
+func (u *UserUseCase) ConfirmTOTP(input ConfirmTOTPInput) error {
+	user, err := u.findUser(input.UserID)
+	if err != nil {
+		return err
+	}
+
+	before := *user
+	err = user.ConfirmTOTP(input.Code, time.Now())
+	if errors.Is(err, entity.ErrInvalidTOTPCode) {
+		return fmt.Errorf("%w: %w", ErrInvalidInput, err)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrConflict, err)
+	}
+
+	// Ativar o segundo fator muda a forma de login, por isso fica registrado na auditoria
+	return u.Transaction.Run(func(repositories database.Repositories) error {
+		if err := repositories.User.Update(user); err != nil {
+			return err
+		}
+
+		return recordAudit(repositories, input.UserID, entity.AuditUpdate, auditUser, user.ID.String(), &before, user)
+	})
+}
+
+// login conclui a autenticação do primeiro fator (senha ou SSO). Com o segundo fator ativo só é liberado
+// um token de desafio, trocado pelo token de acesso junto com o código
+func (u *UserUseCase) login(user *entity.User) (*LoginOutput, error) {
+	if !user.TOTPEnabled {
+		return u.accessToken(user)
+	}
+
+	challengeToken, err := u.Tokens.ChallengeToken(user.ID.String(), TOTPChallengeTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LoginOutput{ChallengeToken: challengeToken, ChallengeExpiresIn: TOTPChallengeTTL}, nil
+}
+
+func (u *UserUseCase) accessToken(user *entity.User) (*LoginOutput, error) {
+	token, err := u.Tokens.AccessToken(user.ID.String(), u.role(user))
+	if err != nil {
+		return nil, err
+	}
+
+	return &LoginOutput{AccessToken: token}, nil
+}
+
+func (u *UserUseCase) role(user *entity.User) string {
+	for _, email := range u.AdminEmails {
+		if strings.EqualFold(email, user.Email) {
+			return RoleAdmin
+		}
+	}
+
+	return RoleUser
+}
+
+func (u *UserUseCase) findUser(id string) (*entity.User, error) {
+	user, err := u.UserDB.FindByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUserNotFound, err)
+	}
+
+	return user, nil
+}
@@ -0,0 +1,178 @@
+package usecase
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/entity"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/auth"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/database"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/pkg/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const issuer = "https://sso.example.com"
+
+func newUserUseCase(t *testing.T) (*UserUseCase, database.Repositories, *auth.KeyRing) {
+	keyRing, err := auth.NewHMACKeyRing("secret")
+	require.NoError(t, err)
+
+	repositories := database.Repositories{
+		Product:      database.NewProductMemory(),
+		PriceHistory: database.NewPriceHistoryMemory(),
+		User:         database.NewUserMemory(),
+		Audit:        database.NewAuditMemory(),
+	}
+	tokens := auth.NewTokenIssuer(keyRing.TokenAuth(), time.Minute)
+
+	return NewUserUseCase(repositories.User, database.NewTransactionMemory(repositories), tokens, []string{"admin@email.com"}), repositories, keyRing
+}
+
+func TestCreateUserAndLogin(t *testing.T) {
+	useCase, repositories, keyRing := newUserUseCase(t)
+
+	output, err := useCase.Create(CreateUserInput{Name: "Admin", Email: "admin@email.com", Password: "123456"})
+	require.NoError(t, err)
+	assert.Equal(t, "admin@email.com", output.Email)
+
+	entries, _ := repositories.Audit.Find(database.AuditFilter{EntityID: output.ID})
+	require.Len(t, entries, 1)
+	assert.Equal(t, entity.AnonymousActor, entries[0].Actor)
+
+	login, err := useCase.Login(LoginInput{Email: "ADMIN@email.com", Password: "123456"})
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	assert.Nil(t, login)
+
+	login, err = useCase.Login(LoginInput{Email: "admin@email.com", Password: "123456"})
+	require.NoError(t, err)
+	assert.Empty(t, login.ChallengeToken)
+
+	token, err := keyRing.Verify(login.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, output.ID, token.Subject())
+	role, _ := token.Get("role")
+	assert.Equal(t, RoleAdmin, role)
+
+	_, err = useCase.Login(LoginInput{Email: "admin@email.com", Password: "wrong"})
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+}
+
+func TestLoginWithTOTP(t *testing.T) {
+	useCase, repositories, _ := newUserUseCase(t)
+	created, _ := useCase.Create(CreateUserInput{Name: "John", Email: "john@email.com", Password: "123456"})
+
+	enrollment, err := useCase.EnrollTOTP(created.ID)
+	require.NoError(t, err)
+	assert.Len(t, enrollment.RecoveryCodes, entity.RecoveryCodeCount)
+
+	err = useCase.ConfirmTOTP(ConfirmTOTPInput{UserID: created.ID, Code: "000000"})
+	assert.ErrorIs(t, err, ErrInvalidInput)
+	assert.ErrorIs(t, err, entity.ErrInvalidTOTPCode)
+
+	code, _ := totp.Code(enrollment.Secret, totp.Step(time.Now()))
+	require.NoError(t, useCase.ConfirmTOTP(ConfirmTOTPInput{UserID: created.ID, Code: code}))
+
+	entries, _ := repositories.Audit.Find(database.AuditFilter{EntityID: created.ID, Actor: created.ID})
+	assert.Len(t, entries, 1)
+
+	_, err = useCase.EnrollTOTP(created.ID)
+	assert.ErrorIs(t, err, ErrConflict)
+	assert.ErrorIs(t, err, entity.ErrTOTPAlreadyEnabled)
+
+	login, err := useCase.Login(LoginInput{Email: "john@email.com", Password: "123456"})
+	require.NoError(t, err)
+	assert.Empty(t, login.AccessToken)
+	assert.Equal(t, TOTPChallengeTTL, login.ChallengeExpiresIn)
+
+	// O código usado na confirmação não vale de novo
+	_, err = useCase.LoginWithTOTP(TOTPLoginInput{ChallengeToken: login.ChallengeToken, Code: code})
+	assert.ErrorIs(t, err, ErrUnauthorized)
+	assert.ErrorIs(t, err, entity.ErrInvalidTOTPCode)
+
+	output, err := useCase.LoginWithTOTP(TOTPLoginInput{ChallengeToken: login.ChallengeToken, Code: enrollment.RecoveryCodes[0]})
+	require.NoError(t, err)
+	assert.NotEmpty(t, output.AccessToken)
+
+	// Um token de acesso não serve como desafio
+	_, err = useCase.LoginWithTOTP(TOTPLoginInput{ChallengeToken: output.AccessToken, Code: enrollment.RecoveryCodes[1]})
+	assert.ErrorIs(t, err, ErrInvalidChallenge)
+
+	_, err = useCase.EnrollTOTP("0b6e8d8e-5f3c-4b8a-9c1d-2e7f4a6b8c90")
+	assert.ErrorIs(t, err, ErrUserNotFound)
+}
+
+func TestLoginWithTOTPIsSafeForConcurrentUse(t *testing.T) {
+	useCase, repositories, _ := newUserUseCase(t)
+	created, _ := useCase.Create(CreateUserInput{Name: "John", Email: "john@email.com", Password: "123456"})
+	enrollment, _ := useCase.EnrollTOTP(created.ID)
+	code, _ := totp.Code(enrollment.Secret, totp.Step(time.Now()))
+	require.NoError(t, useCase.ConfirmTOTP(ConfirmTOTPInput{UserID: created.ID, Code: code}))
+	login, _ := useCase.Login(LoginInput{Email: "john@email.com", Password: "123456"})
+
+	// O mesmo código de recuperação em paralelo só vale uma vez
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	succeeded := 0
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := useCase.LoginWithTOTP(TOTPLoginInput{ChallengeToken: login.ChallengeToken, Code: enrollment.RecoveryCodes[0]}); err == nil {
+				mutex.Lock()
+				succeeded++
+				mutex.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 1, succeeded)
+
+	// Nenhum erro em paralelo se perde no contador, então o bloqueio vem no limite
+	for i := 0; i < entity.MaxTOTPFailures; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			useCase.LoginWithTOTP(TOTPLoginInput{ChallengeToken: login.ChallengeToken, Code: "000000"})
+		}()
+	}
+	wg.Wait()
+
+	_, err := useCase.LoginWithTOTP(TOTPLoginInput{ChallengeToken: login.ChallengeToken, Code: enrollment.RecoveryCodes[1]})
+	assert.ErrorIs(t, err, entity.ErrTOTPLocked)
+	user, _ := repositories.User.FindByID(created.ID)
+	assert.True(t, user.TOTPLockedUntil.After(time.Now()))
+}
+
+func TestLoginWithOIDC(t *testing.T) {
+	useCase, repositories, _ := newUserUseCase(t)
+	existing, _ := useCase.Create(CreateUserInput{Name: "John", Email: "john@email.com", Password: "123456"})
+
+	_, err := useCase.LoginWithOIDC(OIDCLoginInput{Issuer: issuer, Subject: "attacker", Email: "john@email.com"})
+	assert.ErrorIs(t, err, ErrOIDCEmailNotVerified)
+
+	// Primeiro login com o email verificado vincula a conta existente
+	output, err := useCase.LoginWithOIDC(OIDCLoginInput{Issuer: issuer, Subject: "john-sso", Email: "john@email.com", EmailVerified: true})
+	require.NoError(t, err)
+	assert.NotEmpty(t, output.AccessToken)
+
+	linked, err := repositories.User.FindByOIDC(issuer, "john-sso")
+	require.NoError(t, err)
+	assert.Equal(t, existing.ID, linked.ID.String())
+
+	_, err = useCase.LoginWithOIDC(OIDCLoginInput{Issuer: issuer, Subject: "other-sso", Email: "john@email.com", EmailVerified: true})
+	assert.ErrorIs(t, err, ErrConflict)
+	assert.ErrorIs(t, err, entity.ErrOIDCAlreadyLinked)
+
+	// Sem conta com o email, o usuário é criado sem senha
+	_, err = useCase.LoginWithOIDC(OIDCLoginInput{Issuer: issuer, Subject: "mary-sso", Email: "mary@email.com", EmailVerified: true})
+	require.NoError(t, err)
+
+	created, err := repositories.User.FindByOIDC(issuer, "mary-sso")
+	require.NoError(t, err)
+	assert.Equal(t, "mary@email.com", created.Name)
+
+	_, err = useCase.Login(LoginInput{Email: "mary@email.com", Password: ""})
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+}
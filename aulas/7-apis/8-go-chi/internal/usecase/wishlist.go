@@ -0,0 +1,358 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/entity"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/database"
+	entityPkg "github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/pkg/entity"
+	"gorm.io/gorm"
+)
+
+// UserID é o dono da lista, vindo do token. Listas de outros usuários se comportam como inexistentes
+type WishlistInput struct {
+	ID     string
+	UserID string
+}
+
+type CreateWishlistInput struct {
+	UserID string
+	Name   string
+}
+
+type RenameWishlistInput struct {
+	WishlistInput
+	Name string
+}
+
+type WishlistItemInput struct {
+	WishlistInput
+	ProductID string
+}
+
+type ListNotificationsInput struct {
+	UserID string
+	Page   int
+	Limit  int
+}
+
+type WishlistItemOutput struct {
+	ProductID  string    `json:"product_id"`
+	Name       string    `json:"name"`
+	Price      int       `json:"price"`
+	AddedPrice int       `json:"added_price"`
+	AddedAt    time.Time `json:"added_at"`
+}
+
+// WishlistOutput só traz o ShareToken para o dono da lista, e apenas quando ela está compartilhada
+type WishlistOutput struct {
+	ID         string               `json:"id"`
+	Name       string               `json:"name"`
+	Items      []WishlistItemOutput `json:"items"`
+	ShareToken string               `json:"share_token,omitempty"`
+	CreatedAt  time.Time            `json:"created_at"`
+	UpdatedAt  time.Time            `json:"updated_at"`
+}
+
+type NotificationOutput struct {
+	ID          string    `json:"id"`
+	Type        string    `json:"type" enums:"wishlist_price_drop,wishlist_product_removed"`
+	WishlistID  string    `json:"wishlist_id"`
+	ProductID   string    `json:"product_id"`
+	ProductName string    `json:"product_name"`
+	OldPrice    int       `json:"old_price"`
+	NewPrice    int       `json:"new_price"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type WishlistUseCase struct {
+	WishlistDB     database.WishlistInterface
+	ProductDB      database.ProductInterface
+	NotificationDB database.NotificationInterface
+	Transaction    database.TransactionInterface
+}
+
+func NewWishlistUseCase(db database.WishlistInterface, productDB database.ProductInterface, notificationDB database.NotificationInterface, transaction database.TransactionInterface) *WishlistUseCase {
+	return &WishlistUseCase{
+		WishlistDB:     db,
+		ProductDB:      productDB,
+		NotificationDB: notificationDB,
+		Transaction:    transaction,
+	}
+}
+
+func (u *WishlistUseCase) Create(input CreateWishlistInput) (*WishlistOutput, error) {
+	wishlist, err := entity.NewWishlist(input.UserID, input.Name)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidInput, err)
+	}
+
+	return u.save(wishlist)
+}
+
+func (u *WishlistUseCase) List(userID string) ([]*WishlistOutput, error) {
+	wishlists, err := u.WishlistDB.FindByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	output := make([]*WishlistOutput, 0, len(wishlists))
+	for _, wishlist := range wishlists {
+		output = append(output, wishlistOutput(wishlist))
+	}
+
+	return output, nil
+}
+
+func (u *WishlistUseCase) Find(input WishlistInput) (*WishlistOutput, error) {
+	wishlist, err := u.find(input)
+	if err != nil {
+		return nil, err
+	}
+
+	return wishlistOutput(wishlist), nil
+}
+
+// FindShared é a leitura pelo link público: não exige token e não devolve o ShareToken
+func (u *WishlistUseCase) FindShared(token string) (*WishlistOutput, error) {
+	wishlist, err := u.WishlistDB.FindByShareToken(token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrWishlistNotFound, err)
+	}
+
+	output := wishlistOutput(wishlist)
+	output.ShareToken = ""
+
+	return output, nil
+}
+
+func (u *WishlistUseCase) Rename(input RenameWishlistInput) (*WishlistOutput, error) {
+	return u.change(input.WishlistInput, func(repositories database.Repositories, wishlist *entity.Wishlist) error {
+		if err := wishlist.Rename(input.Name); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidInput, err)
+		}
+
+		return nil
+	})
+}
+
+func (u *WishlistUseCase) Delete(input WishlistInput) error {
+	return u.Transaction.Run(func(repositories database.Repositories) error {
+		if _, err := findForChange(repositories, input); err != nil {
+			return err
+		}
+
+		return repositories.Wishlist.Delete(input.ID)
+	})
+}
+
+func (u *WishlistUseCase) AddItem(input WishlistItemInput) (*WishlistOutput, error) {
+	if input.ProductID == "" {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidInput, entity.ErrIDIsRequired)
+	}
+
+	return u.change(input.WishlistInput, func(repositories database.Repositories, wishlist *entity.Wishlist) error {
+		product, err := repositories.Product.FindByID(input.ProductID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%w: %v", ErrProductNotFound, err)
+		}
+		if err != nil {
+			return err
+		}
+
+		wishlist.AddItem(product)
+		return nil
+	})
+}
+
+func (u *WishlistUseCase) RemoveItem(input WishlistItemInput) (*WishlistOutput, error) {
+	productID, err := entityPkg.ParseID(input.ProductID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidInput, entity.ErrInvalidID)
+	}
+
+	return u.change(input.WishlistInput, func(repositories database.Repositories, wishlist *entity.Wishlist) error {
+		_, err := wishlist.RemoveItem(productID)
+		return err
+	})
+}
+
+func (u *WishlistUseCase) Share(input WishlistInput) (*WishlistOutput, error) {
+	return u.change(input, func(repositories database.Repositories, wishlist *entity.Wishlist) error {
+		return wishlist.Share()
+	})
+}
+
+func (u *WishlistUseCase) Unshare(input WishlistInput) (*WishlistOutput, error) {
+	return u.change(input, func(repositories database.Repositories, wishlist *entity.Wishlist) error {
+		wishlist.Unshare()
+		return nil
+	})
+}
+
+func (u *WishlistUseCase) Notifications(input ListNotificationsInput) ([]*NotificationOutput, error) {
+	notifications, err := u.NotificationDB.FindByUserID(input.UserID, input.Page, input.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	output := make([]*NotificationOutput, 0, len(notifications))
+	for _, notification := range notifications {
+		output = append(output, &NotificationOutput{
+			ID:          notification.ID.String(),
+			Type:        notification.Type,
+			WishlistID:  notification.WishlistID.String(),
+			ProductID:   notification.ProductID.String(),
+			ProductName: notification.ProductName,
+			OldPrice:    notification.OldPrice,
+			NewPrice:    notification.NewPrice,
+			CreatedAt:   notification.CreatedAt,
+		})
+	}
+
+	return output, nil
+}
+
+func (u *WishlistUseCase) find(input WishlistInput) (*entity.Wishlist, error) {
+	wishlist, err := u.WishlistDB.FindByID(input.ID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrWishlistNotFound, err)
+	}
+
+	// Não revelamos a existência das listas de outros usuários
+	if wishlist.UserID != input.UserID {
+		return nil, ErrWishlistNotFound
+	}
+
+	return wishlist, nil
+}
+
+// change lê a lista travada, aplica fn e grava tudo na mesma transação. Sem isso uma mudança de preço do produto
+// ou outra requisição na mesma lista seria apagada pelo Save, que regrava todos os itens
+func (u *WishlistUseCase) change(input WishlistInput, fn func(repositories database.Repositories, wishlist *entity.Wishlist) error) (*WishlistOutput, error) {
+	var wishlist *entity.Wishlist
+	err := u.Transaction.Run(func(repositories database.Repositories) error {
+		var err error
+		wishlist, err = findForChange(repositories, input)
+		if err != nil {
+			return err
+		}
+
+		if err := fn(repositories, wishlist); err != nil {
+			return err
+		}
+
+		return repositories.Wishlist.Save(wishlist)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return wishlistOutput(wishlist), nil
+}
+
+// findForChange é o find com a lista travada, para ser usado dentro de Transaction.Run
+func findForChange(repositories database.Repositories, input WishlistInput) (*entity.Wishlist, error) {
+	wishlist, err := repositories.Wishlist.FindByIDForUpdate(input.ID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrWishlistNotFound, err)
+	}
+
+	if wishlist.UserID != input.UserID {
+		return nil, ErrWishlistNotFound
+	}
+
+	return wishlist, nil
+}
+
+func (u *WishlistUseCase) save(wishlist *entity.Wishlist) (*WishlistOutput, error) {
+	if err := u.WishlistDB.Save(wishlist); err != nil {
+		return nil, err
+	}
+
+	return wishlistOutput(wishlist), nil
+}
+
+// updateWishlists aplica o nome e o preço novos do produto nas listas e avisa os donos quando o preço caiu.
+// Deve ser chamado dentro de Transaction.Run, junto com a atualização do produto
+func updateWishlists(repositories database.Repositories, product *entity.Product) error {
+	wishlists, err := repositories.Wishlist.FindByProductID(product.ID.String())
+	if err != nil {
+		return err
+	}
+
+	for _, wishlist := range wishlists {
+		previous, err := wishlist.UpdateProduct(product)
+		if err != nil {
+			return err
+		}
+
+		if err := repositories.Wishlist.Save(wishlist); err != nil {
+			return err
+		}
+
+		if product.Price >= previous {
+			continue
+		}
+
+		for _, item := range wishlist.Items {
+			if item.ProductID == product.ID {
+				if err := repositories.Notification.Create(entity.NewPriceDropNotification(wishlist, item, previous)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// removeFromWishlists tira o produto excluído das listas e avisa os donos. Deve ser chamado dentro de Transaction.Run
+func removeFromWishlists(repositories database.Repositories, product *entity.Product) error {
+	wishlists, err := repositories.Wishlist.FindByProductID(product.ID.String())
+	if err != nil {
+		return err
+	}
+
+	for _, wishlist := range wishlists {
+		item, err := wishlist.RemoveItem(product.ID)
+		if err != nil {
+			return err
+		}
+
+		if err := repositories.Wishlist.Save(wishlist); err != nil {
+			return err
+		}
+
+		if err := repositories.Notification.Create(entity.NewProductRemovedNotification(wishlist, item)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func wishlistOutput(wishlist *entity.Wishlist) *WishlistOutput {
+	output := &WishlistOutput{
+		ID:         wishlist.ID.String(),
+		Name:       wishlist.Name,
+		Items:      make([]WishlistItemOutput, 0, len(wishlist.Items)),
+		ShareToken: wishlist.ShareToken,
+		CreatedAt:  wishlist.CreatedAt,
+		UpdatedAt:  wishlist.UpdatedAt,
+	}
+
+	for _, item := range wishlist.Items {
+		output.Items = append(output.Items, WishlistItemOutput{
+			ProductID:  item.ProductID.String(),
+			Name:       item.ProductName,
+			Price:      item.Price,
+			AddedPrice: item.AddedPrice,
+			AddedAt:    item.AddedAt,
+		})
+	}
+
+	return output
+}
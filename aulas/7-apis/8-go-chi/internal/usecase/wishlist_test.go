@@ -0,0 +1,144 @@
+package usecase
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWishlistLifecycle(t *testing.T) {
+	products, repositories, _ := newProductUseCase()
+	useCase := NewWishlistUseCase(repositories.Wishlist, repositories.Product, repositories.Notification, products.Transaction)
+	product, _ := products.Create(ProductInput{Name: "Product 1", Price: 100})
+
+	wishlist, err := useCase.Create(CreateWishlistInput{UserID: "user-1", Name: "Presentes"})
+	require.NoError(t, err)
+
+	owner := WishlistInput{ID: wishlist.ID, UserID: "user-1"}
+	wishlist, err = useCase.AddItem(WishlistItemInput{WishlistInput: owner, ProductID: product.ID})
+	require.NoError(t, err)
+	require.Len(t, wishlist.Items, 1)
+	assert.Equal(t, "Product 1", wishlist.Items[0].Name)
+
+	wishlist, err = useCase.Rename(RenameWishlistInput{WishlistInput: owner, Name: "Aniversário"})
+	require.NoError(t, err)
+	assert.Equal(t, "Aniversário", wishlist.Name)
+
+	// As listas de outro usuário se comportam como inexistentes
+	_, err = useCase.Find(WishlistInput{ID: wishlist.ID, UserID: "user-2"})
+	assert.ErrorIs(t, err, ErrWishlistNotFound)
+	_, err = useCase.Rename(RenameWishlistInput{WishlistInput: owner})
+	assert.ErrorIs(t, err, ErrInvalidInput)
+	_, err = useCase.AddItem(WishlistItemInput{WishlistInput: owner, ProductID: "00000000-0000-0000-0000-000000000000"})
+	assert.ErrorIs(t, err, ErrProductNotFound)
+
+	wishlist, err = useCase.RemoveItem(WishlistItemInput{WishlistInput: owner, ProductID: product.ID})
+	require.NoError(t, err)
+	assert.Empty(t, wishlist.Items)
+	_, err = useCase.RemoveItem(WishlistItemInput{WishlistInput: owner, ProductID: product.ID})
+	assert.ErrorIs(t, err, entity.ErrWishlistItemNotFound)
+
+	require.NoError(t, useCase.Delete(owner))
+	lists, _ := useCase.List("user-1")
+	assert.Empty(t, lists)
+}
+
+func TestWishlistShare(t *testing.T) {
+	products, repositories, _ := newProductUseCase()
+	useCase := NewWishlistUseCase(repositories.Wishlist, repositories.Product, repositories.Notification, products.Transaction)
+	wishlist, _ := useCase.Create(CreateWishlistInput{UserID: "user-1", Name: "Presentes"})
+	owner := WishlistInput{ID: wishlist.ID, UserID: "user-1"}
+
+	_, err := useCase.FindShared("")
+	assert.ErrorIs(t, err, ErrWishlistNotFound)
+
+	shared, err := useCase.Share(owner)
+	require.NoError(t, err)
+	require.NotEmpty(t, shared.ShareToken)
+
+	// Quem abre o link vê a lista, mas não recebe o token de volta
+	public, err := useCase.FindShared(shared.ShareToken)
+	require.NoError(t, err)
+	assert.Equal(t, "Presentes", public.Name)
+	assert.Empty(t, public.ShareToken)
+
+	_, err = useCase.Unshare(owner)
+	require.NoError(t, err)
+	_, err = useCase.FindShared(shared.ShareToken)
+	assert.ErrorIs(t, err, ErrWishlistNotFound)
+}
+
+func TestProductChangesReachWishlists(t *testing.T) {
+	products, repositories, _ := newProductUseCase()
+	useCase := NewWishlistUseCase(repositories.Wishlist, repositories.Product, repositories.Notification, products.Transaction)
+	product, _ := products.Create(ProductInput{Name: "Product 1", Price: 100})
+	other, _ := products.Create(ProductInput{Name: "Product 2", Price: 50})
+
+	wishlist, _ := useCase.Create(CreateWishlistInput{UserID: "user-1", Name: "Presentes"})
+	owner := WishlistInput{ID: wishlist.ID, UserID: "user-1"}
+	useCase.AddItem(WishlistItemInput{WishlistInput: owner, ProductID: product.ID})
+	useCase.AddItem(WishlistItemInput{WishlistInput: owner, ProductID: other.ID})
+
+	// Aumento de preço atualiza a lista, mas só a queda gera notificação
+	_, err := products.Update(UpdateProductInput{ID: product.ID, ProductInput: ProductInput{Name: "Product 1", Price: 120}})
+	require.NoError(t, err)
+	_, err = products.Update(UpdateProductInput{ID: product.ID, ProductInput: ProductInput{Name: "Product 1", Price: 90}})
+	require.NoError(t, err)
+
+	wishlist, _ = useCase.Find(owner)
+	assert.Equal(t, 90, wishlist.Items[0].Price)
+	assert.Equal(t, 100, wishlist.Items[0].AddedPrice)
+
+	notifications, err := useCase.Notifications(ListNotificationsInput{UserID: "user-1"})
+	require.NoError(t, err)
+	require.Len(t, notifications, 1)
+	assert.Equal(t, entity.NotificationPriceDrop, notifications[0].Type)
+	assert.Equal(t, 120, notifications[0].OldPrice)
+	assert.Equal(t, 90, notifications[0].NewPrice)
+
+	require.NoError(t, products.Delete(DeleteProductInput{ID: other.ID}))
+
+	wishlist, _ = useCase.Find(owner)
+	require.Len(t, wishlist.Items, 1)
+	assert.Equal(t, product.ID, wishlist.Items[0].ProductID)
+
+	notifications, _ = useCase.Notifications(ListNotificationsInput{UserID: "user-1"})
+	require.Len(t, notifications, 2)
+	assert.Equal(t, entity.NotificationProductRemoved, notifications[0].Type)
+	assert.Equal(t, "Product 2", notifications[0].ProductName)
+}
+
+func TestWishlistChangesAreNotLostConcurrently(t *testing.T) {
+	products, repositories, _ := newProductUseCase()
+	useCase := NewWishlistUseCase(repositories.Wishlist, repositories.Product, repositories.Notification, products.Transaction)
+	wishlist, _ := useCase.Create(CreateWishlistInput{UserID: "user-1", Name: "Presentes"})
+	owner := WishlistInput{ID: wishlist.ID, UserID: "user-1"}
+	first, _ := products.Create(ProductInput{Name: "Product 1", Price: 100})
+	useCase.AddItem(WishlistItemInput{WishlistInput: owner, ProductID: first.ID})
+
+	// Itens adicionados em paralelo e a mudança de preço de um item já salvo precisam sobreviver juntos
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		product, _ := products.Create(ProductInput{Name: "Product", Price: 10})
+		wg.Add(1)
+		go func(productID string) {
+			defer wg.Done()
+			_, err := useCase.AddItem(WishlistItemInput{WishlistInput: owner, ProductID: productID})
+			assert.NoError(t, err)
+		}(product.ID)
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, err := products.Update(UpdateProductInput{ID: first.ID, ProductInput: ProductInput{Name: "Product 1", Price: 80}})
+		assert.NoError(t, err)
+	}()
+	wg.Wait()
+
+	wishlist, _ = useCase.Find(owner)
+	require.Len(t, wishlist.Items, 6)
+	assert.Equal(t, 80, wishlist.Items[0].Price)
+}
@@ -0,0 +1,186 @@
+package cron
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidSpec = errors.New("invalid cron spec")
+
+	// Atalhos aceitos além dos cinco campos
+	descriptors = map[string]string{
+		"@yearly":   "0 0 1 1 *",
+		"@annually": "0 0 1 1 *",
+		"@monthly":  "0 0 1 * *",
+		"@weekly":   "0 0 * * 0",
+		"@daily":    "0 0 * * *",
+		"@midnight": "0 0 * * *",
+		"@hourly":   "0 * * * *",
+	}
+)
+
+type field struct {
+	min, max int
+}
+
+// minuto, hora, dia do mês, mês e dia da semana (0 = domingo; 7 também é aceito como domingo)
+var fields = []field{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+
+// Schedule é uma expressão cron de cinco campos já interpretada. Cada campo vira um conjunto de bits
+type Schedule struct {
+	minute, hour, dom, month, dow uint64
+	// Como no cron tradicional, se dia do mês e dia da semana forem restritos, basta um dos dois bater
+	domStar, dowStar bool
+}
+
+// Parse aceita "*", listas (1,15), intervalos (1-5), passos (*/15, 10-30/5) e os atalhos @daily, @hourly...
+func Parse(spec string) (*Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if expanded, ok := descriptors[spec]; ok {
+		spec = expanded
+	}
+
+	parts := strings.Fields(spec)
+	if len(parts) != len(fields) {
+		return nil, fmt.Errorf("%w: expected %d fields, got %d", ErrInvalidSpec, len(fields), len(parts))
+	}
+
+	bits := make([]uint64, len(fields))
+	for i, part := range parts {
+		value, err := parseField(part, fields[i])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q: %v", ErrInvalidSpec, part, err)
+		}
+		bits[i] = value
+	}
+
+	// Domingo pode ser 0 ou 7
+	if bits[4]&(1<<7) != 0 {
+		bits[4] |= 1
+	}
+
+	return &Schedule{
+		minute:  bits[0],
+		hour:    bits[1],
+		dom:     bits[2],
+		month:   bits[3],
+		dow:     bits[4],
+		domStar: parts[2] == "*",
+		dowStar: parts[4] == "*",
+	}, nil
+}
+
+func parseField(value string, f field) (uint64, error) {
+	var bits uint64
+	for _, item := range strings.Split(value, ",") {
+		itemBits, err := parseItem(item, f)
+		if err != nil {
+			return 0, err
+		}
+		bits |= itemBits
+	}
+
+	return bits, nil
+}
+
+func parseItem(item string, f field) (uint64, error) {
+	step := 1
+	if base, stepValue, ok := strings.Cut(item, "/"); ok {
+		var err error
+		if step, err = strconv.Atoi(stepValue); err != nil || step <= 0 {
+			return 0, errors.New("invalid step")
+		}
+		item = base
+	}
+
+	start, end := f.min, f.max
+	switch {
+	case item == "*":
+	case strings.Contains(item, "-"):
+		from, to, _ := strings.Cut(item, "-")
+		var err error
+		if start, err = parseNumber(from, f); err != nil {
+			return 0, err
+		}
+		if end, err = parseNumber(to, f); err != nil {
+			return 0, err
+		}
+		if start > end {
+			return 0, errors.New("invalid range")
+		}
+	default:
+		number, err := parseNumber(item, f)
+		if err != nil {
+			return 0, err
+		}
+		start = number
+		// "5/10" significa de 5 até o fim, de 10 em 10
+		if step == 1 {
+			end = number
+		}
+	}
+
+	var bits uint64
+	for i := start; i <= end; i += step {
+		bits |= 1 << i
+	}
+
+	return bits, nil
+}
+
+func parseNumber(value string, f field) (int, error) {
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, errors.New("not a number")
+	}
+	if number < f.min || number > f.max {
+		return 0, fmt.Errorf("out of range [%d-%d]", f.min, f.max)
+	}
+
+	return number, nil
+}
+
+// Next devolve o primeiro horário depois de after que casa com a expressão, no fuso de after.
+// Expressões impossíveis (ex.: 30 de fevereiro) devolvem o tempo zero
+func (s *Schedule) Next(after time.Time) time.Time {
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+
+		return t
+	}
+
+	return time.Time{}
+}
+
+func (s *Schedule) matchesDay(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+
+	if s.domStar || s.dowStar {
+		return dom && dow
+	}
+
+	return dom || dow
+}
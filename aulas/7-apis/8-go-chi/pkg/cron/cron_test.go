@@ -0,0 +1,54 @@
+package cron
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func date(value string) time.Time {
+	t, _ := time.Parse("2006-01-02 15:04", value)
+	return t
+}
+
+func TestNext(t *testing.T) {
+	cases := []struct {
+		spec, after, next string
+	}{
+		{"* * * * *", "2026-10-19 10:15", "2026-10-19 10:16"},
+		{"*/15 * * * *", "2026-10-19 10:15", "2026-10-19 10:30"},
+		{"0 3 * * *", "2026-10-19 10:15", "2026-10-20 03:00"},
+		{"@daily", "2026-12-31 23:59", "2027-01-01 00:00"},
+		{"@hourly", "2026-10-19 10:00", "2026-10-19 11:00"},
+		{"30 9 * * 1-5", "2026-10-23 10:00", "2026-10-26 09:30"},
+		{"0 0 1,15 * *", "2026-10-02 00:00", "2026-10-15 00:00"},
+		{"0 12 * 2 *", "2026-10-19 10:00", "2027-02-01 12:00"},
+		{"0 0 29 2 *", "2026-03-01 00:00", "2028-02-29 00:00"},
+		// Domingo como 7
+		{"0 8 * * 7", "2026-10-19 10:00", "2026-10-25 08:00"},
+		// Com dia do mês e da semana restritos, basta um deles
+		{"0 0 1 * 1", "2026-10-19 10:00", "2026-10-26 00:00"},
+		{"5/20 * * * *", "2026-10-19 10:45", "2026-10-19 11:05"},
+	}
+
+	for _, c := range cases {
+		schedule, err := Parse(c.spec)
+		assert.Nil(t, err, c.spec)
+		assert.Equal(t, date(c.next), schedule.Next(date(c.after)), c.spec)
+	}
+}
+
+func TestNextImpossibleDate(t *testing.T) {
+	schedule, err := Parse("0 0 30 2 *")
+	assert.Nil(t, err)
+	assert.True(t, schedule.Next(date("2026-10-19 10:00")).IsZero())
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "a * * * *", "@often"} {
+		_, err := Parse(spec)
+		assert.True(t, errors.Is(err, ErrInvalidSpec), spec)
+	}
+}
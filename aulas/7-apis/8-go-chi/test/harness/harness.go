@@ -0,0 +1,298 @@
+package harness
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	v1 "github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/docs/v1"
+	v2 "github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/docs/v2"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/entity"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/auth"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/auth/oidc"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/auth/oidc/oidctest"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/database"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/featureflag"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/jobs"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/webserver"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/webserver/handlers"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/webserver/openapi"
+	"github.com/allangrds/fullcycle-mba-go-expert/aulas/7-apis/internal/infra/webserver/sse"
+	"github.com/go-chi/jwtauth"
+)
+
+const (
+	JWTSecret    = "harness-secret"
+	JWTExpiresIn = 300
+	// Usuários com esse email recebem a role admin ao fazer login
+	AdminEmail = "admin@email.com"
+	// StorefrontOrigin é a única origem liberada no CORS
+	StorefrontOrigin = "https://loja.example.com"
+	MaxBodyBytes     = 64 << 10
+)
+
+// Harness sobe o router completo com repositórios em memória dentro de um httptest.Server,
+// permitindo testar status codes e formatos de resposta sem banco de dados
+type Harness struct {
+	t              testing.TB
+	Server         *httptest.Server
+	ProductDB      *database.ProductMemory
+	PriceHistoryDB *database.PriceHistoryMemory
+	UserDB         *database.UserMemory
+	CartDB         *database.CartMemory
+	CouponDB       *database.CouponMemory
+	PromotionDB    *database.PromotionRuleMemory
+	ReviewDB       *database.ReviewMemory
+	AuditDB        *database.AuditMemory
+	JobDB          *database.JobMemory
+	WishlistDB     *database.WishlistMemory
+	NotificationDB *database.NotificationMemory
+	FeatureFlagDB  *database.FeatureFlagMemory
+	Features       *featureflag.Store
+	Jobs           *jobs.Runner
+	Issuer         *oidctest.Issuer
+	Broker         *sse.Broker
+	KeyRing        *auth.KeyRing
+	TokenAuth      *jwtauth.JWTAuth
+}
+
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+func New(t testing.TB) *Harness {
+	t.Helper()
+
+	keyRing, err := auth.NewHMACKeyRing(JWTSecret)
+	if err != nil {
+		t.Fatalf("key ring: %v", err)
+	}
+
+	return NewWithKeyRing(t, keyRing)
+}
+
+// NewWithKeyRing permite testar o servidor com chaves assimétricas (RS256/ES256)
+func NewWithKeyRing(t testing.TB, keyRing *auth.KeyRing) *Harness {
+	t.Helper()
+
+	harness := &Harness{
+		t:              t,
+		ProductDB:      database.NewProductMemory(),
+		PriceHistoryDB: database.NewPriceHistoryMemory(),
+		UserDB:         database.NewUserMemory(),
+		CartDB:         database.NewCartMemory(),
+		CouponDB:       database.NewCouponMemory(),
+		PromotionDB:    database.NewPromotionRuleMemory(),
+		AuditDB:        database.NewAuditMemory(),
+		JobDB:          database.NewJobMemory(),
+		WishlistDB:     database.NewWishlistMemory(),
+		NotificationDB: database.NewNotificationMemory(),
+		FeatureFlagDB:  database.NewFeatureFlagMemory(),
+		Broker:         sse.NewBroker(sse.DefaultReplaySize),
+		KeyRing:        keyRing,
+		TokenAuth:      keyRing.TokenAuth(),
+	}
+	harness.ReviewDB = database.NewReviewMemory(harness.ProductDB)
+	// Sem Run: o cache só é relido na primeira avaliação e a cada alteração feita pela API
+	harness.Features = featureflag.NewStore(harness.FeatureFlagDB, 0)
+	// Nenhum worker roda em segundo plano: os testes executam os jobs quando quiserem com Jobs.RunPending
+	harness.Jobs = jobs.NewRunner(harness.JobDB, jobs.Options{})
+	if err := jobs.RegisterDefaults(harness.Jobs, harness.ProductDB); err != nil {
+		t.Fatalf("jobs: %v", err)
+	}
+
+	// O issuer redireciona de volta para o servidor, então o endereço precisa existir antes do router
+	harness.Server = httptest.NewUnstartedServer(nil)
+	issuer, err := oidctest.NewIssuer()
+	if err != nil {
+		t.Fatalf("oidc issuer: %v", err)
+	}
+	t.Cleanup(issuer.Close)
+	harness.Issuer = issuer
+
+	oidcClient, err := oidc.Discover(context.Background(), harness.Issuer.Config("http://"+harness.Server.Listener.Addr().String()+"/v1/users/oidc/callback"))
+	if err != nil {
+		t.Fatalf("oidc discovery: %v", err)
+	}
+
+	// Nos testes as respostas também são validadas, então qualquer divergência entre handler e spec quebra o teste
+	validators := map[string]*openapi.Validator{}
+	for version, doc := range map[string]string{webserver.APIV1: v1.SwaggerInfov1.ReadDoc(), webserver.APIV2: v2.SwaggerInfov2.ReadDoc()} {
+		validator, err := openapi.NewValidator(doc, openapi.Options{ValidateResponses: true})
+		if err != nil {
+			t.Fatalf("openapi %s: %v", version, err)
+		}
+		validators[version] = validator
+	}
+
+	router := webserver.NewRouter(webserver.Dependencies{
+		ProductDB:      harness.ProductDB,
+		PriceHistoryDB: harness.PriceHistoryDB,
+		UserDB:         harness.UserDB,
+		CartDB:         harness.CartDB,
+		CouponDB:       harness.CouponDB,
+		PromotionDB:    harness.PromotionDB,
+		ReviewDB:       harness.ReviewDB,
+		AuditDB:        harness.AuditDB,
+		JobDB:          harness.JobDB,
+		WishlistDB:     harness.WishlistDB,
+		NotificationDB: harness.NotificationDB,
+		Transaction: database.NewTransactionMemory(database.Repositories{
+			Product:      harness.ProductDB,
+			PriceHistory: harness.PriceHistoryDB,
+			User:         harness.UserDB,
+			Audit:        harness.AuditDB,
+			Wishlist:     harness.WishlistDB,
+			Notification: harness.NotificationDB,
+			Review:       harness.ReviewDB,
+			Cart:         harness.CartDB,
+		}),
+		KeyRing:       harness.KeyRing,
+		JWTExpiresIn:  JWTExpiresIn,
+		ProductBroker: harness.Broker,
+		AdminEmails:   []string{AdminEmail},
+		Jobs:          harness.Jobs,
+		Validators:    validators,
+		CORS:          handlers.CORSOptions{AllowedOrigins: []string{StorefrontOrigin}},
+		MaxBodyBytes:  MaxBodyBytes,
+		OIDC:          oidcClient,
+		FeatureFlagDB: harness.FeatureFlagDB,
+		Features:      harness.Features,
+	})
+
+	harness.Server.Config.Handler = router
+	harness.Server.Start()
+	t.Cleanup(harness.Server.Close)
+
+	return harness
+}
+
+// Token emite um JWT válido diretamente, sem passar pelo endpoint de login
+func (h *Harness) Token(userID string) string {
+	h.t.Helper()
+
+	return h.tokenWithRole(userID, handlers.RoleUser)
+}
+
+// AdminToken emite um JWT com a role admin
+func (h *Harness) AdminToken(userID string) string {
+	h.t.Helper()
+
+	return h.tokenWithRole(userID, handlers.RoleAdmin)
+}
+
+func (h *Harness) tokenWithRole(userID, role string) string {
+	h.t.Helper()
+
+	_, tokenString, err := h.TokenAuth.Encode(map[string]interface{}{
+		"sub":  userID,
+		"role": role,
+		"exp":  time.Now().Add(time.Second * JWTExpiresIn).Unix(),
+	})
+	if err != nil {
+		h.t.Fatalf("encode token: %v", err)
+	}
+
+	return tokenString
+}
+
+// SeedUser cria um usuário direto no repositório e devolve um token para ele
+func (h *Harness) SeedUser(name, email, password string) (*entity.User, string) {
+	h.t.Helper()
+
+	user, err := entity.NewUser(name, email, password)
+	if err != nil {
+		h.t.Fatalf("new user: %v", err)
+	}
+
+	if err := h.UserDB.Create(user); err != nil {
+		h.t.Fatalf("create user: %v", err)
+	}
+
+	return user, h.Token(user.ID.String())
+}
+
+func (h *Harness) SeedProduct(name string, price int) *entity.Product {
+	h.t.Helper()
+
+	product, err := entity.NewProduct(name, price)
+	if err != nil {
+		h.t.Fatalf("new product: %v", err)
+	}
+
+	if err := h.ProductDB.Create(product); err != nil {
+		h.t.Fatalf("create product: %v", err)
+	}
+
+	return product
+}
+
+// Do executa uma requisição contra o servidor. body pode ser nil, string/[]byte (enviado como está)
+// ou qualquer valor, que será serializado em JSON. token vazio envia a requisição sem Authorization
+func (h *Harness) Do(method, path string, body interface{}, token string) *Response {
+	h.t.Helper()
+
+	request, err := http.NewRequest(method, h.Server.URL+path, encodeBody(h.t, body))
+	if err != nil {
+		h.t.Fatalf("new request: %v", err)
+	}
+
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	return h.Send(request)
+}
+
+// Send permite montar a requisição manualmente, por exemplo para enviar headers customizados
+func (h *Harness) Send(request *http.Request) *Response {
+	h.t.Helper()
+
+	response, err := h.Server.Client().Do(request)
+	if err != nil {
+		h.t.Fatalf("do request: %v", err)
+	}
+	defer response.Body.Close()
+
+	data, err := io.ReadAll(response.Body)
+	if err != nil {
+		h.t.Fatalf("read body: %v", err)
+	}
+
+	return &Response{StatusCode: response.StatusCode, Header: response.Header, Body: data}
+}
+
+func (r *Response) JSON(t testing.TB, target interface{}) {
+	t.Helper()
+
+	if err := json.Unmarshal(r.Body, target); err != nil {
+		t.Fatalf("decode body %q: %v", string(r.Body), err)
+	}
+}
+
+func encodeBody(t testing.TB, body interface{}) io.Reader {
+	switch value := body.(type) {
+	case nil:
+		return nil
+	case string:
+		return bytes.NewBufferString(value)
+	case []byte:
+		return bytes.NewBuffer(value)
+	default:
+		data, err := json.Marshal(value)
+		if err != nil {
+			t.Fatalf("encode body: %v", err)
+		}
+		return bytes.NewBuffer(data)
+	}
+}
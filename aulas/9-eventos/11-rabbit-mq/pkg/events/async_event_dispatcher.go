@@ -0,0 +1,217 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+const (
+	DefaultQueueSize = 100
+	DefaultWorkers   = 4
+)
+
+var (
+	ErrQueueFull        = errors.New("event queue is full")
+	ErrEventDropped     = errors.New("event dropped from a full queue")
+	ErrDispatcherClosed = errors.New("event dispatcher is shut down")
+)
+
+// OverflowPolicy diz o que o Dispatch faz quando a fila está cheia
+type OverflowPolicy int
+
+const (
+	// OverflowBlock espera uma vaga na fila ou o cancelamento do contexto do Dispatch
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropOldest descarta o evento mais antigo da fila para abrir espaço
+	OverflowDropOldest
+	// OverflowError devolve ErrQueueFull na hora
+	OverflowError
+)
+
+type AsyncOptions struct {
+	// Valores zerados usam DefaultQueueSize e DefaultWorkers
+	QueueSize int
+	Workers   int
+	Overflow  OverflowPolicy
+	// OnError recebe as falhas dos handlers e os eventos descartados (ErrEventDropped),
+	// já que o Dispatch retorna antes dos handlers rodarem
+	OnError func(event EventInterface, err error)
+}
+
+// AsyncEventDispatcher usa o mesmo registro de handlers do EventDispatcher, mas o Dispatch só coloca
+// o evento em uma fila limitada. Um número fixo de workers consome a fila, então uma rajada de eventos
+// não cria goroutines sem limite nem trava quem publica
+type AsyncEventDispatcher struct {
+	*EventDispatcher
+	options AsyncOptions
+	queue   chan EventInterface
+
+	// mu é um lock feito com um canal de uma vaga, para que a espera por ele respeite o contexto.
+	// Ele só protege closed e o registro em senders; nenhum envio para a fila acontece com ele preso
+	mu     chan struct{}
+	closed bool
+	// closing é fechado pelo Shutdown e libera quem está esperando vaga na fila
+	closing chan struct{}
+	// senders conta os Dispatch em andamento: a fila só é fechada depois que todos terminaram
+	senders    sync.WaitGroup
+	closeQueue sync.Once
+	workers    sync.WaitGroup
+
+	// Os handlers rodam com este contexto e não com o de quem publicou, que pode acabar antes do evento sair da fila
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func NewAsyncEventDispatcher(options AsyncOptions) *AsyncEventDispatcher {
+	if options.QueueSize <= 0 {
+		options.QueueSize = DefaultQueueSize
+	}
+	if options.Workers <= 0 {
+		options.Workers = DefaultWorkers
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ad := &AsyncEventDispatcher{
+		EventDispatcher: NewEventDispatcher(),
+		options:         options,
+		queue:           make(chan EventInterface, options.QueueSize),
+		mu:              make(chan struct{}, 1),
+		closing:         make(chan struct{}),
+		ctx:             ctx,
+		cancel:          cancel,
+	}
+
+	ad.workers.Add(options.Workers)
+	for i := 0; i < options.Workers; i++ {
+		go ad.work()
+	}
+
+	return ad
+}
+
+// Dispatch enfileira o evento. O contexto limita a espera pelo lock e, com OverflowBlock, a espera por uma vaga
+func (ad *AsyncEventDispatcher) Dispatch(ctx context.Context, event EventInterface) error {
+	if err := ad.lock(ctx); err != nil {
+		return err
+	}
+	if ad.closed {
+		ad.unlock()
+		return ErrDispatcherClosed
+	}
+	ad.senders.Add(1)
+	ad.unlock()
+	defer ad.senders.Done()
+
+	dropped, err := ad.enqueue(ctx, event)
+	// O OnError roda fora do lock, então ele pode publicar outro evento
+	for _, droppedEvent := range dropped {
+		ad.reportError(droppedEvent, ErrEventDropped)
+	}
+	return err
+}
+
+// enqueue aplica a política de fila cheia e devolve os eventos descartados para abrir espaço
+func (ad *AsyncEventDispatcher) enqueue(ctx context.Context, event EventInterface) ([]EventInterface, error) {
+	select {
+	case ad.queue <- event:
+		return nil, nil
+	default:
+	}
+
+	switch ad.options.Overflow {
+	case OverflowError:
+		return nil, ErrQueueFull
+	case OverflowDropOldest:
+		var dropped []EventInterface
+		for {
+			select {
+			case ad.queue <- event:
+				return dropped, nil
+			default:
+			}
+
+			// Outro publicador ou um worker pode ter mexido na fila entre as duas tentativas, por isso o loop
+			select {
+			case oldest := <-ad.queue:
+				dropped = append(dropped, oldest)
+			default:
+			}
+		}
+	default:
+		select {
+		case ad.queue <- event:
+			return nil, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ad.closing:
+			return nil, ErrDispatcherClosed
+		}
+	}
+}
+
+// Shutdown para de aceitar eventos e espera os workers processarem o que já está na fila. Quem estava
+// esperando vaga recebe ErrDispatcherClosed. Se o contexto acabar antes, os handlers em execução são
+// cancelados e o que sobrou na fila é descartado
+func (ad *AsyncEventDispatcher) Shutdown(ctx context.Context) error {
+	if err := ad.lock(ctx); err != nil {
+		return err
+	}
+	if !ad.closed {
+		ad.closed = true
+		close(ad.closing)
+	}
+	ad.unlock()
+
+	done := make(chan struct{})
+	go func() {
+		ad.senders.Wait()
+		ad.closeQueue.Do(func() { close(ad.queue) })
+		ad.workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		ad.cancel()
+		return nil
+	case <-ctx.Done():
+		ad.cancel()
+		return ctx.Err()
+	}
+}
+
+func (ad *AsyncEventDispatcher) lock(ctx context.Context) error {
+	select {
+	case ad.mu <- struct{}{}:
+		return nil
+	default:
+	}
+
+	select {
+	case ad.mu <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (ad *AsyncEventDispatcher) unlock() {
+	<-ad.mu
+}
+
+func (ad *AsyncEventDispatcher) work() {
+	defer ad.workers.Done()
+
+	for event := range ad.queue {
+		if err := ad.EventDispatcher.Dispatch(ad.ctx, event); err != nil {
+			ad.reportError(event, err)
+		}
+	}
+}
+
+func (ad *AsyncEventDispatcher) reportError(event EventInterface, err error) {
+	if ad.options.OnError != nil {
+		ad.options.OnError(event, err)
+	}
+}
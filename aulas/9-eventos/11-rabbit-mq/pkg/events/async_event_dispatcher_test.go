@@ -0,0 +1,179 @@
+package events
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// RecordingHandler guarda os eventos recebidos. Com release preenchido, cada evento espera ser liberado
+type RecordingHandler struct {
+	mu      sync.Mutex
+	names   []string
+	started chan string
+	release chan struct{}
+}
+
+func (h *RecordingHandler) Handle(ctx context.Context, event EventInterface) error {
+	if h.started != nil {
+		h.started <- event.GetName()
+	}
+	if h.release != nil {
+		select {
+		case <-h.release:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.names = append(h.names, event.GetName())
+	return nil
+}
+
+func (h *RecordingHandler) Names() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]string(nil), h.names...)
+}
+
+// newBusyDispatcher devolve um dispatcher com um worker ocupado no evento "first" e a fila de uma vaga livre
+func newBusyDispatcher(t *testing.T, policy OverflowPolicy, onError func(EventInterface, error)) (*AsyncEventDispatcher, *RecordingHandler) {
+	handler := &RecordingHandler{started: make(chan string, 10), release: make(chan struct{})}
+	dispatcher := NewAsyncEventDispatcher(AsyncOptions{QueueSize: 1, Workers: 1, Overflow: policy, OnError: onError})
+	for _, name := range []string{"first", "second", "third"} {
+		require.NoError(t, dispatcher.Register(name, handler))
+	}
+
+	require.NoError(t, dispatcher.Dispatch(context.Background(), &TestEvent{Name: "first"}))
+	assert.Equal(t, "first", <-handler.started)
+
+	return dispatcher, handler
+}
+
+func TestAsyncEventDispatcher_ShutdownDrainsQueue(t *testing.T) {
+	handler := &RecordingHandler{}
+	dispatcher := NewAsyncEventDispatcher(AsyncOptions{QueueSize: 50, Workers: 3})
+	require.NoError(t, dispatcher.Register("test", handler))
+
+	for i := 0; i < 50; i++ {
+		require.NoError(t, dispatcher.Dispatch(context.Background(), &TestEvent{Name: "test"}))
+	}
+
+	require.NoError(t, dispatcher.Shutdown(context.Background()))
+	assert.Len(t, handler.Names(), 50)
+
+	err := dispatcher.Dispatch(context.Background(), &TestEvent{Name: "test"})
+	assert.ErrorIs(t, err, ErrDispatcherClosed)
+	assert.NoError(t, dispatcher.Shutdown(context.Background()))
+}
+
+func TestAsyncEventDispatcher_OverflowError(t *testing.T) {
+	dispatcher, handler := newBusyDispatcher(t, OverflowError, nil)
+
+	assert.NoError(t, dispatcher.Dispatch(context.Background(), &TestEvent{Name: "second"}))
+	assert.ErrorIs(t, dispatcher.Dispatch(context.Background(), &TestEvent{Name: "third"}), ErrQueueFull)
+
+	close(handler.release)
+	require.NoError(t, dispatcher.Shutdown(context.Background()))
+	assert.Equal(t, []string{"first", "second"}, handler.Names())
+}
+
+func TestAsyncEventDispatcher_OverflowDropOldest(t *testing.T) {
+	var dropped []string
+	dispatcher, handler := newBusyDispatcher(t, OverflowDropOldest, func(event EventInterface, err error) {
+		assert.ErrorIs(t, err, ErrEventDropped)
+		dropped = append(dropped, event.GetName())
+	})
+
+	assert.NoError(t, dispatcher.Dispatch(context.Background(), &TestEvent{Name: "second"}))
+	assert.NoError(t, dispatcher.Dispatch(context.Background(), &TestEvent{Name: "third"}))
+
+	close(handler.release)
+	require.NoError(t, dispatcher.Shutdown(context.Background()))
+	assert.Equal(t, []string{"first", "third"}, handler.Names())
+	assert.Equal(t, []string{"second"}, dropped)
+}
+
+func TestAsyncEventDispatcher_OverflowBlock(t *testing.T) {
+	dispatcher, handler := newBusyDispatcher(t, OverflowBlock, nil)
+	assert.NoError(t, dispatcher.Dispatch(context.Background(), &TestEvent{Name: "second"}))
+
+	// Sem vaga, o Dispatch espera até o prazo do contexto
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, dispatcher.Dispatch(ctx, &TestEvent{Name: "third"}), context.DeadlineExceeded)
+
+	// Com o worker livre, a vaga aparece e o Dispatch segue
+	done := make(chan error)
+	go func() {
+		done <- dispatcher.Dispatch(context.Background(), &TestEvent{Name: "third"})
+	}()
+	close(handler.release)
+	assert.NoError(t, <-done)
+
+	require.NoError(t, dispatcher.Shutdown(context.Background()))
+	assert.Equal(t, []string{"first", "second", "third"}, handler.Names())
+}
+
+func TestAsyncEventDispatcher_ShutdownTimeoutCancelsHandlers(t *testing.T) {
+	errs := make(chan error, 1)
+	dispatcher, handler := newBusyDispatcher(t, OverflowError, func(event EventInterface, err error) {
+		errs <- err
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, dispatcher.Shutdown(ctx), context.DeadlineExceeded)
+
+	// O handler preso recebe o cancelamento e a falha chega ao OnError
+	assert.ErrorIs(t, <-errs, context.Canceled)
+	assert.Empty(t, handler.Names())
+}
+
+func TestAsyncEventDispatcher_ShutdownWithBlockedPublisher(t *testing.T) {
+	dispatcher, handler := newBusyDispatcher(t, OverflowBlock, nil)
+	require.NoError(t, dispatcher.Dispatch(context.Background(), &TestEvent{Name: "second"}))
+
+	// Fila cheia e handler preso: o publicador fica esperando vaga
+	published := make(chan error)
+	go func() {
+		published <- dispatcher.Dispatch(context.Background(), &TestEvent{Name: "third"})
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	assert.ErrorIs(t, dispatcher.Shutdown(ctx), context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.ErrorIs(t, <-published, ErrDispatcherClosed)
+	assert.Empty(t, handler.Names())
+}
+
+func TestAsyncEventDispatcher_OnErrorCanDispatch(t *testing.T) {
+	var dispatcher *AsyncEventDispatcher
+	var dropped []string
+	dispatcher, handler := newBusyDispatcher(t, OverflowDropOldest, func(event EventInterface, err error) {
+		dropped = append(dropped, event.GetName())
+		// O aviso de descarte é publicado no mesmo dispatcher e descarta o "third" que estava na fila
+		if event.GetName() == "second" {
+			assert.NoError(t, dispatcher.Dispatch(context.Background(), &TestEvent{Name: "audit"}))
+		}
+	})
+	require.NoError(t, dispatcher.Register("audit", handler))
+
+	require.NoError(t, dispatcher.Dispatch(context.Background(), &TestEvent{Name: "second"}))
+	require.NoError(t, dispatcher.Dispatch(context.Background(), &TestEvent{Name: "third"}))
+
+	close(handler.release)
+	require.NoError(t, dispatcher.Shutdown(context.Background()))
+	assert.Equal(t, []string{"first", "audit"}, handler.Names())
+	assert.Equal(t, []string{"second", "third"}, dropped)
+}
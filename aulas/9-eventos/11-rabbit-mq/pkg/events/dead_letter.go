@@ -0,0 +1,95 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+var ErrDeadLetterNotFound = errors.New("dead letter not found")
+
+// DeadLetter é um evento que um handler não conseguiu processar nem depois de todas as tentativas
+type DeadLetter struct {
+	ID      int
+	Event   EventInterface
+	Handler EventHandlerInterface
+	// Errors tem o erro de cada tentativa, na ordem
+	Errors   []error
+	FailedAt time.Time
+}
+
+type DeadLetterHandler interface {
+	HandleDeadLetter(ctx context.Context, letter DeadLetter) error
+}
+
+// DeadLetterQueue guarda as dead letters em memória para serem consultadas e reenviadas
+type DeadLetterQueue struct {
+	mu      sync.Mutex
+	letters []DeadLetter
+	nextID  int
+}
+
+func NewDeadLetterQueue() *DeadLetterQueue {
+	return &DeadLetterQueue{}
+}
+
+func (q *DeadLetterQueue) HandleDeadLetter(ctx context.Context, letter DeadLetter) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.nextID++
+	letter.ID = q.nextID
+	q.letters = append(q.letters, letter)
+	return nil
+}
+
+// List devolve as dead letters da mais antiga para a mais nova
+func (q *DeadLetterQueue) List() []DeadLetter {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return append([]DeadLetter(nil), q.letters...)
+}
+
+// Remove tira a dead letter da fila e a devolve
+func (q *DeadLetterQueue) Remove(id int) (DeadLetter, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for i, letter := range q.letters {
+		if letter.ID == id {
+			q.letters = append(q.letters[:i], q.letters[i+1:]...)
+			return letter, nil
+		}
+	}
+	return DeadLetter{}, ErrDeadLetterNotFound
+}
+
+// Redispatch tira a dead letter da fila e executa de novo só o handler que falhou, com a política de retry dele.
+// Se falhar outra vez, o dispatcher devolve o evento para a dead letter com um novo ID. Quando o dispatcher não
+// gera a dead letter (contexto cancelado ou dead letter de outro destino), ela volta para a fila com o mesmo ID
+func (q *DeadLetterQueue) Redispatch(ctx context.Context, dispatcher *EventDispatcher, id int) error {
+	letter, err := q.Remove(id)
+	if err != nil {
+		return err
+	}
+
+	err = dispatcher.Redispatch(ctx, letter)
+	if err != nil && (ctx.Err() != nil || dispatcher.deadLetterHandler() != q) {
+		q.restore(letter)
+	}
+	return err
+}
+
+// restore devolve a dead letter para a sua posição original, mantendo a fila ordenada por ID
+func (q *DeadLetterQueue) restore(letter DeadLetter) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	i := sort.Search(len(q.letters), func(i int) bool { return q.letters[i].ID > letter.ID })
+	q.letters = append(q.letters, DeadLetter{})
+	copy(q.letters[i+1:], q.letters[i:])
+	q.letters[i] = letter
+}
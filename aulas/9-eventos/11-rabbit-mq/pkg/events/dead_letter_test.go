@@ -0,0 +1,225 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// FlakyHandler falha nas primeiras failures execuções
+type FlakyHandler struct {
+	mu       sync.Mutex
+	failures int
+	calls    int
+}
+
+func (h *FlakyHandler) Handle(ctx context.Context, event EventInterface) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.calls++
+	if h.calls <= h.failures {
+		return fmt.Errorf("attempt %d failed", h.calls)
+	}
+	return nil
+}
+
+func (h *FlakyHandler) Calls() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.calls
+}
+
+var fastRetry = RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}
+
+func TestEventDispatcher_RetriesUntilSuccess(t *testing.T) {
+	dispatcher := NewEventDispatcher()
+	queue := NewDeadLetterQueue()
+	dispatcher.SetDeadLetterHandler(queue)
+
+	handler := &FlakyHandler{failures: 2}
+	require.NoError(t, dispatcher.Register("order.created", handler))
+	require.NoError(t, dispatcher.SetRetryPolicy(handler, fastRetry))
+
+	assert.NoError(t, dispatcher.Dispatch(context.Background(), &TestEvent{Name: "order.created"}))
+	assert.Equal(t, 3, handler.Calls())
+	assert.Empty(t, queue.List())
+}
+
+func TestEventDispatcher_DeadLetterAfterRetries(t *testing.T) {
+	dispatcher := NewEventDispatcher()
+	queue := NewDeadLetterQueue()
+	dispatcher.SetDeadLetterHandler(queue)
+
+	event := &TestEvent{Name: "order.created"}
+	handler := &FlakyHandler{failures: 6}
+	withoutPolicy := &FlakyHandler{failures: 1}
+	require.NoError(t, dispatcher.Register("order.created", handler))
+	require.NoError(t, dispatcher.Register("order.*", withoutPolicy))
+	require.NoError(t, dispatcher.SetRetryPolicy(handler, fastRetry))
+
+	err := dispatcher.Dispatch(context.Background(), event)
+	assert.ErrorContains(t, err, "attempt 3 failed")
+	assert.Equal(t, 3, handler.Calls())
+	assert.Equal(t, 1, withoutPolicy.Calls())
+
+	letters := queue.List()
+	require.Len(t, letters, 2)
+	var letter DeadLetter
+	for _, l := range letters {
+		if l.Handler == handler {
+			letter = l
+		}
+	}
+	assert.Equal(t, event, letter.Event)
+	require.Len(t, letter.Errors, 3)
+	assert.EqualError(t, letter.Errors[0], "attempt 1 failed")
+	assert.EqualError(t, letter.Errors[2], "attempt 3 failed")
+
+	// O reenvio roda só o handler que falhou, de novo com a política de retry, e volta para a fila se falhar
+	assert.Error(t, queue.Redispatch(context.Background(), dispatcher, letter.ID))
+	assert.Equal(t, 6, handler.Calls())
+	assert.Equal(t, 1, withoutPolicy.Calls())
+	require.Len(t, queue.List(), 2)
+
+	letter = queue.List()[1]
+	assert.NotEqual(t, letters[0].ID, letter.ID)
+	assert.NotEqual(t, letters[1].ID, letter.ID)
+	assert.NoError(t, queue.Redispatch(context.Background(), dispatcher, letter.ID))
+	assert.Equal(t, 7, handler.Calls())
+	assert.Len(t, queue.List(), 1)
+
+	assert.ErrorIs(t, queue.Redispatch(context.Background(), dispatcher, letter.ID), ErrDeadLetterNotFound)
+}
+
+func TestEventDispatcher_CancelStopsRetries(t *testing.T) {
+	dispatcher := NewEventDispatcher()
+	queue := NewDeadLetterQueue()
+	dispatcher.SetDeadLetterHandler(queue)
+
+	handler := &FlakyHandler{failures: 10}
+	require.NoError(t, dispatcher.Register("order.created", handler))
+	require.NoError(t, dispatcher.SetRetryPolicy(handler, RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Hour}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	err := dispatcher.Dispatch(ctx, &TestEvent{Name: "order.created"})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 1, handler.Calls())
+	assert.Empty(t, queue.List())
+}
+
+func TestDeadLetterQueue_RedispatchKeepsLetterWhenNotRequeued(t *testing.T) {
+	dispatcher := NewEventDispatcher()
+	queue := NewDeadLetterQueue()
+	dispatcher.SetDeadLetterHandler(queue)
+
+	handler := &FlakyHandler{failures: 10}
+	require.NoError(t, dispatcher.Register("order.created", handler))
+	require.Error(t, dispatcher.Dispatch(context.Background(), &TestEvent{Name: "order.created"}))
+	require.Error(t, dispatcher.Dispatch(context.Background(), &TestEvent{Name: "order.created"}))
+	letters := queue.List()
+	require.Len(t, letters, 2)
+
+	// Com o contexto cancelado o dispatcher não gera dead letter, então a original volta para o mesmo lugar
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, queue.Redispatch(ctx, dispatcher, letters[0].ID), context.Canceled)
+	assert.Equal(t, letters, queue.List())
+
+	// Um dispatcher sem dead letter também não devolve o evento para esta fila
+	assert.Error(t, queue.Redispatch(context.Background(), NewEventDispatcher(), letters[0].ID))
+	assert.Equal(t, letters, queue.List())
+}
+
+type FailingDeadLetterHandler struct{}
+
+func (h *FailingDeadLetterHandler) HandleDeadLetter(ctx context.Context, letter DeadLetter) error {
+	return errors.New("dead letter storage unavailable")
+}
+
+func TestEventDispatcher_DeadLetterHandlerFailure(t *testing.T) {
+	dispatcher := NewEventDispatcher()
+	dispatcher.SetDeadLetterHandler(&FailingDeadLetterHandler{})
+	require.NoError(t, dispatcher.Register("order.created", &FlakyHandler{failures: 1}))
+
+	err := dispatcher.Dispatch(context.Background(), &TestEvent{Name: "order.created"})
+	assert.ErrorContains(t, err, "attempt 1 failed")
+	assert.ErrorContains(t, err, "dead letter storage unavailable")
+}
+
+// SliceHandler é registrado por valor e tem um slice, então não pode ser comparado nem virar chave de map
+type SliceHandler struct {
+	seen []string
+}
+
+func (h SliceHandler) Handle(ctx context.Context, event EventInterface) error {
+	return errors.New("always fails")
+}
+
+func TestEventDispatcher_NonComparableHandler(t *testing.T) {
+	dispatcher := NewEventDispatcher()
+	queue := NewDeadLetterQueue()
+	dispatcher.SetDeadLetterHandler(queue)
+
+	handler := SliceHandler{seen: []string{"order.created"}}
+	require.NoError(t, dispatcher.Register("order.created", handler))
+	require.NoError(t, dispatcher.Register("order.*", SliceHandler{}))
+	assert.ErrorIs(t, dispatcher.SetRetryPolicy(handler, fastRetry), ErrHandlerNotComparable)
+
+	err := dispatcher.Dispatch(context.Background(), &TestEvent{Name: "order.created"})
+	assert.ErrorContains(t, err, "always fails")
+	assert.Len(t, queue.List(), 2)
+	assert.False(t, dispatcher.Has("order.created", handler))
+}
+
+func TestEventDispatcher_RemoveDropsRetryPolicy(t *testing.T) {
+	dispatcher := NewEventDispatcher()
+	handler := &FlakyHandler{}
+	require.NoError(t, dispatcher.Register("order.created", handler))
+	require.NoError(t, dispatcher.Register("order.*", handler))
+	require.NoError(t, dispatcher.SetRetryPolicy(handler, fastRetry))
+
+	require.NoError(t, dispatcher.Remove("order.created", handler))
+	assert.Contains(t, dispatcher.policies, EventHandlerInterface(handler))
+
+	require.NoError(t, dispatcher.Remove("order.*", handler))
+	assert.Empty(t, dispatcher.policies)
+
+	// Registrado de novo, o handler volta sem a política antiga
+	handler.failures = 2
+	require.NoError(t, dispatcher.Register("order.created", handler))
+	assert.Error(t, dispatcher.Dispatch(context.Background(), &TestEvent{Name: "order.created"}))
+	assert.Equal(t, 1, handler.Calls())
+}
+
+// CancelingHandler cancela o contexto do Dispatch e falha por causa disso
+type CancelingHandler struct {
+	cancel context.CancelFunc
+}
+
+func (h *CancelingHandler) Handle(ctx context.Context, event EventInterface) error {
+	h.cancel()
+	return ctx.Err()
+}
+
+func TestEventDispatcher_CancelOnLastAttemptSkipsDeadLetter(t *testing.T) {
+	dispatcher := NewEventDispatcher()
+	queue := NewDeadLetterQueue()
+	dispatcher.SetDeadLetterHandler(queue)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, dispatcher.Register("order.created", &CancelingHandler{cancel: cancel}))
+
+	err := dispatcher.Dispatch(ctx, &TestEvent{Name: "order.created"})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Empty(t, queue.List())
+}
@@ -1,69 +1,265 @@
 package events
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"reflect"
+	"sort"
 	"sync"
+	"time"
 )
 
-var ErrHandlerAlreadyRegistered = errors.New("handler already registered")
+var (
+	ErrHandlerAlreadyRegistered = errors.New("handler already registered")
+	ErrHandlerNotComparable     = errors.New("handler must be comparable to have a retry policy")
+)
 
+// EventDispatcher pode ser usado por várias goroutines ao mesmo tempo.
+// Handlers podem ser registrados para um nome exato ou para um padrão com "*" e "#" (ex.: order.*)
 type EventDispatcher struct {
+	mu sync.RWMutex
+	// handlers é indexado pelo nome ou padrão usado no Register; patterns indexa só os padrões
 	handlers map[string][]EventHandlerInterface
+	patterns *patternNode
+
+	// Handlers sem política rodam uma vez só. Sem deadLetters, a falha só aparece no retorno do Dispatch.
+	// Só handlers comparáveis entram em policies, já que o handler é a chave do map
+	policies    map[EventHandlerInterface]RetryPolicy
+	deadLetters DeadLetterHandler
 }
 
 func NewEventDispatcher() *EventDispatcher {
 	return &EventDispatcher{
 		handlers: make(map[string][]EventHandlerInterface),
+		patterns: newPatternNode(),
+		policies: make(map[EventHandlerInterface]RetryPolicy),
+	}
+}
+
+// Dispatch executa os handlers do evento em paralelo e devolve as falhas de todos eles juntas (errors.Join).
+// Com o contexto cancelado, os handlers que ainda não começaram não rodam e o erro do contexto entra no retorno
+func (ed *EventDispatcher) Dispatch(ctx context.Context, event EventInterface) error {
+	// Handlers devolve uma cópia, então o lock não fica preso enquanto os handlers rodam
+	handlers := ed.Handlers(event.GetName())
+
+	errs := make([]error, len(handlers))
+	skipped := make([]bool, len(handlers))
+	wg := &sync.WaitGroup{}
+	for i, handler := range handlers {
+		wg.Add(1)
+		go func(i int, handler EventHandlerInterface) {
+			defer wg.Done()
+
+			if ctx.Err() != nil {
+				skipped[i] = true
+				return
+			}
+			errs[i] = ed.handle(ctx, event, handler)
+		}(i, handler)
+	}
+	wg.Wait()
+
+	for _, handlerSkipped := range skipped {
+		if handlerSkipped {
+			errs = append(errs, ctx.Err())
+			break
+		}
 	}
+
+	return errors.Join(errs...)
+}
+
+// Redispatch executa de novo o handler de uma dead letter, seguindo a política de retry dele
+func (ed *EventDispatcher) Redispatch(ctx context.Context, letter DeadLetter) error {
+	return ed.handle(ctx, letter.Event, letter.Handler)
 }
 
-func (ev *EventDispatcher) Dispatch(event EventInterface) error {
-	if handlers, ok := ev.handlers[event.GetName()]; ok {
-        wg := &sync.WaitGroup{}
-		for _, handler := range handlers {
-            wg.Add(1)
-			go handler.Handle(event, wg) 
+// handle executa o handler até dar certo ou acabarem as tentativas, e aí manda o evento para a dead letter
+// com o erro de cada tentativa. O cancelamento do contexto interrompe as tentativas sem gerar dead letter
+func (ed *EventDispatcher) handle(ctx context.Context, event EventInterface, handler EventHandlerInterface) error {
+	var policy RetryPolicy
+	ed.mu.RLock()
+	if isComparable(handler) {
+		policy = ed.policies[handler]
+	}
+	deadLetters := ed.deadLetters
+	ed.mu.RUnlock()
+
+	var errs []error
+	for attempt := 1; ; attempt++ {
+		err := handler.Handle(ctx, event)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, err)
+
+		if attempt >= policy.attempts() {
+			break
 		}
-        wg.Wait()
+
+		timer := time.NewTimer(policy.Backoff(attempt))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%s: %T: %w", event.GetName(), handler, errors.Join(append(errs, ctx.Err())...))
+		}
+	}
+
+	// A última tentativa pode ter falhado porque o contexto foi cancelado, o que não é falha do handler
+	if ctx.Err() != nil {
+		return fmt.Errorf("%s: %T: %w", event.GetName(), handler, errors.Join(append(errs, ctx.Err())...))
+	}
+
+	err := fmt.Errorf("%s: %T: %w", event.GetName(), handler, errors.Join(errs...))
+	if deadLetters != nil {
+		letter := DeadLetter{Event: event, Handler: handler, Errors: errs, FailedAt: time.Now()}
+		if deadLetterErr := deadLetters.HandleDeadLetter(ctx, letter); deadLetterErr != nil {
+			return errors.Join(err, deadLetterErr)
+		}
+	}
+	return err
+}
+
+// SetRetryPolicy define a política de retry do handler, valendo para todos os eventos em que ele está registrado.
+// A política some junto com o último registro do handler. Handlers não comparáveis (ex.: uma struct com slice
+// registrada por valor) não podem ter política; registre um ponteiro para eles
+func (ed *EventDispatcher) SetRetryPolicy(handler EventHandlerInterface, policy RetryPolicy) error {
+	if !isComparable(handler) {
+		return ErrHandlerNotComparable
 	}
+
+	ed.mu.Lock()
+	defer ed.mu.Unlock()
+
+	ed.policies[handler] = policy
 	return nil
 }
 
-func (ed *EventDispatcher) Register(eventName string, handler EventHandlerInterface) error {
-	if _, ok := ed.handlers[eventName]; ok {
-		for _, h := range ed.handlers[eventName] {
-			if h == handler {
-				return ErrHandlerAlreadyRegistered
+// SetDeadLetterHandler define quem recebe os eventos que falharam em todas as tentativas (ex.: DeadLetterQueue)
+func (ed *EventDispatcher) SetDeadLetterHandler(handler DeadLetterHandler) {
+	ed.mu.Lock()
+	defer ed.mu.Unlock()
+
+	ed.deadLetters = handler
+}
+
+func (ed *EventDispatcher) deadLetterHandler() DeadLetterHandler {
+	ed.mu.RLock()
+	defer ed.mu.RUnlock()
+
+	return ed.deadLetters
+}
+
+// Handlers lista os handlers que o Dispatch executaria para o evento: primeiro os do nome exato, na ordem
+// do registro, e depois os dos padrões que casam, em ordem alfabética do padrão. Um handler aparece uma vez só
+func (ed *EventDispatcher) Handlers(eventName string) []EventHandlerInterface {
+	ed.mu.RLock()
+	defer ed.mu.RUnlock()
+
+	matched := make(map[string]struct{})
+	ed.patterns.match(eventName, matched)
+	patterns := make([]string, 0, len(matched))
+	for pattern := range matched {
+		if pattern != eventName {
+			patterns = append(patterns, pattern)
+		}
+	}
+	sort.Strings(patterns)
+
+	handlers := append([]EventHandlerInterface(nil), ed.handlers[eventName]...)
+	for _, pattern := range patterns {
+		for _, handler := range ed.handlers[pattern] {
+			if !contains(handlers, handler) {
+				handlers = append(handlers, handler)
 			}
 		}
 	}
+	return handlers
+}
+
+// Register aceita um nome exato ou um padrão
+func (ed *EventDispatcher) Register(eventName string, handler EventHandlerInterface) error {
+	ed.mu.Lock()
+	defer ed.mu.Unlock()
+
+	if contains(ed.handlers[eventName], handler) {
+		return ErrHandlerAlreadyRegistered
+	}
+	if isPattern(eventName) {
+		ed.patterns.insert(eventName)
+	}
 	ed.handlers[eventName] = append(ed.handlers[eventName], handler)
 	return nil
 }
 
+// Has e Remove comparam o nome como foi registrado: Has("order.*", h) não olha os handlers de order.created
 func (ed *EventDispatcher) Has(eventName string, handler EventHandlerInterface) bool {
-	if _, ok := ed.handlers[eventName]; ok {
-		for _, h := range ed.handlers[eventName] {
-			if h == handler {
-				return true
+	ed.mu.RLock()
+	defer ed.mu.RUnlock()
+
+	return contains(ed.handlers[eventName], handler)
+}
+
+func (ed *EventDispatcher) Remove(eventName string, handler EventHandlerInterface) error {
+	ed.mu.Lock()
+	defer ed.mu.Unlock()
+
+	for i, h := range ed.handlers[eventName] {
+		if sameHandler(h, handler) {
+			ed.handlers[eventName] = append(ed.handlers[eventName][:i], ed.handlers[eventName][i+1:]...)
+			if len(ed.handlers[eventName]) == 0 {
+				delete(ed.handlers, eventName)
+				ed.patterns.remove(eventName)
 			}
+			if !ed.registered(handler) && isComparable(handler) {
+				delete(ed.policies, handler)
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+func (ed *EventDispatcher) Clear() {
+	ed.mu.Lock()
+	defer ed.mu.Unlock()
+
+	ed.handlers = make(map[string][]EventHandlerInterface)
+	ed.patterns = newPatternNode()
+	ed.policies = make(map[EventHandlerInterface]RetryPolicy)
+}
+
+// registered diz se o handler ainda está registrado para algum nome ou padrão
+func (ed *EventDispatcher) registered(handler EventHandlerInterface) bool {
+	for _, handlers := range ed.handlers {
+		if contains(handlers, handler) {
+			return true
 		}
 	}
 	return false
 }
 
-func (ed *EventDispatcher) Remove(eventName string, handler EventHandlerInterface) error {
-    if _, ok := ed.handlers[eventName]; ok {
-        for i, h := range ed.handlers[eventName] {
-            if h == handler {
-                ed.handlers[eventName] = append(ed.handlers[eventName][:i], ed.handlers[eventName][i+1:]...)
-                return nil
-            }
-        }
-    }
-    return nil
+func contains(handlers []EventHandlerInterface, handler EventHandlerInterface) bool {
+	for _, h := range handlers {
+		if sameHandler(h, handler) {
+			return true
+		}
+	}
+	return false
 }
 
-func (ed *EventDispatcher) Clear() {
-	ed.handlers = make(map[string][]EventHandlerInterface)
+// sameHandler compara dois handlers sem causar panic: comparar duas interfaces com o mesmo tipo
+// não comparável (ex.: struct com slice passada por valor) quebra o ==, então esses handlers nunca são iguais
+func sameHandler(a, b EventHandlerInterface) bool {
+	if reflect.TypeOf(a) != reflect.TypeOf(b) || !isComparable(a) {
+		return false
+	}
+	return a == b
+}
+
+func isComparable(handler EventHandlerInterface) bool {
+	handlerType := reflect.TypeOf(handler)
+	return handlerType == nil || handlerType.Comparable()
 }
@@ -1,6 +1,8 @@
 package events
 
 import (
+	"context"
+	"errors"
 	"sync"
 	"testing"
 	"time"
@@ -31,7 +33,8 @@ type TestEventHandler struct {
 	ID int
 }
 
-func (h *TestEventHandler) Handle(event EventInterface, wg *sync.WaitGroup) {
+func (h *TestEventHandler) Handle(ctx context.Context, event EventInterface) error {
+	return nil
 }
 
 type EventDispatcherTestSuite struct {
@@ -147,28 +150,152 @@ type MockHandler struct {
 	mock.Mock
 }
 
-func (m *MockHandler) Handle(event EventInterface, wg *sync.WaitGroup) {
-	m.Called(event)
-    wg.Done()
+func (m *MockHandler) Handle(ctx context.Context, event EventInterface) error {
+	args := m.Called(event)
+	return args.Error(0)
 }
 
 func (suite *EventDispatcherTestSuite) TestEventDispatch_Dispatch() {
 	eh := &MockHandler{}
-	eh.On("Handle", &suite.event)
+	eh.On("Handle", &suite.event).Return(nil)
 
     eh2 := &MockHandler{}
-    eh2.On("Handle", &suite.event)
+    eh2.On("Handle", &suite.event).Return(nil)
 
 	suite.eventDispatcher.Register(suite.event.GetName(), eh)
     suite.eventDispatcher.Register(suite.event.GetName(), eh2)
 
-	suite.eventDispatcher.Dispatch(&suite.event)
+	err := suite.eventDispatcher.Dispatch(context.Background(), &suite.event)
+	suite.Nil(err)
 	eh.AssertExpectations(suite.T())
     eh2.AssertExpectations(suite.T())
 	eh.AssertNumberOfCalls(suite.T(), "Handle", 1)
     eh2.AssertNumberOfCalls(suite.T(), "Handle", 1)
 }
 
+func (suite *EventDispatcherTestSuite) TestEventDispatch_Dispatch_JoinsErrors() {
+	errEmail := errors.New("smtp unavailable")
+	errStock := errors.New("stock service unavailable")
+
+	eh := &MockHandler{}
+	eh.On("Handle", &suite.event).Return(errEmail)
+	eh2 := &MockHandler{}
+	eh2.On("Handle", &suite.event).Return(errStock)
+	eh3 := &MockHandler{}
+	eh3.On("Handle", &suite.event).Return(nil)
+
+	suite.eventDispatcher.Register(suite.event.GetName(), eh)
+	suite.eventDispatcher.Register(suite.event.GetName(), eh2)
+	suite.eventDispatcher.Register(suite.event.GetName(), eh3)
+
+	// Uma falha não impede os outros handlers de rodar
+	err := suite.eventDispatcher.Dispatch(context.Background(), &suite.event)
+	suite.ErrorIs(err, errEmail)
+	suite.ErrorIs(err, errStock)
+	eh3.AssertNumberOfCalls(suite.T(), "Handle", 1)
+}
+
+func (suite *EventDispatcherTestSuite) TestEventDispatch_Dispatch_WithCanceledContext() {
+	eh := &MockHandler{}
+	suite.eventDispatcher.Register(suite.event.GetName(), eh)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := suite.eventDispatcher.Dispatch(ctx, &suite.event)
+	suite.ErrorIs(err, context.Canceled)
+	eh.AssertNotCalled(suite.T(), "Handle", &suite.event)
+}
+
+type BlockingHandler struct {
+	started chan struct{}
+}
+
+func (h *BlockingHandler) Handle(ctx context.Context, event EventInterface) error {
+	close(h.started)
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (suite *EventDispatcherTestSuite) TestEventDispatch_Dispatch_CancelStopsRunningHandler() {
+	handler := &BlockingHandler{started: make(chan struct{})}
+	suite.eventDispatcher.Register(suite.event.GetName(), handler)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-handler.started
+		cancel()
+	}()
+
+	err := suite.eventDispatcher.Dispatch(ctx, &suite.event)
+	suite.ErrorIs(err, context.Canceled)
+}
+
+func (suite *EventDispatcherTestSuite) TestEventDispatcher_ConcurrentAccess() {
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 50; i++ {
+		wg.Add(2)
+		go func(id int) {
+			defer wg.Done()
+			handler := &TestEventHandler{ID: id}
+			suite.eventDispatcher.Register(suite.event.GetName(), handler)
+			suite.eventDispatcher.Has(suite.event.GetName(), handler)
+			suite.eventDispatcher.Remove(suite.event.GetName(), handler)
+		}(i)
+		go func() {
+			defer wg.Done()
+			suite.eventDispatcher.Dispatch(context.Background(), &suite.event)
+		}()
+	}
+	wg.Wait()
+
+	suite.Equal(0, len(suite.eventDispatcher.handlers[suite.event.GetName()]))
+}
+
+func (suite *EventDispatcherTestSuite) TestEventDispatcher_Patterns() {
+	created := TestEvent{Name: "order.created"}
+	itemAdded := TestEvent{Name: "order.item.added"}
+
+	// handler ouve order.created pelo nome e pelo padrão, mas roda uma vez só
+	suite.Nil(suite.eventDispatcher.Register("order.created", &suite.handler))
+	suite.Nil(suite.eventDispatcher.Register("order.*", &suite.handler))
+	suite.Nil(suite.eventDispatcher.Register("order.*", &suite.handler2))
+	suite.Nil(suite.eventDispatcher.Register("order.#", &suite.handler3))
+	suite.Equal(ErrHandlerAlreadyRegistered, suite.eventDispatcher.Register("order.*", &suite.handler2))
+
+	// Os padrões entram em ordem alfabética: order.# vem antes de order.*
+	suite.Equal([]EventHandlerInterface{&suite.handler, &suite.handler3, &suite.handler2}, suite.eventDispatcher.Handlers(created.GetName()))
+	suite.Equal([]EventHandlerInterface{&suite.handler3}, suite.eventDispatcher.Handlers(itemAdded.GetName()))
+	suite.Empty(suite.eventDispatcher.Handlers("user.created"))
+
+	suite.True(suite.eventDispatcher.Has("order.*", &suite.handler2))
+	suite.False(suite.eventDispatcher.Has("order.created", &suite.handler2))
+
+	suite.Nil(suite.eventDispatcher.Remove("order.*", &suite.handler2))
+	suite.False(suite.eventDispatcher.Has("order.*", &suite.handler2))
+	suite.Equal([]EventHandlerInterface{&suite.handler, &suite.handler3}, suite.eventDispatcher.Handlers(created.GetName()))
+
+	suite.eventDispatcher.Clear()
+	suite.Empty(suite.eventDispatcher.Handlers(created.GetName()))
+}
+
+func (suite *EventDispatcherTestSuite) TestEventDispatch_Dispatch_WithPatterns() {
+	audit := &MockHandler{}
+	audit.On("Handle", &suite.event).Return(nil)
+	audit.On("Handle", &suite.event2).Return(nil)
+
+	exact := &MockHandler{}
+	exact.On("Handle", &suite.event).Return(nil)
+
+	suite.eventDispatcher.Register("#", audit)
+	suite.eventDispatcher.Register(suite.event.GetName(), exact)
+
+	suite.Nil(suite.eventDispatcher.Dispatch(context.Background(), &suite.event))
+	suite.Nil(suite.eventDispatcher.Dispatch(context.Background(), &suite.event2))
+	audit.AssertNumberOfCalls(suite.T(), "Handle", 2)
+	exact.AssertNumberOfCalls(suite.T(), "Handle", 1)
+}
+
 func TestSuite(t *testing.T) {
 	suite.Run(t, new(EventDispatcherTestSuite))
 }
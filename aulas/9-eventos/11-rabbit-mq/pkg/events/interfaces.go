@@ -1,7 +1,7 @@
 package events
 
 import (
-	"sync"
+	"context"
 	"time"
 )
 
@@ -11,14 +11,16 @@ type EventInterface interface {
 	GetPayload() interface{}
 }
 
+// EventHandlerInterface recebe o contexto do Dispatch e deve parar quando ele for cancelado
 type EventHandlerInterface interface {
-	Handle(event EventInterface, wg *sync.WaitGroup)
+	Handle(ctx context.Context, event EventInterface) error
 }
 
 type EventDispatcherInterface interface {
 	Register(eventName string, handler EventHandlerInterface) error
-	Dispatch(event EventInterface) error
+	Dispatch(ctx context.Context, event EventInterface) error
 	Remove(eventName string, handler EventHandlerInterface) error
 	Has(eventName string, handler EventHandlerInterface) bool
-	Clear() error
+	Handlers(eventName string) []EventHandlerInterface
+	Clear()
 }
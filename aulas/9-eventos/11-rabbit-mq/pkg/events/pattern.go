@@ -0,0 +1,90 @@
+package events
+
+import "strings"
+
+// Os padrões seguem a convenção dos tópicos do RabbitMQ: os nomes são separados por ponto,
+// "*" casa exatamente um segmento e "#" casa zero ou mais segmentos (order.# casa order e order.item.added)
+const (
+	patternSeparator = "."
+	singleSegment    = "*"
+	multipleSegments = "#"
+)
+
+func isPattern(eventName string) bool {
+	for _, segment := range strings.Split(eventName, patternSeparator) {
+		if segment == singleSegment || segment == multipleSegments {
+			return true
+		}
+	}
+	return false
+}
+
+// patternNode é uma árvore de segmentos. Assim o Dispatch percorre só os segmentos do nome do evento,
+// em vez de testar todos os padrões registrados
+type patternNode struct {
+	children map[string]*patternNode
+	// pattern fica preenchido no nó onde termina um padrão registrado
+	pattern string
+}
+
+func newPatternNode() *patternNode {
+	return &patternNode{children: make(map[string]*patternNode)}
+}
+
+func (n *patternNode) insert(pattern string) {
+	node := n
+	for _, segment := range strings.Split(pattern, patternSeparator) {
+		child, ok := node.children[segment]
+		if !ok {
+			child = newPatternNode()
+			node.children[segment] = child
+		}
+		node = child
+	}
+	node.pattern = pattern
+}
+
+func (n *patternNode) remove(pattern string) {
+	n.removeSegments(strings.Split(pattern, patternSeparator))
+}
+
+// removeSegments devolve true quando o nó ficou vazio e pode sair da árvore
+func (n *patternNode) removeSegments(segments []string) bool {
+	if len(segments) == 0 {
+		n.pattern = ""
+	} else if child, ok := n.children[segments[0]]; ok && child.removeSegments(segments[1:]) {
+		delete(n.children, segments[0])
+	}
+
+	return n.pattern == "" && len(n.children) == 0
+}
+
+// match adiciona em matched os padrões que casam com o nome do evento
+func (n *patternNode) match(eventName string, matched map[string]struct{}) {
+	n.matchSegments(strings.Split(eventName, patternSeparator), matched)
+}
+
+func (n *patternNode) matchSegments(segments []string, matched map[string]struct{}) {
+	if len(segments) == 0 {
+		if n.pattern != "" {
+			matched[n.pattern] = struct{}{}
+		}
+		// "#" também casa com zero segmentos
+		if child, ok := n.children[multipleSegments]; ok {
+			child.matchSegments(segments, matched)
+		}
+		return
+	}
+
+	if child, ok := n.children[segments[0]]; ok {
+		child.matchSegments(segments[1:], matched)
+	}
+	if child, ok := n.children[singleSegment]; ok {
+		child.matchSegments(segments[1:], matched)
+	}
+	if child, ok := n.children[multipleSegments]; ok {
+		for i := 0; i <= len(segments); i++ {
+			child.matchSegments(segments[i:], matched)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPatternNode_Match(t *testing.T) {
+	root := newPatternNode()
+	for _, pattern := range []string{"order.*", "order.#", "#", "*.created", "order.*.added", "order.#.added"} {
+		root.insert(pattern)
+	}
+
+	tests := []struct {
+		eventName string
+		expected  []string
+	}{
+		{"order", []string{"#", "order.#"}},
+		{"order.created", []string{"#", "*.created", "order.#", "order.*"}},
+		{"order.item.added", []string{"#", "order.#", "order.#.added", "order.*.added"}},
+		{"order.item.gift.added", []string{"#", "order.#", "order.#.added"}},
+		{"order.added", []string{"#", "order.#", "order.#.added", "order.*"}},
+		{"user.created", []string{"#", "*.created"}},
+	}
+
+	for _, test := range tests {
+		matched := make(map[string]struct{})
+		root.match(test.eventName, matched)
+
+		var patterns []string
+		for pattern := range matched {
+			patterns = append(patterns, pattern)
+		}
+		assert.ElementsMatch(t, test.expected, patterns, test.eventName)
+	}
+}
+
+func TestPatternNode_Remove(t *testing.T) {
+	root := newPatternNode()
+	root.insert("order.*")
+	root.insert("order.#")
+
+	root.remove("order.*")
+	matched := make(map[string]struct{})
+	root.match("order.created", matched)
+	assert.Equal(t, map[string]struct{}{"order.#": {}}, matched)
+
+	root.remove("order.#")
+	assert.Empty(t, root.children)
+}
@@ -0,0 +1,54 @@
+package events
+
+import (
+	"math/rand"
+	"time"
+)
+
+const defaultBackoffMultiplier = 2
+
+// RetryPolicy diz quantas vezes um handler que falhou é executado de novo e quanto esperar entre as tentativas.
+// A espera começa em InitialBackoff e é multiplicada por Multiplier a cada tentativa, até MaxBackoff
+type RetryPolicy struct {
+	// MaxAttempts conta a primeira execução. Zero ou um desliga o retry
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	// MaxBackoff zerado não limita a espera
+	MaxBackoff time.Duration
+	// Multiplier zerado usa 2
+	Multiplier float64
+	// Jitter é a fração da espera sorteada para mais ou para menos (0.2 = ±20%), para que handlers
+	// que falharam juntos não tentem de novo todos ao mesmo tempo
+	Jitter float64
+}
+
+func (p RetryPolicy) attempts() int {
+	if p.MaxAttempts < 1 {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+// Backoff devolve a espera depois da tentativa attempt (começando em 1)
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier <= 0 {
+		multiplier = defaultBackoffMultiplier
+	}
+
+	backoff := float64(p.InitialBackoff)
+	for i := 1; i < attempt; i++ {
+		backoff *= multiplier
+		if p.MaxBackoff > 0 && backoff >= float64(p.MaxBackoff) {
+			break
+		}
+	}
+	if p.MaxBackoff > 0 && backoff > float64(p.MaxBackoff) {
+		backoff = float64(p.MaxBackoff)
+	}
+
+	if p.Jitter > 0 {
+		backoff += (rand.Float64()*2 - 1) * p.Jitter * backoff
+	}
+	return time.Duration(backoff)
+}